@@ -1,6 +1,11 @@
 package ast
 
-import "github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
 
 // Base interfaces
 type Stmt interface {
@@ -15,20 +20,74 @@ type Expr interface {
 	GetColumn() int
 }
 
+type Pattern interface {
+	pattern()
+	GetLine() int
+	GetColumn() int
+}
+
 // Modifiers and Type
 type Modifier struct {
 	Kind lexer.TokenKind
 }
 
-type Type struct {
+// An attribute like [Obsolete("use X")]. Target is only set for explicit targets like [return: Test].
+// Args are passed to the constructor of the attribute class, NamedArgs like AllowMultiple = true set its fields.
+type Attribute struct {
+	Target    string
+	Name      string
+	Args      []Expr
+	NamedArgs []NamedAttributeArg
+	Line      int
+	Column    int
+}
+
+type NamedAttributeArg struct {
 	Name   string
+	Value  Expr
 	Line   int
 	Column int
 }
 
+type Type struct {
+	Name          string
+	TypeArguments []Type
+	// Reference types declared with ? like string? are annotated as nullable, the type checker
+	// removes the ? from their name. Nullable value types like int? keep it.
+	IsNullable bool
+	Line       int
+	Column     int
+}
+
 // Program
 type Program struct {
-	Classes []ClassDeclStmt
+	Classes   []ClassDeclStmt
+	Enums     []EnumDeclStmt
+	Delegates []DelegateDeclStmt
+	// Top-level statements, they become the body of Program.Main
+	Statements []Stmt
+	// #nullable directives in the order they appear in the source
+	NullableDirectives []NullableDirective
+	Usings             []UsingDirective
+}
+
+// using System.Text; makes the extension methods declared in the namespace available in the file
+type UsingDirective struct {
+	Namespace string
+	Line      int
+	Column    int
+}
+
+// #nullable enable, disable or restore, it applies to the lines after it
+type NullableDirective struct {
+	Setting string
+	Line    int
+}
+
+// The declarations of a single source file
+type CompilationUnit struct {
+	Path    string
+	Program Program
 }
 
 // ========================================================================================================
@@ -36,7 +95,7 @@ type Program struct {
 // ========================================================================================================
 
 type TypedStmt struct {
-	Type   string
+	Type   types.Type
 	Stmt   Stmt
 	Line   int
 	Column int
@@ -47,9 +106,11 @@ func (stmt TypedStmt) GetLine() int   { return stmt.Line }
 func (stmt TypedStmt) GetColumn() int { return stmt.Column }
 
 type BlockStmt struct {
-	Body   []Stmt
-	Line   int
-	Column int
+	Body []Stmt
+	// CHECKED or UNCHECKED for checked { } and unchecked { } blocks, the enclosing context otherwise
+	Context lexer.TokenKind
+	Line    int
+	Column  int
 }
 
 func (stmt BlockStmt) stmt()          {}
@@ -66,19 +127,33 @@ func (stmt ExpressionStmt) stmt()          {}
 func (stmt ExpressionStmt) GetLine() int   { return stmt.Line }
 func (stmt ExpressionStmt) GetColumn() int { return stmt.Column }
 
+// IsUninitialized marks declarators without an initializer, the parser gives them the default value of their type
 type VarDeclStmt struct {
-	Modifiers  []Modifier
-	Identifier string
-	Type       Type
-	Value      Expr
-	Line       int
-	Column     int
+	Modifiers       []Modifier
+	Identifier      string
+	Type            Type
+	Value           Expr
+	IsUninitialized bool
+	Line            int
+	Column          int
 }
 
 func (stmt VarDeclStmt) stmt()          {}
 func (stmt VarDeclStmt) GetLine() int   { return stmt.Line }
 func (stmt VarDeclStmt) GetColumn() int { return stmt.Column }
 
+// A declaration with several declarators like int a = 1, b = 2;
+// Every declarator gets its own VarDeclStmt with the shared modifiers and type.
+type MultiVarDeclStmt struct {
+	Declarations []VarDeclStmt
+	Line         int
+	Column       int
+}
+
+func (stmt MultiVarDeclStmt) stmt()          {}
+func (stmt MultiVarDeclStmt) GetLine() int   { return stmt.Line }
+func (stmt MultiVarDeclStmt) GetColumn() int { return stmt.Column }
+
 type ReturnStmt struct {
 	Value  Expr
 	Line   int
@@ -90,17 +165,29 @@ func (stmt ReturnStmt) GetLine() int   { return stmt.Line }
 func (stmt ReturnStmt) GetColumn() int { return stmt.Column }
 
 // Class-related statements
+// Kind is either lexer.CLASS or lexer.STRUCT
 type ClassDeclStmt struct {
-	Modifiers []Modifier
-	Name      string
-	Body      ClassBody
+	Attributes []Attribute
+	Modifiers  []Modifier
+	Kind       lexer.TokenKind
+	Name       string
+	BaseTypes  []Type
+	Body       ClassBody
+	// The parser synthesizes the properties, constructor and equality members of records from their positional parameters
+	IsRecord   bool
+	Parameters []Parameter
+	// All types share one scope, the namespace only decides where extension methods of the class are visible
+	Namespace string
+	File      string
 	Line      int
 	Column    int
 }
 
-func (stmt ClassDeclStmt) stmt()          {}
-func (stmt ClassDeclStmt) GetLine() int   { return stmt.Line }
-func (stmt ClassDeclStmt) GetColumn() int { return stmt.Column }
+func (stmt ClassDeclStmt) stmt()           {}
+func (stmt ClassDeclStmt) classMember()    {}
+func (stmt ClassDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt ClassDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt ClassDeclStmt) GetFile() string { return stmt.File }
 
 type ClassBody struct {
 	Members []ClassMember
@@ -111,55 +198,287 @@ type ClassBody struct {
 func (body ClassBody) GetLine() int   { return body.Line }
 func (body ClassBody) GetColumn() int { return body.Column }
 
+// GetFile returns the path of the source file the member was declared in
 type ClassMember interface {
 	classMember()
 	GetLine() int
 	GetColumn() int
+	GetFile() string
 }
 
 type FieldDeclStmt struct {
+	Attributes []Attribute
 	Modifiers  []Modifier
 	Type       Type
 	Identifier string
 	Value      Expr
+	File       string
 	Line       int
 	Column     int
 }
 
-func (stmt FieldDeclStmt) classMember()   {}
-func (stmt FieldDeclStmt) GetLine() int   { return stmt.Line }
-func (stmt FieldDeclStmt) GetColumn() int { return stmt.Column }
+func (stmt FieldDeclStmt) classMember()    {}
+func (stmt FieldDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt FieldDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt FieldDeclStmt) GetFile() string { return stmt.File }
 
+// IsTopLevel marks the Main method synthesised from top-level statements
 type MethodDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	ReturnType Type
+	Name       string
+	Parameters []Parameter
+	Body       Stmt
+	IsTopLevel bool
+	// The operator like + or true that an operator declaration overloads, implicit or explicit for
+	// conversions and empty for other methods. Operators are named like op_Addition.
+	Operator string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (stmt MethodDeclStmt) classMember()    {}
+func (stmt MethodDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt MethodDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt MethodDeclStmt) GetFile() string { return stmt.File }
+
+// T this[int i] { get { ... } set { ... } }, an expression-bodied indexer has a single get accessor
+type IndexerDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	Type       Type
+	Parameters []Parameter
+	Accessors  []Accessor
+	File       string
+	Line       int
+	Column     int
+}
+
+func (stmt IndexerDeclStmt) classMember()    {}
+func (stmt IndexerDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt IndexerDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt IndexerDeclStmt) GetFile() string { return stmt.File }
+
+// T Name { get; init; } or T Name => expr;, Value initializes an automatically implemented property
+type PropertyDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	Type       Type
+	Name       string
+	Accessors  []Accessor
+	Value      Expr
+	File       string
+	Line       int
+	Column     int
+}
+
+func (stmt PropertyDeclStmt) classMember()    {}
+func (stmt PropertyDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt PropertyDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt PropertyDeclStmt) GetFile() string { return stmt.File }
+
+// A get, set, init, add or remove accessor, Body is nil for an automatically implemented accessor like get;
+type Accessor struct {
+	Kind   string
+	Body   Stmt
+	Line   int
+	Column int
+}
+
+// event EventHandler Clicked; or an event with add and remove accessors. Events without accessors
+// are field-like, the declaring class can use them like a field of the delegate type.
+type EventDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	Type       Type
+	Name       string
+	Accessors  []Accessor
+	File       string
+	Line       int
+	Column     int
+}
+
+func (stmt EventDeclStmt) classMember()    {}
+func (stmt EventDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt EventDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt EventDeclStmt) GetFile() string { return stmt.File }
+
+var binaryOperatorNames = map[lexer.TokenKind]string{
+	lexer.PLUS:                  "op_Addition",
+	lexer.MINUS:                 "op_Subtraction",
+	lexer.MULTIPLY:              "op_Multiply",
+	lexer.DIVIDE:                "op_Division",
+	lexer.MODULUS:               "op_Modulus",
+	lexer.BITWISE_OR:            "op_BitwiseOr",
+	lexer.BITWISE_AND:           "op_BitwiseAnd",
+	lexer.EQUALS:                "op_Equality",
+	lexer.NOT_EQUALS:            "op_Inequality",
+	lexer.LESS_THAN:             "op_LessThan",
+	lexer.GREATER_THAN:          "op_GreaterThan",
+	lexer.LESS_THAN_OR_EQUAL:    "op_LessThanOrEqual",
+	lexer.GREATER_THAN_OR_EQUAL: "op_GreaterThanOrEqual",
+}
+
+var unaryOperatorNames = map[lexer.TokenKind]string{
+	lexer.PLUS:      "op_UnaryPlus",
+	lexer.MINUS:     "op_UnaryNegation",
+	lexer.NOT:       "op_LogicalNot",
+	lexer.INCREMENT: "op_Increment",
+	lexer.DECREMENT: "op_Decrement",
+	lexer.TRUE:      "op_True",
+	lexer.FALSE:     "op_False",
+	lexer.IMPLICIT:  "op_Implicit",
+	lexer.EXPLICIT:  "op_Explicit",
+}
+
+// Returns the method name of an overloadable operator with the given number of operands
+// or an empty string if the operator can not be overloaded like that
+func OperatorMethodName(operator lexer.TokenKind, operands int) string {
+	switch operands {
+	case 1:
+		return unaryOperatorNames[operator]
+	case 2:
+		return binaryOperatorNames[operator]
+	}
+	return ""
+}
+
+// Identifies the value of a checked constant expression like a case label, equal constants have equal keys
+type ConstantKey struct {
+	// The enum of an enum member, nil for the other constants
+	Enum types.Type
+	// Literals as they are written in source, the member of enum members and the name of constant variables
+	Value  string
+	IsNull bool
+}
+
+func ConstantKeyOf(expr Expr) ConstantKey {
+	switch e := expr.(type) {
+	case TypedExpr:
+		if member, ok := e.Expr.(MemberAccessExpr); ok {
+			return ConstantKey{Enum: e.Type, Value: member.Member}
+		}
+		return ConstantKeyOf(e.Expr)
+	case IntLiteralExpr:
+		return ConstantKey{Value: fmt.Sprintf("%d", e.Value)}
+	case RealLiteralExpr:
+		return ConstantKey{Value: fmt.Sprintf("%g", e.Value)}
+	case BoolLiteralExpr:
+		return ConstantKey{Value: fmt.Sprintf("%t", e.Value)}
+	case CharLiteralExpr:
+		return ConstantKey{Value: fmt.Sprintf("'%c'", e.Value)}
+	case StringExpr:
+		return ConstantKey{Value: fmt.Sprintf("%q", e.Value)}
+	case NullLiteralExpr:
+		return ConstantKey{IsNull: true}
+	case PrefixExpr:
+		key := ConstantKeyOf(e.Expression)
+		key.Value = e.Operator.Value + key.Value
+		return key
+	case LocalVarExpr:
+		return ConstantKey{Value: e.Name}
+	case FieldVarExpr:
+		return ConstantKey{Value: e.Name}
+	}
+	return ConstantKey{}
+}
+
+func (key ConstantKey) String() string {
+	switch {
+	case key.IsNull:
+		return "null"
+	case key.Enum != nil:
+		return key.Enum.String() + "." + key.Value
+	}
+	return key.Value
+}
+
+// A function declared inside of a block. Captures lists the enclosing locals it refers to.
+type LocalFunctionStmt struct {
 	Modifiers  []Modifier
 	ReturnType Type
 	Name       string
 	Parameters []Parameter
 	Body       Stmt
+	Captures   []string
 	Line       int
 	Column     int
 }
 
-func (stmt MethodDeclStmt) classMember()   {}
-func (stmt MethodDeclStmt) GetLine() int   { return stmt.Line }
-func (stmt MethodDeclStmt) GetColumn() int { return stmt.Column }
+func (stmt LocalFunctionStmt) stmt()          {}
+func (stmt LocalFunctionStmt) GetLine() int   { return stmt.Line }
+func (stmt LocalFunctionStmt) GetColumn() int { return stmt.Column }
 
+// IsImplicit marks the parameterless constructor added by the parser to classes without one
 type ConstructorDeclStmt struct {
+	Attributes []Attribute
 	Modifiers  []Modifier
 	Name       string
 	Parameters []Parameter
 	Body       Stmt
+	IsImplicit bool
+	File       string
 	Line       int
 	Column     int
 }
 
-func (stmt ConstructorDeclStmt) classMember()   {}
-func (stmt ConstructorDeclStmt) GetLine() int   { return stmt.Line }
-func (stmt ConstructorDeclStmt) GetColumn() int { return stmt.Column }
+func (stmt ConstructorDeclStmt) classMember()    {}
+func (stmt ConstructorDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt ConstructorDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt ConstructorDeclStmt) GetFile() string { return stmt.File }
 
+// Modifiers are ref, out, in or params. Default is only set for optional parameters.
 type Parameter struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
 	Type       Type
 	Identifier string
+	Default    Expr
+}
+
+// Delegates can be declared on the top level or nested inside of a class
+type DelegateDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	ReturnType Type
+	Name       string
+	Parameters []Parameter
+	File       string
+	Line       int
+	Column     int
+}
+
+func (stmt DelegateDeclStmt) stmt()           {}
+func (stmt DelegateDeclStmt) classMember()    {}
+func (stmt DelegateDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt DelegateDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt DelegateDeclStmt) GetFile() string { return stmt.File }
+
+type EnumDeclStmt struct {
+	Attributes []Attribute
+	Modifiers  []Modifier
+	Name       string
+	Members    []EnumMember
+	File       string
+	Line       int
+	Column     int
+}
+
+func (stmt EnumDeclStmt) stmt()           {}
+func (stmt EnumDeclStmt) classMember()    {}
+func (stmt EnumDeclStmt) GetLine() int    { return stmt.Line }
+func (stmt EnumDeclStmt) GetColumn() int  { return stmt.Column }
+func (stmt EnumDeclStmt) GetFile() string { return stmt.File }
+
+// Value is nil if the member has no explicit value
+type EnumMember struct {
+	Attributes []Attribute
+	Name       string
+	Value      Expr
+	Line       int
+	Column     int
 }
 
 // Control flow statements
@@ -207,8 +526,7 @@ func (stmt BreakStmt) GetColumn() int { return stmt.Column }
 
 type SwitchStmt struct {
 	Expression Expr
-	Cases      []SwitchCase
-	Default    Stmt
+	Sections   []SwitchSection
 	Line       int
 	Column     int
 }
@@ -217,23 +535,104 @@ func (stmt SwitchStmt) stmt()          {}
 func (stmt SwitchStmt) GetLine() int   { return stmt.Line }
 func (stmt SwitchStmt) GetColumn() int { return stmt.Column }
 
-type SwitchCase struct {
-	Value  Expr
+// A switch section is a list of stacked labels followed by the statements they share
+type SwitchSection struct {
+	Labels []SwitchLabel
 	Body   Stmt
 	Line   int
 	Column int
 }
 
-func (stmt SwitchCase) stmt()          {}
-func (stmt SwitchCase) GetLine() int   { return stmt.Line }
-func (stmt SwitchCase) GetColumn() int { return stmt.Column }
+func (stmt SwitchSection) stmt()          {}
+func (stmt SwitchSection) GetLine() int   { return stmt.Line }
+func (stmt SwitchSection) GetColumn() int { return stmt.Column }
+
+// case pattern when guard: or default:
+type SwitchLabel struct {
+	IsDefault bool
+	Pattern   Pattern
+	Guard     Expr
+	Line      int
+	Column    int
+}
+
+// goto case value; or goto default; if Value is nil
+type GotoCaseStmt struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+func (stmt GotoCaseStmt) stmt()          {}
+func (stmt GotoCaseStmt) GetLine() int   { return stmt.Line }
+func (stmt GotoCaseStmt) GetColumn() int { return stmt.Column }
+
+// Exception handling
+
+type TryStmt struct {
+	Body    Stmt
+	Catches []CatchClause
+	Finally Stmt
+	Line    int
+	Column  int
+}
+
+func (stmt TryStmt) stmt()          {}
+func (stmt TryStmt) GetLine() int   { return stmt.Line }
+func (stmt TryStmt) GetColumn() int { return stmt.Column }
+
+// A catch clause without a type catches every exception.
+// Identifier and Filter are optional.
+type CatchClause struct {
+	Type       Type
+	Identifier string
+	Filter     Expr
+	Body       Stmt
+	Line       int
+	Column     int
+}
+
+func (clause CatchClause) GetLine() int   { return clause.Line }
+func (clause CatchClause) GetColumn() int { return clause.Column }
+
+// A throw statement without a value rethrows the exception of the enclosing catch
+type ThrowStmt struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+func (stmt ThrowStmt) stmt()          {}
+func (stmt ThrowStmt) GetLine() int   { return stmt.Line }
+func (stmt ThrowStmt) GetColumn() int { return stmt.Column }
+
+// yield return Value; inside of an iterator produces the next element of the sequence
+type YieldReturnStmt struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+func (stmt YieldReturnStmt) stmt()          {}
+func (stmt YieldReturnStmt) GetLine() int   { return stmt.Line }
+func (stmt YieldReturnStmt) GetColumn() int { return stmt.Column }
+
+// yield break; ends the iteration
+type YieldBreakStmt struct {
+	Line   int
+	Column int
+}
+
+func (stmt YieldBreakStmt) stmt()          {}
+func (stmt YieldBreakStmt) GetLine() int   { return stmt.Line }
+func (stmt YieldBreakStmt) GetColumn() int { return stmt.Column }
 
 // ========================================================================================================
 // Expressions
 // ========================================================================================================
 
 type TypedExpr struct {
-	Type   string
+	Type   types.Type
 	Expr   Expr
 	Line   int
 	Column int
@@ -283,8 +682,10 @@ func (expr FieldVarExpr) expr()          {}
 func (expr FieldVarExpr) GetLine() int   { return expr.Line }
 func (expr FieldVarExpr) GetColumn() int { return expr.Column }
 
+// Values above the range of long are stored with the bits of the ulong value, Suffix is U, L or UL
 type IntLiteralExpr struct {
 	Value  int64
+	Suffix string
 	Line   int
 	Column int
 }
@@ -293,6 +694,18 @@ func (expr IntLiteralExpr) expr()          {}
 func (expr IntLiteralExpr) GetLine() int   { return expr.Line }
 func (expr IntLiteralExpr) GetColumn() int { return expr.Column }
 
+// Suffix is F for float, M for decimal and D or empty for double
+type RealLiteralExpr struct {
+	Value  float64
+	Suffix string
+	Line   int
+	Column int
+}
+
+func (expr RealLiteralExpr) expr()          {}
+func (expr RealLiteralExpr) GetLine() int   { return expr.Line }
+func (expr RealLiteralExpr) GetColumn() int { return expr.Column }
+
 type BoolLiteralExpr struct {
 	Value  bool
 	Line   int
@@ -323,8 +736,10 @@ func (expr ThisExpr) GetLine() int   { return expr.Line }
 func (expr ThisExpr) GetColumn() int { return expr.Column }
 
 type NullLiteralExpr struct {
-	Line   int
-	Column int
+	// The default value of a declaration without initializer
+	IsImplicit bool
+	Line       int
+	Column     int
 }
 
 func (expr NullLiteralExpr) expr()          {}
@@ -335,8 +750,10 @@ type BinaryExpr struct {
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
-	Line     int
-	Column   int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr BinaryExpr) expr()          {}
@@ -346,14 +763,18 @@ func (expr BinaryExpr) GetColumn() int { return expr.Column }
 type PrefixExpr struct {
 	Operator   lexer.Token
 	Expression Expr
-	Line       int
-	Column     int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr PrefixExpr) expr()          {}
 func (expr PrefixExpr) GetLine() int   { return expr.Line }
 func (expr PrefixExpr) GetColumn() int { return expr.Column }
 
+// Compound assignments like a += b keep their operator, the type checker turns them into a = a + b
+// or into an event subscription
 type AssignmentExpr struct {
 	Assignee Expr
 	Operator lexer.Token
@@ -366,10 +787,24 @@ func (expr AssignmentExpr) expr()          {}
 func (expr AssignmentExpr) GetLine() int   { return expr.Line }
 func (expr AssignmentExpr) GetColumn() int { return expr.Column }
 
+// Identifies the overload a method or constructor call was resolved to by the type checker
+type MethodSignature struct {
+	Class          string
+	Name           string
+	ParameterTypes []types.Type
+	// "ref", "out" or "in" for the parameters passed by reference, it can be nil if there are none
+	ParameterModifiers []string
+	ReturnType         types.Type
+	// The return type is a reference type declared with ?
+	ReturnsNullable bool
+	IsStatic        bool
+}
+
 type MethodCallExpr struct {
 	Receiver   Expr
 	MethodName string
 	Args       []Expr
+	Signature  *MethodSignature
 	Line       int
 	Column     int
 }
@@ -389,21 +824,48 @@ func (expr MemberAccessExpr) expr()          {}
 func (expr MemberAccessExpr) GetLine() int   { return expr.Line }
 func (expr MemberAccessExpr) GetColumn() int { return expr.Column }
 
+// new T(args) with an optional initializer. TypeName is empty for a target-typed new() until the type checker sets it.
 type ConstructorCallExpr struct {
-	TypeName string
-	Args     []Expr
-	Line     int
-	Column   int
+	TypeName    string
+	Args        []Expr
+	Initializer *ObjectInitializer
+	Signature   *MethodSignature
+	Line        int
+	Column      int
 }
 
 func (expr ConstructorCallExpr) expr()          {}
 func (expr ConstructorCallExpr) GetLine() int   { return expr.Line }
 func (expr ConstructorCallExpr) GetColumn() int { return expr.Column }
 
+// The braces after an object creation like { Name = "x", [key] = value } or { 1, 2, { "key", 3 } }
+type ObjectInitializer struct {
+	Elements []InitializerElement
+	Line     int
+	Column   int
+}
+
+// Member = Value assigns a field, [Index] = Value assigns through the indexer and
+// elements of a collection initializer pass Args to the Add method. Instead of a Value,
+// Member = { ... } initializes the object already stored in the member.
+type InitializerElement struct {
+	Member string
+	Index  []Expr
+	Args   []Expr
+	Value  Expr
+	Nested *ObjectInitializer
+	// The Add method called for collection elements or the indexer accessor of an index initializer, set by the type checker
+	Signature *MethodSignature
+	Line      int
+	Column    int
+}
+
 type PreIncrementExpr struct {
 	Operand Expr
-	Line    int
-	Column  int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr PreIncrementExpr) expr()          {}
@@ -412,8 +874,10 @@ func (expr PreIncrementExpr) GetColumn() int { return expr.Column }
 
 type PostIncrementExpr struct {
 	Operand Expr
-	Line    int
-	Column  int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr PostIncrementExpr) expr()          {}
@@ -422,8 +886,10 @@ func (expr PostIncrementExpr) GetColumn() int { return expr.Column }
 
 type PreDecrementExpr struct {
 	Operand Expr
-	Line    int
-	Column  int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr PreDecrementExpr) expr()          {}
@@ -432,10 +898,503 @@ func (expr PreDecrementExpr) GetColumn() int { return expr.Column }
 
 type PostDecrementExpr struct {
 	Operand Expr
-	Line    int
-	Column  int
+	// The user-defined operator the expression resolves to, nil for built-in operators
+	Signature *MethodSignature
+	Line      int
+	Column    int
 }
 
 func (expr PostDecrementExpr) expr()          {}
 func (expr PostDecrementExpr) GetLine() int   { return expr.Line }
 func (expr PostDecrementExpr) GetColumn() int { return expr.Column }
+
+// Implicitly typed lambda parameters have an empty type name.
+// Either Body (block lambda) or Expression (expression lambda) is set.
+type LambdaExpr struct {
+	Parameters []Parameter
+	Body       Stmt
+	Expression Expr
+	Captures   []string
+	IsAsync    bool
+	Line       int
+	Column     int
+}
+
+func (expr LambdaExpr) expr()          {}
+func (expr LambdaExpr) GetLine() int   { return expr.Line }
+func (expr LambdaExpr) GetColumn() int { return expr.Column }
+
+// Created by the type checker when a method name is converted to a delegate
+type MethodGroupExpr struct {
+	Receiver   Expr
+	MethodName string
+	Signature  *MethodSignature
+	Line       int
+	Column     int
+}
+
+func (expr MethodGroupExpr) expr()          {}
+func (expr MethodGroupExpr) GetLine() int   { return expr.Line }
+func (expr MethodGroupExpr) GetColumn() int { return expr.Column }
+
+// Created by the type checker when a call targets a delegate instead of a method
+type InvocationExpr struct {
+	Callee Expr
+	Args   []Expr
+	Line   int
+	Column int
+}
+
+func (expr InvocationExpr) expr()          {}
+func (expr InvocationExpr) GetLine() int   { return expr.Line }
+func (expr InvocationExpr) GetColumn() int { return expr.Column }
+
+type ThrowExpr struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+func (expr ThrowExpr) expr()          {}
+func (expr ThrowExpr) GetLine() int   { return expr.Line }
+func (expr ThrowExpr) GetColumn() int { return expr.Column }
+
+// Arguments with a name (Foo(count: 3)) or a modifier (Foo(ref x)) are wrapped, positional ones are not
+type ArgumentExpr struct {
+	Name      string
+	Modifiers []Modifier
+	Value     Expr
+	Line      int
+	Column    int
+}
+
+func (expr ArgumentExpr) expr()          {}
+func (expr ArgumentExpr) GetLine() int   { return expr.Line }
+func (expr ArgumentExpr) GetColumn() int { return expr.Column }
+
+// Declares a new local inside of an out argument like Foo(out var x) or a deconstruction like (int x, var y) = pair
+type DeclarationExpr struct {
+	Type       Type
+	Identifier string
+	Line       int
+	Column     int
+}
+
+func (expr DeclarationExpr) expr()          {}
+func (expr DeclarationExpr) GetLine() int   { return expr.Line }
+func (expr DeclarationExpr) GetColumn() int { return expr.Column }
+
+// expr! suppresses nullable warnings for expr
+type NullForgivingExpr struct {
+	Operand Expr
+	Line    int
+	Column  int
+}
+
+func (expr NullForgivingExpr) expr()          {}
+func (expr NullForgivingExpr) GetLine() int   { return expr.Line }
+func (expr NullForgivingExpr) GetColumn() int { return expr.Column }
+
+// (a, b) or (Count: a, Name: b), Names holds an empty string for unnamed elements
+type TupleExpr struct {
+	Elements []Expr
+	Names    []string
+	Line     int
+	Column   int
+}
+
+func (expr TupleExpr) expr()          {}
+func (expr TupleExpr) GetLine() int   { return expr.Line }
+func (expr TupleExpr) GetColumn() int { return expr.Column }
+
+// (a, var b) = value, created by the type checker from an assignment to a tuple.
+// Signature is the Deconstruct method for values that are not tuples. Nested
+// deconstructions have no Value, they deconstruct the element at their position.
+type DeconstructionExpr struct {
+	Targets   []Expr
+	Value     Expr
+	Signature *MethodSignature
+	Line      int
+	Column    int
+}
+
+func (expr DeconstructionExpr) expr()          {}
+func (expr DeconstructionExpr) GetLine() int   { return expr.Line }
+func (expr DeconstructionExpr) GetColumn() int { return expr.Column }
+
+// new T[] { ... }, also created by the type checker for the arguments of a params parameter in expanded form
+type ArrayCreationExpr struct {
+	ElementType Type
+	Elements    []Expr
+	Line        int
+	Column      int
+}
+
+func (expr ArrayCreationExpr) expr()          {}
+func (expr ArrayCreationExpr) GetLine() int   { return expr.Line }
+func (expr ArrayCreationExpr) GetColumn() int { return expr.Column }
+
+// expr is pattern
+type IsPatternExpr struct {
+	Expression Expr
+	Pattern    Pattern
+	Line       int
+	Column     int
+}
+
+func (expr IsPatternExpr) expr()          {}
+func (expr IsPatternExpr) GetLine() int   { return expr.Line }
+func (expr IsPatternExpr) GetColumn() int { return expr.Column }
+
+// receiver[args] on arrays, strings and indexers. Getter and Setter are the accessors of the indexer
+// that the access uses, they are nil for arrays and strings.
+type ElementAccessExpr struct {
+	Receiver Expr
+	Args     []Expr
+	Getter   *MethodSignature
+	Setter   *MethodSignature
+	Line     int
+	Column   int
+}
+
+func (expr ElementAccessExpr) expr()          {}
+func (expr ElementAccessExpr) GetLine() int   { return expr.Line }
+func (expr ElementAccessExpr) GetColumn() int { return expr.Column }
+
+// (Type)expr, Signature is the user-defined conversion operator if one is used
+type CastExpr struct {
+	Type       Type
+	Expression Expr
+	Signature  *MethodSignature
+	Line       int
+	Column     int
+}
+
+func (expr CastExpr) expr()          {}
+func (expr CastExpr) GetLine() int   { return expr.Line }
+func (expr CastExpr) GetColumn() int { return expr.Column }
+
+// receiver with { Member = value }, a copy of the record or struct with the members changed
+type WithExpr struct {
+	Receiver    Expr
+	Initializer ObjectInitializer
+	Line        int
+	Column      int
+}
+
+func (expr WithExpr) expr()          {}
+func (expr WithExpr) GetLine() int   { return expr.Line }
+func (expr WithExpr) GetColumn() int { return expr.Column }
+
+// Left and Right compared like EqualityComparer<T>.Default.Equals does, the parser uses it for the members of
+// records. The type checker replaces it with the comparison the operand type needs.
+type ValueEqualsExpr struct {
+	Left   Expr
+	Right  Expr
+	Line   int
+	Column int
+}
+
+func (expr ValueEqualsExpr) expr()          {}
+func (expr ValueEqualsExpr) GetLine() int   { return expr.Line }
+func (expr ValueEqualsExpr) GetColumn() int { return expr.Column }
+
+// Value hashed like EqualityComparer<T>.Default.GetHashCode does, the parser uses it for the members of records.
+// The type checker sets Method to the GetHashCode method the value type declares, without one the runtime
+// hashes the value. Null hashes to 0.
+type ValueHashExpr struct {
+	Value  Expr
+	Method *MethodSignature
+	Line   int
+	Column int
+}
+
+func (expr ValueHashExpr) expr()          {}
+func (expr ValueHashExpr) GetLine() int   { return expr.Line }
+func (expr ValueHashExpr) GetColumn() int { return expr.Column }
+
+// receiver.Event += handler or receiver.Event -= handler, Accessor is the add or remove accessor it calls.
+// Receiver is a class name for static events.
+type EventSubscriptionExpr struct {
+	Receiver Expr
+	Event    string
+	Operator lexer.Token
+	Handler  Expr
+	Accessor *MethodSignature
+	Line     int
+	Column   int
+}
+
+func (expr EventSubscriptionExpr) expr()          {}
+func (expr EventSubscriptionExpr) GetLine() int   { return expr.Line }
+func (expr EventSubscriptionExpr) GetColumn() int { return expr.Column }
+
+// expr as Type evaluates to null instead of throwing if the conversion fails
+type AsExpr struct {
+	Expression Expr
+	Type       Type
+	Line       int
+	Column     int
+}
+
+func (expr AsExpr) expr()          {}
+func (expr AsExpr) GetLine() int   { return expr.Line }
+func (expr AsExpr) GetColumn() int { return expr.Column }
+
+// x switch { pattern when guard => value, ... }
+type SwitchExpr struct {
+	Expression Expr
+	Arms       []SwitchExprArm
+	Line       int
+	Column     int
+}
+
+func (expr SwitchExpr) expr()          {}
+func (expr SwitchExpr) GetLine() int   { return expr.Line }
+func (expr SwitchExpr) GetColumn() int { return expr.Column }
+
+type SwitchExprArm struct {
+	Pattern Pattern
+	Guard   Expr
+	Value   Expr
+	Line    int
+	Column  int
+}
+
+// await task, suspends the enclosing async method until the awaited task completed
+type AwaitExpr struct {
+	Expression Expr
+	Line       int
+	Column     int
+}
+
+func (expr AwaitExpr) expr()          {}
+func (expr AwaitExpr) GetLine() int   { return expr.Line }
+func (expr AwaitExpr) GetColumn() int { return expr.Column }
+
+// checked(x) or unchecked(x), Context is CHECKED or UNCHECKED
+type CheckedExpr struct {
+	Expression Expr
+	Context    lexer.TokenKind
+	Line       int
+	Column     int
+}
+
+func (expr CheckedExpr) expr()          {}
+func (expr CheckedExpr) GetLine() int   { return expr.Line }
+func (expr CheckedExpr) GetColumn() int { return expr.Column }
+
+// from x in xs where ... select ..., the type checker translates it into calls of the query operators.
+// Clauses starts with a FromClause, a SelectClause or GroupClause ends the query or is followed by an
+// IntoClause that continues it.
+type QueryExpr struct {
+	Clauses []QueryClause
+	Line    int
+	Column  int
+}
+
+func (expr QueryExpr) expr()          {}
+func (expr QueryExpr) GetLine() int   { return expr.Line }
+func (expr QueryExpr) GetColumn() int { return expr.Column }
+
+type QueryClause interface {
+	queryClause()
+	GetLine() int
+	GetColumn() int
+}
+
+// from T x in source, Type is empty for implicitly typed range variables
+type FromClause struct {
+	Type       Type
+	Identifier string
+	Source     Expr
+	Line       int
+	Column     int
+}
+
+type LetClause struct {
+	Identifier string
+	Value      Expr
+	Line       int
+	Column     int
+}
+
+type WhereClause struct {
+	Condition Expr
+	Line      int
+	Column    int
+}
+
+// join T x in source on outerKey equals innerKey into group, Into is empty without into
+type JoinClause struct {
+	Type       Type
+	Identifier string
+	Source     Expr
+	OuterKey   Expr
+	InnerKey   Expr
+	Into       string
+	Line       int
+	Column     int
+}
+
+type OrderByClause struct {
+	Orderings []Ordering
+	Line      int
+	Column    int
+}
+
+type Ordering struct {
+	Key        Expr
+	Descending bool
+	Line       int
+	Column     int
+}
+
+type SelectClause struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+// group element by key
+type GroupClause struct {
+	Element Expr
+	Key     Expr
+	Line    int
+	Column  int
+}
+
+// into x, continues the query with the results of the select or group clause before it
+type IntoClause struct {
+	Identifier string
+	Line       int
+	Column     int
+}
+
+func (clause FromClause) queryClause()      {}
+func (clause FromClause) GetLine() int      { return clause.Line }
+func (clause FromClause) GetColumn() int    { return clause.Column }
+func (clause LetClause) queryClause()       {}
+func (clause LetClause) GetLine() int       { return clause.Line }
+func (clause LetClause) GetColumn() int     { return clause.Column }
+func (clause WhereClause) queryClause()     {}
+func (clause WhereClause) GetLine() int     { return clause.Line }
+func (clause WhereClause) GetColumn() int   { return clause.Column }
+func (clause JoinClause) queryClause()      {}
+func (clause JoinClause) GetLine() int      { return clause.Line }
+func (clause JoinClause) GetColumn() int    { return clause.Column }
+func (clause OrderByClause) queryClause()   {}
+func (clause OrderByClause) GetLine() int   { return clause.Line }
+func (clause OrderByClause) GetColumn() int { return clause.Column }
+func (clause SelectClause) queryClause()    {}
+func (clause SelectClause) GetLine() int    { return clause.Line }
+func (clause SelectClause) GetColumn() int  { return clause.Column }
+func (clause GroupClause) queryClause()     {}
+func (clause GroupClause) GetLine() int     { return clause.Line }
+func (clause GroupClause) GetColumn() int   { return clause.Column }
+func (clause IntoClause) queryClause()      {}
+func (clause IntoClause) GetLine() int      { return clause.Line }
+func (clause IntoClause) GetColumn() int    { return clause.Column }
+
+// ========================================================================================================
+// Patterns
+// ========================================================================================================
+
+// Matches if the input equals a constant value
+type ConstantPattern struct {
+	Value  Expr
+	Line   int
+	Column int
+}
+
+func (pattern ConstantPattern) pattern()       {}
+func (pattern ConstantPattern) GetLine() int   { return pattern.Line }
+func (pattern ConstantPattern) GetColumn() int { return pattern.Column }
+
+// Matches if the input is a non-null instance of Type
+type TypePattern struct {
+	Type   Type
+	Line   int
+	Column int
+}
+
+func (pattern TypePattern) pattern()       {}
+func (pattern TypePattern) GetLine() int   { return pattern.Line }
+func (pattern TypePattern) GetColumn() int { return pattern.Column }
+
+// A type pattern that also declares a variable holding the converted input: Dog d or var x
+type DeclarationPattern struct {
+	Type       Type
+	Identifier string
+	Line       int
+	Column     int
+}
+
+func (pattern DeclarationPattern) pattern()       {}
+func (pattern DeclarationPattern) GetLine() int   { return pattern.Line }
+func (pattern DeclarationPattern) GetColumn() int { return pattern.Column }
+
+// _ matches everything
+type DiscardPattern struct {
+	Line   int
+	Column int
+}
+
+func (pattern DiscardPattern) pattern()       {}
+func (pattern DiscardPattern) GetLine() int   { return pattern.Line }
+func (pattern DiscardPattern) GetColumn() int { return pattern.Column }
+
+// < 5, >= 'a' and so on
+type RelationalPattern struct {
+	Operator lexer.Token
+	Value    Expr
+	Line     int
+	Column   int
+}
+
+func (pattern RelationalPattern) pattern()       {}
+func (pattern RelationalPattern) GetLine() int   { return pattern.Line }
+func (pattern RelationalPattern) GetColumn() int { return pattern.Column }
+
+type NotPattern struct {
+	Pattern Pattern
+	Line    int
+	Column  int
+}
+
+func (pattern NotPattern) pattern()       {}
+func (pattern NotPattern) GetLine() int   { return pattern.Line }
+func (pattern NotPattern) GetColumn() int { return pattern.Column }
+
+// Combines two patterns with the contextual keywords and / or
+type BinaryPattern struct {
+	Left     Pattern
+	Operator string
+	Right    Pattern
+	Line     int
+	Column   int
+}
+
+func (pattern BinaryPattern) pattern()       {}
+func (pattern BinaryPattern) GetLine() int   { return pattern.Line }
+func (pattern BinaryPattern) GetColumn() int { return pattern.Column }
+
+// Type { Member: pattern, ... } identifier where the type and the identifier are optional
+type PropertyPattern struct {
+	Type       Type
+	Properties []PropertySubpattern
+	Identifier string
+	Line       int
+	Column     int
+}
+
+func (pattern PropertyPattern) pattern()       {}
+func (pattern PropertyPattern) GetLine() int   { return pattern.Line }
+func (pattern PropertyPattern) GetColumn() int { return pattern.Column }
+
+type PropertySubpattern struct {
+	Member  string
+	Pattern Pattern
+	Line    int
+	Column  int
+}
@@ -17,7 +17,75 @@ func (prog Program) String() string {
 	for i, c := range prog.Classes {
 		classes[i] = indentString(c.String(), 1)
 	}
-	return fmt.Sprintf("Program{\n  Classes: [\n%s\n  ]\n}", strings.Join(classes, ",\n"))
+	enums := make([]string, len(prog.Enums))
+	for i, e := range prog.Enums {
+		enums[i] = indentString(e.String(), 1)
+	}
+	delegates := make([]string, len(prog.Delegates))
+	for i, d := range prog.Delegates {
+		delegates[i] = indentString(d.String(), 1)
+	}
+	statements := make([]string, len(prog.Statements))
+	for i, s := range prog.Statements {
+		statements[i] = indentString(fmt.Sprint(s), 1)
+	}
+	return fmt.Sprintf("Program{\n  Classes: [\n%s\n  ],\n  Enums: [\n%s\n  ],\n  Delegates: [\n%s\n  ],\n  Statements: [\n%s\n  ]\n}",
+		strings.Join(classes, ",\n"), strings.Join(enums, ",\n"), strings.Join(delegates, ",\n"), strings.Join(statements, ",\n"))
+}
+
+func (typ Type) String() string {
+	if typ.IsNullable {
+		return typ.Name + "?"
+	}
+	return typ.Name
+}
+
+func parametersString(parameters []Parameter) string {
+	params := make([]string, len(parameters))
+	for i, p := range parameters {
+		params[i] = p.String()
+	}
+	return strings.Join(params, ", ")
+}
+
+func (param Parameter) String() string {
+	result := param.Identifier
+	if param.Type.Name != "" {
+		result = fmt.Sprintf("%s %s", param.Type, param.Identifier)
+	}
+	for i := len(param.Modifiers) - 1; i >= 0; i-- {
+		result = fmt.Sprintf("%s %s", strings.ToLower(lexer.TokenKindString(param.Modifiers[i].Kind)), result)
+	}
+	if param.Default != nil {
+		result = fmt.Sprintf("%s = %s", result, strings.ReplaceAll(fmt.Sprintf("%s", param.Default), "\n", ""))
+	}
+	if len(param.Attributes) > 0 {
+		result = fmt.Sprintf("[%s] %s", attributesString(param.Attributes), result)
+	}
+	return result
+}
+
+func (attribute Attribute) String() string {
+	args := make([]string, 0, len(attribute.Args)+len(attribute.NamedArgs))
+	for _, arg := range attribute.Args {
+		args = append(args, strings.ReplaceAll(fmt.Sprintf("%s", arg), "\n", ""))
+	}
+	for _, arg := range attribute.NamedArgs {
+		args = append(args, fmt.Sprintf("%s = %s", arg.Name, strings.ReplaceAll(fmt.Sprintf("%s", arg.Value), "\n", "")))
+	}
+	result := fmt.Sprintf("%s(%s)", attribute.Name, strings.Join(args, ", "))
+	if attribute.Target != "" {
+		result = fmt.Sprintf("%s: %s", attribute.Target, result)
+	}
+	return result
+}
+
+func attributesString(attributes []Attribute) string {
+	result := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		result[i] = attribute.String()
+	}
+	return strings.Join(result, ", ")
 }
 
 //=========================================================================================================
@@ -29,7 +97,11 @@ func (expr TypedExpr) String() string {
 }
 
 func (expr IntLiteralExpr) String() string {
-	return fmt.Sprintf("IntLiteralExpr{\n  Value: %d\n}", expr.Value)
+	return fmt.Sprintf("IntLiteralExpr{\n  Value: %d%s\n}", expr.Value, expr.Suffix)
+}
+
+func (expr RealLiteralExpr) String() string {
+	return fmt.Sprintf("RealLiteralExpr{\n  Value: %g%s\n}", expr.Value, expr.Suffix)
 }
 
 func (expr StringExpr) String() string {
@@ -65,11 +137,18 @@ func (expr CharLiteralExpr) String() string {
 }
 
 func (expr BinaryExpr) String() string {
+	if expr.Signature != nil {
+		return fmt.Sprintf("BinaryExpr{\n  Left: %s,\n  Operator: %s,\n  Signature: %s,\n  Right: %s\n}",
+			indentString(fmt.Sprintf("%s", expr.Left), 1), expr.Operator, expr.Signature, indentString(fmt.Sprintf("%s", expr.Right), 1))
+	}
 	return fmt.Sprintf("BinaryExpr{\n  Left: %s,\n  Operator: %s,\n  Right: %s\n}",
 		indentString(fmt.Sprintf("%s", expr.Left), 1), expr.Operator, indentString(fmt.Sprintf("%s", expr.Right), 1))
 }
 
 func (expr PrefixExpr) String() string {
+	if expr.Signature != nil {
+		return fmt.Sprintf("PrefixExpr{\n  Operator: %s,\n  Signature: %s,\n  Expression: %s\n}", expr.Operator, expr.Signature, indentString(fmt.Sprintf("%s", expr.Expression), 1))
+	}
 	return fmt.Sprintf("PrefixExpr{\n  Operator: %s,\n  Expression: %s\n}", expr.Operator, indentString(fmt.Sprintf("%s", expr.Expression), 1))
 }
 
@@ -83,7 +162,7 @@ func (expr MethodCallExpr) String() string {
 	for i, arg := range expr.Args {
 		args[i] = indentString(fmt.Sprintf("%s", arg), 2)
 	}
-	return fmt.Sprintf("MethodCallExpr{\n  Receiver: %s,\n  MethodName: %s,\n  Arguments: [\n%s\n  ]\n}", indentString(fmt.Sprintf("%s", expr.Receiver), 1), expr.MethodName, strings.Join(args, ",\n"))
+	return fmt.Sprintf("MethodCallExpr{\n  Receiver: %s,\n  MethodName: %s,\n  Signature: %s,\n  Arguments: [\n%s\n  ]\n}", indentString(fmt.Sprintf("%s", expr.Receiver), 1), expr.MethodName, expr.Signature, strings.Join(args, ",\n"))
 }
 
 func (expr MemberAccessExpr) String() string {
@@ -95,7 +174,135 @@ func (expr ConstructorCallExpr) String() string {
 	for i, arg := range expr.Args {
 		args[i] = indentString(fmt.Sprintf("%s", arg), 2)
 	}
-	return fmt.Sprintf("ConstructorCallExpr{\n  ClassName: %s,\n  Arguments: [\n%s\n  ]\n}", expr.TypeName, strings.Join(args, ",\n"))
+	if expr.Initializer == nil {
+		return fmt.Sprintf("ConstructorCallExpr{\n  ClassName: %s,\n  Signature: %s,\n  Arguments: [\n%s\n  ]\n}", expr.TypeName, expr.Signature, strings.Join(args, ",\n"))
+	}
+	return fmt.Sprintf("ConstructorCallExpr{\n  ClassName: %s,\n  Signature: %s,\n  Arguments: [\n%s\n  ],\n  Initializer: %s\n}",
+		expr.TypeName, expr.Signature, strings.Join(args, ",\n"), indentString(expr.Initializer.String(), 1))
+}
+
+func (initializer ObjectInitializer) String() string {
+	elements := make([]string, len(initializer.Elements))
+	for i, element := range initializer.Elements {
+		elements[i] = indentString(element.String(), 2)
+	}
+	return fmt.Sprintf("ObjectInitializer{\n  Elements: [\n%s\n  ]\n}", strings.Join(elements, ",\n"))
+}
+
+func (element InitializerElement) String() string {
+	values := make([]string, len(element.Args))
+	for i, arg := range element.Args {
+		values[i] = indentString(fmt.Sprintf("%s", arg), 2)
+	}
+	if element.Args != nil {
+		return fmt.Sprintf("CollectionElement{\n  Signature: %s,\n  Arguments: [\n%s\n  ]\n}", element.Signature, strings.Join(values, ",\n"))
+	}
+
+	target := element.Member
+	if element.Index != nil {
+		indices := make([]string, len(element.Index))
+		for i, index := range element.Index {
+			indices[i] = strings.ReplaceAll(fmt.Sprintf("%s", index), "\n", "")
+		}
+		target = fmt.Sprintf("[%s]", strings.Join(indices, ", "))
+	}
+	if element.Nested != nil {
+		return fmt.Sprintf("MemberInitializer{\n  Target: %s,\n  Initializer: %s\n}", target, indentString(element.Nested.String(), 1))
+	}
+	return fmt.Sprintf("MemberInitializer{\n  Target: %s,\n  Value: %s\n}", target, indentString(fmt.Sprintf("%s", element.Value), 1))
+}
+
+func (signature *MethodSignature) String() string {
+	if signature == nil {
+		return "unresolved"
+	}
+	return fmt.Sprintf("%s %s.%s(%s)", signature.ReturnType, signature.Class, signature.Name, signature.ParameterList())
+}
+
+// The parameter types separated by commas, parameters passed by reference with their modifier
+func (signature *MethodSignature) ParameterList() string {
+	parameters := make([]string, len(signature.ParameterTypes))
+	for i, typ := range signature.ParameterTypes {
+		parameters[i] = typ.String()
+		if i < len(signature.ParameterModifiers) && signature.ParameterModifiers[i] != "" {
+			parameters[i] = signature.ParameterModifiers[i] + " " + parameters[i]
+		}
+	}
+	return strings.Join(parameters, ", ")
+}
+
+func (expr LambdaExpr) String() string {
+	body := fmt.Sprintf("%s", expr.Body)
+	if expr.Expression != nil {
+		body = fmt.Sprintf("%s", expr.Expression)
+	}
+	async := ""
+	if expr.IsAsync {
+		async = "\n  Async: true,"
+	}
+	return fmt.Sprintf("LambdaExpr{%s\n  Parameters: [%s],\n  Captures: [%s],\n  Body: %s\n}",
+		async, parametersString(expr.Parameters), strings.Join(expr.Captures, ", "), indentString(body, 1))
+}
+
+func (expr MethodGroupExpr) String() string {
+	return fmt.Sprintf("MethodGroupExpr{\n  Receiver: %s,\n  MethodName: %s,\n  Signature: %s\n}", indentString(fmt.Sprintf("%s", expr.Receiver), 1), expr.MethodName, expr.Signature)
+}
+
+func (expr InvocationExpr) String() string {
+	args := make([]string, len(expr.Args))
+	for i, arg := range expr.Args {
+		args[i] = indentString(fmt.Sprintf("%s", arg), 2)
+	}
+	return fmt.Sprintf("InvocationExpr{\n  Callee: %s,\n  Arguments: [\n%s\n  ]\n}", indentString(fmt.Sprintf("%s", expr.Callee), 1), strings.Join(args, ",\n"))
+}
+
+func (expr NullForgivingExpr) String() string {
+	return fmt.Sprintf("NullForgivingExpr{\n  Operand: %s\n}", indentString(fmt.Sprintf("%s", expr.Operand), 1))
+}
+
+func (expr TupleExpr) String() string {
+	elements := make([]string, len(expr.Elements))
+	for i, element := range expr.Elements {
+		if expr.Names[i] != "" {
+			elements[i] = indentString(fmt.Sprintf("%s: %s", expr.Names[i], element), 2)
+		} else {
+			elements[i] = indentString(fmt.Sprintf("%s", element), 2)
+		}
+	}
+	return fmt.Sprintf("TupleExpr{\n  Elements: [\n%s\n  ]\n}", strings.Join(elements, ",\n"))
+}
+
+func (expr DeconstructionExpr) String() string {
+	targets := make([]string, len(expr.Targets))
+	for i, target := range expr.Targets {
+		targets[i] = indentString(fmt.Sprintf("%s", target), 2)
+	}
+	return fmt.Sprintf("DeconstructionExpr{\n  Targets: [\n%s\n  ],\n  Value: %s,\n  Signature: %s\n}",
+		strings.Join(targets, ",\n"), indentString(fmt.Sprintf("%s", expr.Value), 1), expr.Signature)
+}
+
+func (expr ThrowExpr) String() string {
+	return fmt.Sprintf("ThrowExpr{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", expr.Value), 1))
+}
+
+func (expr ArgumentExpr) String() string {
+	modifiers := make([]string, len(expr.Modifiers))
+	for i, mod := range expr.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
+	}
+	return fmt.Sprintf("ArgumentExpr{\n  Name: %s,\n  Modifiers: [%s],\n  Value: %s\n}", expr.Name, strings.Join(modifiers, ", "), indentString(fmt.Sprintf("%s", expr.Value), 1))
+}
+
+func (expr DeclarationExpr) String() string {
+	return fmt.Sprintf("DeclarationExpr{\n  Type: %s,\n  Identifier: %s\n}", expr.Type.Name, expr.Identifier)
+}
+
+func (expr ArrayCreationExpr) String() string {
+	elements := make([]string, len(expr.Elements))
+	for i, element := range expr.Elements {
+		elements[i] = indentString(fmt.Sprintf("%s", element), 2)
+	}
+	return fmt.Sprintf("ArrayCreationExpr{\n  ElementType: %s,\n  Elements: [\n%s\n  ]\n}", expr.ElementType.Name, strings.Join(elements, ",\n"))
 }
 
 func (expr PreDecrementExpr) String() string {
@@ -140,7 +347,15 @@ func (stmt VarDeclStmt) String() string {
 		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
 	return fmt.Sprintf("VarDeclStmt{\n  Modifiers: [%s],\n  Type: %s,\n  Identifier: %s,\n  Value: %s\n}",
-		strings.Join(modifiers, ", "), stmt.Type.Name, stmt.Identifier, indentString(fmt.Sprintf("%s", stmt.Value), 1))
+		strings.Join(modifiers, ", "), stmt.Type, stmt.Identifier, indentString(fmt.Sprintf("%s", stmt.Value), 1))
+}
+
+func (stmt MultiVarDeclStmt) String() string {
+	declarations := make([]string, len(stmt.Declarations))
+	for i, decl := range stmt.Declarations {
+		declarations[i] = indentString(decl.String(), 1)
+	}
+	return fmt.Sprintf("MultiVarDeclStmt{\n  Declarations: [\n%s\n  ]\n}", strings.Join(declarations, ",\n"))
 }
 
 func (stmt ClassDeclStmt) String() string {
@@ -148,8 +363,16 @@ func (stmt ClassDeclStmt) String() string {
 	for i, mod := range stmt.Modifiers {
 		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	return fmt.Sprintf("ClassDeclStmt{\n  Modifiers: [%s],\n  Name: %s,\n  Body: %s\n}",
-		strings.Join(modifiers, ", "), stmt.Name, indentString(stmt.Body.String(), 1))
+	baseTypes := make([]string, len(stmt.BaseTypes))
+	for i, base := range stmt.BaseTypes {
+		baseTypes[i] = base.Name
+	}
+	kind := lexer.TokenKindString(stmt.Kind)
+	if stmt.IsRecord {
+		kind = fmt.Sprintf("RECORD %s (%s)", kind, parametersString(stmt.Parameters))
+	}
+	return fmt.Sprintf("ClassDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Kind: %s,\n  Name: %s,\n  Namespace: %s,\n  BaseTypes: [%s],\n  Body: %s\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), kind, stmt.Name, stmt.Namespace, strings.Join(baseTypes, ", "), indentString(stmt.Body.String(), 1))
 }
 
 func (body ClassBody) String() string {
@@ -165,8 +388,8 @@ func (stmt FieldDeclStmt) String() string {
 	for i, mod := range stmt.Modifiers {
 		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	return fmt.Sprintf("FieldDeclStmt{\n  Modifiers: [%s],\n  Type: %s,\n  Identifier: %s,\n  Value: %s\n}",
-		strings.Join(modifiers, ", "), stmt.Type.Name, stmt.Identifier, indentString(fmt.Sprintf("%s", stmt.Value), 1))
+	return fmt.Sprintf("FieldDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Type: %s,\n  Identifier: %s,\n  Value: %s\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Type, stmt.Identifier, indentString(fmt.Sprintf("%s", stmt.Value), 1))
 }
 
 func (stmt MethodDeclStmt) String() string {
@@ -174,12 +397,71 @@ func (stmt MethodDeclStmt) String() string {
 	for i, mod := range stmt.Modifiers {
 		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	params := make([]string, len(stmt.Parameters))
-	for i, p := range stmt.Parameters {
-		params[i] = fmt.Sprintf("%s %s", p.Type.Name, p.Identifier)
+	name := stmt.Name
+	if stmt.Operator != "" {
+		name = fmt.Sprintf("%s (operator %s)", stmt.Name, stmt.Operator)
+	}
+	return fmt.Sprintf("MethodDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  ReturnType: %s,\n  Name: %s,\n  Parameters: [%s],\n  Body: %s\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.ReturnType, name, parametersString(stmt.Parameters), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+}
+
+func (stmt IndexerDeclStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
+	}
+	accessors := make([]string, len(stmt.Accessors))
+	for i, accessor := range stmt.Accessors {
+		accessors[i] = indentString(accessor.String(), 2)
+	}
+	return fmt.Sprintf("IndexerDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Type: %s,\n  Parameters: [%s],\n  Accessors: [\n%s\n  ]\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Type, parametersString(stmt.Parameters), strings.Join(accessors, ",\n"))
+}
+
+func (stmt PropertyDeclStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
+	}
+	accessors := make([]string, len(stmt.Accessors))
+	for i, accessor := range stmt.Accessors {
+		accessors[i] = indentString(accessor.String(), 2)
+	}
+	value := ""
+	if stmt.Value != nil {
+		value = fmt.Sprintf(",\n  Value: %s", indentString(fmt.Sprintf("%s", stmt.Value), 1))
+	}
+	return fmt.Sprintf("PropertyDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Type: %s,\n  Name: %s,\n  Accessors: [\n%s\n  ]%s\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Type, stmt.Name, strings.Join(accessors, ",\n"), value)
+}
+
+func (stmt EventDeclStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
+	}
+	accessors := make([]string, len(stmt.Accessors))
+	for i, accessor := range stmt.Accessors {
+		accessors[i] = indentString(accessor.String(), 2)
+	}
+	return fmt.Sprintf("EventDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Type: %s,\n  Name: %s,\n  Accessors: [\n%s\n  ]\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Type, stmt.Name, strings.Join(accessors, ",\n"))
+}
+
+func (accessor Accessor) String() string {
+	if accessor.Body == nil {
+		return fmt.Sprintf("Accessor{\n  Kind: %s\n}", accessor.Kind)
+	}
+	return fmt.Sprintf("Accessor{\n  Kind: %s,\n  Body: %s\n}", accessor.Kind, indentString(fmt.Sprintf("%s", accessor.Body), 1))
+}
+
+func (stmt LocalFunctionStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	return fmt.Sprintf("MethodDeclStmt{\n  Modifiers: [%s],\n  ReturnType: %s,\n  Name: %s,\n  Parameters: [%s],\n  Body: %s\n}",
-		strings.Join(modifiers, ", "), stmt.ReturnType.Name, stmt.Name, strings.Join(params, ", "), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+	return fmt.Sprintf("LocalFunctionStmt{\n  Modifiers: [%s],\n  ReturnType: %s,\n  Name: %s,\n  Parameters: [%s],\n  Captures: [%s],\n  Body: %s\n}",
+		strings.Join(modifiers, ", "), stmt.ReturnType, stmt.Name, parametersString(stmt.Parameters), strings.Join(stmt.Captures, ", "), indentString(fmt.Sprintf("%s", stmt.Body), 1))
 }
 
 func (stmt ConstructorDeclStmt) String() string {
@@ -187,12 +469,37 @@ func (stmt ConstructorDeclStmt) String() string {
 	for i, mod := range stmt.Modifiers {
 		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	params := make([]string, len(stmt.Parameters))
-	for i, p := range stmt.Parameters {
-		params[i] = fmt.Sprintf("%s %s", p.Type.Name, p.Identifier)
+	return fmt.Sprintf("ConstructorDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Name: %s,\n  Parameters: [%s],\n  Body: %s\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Name, parametersString(stmt.Parameters), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+}
+
+func (stmt DelegateDeclStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
 	}
-	return fmt.Sprintf("ConstructorDeclStmt{\n  Modifiers: [%s],\n  Name: %s,\n  Parameters: [%s],\n  Body: %s\n}",
-		strings.Join(modifiers, ", "), stmt.Name, strings.Join(params, ", "), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+	return fmt.Sprintf("DelegateDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  ReturnType: %s,\n  Name: %s,\n  Parameters: [%s]\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.ReturnType, stmt.Name, parametersString(stmt.Parameters))
+}
+
+func (stmt EnumDeclStmt) String() string {
+	modifiers := make([]string, len(stmt.Modifiers))
+	for i, mod := range stmt.Modifiers {
+		modifiers[i] = lexer.TokenKindString(mod.Kind)
+	}
+	members := make([]string, len(stmt.Members))
+	for i, member := range stmt.Members {
+		if member.Value != nil {
+			members[i] = fmt.Sprintf("%s = %s", member.Name, member.Value)
+		} else {
+			members[i] = member.Name
+		}
+		if len(member.Attributes) > 0 {
+			members[i] = fmt.Sprintf("[%s] %s", attributesString(member.Attributes), members[i])
+		}
+	}
+	return fmt.Sprintf("EnumDeclStmt{\n  Attributes: [%s],\n  Modifiers: [%s],\n  Name: %s,\n  Members: [%s]\n}",
+		attributesString(stmt.Attributes), strings.Join(modifiers, ", "), stmt.Name, strings.Join(members, ", "))
 }
 
 func (stmt ReturnStmt) String() string {
@@ -218,19 +525,218 @@ func (stmt BreakStmt) String() string {
 }
 
 func (stmt SwitchStmt) String() string {
-	cases := make([]string, len(stmt.Cases))
-	for i, c := range stmt.Cases {
-		cases[i] = indentString(c.String(), 1)
+	sections := make([]string, len(stmt.Sections))
+	for i, section := range stmt.Sections {
+		sections[i] = indentString(section.String(), 2)
+	}
+	return fmt.Sprintf("SwitchStmt{\n  Expression: %s,\n  Sections: [\n%s\n  ]\n}",
+		indentString(fmt.Sprintf("%s", stmt.Expression), 1), strings.Join(sections, ",\n"))
+}
+
+func (stmt SwitchSection) String() string {
+	labels := make([]string, len(stmt.Labels))
+	for i, label := range stmt.Labels {
+		labels[i] = indentString(label.String(), 2)
 	}
-	defaultCase := ""
-	if stmt.Default != nil {
-		defaultCase = indentString(fmt.Sprintf("%s", stmt.Default), 1)
+	return fmt.Sprintf("SwitchSection{\n  Labels: [\n%s\n  ],\n  Body: %s\n}",
+		strings.Join(labels, ",\n"), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+}
+
+func (label SwitchLabel) String() string {
+	if label.IsDefault {
+		return "Default"
+	}
+	if label.Guard == nil {
+		return fmt.Sprintf("Case{\n  Pattern: %s\n}", indentString(fmt.Sprintf("%s", label.Pattern), 1))
+	}
+	return fmt.Sprintf("Case{\n  Pattern: %s,\n  Guard: %s\n}",
+		indentString(fmt.Sprintf("%s", label.Pattern), 1), indentString(fmt.Sprintf("%s", label.Guard), 1))
+}
+
+func (stmt GotoCaseStmt) String() string {
+	if stmt.Value == nil {
+		return "GotoDefaultStmt{}"
 	}
-	return fmt.Sprintf("SwitchStmt{\n  Expression: %s,\n  Cases: [\n%s\n  ],\n  DefaultCase: %s\n}",
-		indentString(fmt.Sprintf("%s", stmt.Expression), 1), strings.Join(cases, ",\n"), defaultCase)
+	return fmt.Sprintf("GotoCaseStmt{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", stmt.Value), 1))
 }
 
-func (stmt SwitchCase) String() string {
-	return fmt.Sprintf("SwitchCase{\n  Value: %s,\n  Body: %s\n}",
-		indentString(fmt.Sprintf("%s", stmt.Value), 1), indentString(fmt.Sprintf("%s", stmt.Body), 1))
+func (stmt TryStmt) String() string {
+	catches := make([]string, len(stmt.Catches))
+	for i, c := range stmt.Catches {
+		catches[i] = indentString(c.String(), 1)
+	}
+	finally := ""
+	if stmt.Finally != nil {
+		finally = indentString(fmt.Sprintf("%s", stmt.Finally), 1)
+	}
+	return fmt.Sprintf("TryStmt{\n  Body: %s,\n  Catches: [\n%s\n  ],\n  Finally: %s\n}",
+		indentString(fmt.Sprintf("%s", stmt.Body), 1), strings.Join(catches, ",\n"), finally)
+}
+
+func (clause CatchClause) String() string {
+	return fmt.Sprintf("CatchClause{\n  Type: %s,\n  Identifier: %s,\n  Filter: %s,\n  Body: %s\n}",
+		clause.Type.Name, clause.Identifier, indentString(fmt.Sprintf("%s", clause.Filter), 1), indentString(fmt.Sprintf("%s", clause.Body), 1))
+}
+
+func (stmt ThrowStmt) String() string {
+	return fmt.Sprintf("ThrowStmt{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", stmt.Value), 1))
+}
+
+func (stmt YieldReturnStmt) String() string {
+	return fmt.Sprintf("YieldReturnStmt{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", stmt.Value), 1))
+}
+
+func (stmt YieldBreakStmt) String() string {
+	return "YieldBreakStmt{}"
+}
+
+func (expr IsPatternExpr) String() string {
+	return fmt.Sprintf("IsPatternExpr{\n  Expression: %s,\n  Pattern: %s\n}",
+		indentString(fmt.Sprintf("%s", expr.Expression), 1), indentString(fmt.Sprintf("%s", expr.Pattern), 1))
+}
+
+func (expr ElementAccessExpr) String() string {
+	args := make([]string, len(expr.Args))
+	for i, arg := range expr.Args {
+		args[i] = indentString(fmt.Sprintf("%s", arg), 2)
+	}
+	return fmt.Sprintf("ElementAccessExpr{\n  Receiver: %s,\n  Getter: %s,\n  Setter: %s,\n  Arguments: [\n%s\n  ]\n}", indentString(fmt.Sprintf("%s", expr.Receiver), 1), expr.Getter, expr.Setter, strings.Join(args, ",\n"))
+}
+
+func (expr CastExpr) String() string {
+	if expr.Signature != nil {
+		return fmt.Sprintf("CastExpr{\n  Type: %s,\n  Signature: %s,\n  Expression: %s\n}", expr.Type, expr.Signature, indentString(fmt.Sprintf("%s", expr.Expression), 1))
+	}
+	return fmt.Sprintf("CastExpr{\n  Type: %s,\n  Expression: %s\n}", expr.Type, indentString(fmt.Sprintf("%s", expr.Expression), 1))
+}
+
+func (expr ValueEqualsExpr) String() string {
+	return fmt.Sprintf("ValueEqualsExpr{\n  Left: %s,\n  Right: %s\n}", indentString(fmt.Sprintf("%s", expr.Left), 1), indentString(fmt.Sprintf("%s", expr.Right), 1))
+}
+
+func (expr ValueHashExpr) String() string {
+	return fmt.Sprintf("ValueHashExpr{\n  Value: %s,\n  Method: %s\n}", indentString(fmt.Sprintf("%s", expr.Value), 1), expr.Method)
+}
+
+func (expr WithExpr) String() string {
+	return fmt.Sprintf("WithExpr{\n  Receiver: %s,\n  Initializer: %s\n}", indentString(fmt.Sprintf("%s", expr.Receiver), 1), indentString(expr.Initializer.String(), 1))
+}
+
+func (expr EventSubscriptionExpr) String() string {
+	return fmt.Sprintf("EventSubscriptionExpr{\n  Receiver: %s,\n  Event: %s,\n  Operator: %s,\n  Accessor: %s,\n  Handler: %s\n}",
+		indentString(fmt.Sprintf("%s", expr.Receiver), 1), expr.Event, expr.Operator.Value, expr.Accessor, indentString(fmt.Sprintf("%s", expr.Handler), 1))
+}
+
+func (expr AsExpr) String() string {
+	return fmt.Sprintf("AsExpr{\n  Expression: %s,\n  Type: %s\n}", indentString(fmt.Sprintf("%s", expr.Expression), 1), expr.Type)
+}
+
+func (expr SwitchExpr) String() string {
+	arms := make([]string, len(expr.Arms))
+	for i, arm := range expr.Arms {
+		arms[i] = indentString(arm.String(), 2)
+	}
+	return fmt.Sprintf("SwitchExpr{\n  Expression: %s,\n  Arms: [\n%s\n  ]\n}",
+		indentString(fmt.Sprintf("%s", expr.Expression), 1), strings.Join(arms, ",\n"))
+}
+
+func (arm SwitchExprArm) String() string {
+	guard := ""
+	if arm.Guard != nil {
+		guard = fmt.Sprintf(",\n  Guard: %s", indentString(fmt.Sprintf("%s", arm.Guard), 1))
+	}
+	return fmt.Sprintf("Arm{\n  Pattern: %s%s,\n  Value: %s\n}",
+		indentString(fmt.Sprintf("%s", arm.Pattern), 1), guard, indentString(fmt.Sprintf("%s", arm.Value), 1))
+}
+
+func (expr AwaitExpr) String() string {
+	return fmt.Sprintf("AwaitExpr{\n  Expression: %s\n}", indentString(fmt.Sprintf("%s", expr.Expression), 1))
+}
+
+func (expr CheckedExpr) String() string {
+	return fmt.Sprintf("CheckedExpr{\n  Context: %s,\n  Expression: %s\n}", lexer.TokenKindString(expr.Context), indentString(fmt.Sprintf("%s", expr.Expression), 1))
+}
+
+func (expr QueryExpr) String() string {
+	clauses := make([]string, len(expr.Clauses))
+	for i, clause := range expr.Clauses {
+		clauses[i] = indentString(fmt.Sprintf("%s", clause), 2)
+	}
+	return fmt.Sprintf("QueryExpr{\n  Clauses: [\n%s\n  ]\n}", strings.Join(clauses, ",\n"))
+}
+
+func (clause FromClause) String() string {
+	return fmt.Sprintf("FromClause{\n  Type: %s,\n  Identifier: %s,\n  Source: %s\n}", clause.Type, clause.Identifier, indentString(fmt.Sprintf("%s", clause.Source), 1))
+}
+
+func (clause LetClause) String() string {
+	return fmt.Sprintf("LetClause{\n  Identifier: %s,\n  Value: %s\n}", clause.Identifier, indentString(fmt.Sprintf("%s", clause.Value), 1))
+}
+
+func (clause WhereClause) String() string {
+	return fmt.Sprintf("WhereClause{\n  Condition: %s\n}", indentString(fmt.Sprintf("%s", clause.Condition), 1))
+}
+
+func (clause JoinClause) String() string {
+	return fmt.Sprintf("JoinClause{\n  Type: %s,\n  Identifier: %s,\n  Source: %s,\n  OuterKey: %s,\n  InnerKey: %s,\n  Into: %s\n}",
+		clause.Type, clause.Identifier, indentString(fmt.Sprintf("%s", clause.Source), 1), indentString(fmt.Sprintf("%s", clause.OuterKey), 1),
+		indentString(fmt.Sprintf("%s", clause.InnerKey), 1), clause.Into)
+}
+
+func (clause OrderByClause) String() string {
+	orderings := make([]string, len(clause.Orderings))
+	for i, ordering := range clause.Orderings {
+		orderings[i] = indentString(fmt.Sprintf("Ordering{Descending: %t, Key: %s}", ordering.Descending, ordering.Key), 2)
+	}
+	return fmt.Sprintf("OrderByClause{\n  Orderings: [\n%s\n  ]\n}", strings.Join(orderings, ",\n"))
+}
+
+func (clause SelectClause) String() string {
+	return fmt.Sprintf("SelectClause{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", clause.Value), 1))
+}
+
+func (clause GroupClause) String() string {
+	return fmt.Sprintf("GroupClause{\n  Element: %s,\n  Key: %s\n}", indentString(fmt.Sprintf("%s", clause.Element), 1), indentString(fmt.Sprintf("%s", clause.Key), 1))
+}
+
+func (clause IntoClause) String() string {
+	return fmt.Sprintf("IntoClause{Identifier: %s}", clause.Identifier)
+}
+
+func (pattern ConstantPattern) String() string {
+	return fmt.Sprintf("ConstantPattern{\n  Value: %s\n}", indentString(fmt.Sprintf("%s", pattern.Value), 1))
+}
+
+func (pattern TypePattern) String() string {
+	return fmt.Sprintf("TypePattern{Type: %s}", pattern.Type)
+}
+
+func (pattern DeclarationPattern) String() string {
+	return fmt.Sprintf("DeclarationPattern{Type: %s, Identifier: %s}", pattern.Type, pattern.Identifier)
+}
+
+func (pattern DiscardPattern) String() string {
+	return "DiscardPattern{}"
+}
+
+func (pattern RelationalPattern) String() string {
+	return fmt.Sprintf("RelationalPattern{\n  Operator: %s,\n  Value: %s\n}", pattern.Operator.Value, indentString(fmt.Sprintf("%s", pattern.Value), 1))
+}
+
+func (pattern NotPattern) String() string {
+	return fmt.Sprintf("NotPattern{\n  Pattern: %s\n}", indentString(fmt.Sprintf("%s", pattern.Pattern), 1))
+}
+
+func (pattern BinaryPattern) String() string {
+	return fmt.Sprintf("BinaryPattern{\n  Left: %s,\n  Operator: %s,\n  Right: %s\n}",
+		indentString(fmt.Sprintf("%s", pattern.Left), 1), pattern.Operator, indentString(fmt.Sprintf("%s", pattern.Right), 1))
+}
+
+func (pattern PropertyPattern) String() string {
+	properties := make([]string, len(pattern.Properties))
+	for i, property := range pattern.Properties {
+		properties[i] = indentString(fmt.Sprintf("%s: %s", property.Member, property.Pattern), 2)
+	}
+	return fmt.Sprintf("PropertyPattern{\n  Type: %s,\n  Properties: [\n%s\n  ],\n  Identifier: %s\n}",
+		pattern.Type, strings.Join(properties, ",\n"), pattern.Identifier)
 }
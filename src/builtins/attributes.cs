@@ -0,0 +1,45 @@
+// Attribute classes of the built-in library
+
+public class Attribute {
+    public Attribute() {}
+}
+
+public enum AttributeTargets {
+    Assembly = 1,
+    Module = 2,
+    Class = 4,
+    Struct = 8,
+    Enum = 16,
+    Constructor = 32,
+    Method = 64,
+    Property = 128,
+    Field = 256,
+    Event = 512,
+    Interface = 1024,
+    Parameter = 2048,
+    Delegate = 4096,
+    ReturnValue = 8192,
+    GenericParameter = 16384,
+    All = 32767
+}
+
+[AttributeUsage(AttributeTargets.Class)]
+public class AttributeUsageAttribute : Attribute {
+    public bool AllowMultiple = false;
+    public bool Inherited = true;
+
+    public AttributeUsageAttribute(AttributeTargets validOn) {}
+}
+
+// Uses of obsolete declarations produce a warning, or an error if error is true
+[AttributeUsage(AttributeTargets.Class | AttributeTargets.Struct | AttributeTargets.Enum | AttributeTargets.Constructor | AttributeTargets.Method | AttributeTargets.Property | AttributeTargets.Field | AttributeTargets.Event | AttributeTargets.Interface | AttributeTargets.Delegate)]
+public class ObsoleteAttribute : Attribute {
+    public ObsoleteAttribute() {}
+    public ObsoleteAttribute(string message) {}
+    public ObsoleteAttribute(string message, bool error) {}
+}
+
+[AttributeUsage(AttributeTargets.Enum)]
+public class FlagsAttribute : Attribute {
+    public FlagsAttribute() {}
+}
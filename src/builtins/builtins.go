@@ -0,0 +1,38 @@
+package builtins
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/parser"
+)
+
+// The built-in library is written in the language itself and parsed on demand
+//
+//go:embed *.cs
+var sources embed.FS
+
+func Load() ast.Program {
+	library := ast.Program{}
+
+	entries, err := sources.ReadDir(".")
+	if err != nil {
+		panic(fmt.Sprintf("Could not read built-in library: %v", err))
+	}
+
+	for _, entry := range entries {
+		source, err := sources.ReadFile(entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("Could not read built-in library file %s: %v", entry.Name(), err))
+		}
+
+		program := parser.ParseFile(entry.Name(), lexer.Tokenize(string(source))).Program
+		library.Classes = append(library.Classes, program.Classes...)
+		library.Enums = append(library.Enums, program.Enums...)
+		library.Delegates = append(library.Delegates, program.Delegates...)
+	}
+
+	return library
+}
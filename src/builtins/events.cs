@@ -0,0 +1,7 @@
+// Types used by events of the built-in library
+
+public class EventArgs {
+    public EventArgs() {}
+}
+
+public delegate void EventHandler(object sender, EventArgs e);
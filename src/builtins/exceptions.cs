@@ -0,0 +1,73 @@
+// Exception hierarchy of the built-in library
+
+public class Exception {
+    public string Message;
+
+    public Exception() {}
+    public Exception(string message) {}
+}
+
+public class SystemException : Exception {
+    public SystemException() {}
+    public SystemException(string message) {}
+}
+
+public class ArgumentException : SystemException {
+    public ArgumentException() {}
+    public ArgumentException(string message) {}
+}
+
+public class ArgumentNullException : ArgumentException {
+    public ArgumentNullException() {}
+    public ArgumentNullException(string message) {}
+}
+
+public class ArgumentOutOfRangeException : ArgumentException {
+    public ArgumentOutOfRangeException() {}
+    public ArgumentOutOfRangeException(string message) {}
+}
+
+public class InvalidOperationException : SystemException {
+    public InvalidOperationException() {}
+    public InvalidOperationException(string message) {}
+}
+
+public class NotSupportedException : SystemException {
+    public NotSupportedException() {}
+    public NotSupportedException(string message) {}
+}
+
+public class NotImplementedException : SystemException {
+    public NotImplementedException() {}
+    public NotImplementedException(string message) {}
+}
+
+public class NullReferenceException : SystemException {
+    public NullReferenceException() {}
+    public NullReferenceException(string message) {}
+}
+
+public class IndexOutOfRangeException : SystemException {
+    public IndexOutOfRangeException() {}
+    public IndexOutOfRangeException(string message) {}
+}
+
+public class FormatException : SystemException {
+    public FormatException() {}
+    public FormatException(string message) {}
+}
+
+public class ArithmeticException : SystemException {
+    public ArithmeticException() {}
+    public ArithmeticException(string message) {}
+}
+
+public class DivideByZeroException : ArithmeticException {
+    public DivideByZeroException() {}
+    public DivideByZeroException(string message) {}
+}
+
+public class OverflowException : ArithmeticException {
+    public OverflowException() {}
+    public OverflowException(string message) {}
+}
@@ -0,0 +1,8 @@
+// Query operators of the built-in library
+//
+// Classes can not declare type parameters, so the query operators are not declared here. The type checker
+// adds the operators a call needs to Enumerable with the element type of the source and the types the
+// lambda arguments return, like Select(this List<int> source, Func<int, string> selector).
+namespace System.Linq;
+
+public static class Enumerable {}
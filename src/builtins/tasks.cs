@@ -0,0 +1,62 @@
+// Tasks of the built-in library
+//
+// Execution engines run async code on a deterministic single-threaded scheduler instead of threads and
+// timers. Continuations run one after another in the order they were scheduled. Delay registers a timer on
+// a virtual clock, once no continuation is left the clock jumps to the earliest timer. Waiting for a task
+// runs the scheduler until the task completed, so every run of an async program behaves the same.
+
+public class Task {
+    public bool IsCompleted { get; }
+    public bool IsFaulted { get; }
+    public Exception Exception { get; }
+
+    // A task that has already completed
+    public static Task CompletedTask { get; }
+
+    // Completes once the virtual clock advanced by the given number of milliseconds
+    public static Task Delay(int milliseconds) {}
+    // Completes after the continuations that were already scheduled have run
+    public static Task Yield() {}
+    // Schedules the action and completes after it ran
+    public static Task Run(Action action) {}
+
+    // Runs the scheduler until the task completed and rethrows its exception if it faulted
+    public void Wait() {}
+    public TaskAwaiter GetAwaiter() {}
+}
+
+public class TaskAwaiter {
+    public bool IsCompleted { get; }
+
+    // Runs the scheduler until the task completed and rethrows its exception if it faulted
+    public void GetResult() {}
+    // Schedules the continuation once the task completed
+    public void OnCompleted(Action continuation) {}
+}
+
+// Creates and completes the task of an async method, used by the lowered state machines
+public class AsyncTaskMethodBuilder {
+    public AsyncTaskMethodBuilder() {}
+
+    public Task Task { get; }
+
+    public void SetResult() {}
+    public void SetException(Exception exception) {}
+}
+
+// Async void methods have no task, the scheduler rethrows their exceptions after the continuation ran
+public class AsyncVoidMethodBuilder {
+    public AsyncVoidMethodBuilder() {}
+
+    public void SetResult() {}
+    public void SetException(Exception exception) {}
+}
+
+// The scheduler the execution engines provide
+public static class Scheduler {
+    // The time of the virtual clock in milliseconds
+    public static int Now { get; }
+
+    // Runs continuations and advances the virtual clock until nothing is scheduled anymore
+    public static void RunAll() {}
+}
@@ -3,6 +3,7 @@ package lexer
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 type regexHandler func(lex *lexer, regex *regexp.Regexp)
@@ -80,7 +81,7 @@ func createLexer(source string) *lexer {
 		patterns: []regexPattern{
 			// Definition of all patterns
 			// The Order is really important because the lexer will try to match the first pattern first
-			{regexp.MustCompile(`^[0-9]+(\.[0-9]+)?`), numberHandler},
+			{regexp.MustCompile(`^(0[xX][0-9a-fA-F_]+|0[bB][01_]+|[0-9][0-9_]*(\.[0-9][0-9_]*)?([eE][+-]?[0-9]+)?)([uU][lL]?|[lL][uU]?|[fFdDmM])?`), numberHandler},
 			{regexp.MustCompile(`^"[^"]*"`), stringHandler},
 			{regexp.MustCompile(`^'[^']'`), charHandler},
 			{regexp.MustCompile(`^\/\/.*`), skipHandler}, // skip comments
@@ -91,6 +92,7 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`^\}`), defaultHandler(CLOSE_BRACE, "}")},
 			{regexp.MustCompile(`^\[`), defaultHandler(OPEN_BRACKET, "[")},
 			{regexp.MustCompile(`^\]`), defaultHandler(CLOSE_BRACKET, "]")},
+			{regexp.MustCompile(`^\=\>`), defaultHandler(ARROW, "=>")},
 			{regexp.MustCompile(`^\==`), defaultHandler(EQUALS, "==")},
 			{regexp.MustCompile(`^\=`), defaultHandler(ASSIGNMENT, "=")},
 			{regexp.MustCompile(`^\!=`), defaultHandler(NOT_EQUALS, "!=")},
@@ -117,6 +119,11 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`^\,`), defaultHandler(COMMA, ",")},
 			{regexp.MustCompile(`^\&\&`), defaultHandler(AND, "&&")},
 			{regexp.MustCompile(`^\|\|`), defaultHandler(OR, "||")},
+			{regexp.MustCompile(`^\|`), defaultHandler(BITWISE_OR, "|")},
+			{regexp.MustCompile(`^\&`), defaultHandler(BITWISE_AND, "&")},
+			{regexp.MustCompile(`^\?\?`), defaultHandler(NULL_COALESCING, "??")},
+			{regexp.MustCompile(`^\?`), defaultHandler(QUESTION, "?")},
+			{regexp.MustCompile(`^#nullable[ \t]+(enable|disable|restore)\b`), nullableDirectiveHandler},
 			{regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`), identifierHandler},
 			// Keywords will be matched as identifiers and converted in the handler
 		},
@@ -131,9 +138,14 @@ func defaultHandler(kind TokenKind, value string) regexHandler {
 	}
 }
 
+// Numbers with a fraction, an exponent or a real suffix are real literals, the parser reads the suffixes
 func numberHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
-	lex.push(NewToken(INTLITERAL, match, lex.line, lex.column))
+	kind := INTLITERAL
+	if !strings.HasPrefix(match, "0x") && !strings.HasPrefix(match, "0X") && strings.ContainsAny(match, ".eEfFdDmM") {
+		kind = REALLITERAL
+	}
+	lex.push(NewToken(kind, match, lex.line, lex.column))
 	lex.advanceN(len(match))
 }
 
@@ -156,6 +168,12 @@ func charHandler(lex *lexer, regex *regexp.Regexp) {
 	lex.advanceN(len(match) + 2) // +2 for the quotes
 }
 
+func nullableDirectiveHandler(lex *lexer, regex *regexp.Regexp) {
+	match := regex.FindStringSubmatch(lex.remainder())
+	lex.push(NewToken(NULLABLE_DIRECTIVE, match[1], lex.line, lex.column))
+	lex.advanceN(len(match[0]))
+}
+
 func identifierHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
 	if kind, exists := keywords[match]; exists {
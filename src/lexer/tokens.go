@@ -37,8 +37,14 @@ const (
 	MULTIPLY_EQUALS       // *=
 	DIVIDE_EQUALS         // /=
 	MODULUS_EQUALS        // %=
+	ARROW                 // =>
 	AND                   // &&
 	OR                    // ||
+	BITWISE_OR            // |
+	BITWISE_AND           // &
+	QUESTION              // ?
+	NULL_COALESCING       // ??
+	NULLABLE_DIRECTIVE    // #nullable enable, the value is the setting
 	IF
 	ELSE
 	FOR
@@ -47,13 +53,21 @@ const (
 	SWITCH
 	CASE
 	DEFAULT
+	GOTO
 	BREAK
 	CONTINUE
 	RETURN
+	TRY
+	CATCH
+	FINALLY
+	THROW
 	TRUE
 	FALSE
 	NULL
 	NEW
+	IS
+	AS
+	WITH
 	THIS
 	BASE
 	IMPORT
@@ -61,16 +75,29 @@ const (
 	USING
 	CLASS
 	STRUCT
+	RECORD
 	INTERFACE
 	ENUM
+	DELEGATE
+	EVENT
+	OPERATOR
+	IMPLICIT
+	EXPLICIT
 	PUBLIC
-	FINAL
+	READONLY
 	PRIVATE
 	PROTECTED
 	INTERNAL
 	INCREMENT
 	DECREMENT
 	STATIC
+	PARTIAL
+	ASYNC
+	AWAIT
+	REF
+	OUT
+	IN
+	PARAMS
 	CONST
 	VOID
 	VAR
@@ -80,10 +107,24 @@ const (
 	FLOAT
 	DOUBLE
 	STRING
+	LONG
+	SHORT
+	BYTE
+	SBYTE
+	UINT
+	ULONG
+	USHORT
+	DECIMAL
+	OBJECT
+	CHECKED
+	UNCHECKED
 	STRINGLITERAL
 	CHARLITERAL
+	REALLITERAL
 )
 
+// WITH, RECORD, PARTIAL, ASYNC and AWAIT are contextual keywords, they are lexed as identifiers and
+// the parser decides where they are keywords
 var keywords = map[string]TokenKind{
 	"if":        IF,
 	"else":      ELSE,
@@ -93,14 +134,20 @@ var keywords = map[string]TokenKind{
 	"switch":    SWITCH,
 	"case":      CASE,
 	"default":   DEFAULT,
+	"goto":      GOTO,
 	"break":     BREAK,
 	"continue":  CONTINUE,
 	"return":    RETURN,
+	"try":       TRY,
+	"catch":     CATCH,
+	"finally":   FINALLY,
+	"throw":     THROW,
 	"true":      TRUE,
 	"false":     FALSE,
-	"final":     FINAL,
 	"null":      NULL,
 	"new":       NEW,
+	"is":        IS,
+	"as":        AS,
 	"this":      THIS,
 	"base":      BASE,
 	"import":    IMPORT,
@@ -110,11 +157,21 @@ var keywords = map[string]TokenKind{
 	"struct":    STRUCT,
 	"interface": INTERFACE,
 	"enum":      ENUM,
+	"delegate":  DELEGATE,
+	"event":     EVENT,
+	"operator":  OPERATOR,
+	"implicit":  IMPLICIT,
+	"explicit":  EXPLICIT,
 	"public":    PUBLIC,
 	"private":   PRIVATE,
 	"protected": PROTECTED,
 	"internal":  INTERNAL,
+	"readonly":  READONLY,
 	"static":    STATIC,
+	"ref":       REF,
+	"out":       OUT,
+	"in":        IN,
+	"params":    PARAMS,
 	"const":     CONST,
 	"void":      VOID,
 	"var":       VAR,
@@ -123,7 +180,18 @@ var keywords = map[string]TokenKind{
 	"int":       INT,
 	"float":     FLOAT,
 	"double":    DOUBLE,
-	"string":    STRINGLITERAL,
+	"string":    STRING,
+	"long":      LONG,
+	"short":     SHORT,
+	"byte":      BYTE,
+	"sbyte":     SBYTE,
+	"uint":      UINT,
+	"ulong":     ULONG,
+	"ushort":    USHORT,
+	"decimal":   DECIMAL,
+	"object":    OBJECT,
+	"checked":   CHECKED,
+	"unchecked": UNCHECKED,
 }
 
 type Token struct {
@@ -134,7 +202,7 @@ type Token struct {
 }
 
 func (token Token) String() string {
-	if token.isOneOfMany(INTLITERAL, STRINGLITERAL, IDENTIFIER) {
+	if token.isOneOfMany(INTLITERAL, REALLITERAL, STRINGLITERAL, IDENTIFIER) {
 		return fmt.Sprintf("%s (%s) at %d:%d", TokenKindString(token.Kind), token.Value, token.Line, token.Column)
 	}
 	return fmt.Sprintf("%s at %d:%d", TokenKindString(token.Kind), token.Line, token.Column)
@@ -150,7 +218,7 @@ func (token Token) isOneOfMany(expectedTokens ...TokenKind) bool {
 }
 
 func (token Token) Debug() {
-	if token.isOneOfMany(INTLITERAL, STRINGLITERAL, IDENTIFIER, CHARLITERAL) {
+	if token.isOneOfMany(INTLITERAL, REALLITERAL, STRINGLITERAL, IDENTIFIER, CHARLITERAL) {
 		fmt.Printf("%s (%s) at %d:%d\n", TokenKindString(token.Kind), token.Value, token.Line, token.Column)
 	} else {
 		fmt.Printf("%s at %d:%d\n", TokenKindString(token.Kind), token.Line, token.Column)
@@ -167,6 +235,8 @@ func TokenKindString(kind TokenKind) string {
 		return "EOF"
 	case INTLITERAL:
 		return "INTLITERAL"
+	case REALLITERAL:
+		return "REALLITERAL"
 	case CHARLITERAL:
 		return "CHARLITERAL"
 	case STRING:
@@ -229,6 +299,8 @@ func TokenKindString(kind TokenKind) string {
 		return "DIVIDE_EQUALS"
 	case MODULUS_EQUALS:
 		return "MODULUS_EQUALS"
+	case ARROW:
+		return "ARROW"
 	case IF:
 		return "IF"
 	case ELSE:
@@ -245,12 +317,22 @@ func TokenKindString(kind TokenKind) string {
 		return "CASE"
 	case DEFAULT:
 		return "DEFAULT"
+	case GOTO:
+		return "GOTO"
 	case BREAK:
 		return "BREAK"
 	case CONTINUE:
 		return "CONTINUE"
 	case RETURN:
 		return "RETURN"
+	case TRY:
+		return "TRY"
+	case CATCH:
+		return "CATCH"
+	case FINALLY:
+		return "FINALLY"
+	case THROW:
+		return "THROW"
 	case TRUE:
 		return "TRUE"
 	case FALSE:
@@ -259,6 +341,12 @@ func TokenKindString(kind TokenKind) string {
 		return "NULL"
 	case NEW:
 		return "NEW"
+	case IS:
+		return "IS"
+	case AS:
+		return "AS"
+	case WITH:
+		return "WITH"
 	case THIS:
 		return "THIS"
 	case BASE:
@@ -273,10 +361,22 @@ func TokenKindString(kind TokenKind) string {
 		return "CLASS"
 	case STRUCT:
 		return "STRUCT"
+	case RECORD:
+		return "RECORD"
 	case INTERFACE:
 		return "INTERFACE"
 	case ENUM:
 		return "ENUM"
+	case DELEGATE:
+		return "DELEGATE"
+	case EVENT:
+		return "EVENT"
+	case OPERATOR:
+		return "OPERATOR"
+	case IMPLICIT:
+		return "IMPLICIT"
+	case EXPLICIT:
+		return "EXPLICIT"
 	case PUBLIC:
 		return "PUBLIC"
 	case PRIVATE:
@@ -287,6 +387,20 @@ func TokenKindString(kind TokenKind) string {
 		return "INTERNAL"
 	case STATIC:
 		return "STATIC"
+	case PARTIAL:
+		return "PARTIAL"
+	case ASYNC:
+		return "ASYNC"
+	case AWAIT:
+		return "AWAIT"
+	case REF:
+		return "REF"
+	case OUT:
+		return "OUT"
+	case IN:
+		return "IN"
+	case PARAMS:
+		return "PARAMS"
 	case CONST:
 		return "CONST"
 	case VOID:
@@ -303,14 +417,46 @@ func TokenKindString(kind TokenKind) string {
 		return "FLOAT"
 	case DOUBLE:
 		return "DOUBLE"
+	case LONG:
+		return "LONG"
+	case SHORT:
+		return "SHORT"
+	case BYTE:
+		return "BYTE"
+	case SBYTE:
+		return "SBYTE"
+	case UINT:
+		return "UINT"
+	case ULONG:
+		return "ULONG"
+	case USHORT:
+		return "USHORT"
+	case DECIMAL:
+		return "DECIMAL"
+	case OBJECT:
+		return "OBJECT"
+	case CHECKED:
+		return "CHECKED"
+	case UNCHECKED:
+		return "UNCHECKED"
 	case STRINGLITERAL:
 		return "STRINGLITERAL"
 	case AND:
 		return "AND"
 	case OR:
 		return "OR"
-	case FINAL:
-		return "FINAL"
+	case BITWISE_OR:
+		return "BITWISE_OR"
+	case BITWISE_AND:
+		return "BITWISE_AND"
+	case QUESTION:
+		return "QUESTION"
+	case NULL_COALESCING:
+		return "NULL_COALESCING"
+	case NULLABLE_DIRECTIVE:
+		return "NULLABLE_DIRECTIVE"
+	case READONLY:
+		return "READONLY"
 	case INCREMENT:
 		return "INCREMENT"
 	case DECREMENT:
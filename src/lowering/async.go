@@ -0,0 +1,203 @@
+package lowering
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// An async method and the state machine class it is lowered to. MoveNext runs the method until it
+// awaits a task that has not completed yet and registers itself as the continuation of that task.
+// <>t__builder creates the task the method returns and completes it with the result or the exception
+// of the method. <>1__state starts with the first state 0 and is -2 once the method completed.
+type asyncMethod struct {
+	*machineClass
+	// void for void and Task, T for Task<T>
+	result      string
+	returnType  types.Type
+	builderType string
+}
+
+func isAsync(method ast.MethodDeclStmt) bool {
+	return method.Body != nil && hasModifier(method.Modifiers, lexer.ASYNC)
+}
+
+// Replaces the body of the async method with the creation and the first run of its state machine
+func (l *lowerer) lowerAsync(class string, method ast.MethodDeclStmt) (ast.MethodDeclStmt, ast.ClassDeclStmt) {
+	l.machines++
+	simpleName := fmt.Sprintf("<%s>d__%d", method.Name, l.machines)
+	a := &asyncMethod{machineClass: l.machineClass(class, simpleName, method), returnType: l.registry.Parse(method.ReturnType.Name)}
+	builderClass := "AsyncTaskMethodBuilder"
+	switch generic, isGeneric := a.returnType.(*types.Generic); {
+	case a.returnType == types.Void:
+		builderClass = "AsyncVoidMethodBuilder"
+		a.result, a.builderType = "void", builderClass
+	case isGeneric && generic.Name == "Task":
+		a.result, a.builderType = generic.Arguments[0].String(), l.registry.NewGeneric(builderClass, generic.Arguments).String()
+	default:
+		a.result, a.builderType = "void", builderClass
+	}
+	machine := a.stateMachine(method)
+
+	builderConstructor := &ast.MethodSignature{Class: a.builderType, Name: builderClass, ReturnType: types.Void}
+	members := []string{"<>t__builder"}
+	values := []ast.Expr{a.construct(a.builderType, builderConstructor, nil)}
+	if !a.isStatic {
+		members = append(members, "<>4__this")
+		values = append(values, a.this(class))
+	}
+	for _, param := range method.Parameters {
+		members = append(members, param.Identifier)
+		values = append(values, a.local(param.Identifier, param.Type.Name))
+	}
+	constructor := &ast.MethodSignature{Class: a.name, Name: a.simpleName, ReturnType: types.Void}
+	stateMachine := a.local("stateMachine", a.name)
+	body := []ast.Stmt{
+		a.declare("stateMachine", a.name, a.construct(a.name, constructor, a.initializer(members, values))),
+		a.expression(a.call(stateMachine, &ast.MethodSignature{Class: a.name, Name: "MoveNext", ReturnType: types.Void})),
+	}
+	if a.returnType != types.Void {
+		builder := a.member(stateMachine, "<>t__builder", a.builderType)
+		body = append(body, a.returns(a.member(builder, "Task", a.returnType.String())))
+	}
+	method.Body = a.block(body...)
+
+	modifiers := []ast.Modifier{}
+	for _, modifier := range method.Modifiers {
+		if modifier.Kind != lexer.ASYNC {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	method.Modifiers = modifiers
+	return method, machine
+}
+
+func (a *asyncMethod) stateMachine(method ast.MethodDeclStmt) ast.ClassDeclStmt {
+	m := newStateMachine(a.machineClass)
+	m.async = a
+	parameters := map[string]string{}
+	for _, param := range method.Parameters {
+		parameters[param.Identifier] = param.Identifier
+	}
+	m.scopes = []map[string]string{parameters}
+	m.statement(method.Body)
+	m.emit(m.finishStmts()...)
+
+	public := []lexer.TokenKind{lexer.PUBLIC}
+	members := []ast.ClassMember{
+		a.field([]lexer.TokenKind{lexer.PRIVATE}, "int", "<>1__state"),
+		a.field(public, a.builderType, "<>t__builder"),
+	}
+	if !a.isStatic {
+		members = append(members, a.field(public, a.class, "<>4__this"))
+	}
+	for _, param := range method.Parameters {
+		members = append(members, a.field(public, param.Type.Name, param.Identifier))
+	}
+	members = append(members, m.fields...)
+	members = append(members,
+		ast.ConstructorDeclStmt{Modifiers: a.modifiers(public...), Name: a.simpleName, Body: a.block(), Line: a.line, Column: a.column},
+		a.method(public, "void", "MoveNext", nil, m.asyncMoveNext()...),
+	)
+	return a.declaration(nil, members, method.File)
+}
+
+// The states of MoveNext like the ones of an iterator, an exception that leaves the method completes
+// its task with the exception
+func (m *stateMachine) asyncMoveNext() []ast.Stmt {
+	sections := []ast.SwitchSection{}
+	for _, state := range m.order {
+		sections = append(sections, m.section(m.protect(m.blocks[state], m.regionsOf[state]), m.caseLabel(state)))
+	}
+	sections = append(sections, m.section([]ast.Stmt{m.returnVoid()}, m.defaultLabel()))
+	loop := m.builder.stmt(types.Void, ast.WhileStmt{Condition: m.boolLiteral(true), Body: m.block(m.builder.switchStmt(m.machine.state(), sections)), Line: m.line, Column: m.column})
+
+	builder := m.machineField("<>t__builder", m.async.builderType, m.line, m.column)
+	setException := &ast.MethodSignature{Class: m.async.builderType, Name: "SetException", ParameterTypes: []types.Type{m.registry.NewClass("Exception")}, ReturnType: types.Void}
+	failed := ast.CatchClause{
+		Type:       m.typ("Exception"),
+		Identifier: "exception",
+		Body:       m.block(m.setState(-2), m.expression(m.call(builder, setException, m.local("exception", "Exception")))),
+		Line:       m.line,
+		Column:     m.column,
+	}
+	body := m.builder.stmt(types.Void, ast.TryStmt{Body: m.block(loop), Catches: []ast.CatchClause{failed}, Line: m.line, Column: m.column})
+	return append(append([]ast.Stmt{}, m.functions...), body)
+}
+
+// Wraps the code of a state in the catch clauses of the regions it is inside of, innermost first. An
+// exception that leaves a region with a finally clause runs the finally clause unless the exception
+// came from the finally clause. The catch clauses of split try statements store the exception and
+// continue with their handler.
+func (m *stateMachine) protect(stmts []ast.Stmt, regions []*tryRegion) []ast.Stmt {
+	for i := len(regions) - 1; i >= 0; i-- {
+		region := regions[i]
+		catches := []ast.CatchClause{}
+		if region.awaitsInFinally() {
+			exception := m.local("exception", "Exception")
+			catches = append(catches, ast.CatchClause{
+				Type:       m.typ("Exception"),
+				Identifier: "exception",
+				Body:       m.block(m.assign(region.exception, exception), m.setState(region.finallyState), m.continueStmt()),
+				Line:       m.line,
+				Column:     m.column,
+			})
+		}
+		if region.finally != nil {
+			running := m.equals(m.machine.state(), m.intLiteral(int64(region.outerState)))
+			catches = append(catches, ast.CatchClause{
+				Type:   m.typ("Exception"),
+				Filter: m.not(running),
+				Body:   m.block(m.setState(region.outerState), region.finally, m.throw(nil)),
+				Line:   m.line,
+				Column: m.column,
+			})
+		}
+		for _, handler := range region.catches {
+			clause := handler.clause
+			if clause.Type.Name == "" {
+				clause.Type = m.typ("Exception")
+			}
+			if clause.Identifier == "" || clause.Identifier == "_" {
+				clause.Identifier = "exception"
+			}
+			at := m.at(clause.Line, clause.Column)
+			clause.Body = at.block(
+				at.assign(handler.exception, at.local(clause.Identifier, clause.Type.Name)),
+				m.setState(handler.state),
+				at.continueStmt(),
+			)
+			catches = append(catches, clause)
+		}
+		stmts = []ast.Stmt{m.builder.stmt(types.Void, ast.TryStmt{Body: m.block(stmts...), Catches: catches, Line: m.line, Column: m.column})}
+	}
+	return stmts
+}
+
+// Leaves the split try statements, completes the task with the value and returns
+func (m *stateMachine) completeStmts(value ast.Expr) []ast.Stmt {
+	stmts := []ast.Stmt{}
+	hasFinally := false
+	for _, region := range m.regions {
+		hasFinally = hasFinally || region.finally != nil || region.awaitsInFinally()
+	}
+	if typed, ok := value.(ast.TypedExpr); ok && hasFinally && !isStable(typed) {
+		// The finally clauses could change what the value refers to
+		field := m.machineField(m.temporary(typed.Type.String()), typed.Type.String(), typed.Line, typed.Column)
+		stmts = append(stmts, m.assign(field, typed))
+		value = field
+	}
+
+	builder := m.machineField("<>t__builder", m.async.builderType, m.line, m.column)
+	setResult := &ast.MethodSignature{Class: m.async.builderType, Name: "SetResult", ReturnType: types.Void}
+	args := []ast.Expr{}
+	if value != nil {
+		setResult.ParameterTypes = []types.Type{m.registry.Parse(m.async.result)}
+		args = append(args, value)
+	}
+	return append(stmts, m.leaveThen(0, func() []ast.Stmt {
+		return []ast.Stmt{m.setState(-2), m.expression(m.call(builder, setResult, args...)), m.returnVoid()}
+	})...)
+}
@@ -0,0 +1,594 @@
+package lowering
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Rewrites the code of an iterator or async method so that it can run inside of the state machine class.
+// Locals of the method become fields of the state machine, its parameters are fields with the same name.
+// this and the instance members of the method's class are reached through the <>4__this field. Locals of
+// lambdas and local functions stay locals, so do catch variables. Pattern variables of conditions are
+// copied into fields once they matched because their uses can be in a later state.
+type hoister struct {
+	builder
+	machine *machineClass
+	// Innermost scope last, a name maps to its field or to an empty string if it stays a local
+	scopes []map[string]string
+	// Depth of lambdas and local functions around the code being rewritten
+	nested  int
+	fields  []ast.ClassMember
+	hoisted int
+	// Local functions of the method, they are declared at the start of MoveNext
+	functions []ast.Stmt
+}
+
+func (h *hoister) push() {
+	h.scopes = append(h.scopes, map[string]string{})
+}
+
+func (h *hoister) pop() {
+	h.scopes = h.scopes[:len(h.scopes)-1]
+}
+
+func (h *hoister) declare(name, field string) {
+	if name != "" && name != "_" {
+		h.scopes[len(h.scopes)-1][name] = field
+	}
+}
+
+// Returns the field a local has been hoisted to or an empty string if it is not hoisted
+func (h *hoister) lookup(name string) string {
+	for i := len(h.scopes) - 1; i >= 0; i-- {
+		if field, ok := h.scopes[i][name]; ok {
+			return field
+		}
+	}
+	return ""
+}
+
+// Adds a field for a local of the method to the state machine
+func (h *hoister) hoist(name, typ string) string {
+	h.hoisted++
+	field := fmt.Sprintf("<%s>5__%d", name, h.hoisted)
+	h.fields = append(h.fields, h.field([]lexer.TokenKind{lexer.PRIVATE}, typ, field))
+	h.declare(name, field)
+	return field
+}
+
+// this.name on the state machine
+func (h *hoister) machineField(name, typ string, line, column int) ast.TypedExpr {
+	at := h.at(line, column)
+	return at.member(at.this(h.machine.name), name, typ)
+}
+
+// The instance of the method's class
+func (h *hoister) outerThis(line, column int) ast.TypedExpr {
+	return h.machineField("<>4__this", h.machine.class, line, column)
+}
+
+// Locals declared directly in the statements are hoisted up front, local functions declared among
+// them can already use them
+func (h *hoister) predeclare(stmts []ast.Stmt) {
+	if h.nested > 0 {
+		return
+	}
+	for _, stmt := range stmts {
+		switch s := unwrap(stmt).(type) {
+		case ast.VarDeclStmt:
+			h.hoist(s.Identifier, s.Type.Name)
+		case ast.MultiVarDeclStmt:
+			for _, decl := range s.Declarations {
+				h.hoist(decl.Identifier, decl.Type.Name)
+			}
+		}
+	}
+}
+
+func (h *hoister) stmts(stmts []ast.Stmt) []ast.Stmt {
+	h.predeclare(stmts)
+	rewritten := []ast.Stmt{}
+	for _, stmt := range stmts {
+		rewritten = append(rewritten, h.expand(stmt)...)
+	}
+	return rewritten
+}
+
+// Rewrites a statement of a block. Declarations of several hoisted locals become several assignments
+// and local functions of the method are moved to MoveNext.
+func (h *hoister) expand(stmt ast.Stmt) []ast.Stmt {
+	if h.nested > 0 {
+		return []ast.Stmt{h.stmt(stmt)}
+	}
+	switch s := unwrap(stmt).(type) {
+	case ast.MultiVarDeclStmt:
+		assignments := []ast.Stmt{}
+		for _, decl := range s.Declarations {
+			assignments = append(assignments, h.varDecl(decl))
+		}
+		return assignments
+	case ast.LocalFunctionStmt:
+		h.functions = append(h.functions, h.stmt(stmt))
+		return nil
+	}
+	return []ast.Stmt{h.stmt(stmt)}
+}
+
+func (h *hoister) stmt(stmt ast.Stmt) ast.Stmt {
+	if stmt == nil {
+		return nil
+	}
+	typ := types.Void
+	if typed, ok := stmt.(ast.TypedStmt); ok {
+		typ = typed.Type
+	}
+	inner := unwrap(stmt)
+	rewritten := h.rewriteStmt(inner)
+	if typed, ok := rewritten.(ast.TypedStmt); ok {
+		return typed
+	}
+	return ast.TypedStmt{Type: typ, Stmt: rewritten, Line: inner.GetLine(), Column: inner.GetColumn()}
+}
+
+// A local of the method is assigned to its field
+func (h *hoister) varDecl(decl ast.VarDeclStmt) ast.Stmt {
+	value := h.expr(decl.Value)
+	field, ok := h.scopes[len(h.scopes)-1][decl.Identifier]
+	if !ok {
+		field = h.hoist(decl.Identifier, decl.Type.Name)
+	}
+	at := h.at(decl.Line, decl.Column)
+	return at.assign(h.machineField(field, decl.Type.Name, decl.Line, decl.Column), value)
+}
+
+func (h *hoister) rewriteStmt(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case ast.BlockStmt:
+		h.push()
+		defer h.pop()
+		s.Body = h.stmts(s.Body)
+		return s
+	case ast.ExpressionStmt:
+		s.Expression = h.expr(s.Expression)
+		return s
+	case ast.VarDeclStmt:
+		if h.nested == 0 {
+			return h.varDecl(s)
+		}
+		s.Value = h.expr(s.Value)
+		h.declare(s.Identifier, "")
+		return s
+	case ast.MultiVarDeclStmt:
+		if h.nested == 0 {
+			return h.block(h.expand(s)...)
+		}
+		declarations := make([]ast.VarDeclStmt, len(s.Declarations))
+		for i, decl := range s.Declarations {
+			decl.Value = h.expr(decl.Value)
+			h.declare(decl.Identifier, "")
+			declarations[i] = decl
+		}
+		s.Declarations = declarations
+		return s
+	case ast.ReturnStmt:
+		s.Value = h.expr(s.Value)
+		return s
+	case ast.IfStmt:
+		s.Condition = h.expr(s.Condition)
+		if h.nested > 0 {
+			s.Then = h.stmt(s.Then)
+			s.Else = h.stmt(s.Else)
+			return s
+		}
+		// Pattern variables of the condition stay in scope after the if statement
+		whenTrue := h.copyPatternVariables(assignedWhen(s.Condition, true))
+		whenFalse := h.copyPatternVariables(assignedWhen(s.Condition, false))
+		s.Then = h.withCopies(whenTrue, h.stmt(s.Then))
+		if s.Else != nil || len(whenFalse) > 0 {
+			s.Else = h.withCopies(whenFalse, h.stmt(s.Else))
+		}
+		return s
+	case ast.WhileStmt:
+		h.push()
+		defer h.pop()
+		s.Condition = h.expr(s.Condition)
+		if h.nested > 0 {
+			s.Body = h.stmt(s.Body)
+			return s
+		}
+		s.Body = h.withCopies(h.copyPatternVariables(assignedWhen(s.Condition, true)), h.stmt(s.Body))
+		return s
+	case ast.SwitchStmt:
+		s.Expression = h.expr(s.Expression)
+		sections := make([]ast.SwitchSection, len(s.Sections))
+		for i, section := range s.Sections {
+			h.push()
+			section.Labels = h.labels(section.Labels)
+			section.Body = h.stmt(section.Body)
+			h.pop()
+			sections[i] = section
+		}
+		s.Sections = sections
+		return s
+	case ast.GotoCaseStmt:
+		s.Value = h.expr(s.Value)
+		return s
+	case ast.TryStmt:
+		s.Body = h.stmt(s.Body)
+		catches := make([]ast.CatchClause, len(s.Catches))
+		for i, clause := range s.Catches {
+			h.push()
+			h.declare(clause.Identifier, "")
+			clause.Filter = h.expr(clause.Filter)
+			clause.Body = h.stmt(clause.Body)
+			h.pop()
+			catches[i] = clause
+		}
+		s.Catches = catches
+		s.Finally = h.stmt(s.Finally)
+		return s
+	case ast.ThrowStmt:
+		s.Value = h.expr(s.Value)
+		return s
+	case ast.LocalFunctionStmt:
+		s.Captures = h.captures(s.Captures)
+		h.nested++
+		h.push()
+		for _, param := range s.Parameters {
+			h.declare(param.Identifier, "")
+		}
+		s.Body = h.stmt(s.Body)
+		h.pop()
+		h.nested--
+		return s
+	case ast.YieldReturnStmt:
+		s.Value = h.expr(s.Value)
+		return s
+	}
+	return stmt
+}
+
+// Hoisted locals are no longer captured, lambdas and local functions reach them through this
+func (h *hoister) captures(captures []string) []string {
+	remaining := []string{}
+	for _, name := range captures {
+		if h.lookup(name) == "" {
+			remaining = append(remaining, name)
+		}
+	}
+	return remaining
+}
+
+func (h *hoister) labels(labels []ast.SwitchLabel) []ast.SwitchLabel {
+	rewritten := make([]ast.SwitchLabel, len(labels))
+	for i, label := range labels {
+		label.Pattern = h.pattern(label.Pattern)
+		label.Guard = h.expr(label.Guard)
+		rewritten[i] = label
+	}
+	return rewritten
+}
+
+func (h *hoister) exprs(exprs []ast.Expr) []ast.Expr {
+	if exprs == nil {
+		return nil
+	}
+	rewritten := make([]ast.Expr, len(exprs))
+	for i, expr := range exprs {
+		rewritten[i] = h.expr(expr)
+	}
+	return rewritten
+}
+
+func (h *hoister) initializer(initializer ast.ObjectInitializer) ast.ObjectInitializer {
+	elements := make([]ast.InitializerElement, len(initializer.Elements))
+	for i, element := range initializer.Elements {
+		element.Index = h.exprs(element.Index)
+		element.Args = h.exprs(element.Args)
+		element.Value = h.expr(element.Value)
+		if element.Nested != nil {
+			nested := h.initializer(*element.Nested)
+			element.Nested = &nested
+		}
+		elements[i] = element
+	}
+	initializer.Elements = elements
+	return initializer
+}
+
+// Receivers of calls to methods of the method's class, static ones are called on the class
+func (h *hoister) receiver(receiver ast.Expr, signature *ast.MethodSignature, line, column int) ast.Expr {
+	isThis := false
+	switch r := receiver.(type) {
+	case ast.ThisExpr:
+		isThis = true
+	case ast.TypedExpr:
+		_, isThis = r.Expr.(ast.ThisExpr)
+	}
+	if isThis && signature != nil && signature.IsStatic {
+		return h.at(line, column).className(signature.Class)
+	}
+	if _, ok := receiver.(ast.ThisExpr); ok && !h.machine.isStatic {
+		return h.outerThis(line, column)
+	}
+	return h.expr(receiver)
+}
+
+func (h *hoister) expr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case ast.TypedExpr:
+		switch inner := e.Expr.(type) {
+		case ast.LocalVarExpr:
+			if field := h.lookup(inner.Name); field != "" {
+				return h.machineField(field, e.Type.String(), inner.Line, inner.Column)
+			}
+			return e
+		case ast.DeclarationExpr:
+			typ := inner.Type.Name
+			if typ == "var" || typ == "" {
+				typ = e.Type.String()
+			}
+			return h.declaration(inner, typ, e)
+		case ast.ThisExpr:
+			if h.machine.isStatic {
+				return e
+			}
+			return h.outerThis(inner.Line, inner.Column)
+		case ast.FieldVarExpr:
+			if h.machine.isStatic || !h.machine.lowerer.isInstanceMember(h.machine.class, inner.Name) {
+				return e
+			}
+			at := h.at(inner.Line, inner.Column)
+			return at.member(h.outerThis(inner.Line, inner.Column), inner.Name, e.Type.String())
+		}
+		e.Expr = h.expr(e.Expr)
+		return e
+	case ast.DeclarationExpr:
+		return h.declaration(e, e.Type.Name, e)
+	case ast.ThisExpr:
+		if h.machine.isStatic {
+			return e
+		}
+		return h.outerThis(e.Line, e.Column)
+	case ast.BinaryExpr:
+		e.Left, e.Right = h.expr(e.Left), h.expr(e.Right)
+		return e
+	case ast.PrefixExpr:
+		e.Expression = h.expr(e.Expression)
+		return e
+	case ast.AssignmentExpr:
+		e.Assignee, e.Value = h.expr(e.Assignee), h.expr(e.Value)
+		return e
+	case ast.MethodCallExpr:
+		// A this receiver that was not typed by the type checker calls a local function
+		if _, isLocalFunction := e.Receiver.(ast.ThisExpr); !isLocalFunction {
+			e.Receiver = h.receiver(e.Receiver, e.Signature, e.Line, e.Column)
+		}
+		e.Args = h.exprs(e.Args)
+		return e
+	case ast.MemberAccessExpr:
+		e.Receiver = h.expr(e.Receiver)
+		return e
+	case ast.ConstructorCallExpr:
+		e.Args = h.exprs(e.Args)
+		if e.Initializer != nil {
+			initializer := h.initializer(*e.Initializer)
+			e.Initializer = &initializer
+		}
+		return e
+	case ast.PreIncrementExpr:
+		e.Operand = h.expr(e.Operand)
+		return e
+	case ast.PostIncrementExpr:
+		e.Operand = h.expr(e.Operand)
+		return e
+	case ast.PreDecrementExpr:
+		e.Operand = h.expr(e.Operand)
+		return e
+	case ast.PostDecrementExpr:
+		e.Operand = h.expr(e.Operand)
+		return e
+	case ast.LambdaExpr:
+		e.Captures = h.captures(e.Captures)
+		h.nested++
+		h.push()
+		for _, param := range e.Parameters {
+			h.declare(param.Identifier, "")
+		}
+		e.Body = h.stmt(e.Body)
+		e.Expression = h.expr(e.Expression)
+		h.pop()
+		h.nested--
+		return e
+	case ast.MethodGroupExpr:
+		if _, isLocalFunction := e.Receiver.(ast.ThisExpr); !isLocalFunction {
+			e.Receiver = h.receiver(e.Receiver, e.Signature, e.Line, e.Column)
+		}
+		return e
+	case ast.InvocationExpr:
+		e.Callee = h.expr(e.Callee)
+		e.Args = h.exprs(e.Args)
+		return e
+	case ast.ThrowExpr:
+		e.Value = h.expr(e.Value)
+		return e
+	case ast.ArgumentExpr:
+		e.Value = h.expr(e.Value)
+		return e
+	case ast.NullForgivingExpr:
+		e.Operand = h.expr(e.Operand)
+		return e
+	case ast.TupleExpr:
+		e.Elements = h.exprs(e.Elements)
+		return e
+	case ast.DeconstructionExpr:
+		e.Targets = h.exprs(e.Targets)
+		e.Value = h.expr(e.Value)
+		return e
+	case ast.ArrayCreationExpr:
+		e.Elements = h.exprs(e.Elements)
+		return e
+	case ast.IsPatternExpr:
+		e.Expression = h.expr(e.Expression)
+		e.Pattern = h.pattern(e.Pattern)
+		return e
+	case ast.ElementAccessExpr:
+		e.Receiver = h.expr(e.Receiver)
+		e.Args = h.exprs(e.Args)
+		return e
+	case ast.CastExpr:
+		e.Expression = h.expr(e.Expression)
+		return e
+	case ast.WithExpr:
+		e.Receiver = h.expr(e.Receiver)
+		e.Initializer = h.initializer(e.Initializer)
+		return e
+	case ast.EventSubscriptionExpr:
+		e.Receiver = h.expr(e.Receiver)
+		e.Handler = h.expr(e.Handler)
+		return e
+	case ast.AsExpr:
+		e.Expression = h.expr(e.Expression)
+		return e
+	case ast.AwaitExpr:
+		e.Expression = h.expr(e.Expression)
+		return e
+	case ast.CheckedExpr:
+		e.Expression = h.expr(e.Expression)
+		return e
+	case ast.ValueHashExpr:
+		e.Value = h.expr(e.Value)
+		return e
+	case ast.SwitchExpr:
+		e.Expression = h.expr(e.Expression)
+		if h.nested == 0 && armsContainAwait(e) {
+			// Matched arm by arm when the method awaits
+			return e
+		}
+		arms := make([]ast.SwitchExprArm, len(e.Arms))
+		for i, arm := range e.Arms {
+			h.push()
+			arm.Pattern = h.pattern(arm.Pattern)
+			arm.Guard = h.expr(arm.Guard)
+			arm.Value = h.expr(arm.Value)
+			h.pop()
+			arms[i] = arm
+		}
+		e.Arms = arms
+		return e
+	}
+	return expr
+}
+
+// out var x and the variables of a deconstruction declare locals inside of an expression
+func (h *hoister) declaration(decl ast.DeclarationExpr, typ string, original ast.Expr) ast.Expr {
+	if h.nested > 0 || decl.Identifier == "_" {
+		h.declare(decl.Identifier, "")
+		return original
+	}
+	return h.machineField(h.hoist(decl.Identifier, typ), typ, decl.Line, decl.Column)
+}
+
+func (h *hoister) pattern(pattern ast.Pattern) ast.Pattern {
+	switch p := pattern.(type) {
+	case ast.ConstantPattern:
+		p.Value = h.expr(p.Value)
+		return p
+	case ast.RelationalPattern:
+		p.Value = h.expr(p.Value)
+		return p
+	case ast.DeclarationPattern:
+		h.declare(p.Identifier, "")
+		return p
+	case ast.NotPattern:
+		p.Pattern = h.pattern(p.Pattern)
+		return p
+	case ast.BinaryPattern:
+		p.Left, p.Right = h.pattern(p.Left), h.pattern(p.Right)
+		return p
+	case ast.PropertyPattern:
+		properties := make([]ast.PropertySubpattern, len(p.Properties))
+		for i, property := range p.Properties {
+			property.Pattern = h.pattern(property.Pattern)
+			properties[i] = property
+		}
+		p.Properties = properties
+		h.declare(p.Identifier, "")
+		return p
+	}
+	return pattern
+}
+
+// Pattern variables are declared by the code that matches them, afterwards they are hoisted and the
+// values are copied into their fields
+func (h *hoister) copyPatternVariables(variables []patternVariable) []ast.Stmt {
+	copies := []ast.Stmt{}
+	for _, variable := range variables {
+		field := h.hoist(variable.name, variable.typ)
+		copies = append(copies, h.assign(h.machineField(field, variable.typ, h.line, h.column), h.local(variable.name, variable.typ)))
+	}
+	return copies
+}
+
+func (h *hoister) withCopies(copies []ast.Stmt, stmt ast.Stmt) ast.Stmt {
+	if len(copies) == 0 {
+		return stmt
+	}
+	if stmt != nil {
+		copies = append(copies, stmt)
+	}
+	return h.block(copies...)
+}
+
+// A variable declared by a pattern
+type patternVariable struct {
+	name string
+	typ  string
+}
+
+func patternVariables(pattern ast.Pattern) []patternVariable {
+	variables := []patternVariable{}
+	switch p := pattern.(type) {
+	case ast.DeclarationPattern:
+		if p.Identifier != "_" {
+			variables = append(variables, patternVariable{name: p.Identifier, typ: p.Type.Name})
+		}
+	case ast.PropertyPattern:
+		for _, property := range p.Properties {
+			variables = append(variables, patternVariables(property.Pattern)...)
+		}
+		if p.Identifier != "" && p.Identifier != "_" {
+			variables = append(variables, patternVariable{name: p.Identifier, typ: p.Type.Name})
+		}
+	case ast.BinaryPattern:
+		variables = append(variables, patternVariables(p.Left)...)
+		variables = append(variables, patternVariables(p.Right)...)
+	}
+	return variables
+}
+
+// The pattern variables that are assigned when a checked condition evaluates to whenTrue
+func assignedWhen(condition ast.Expr, whenTrue bool) []patternVariable {
+	switch c := condition.(type) {
+	case ast.TypedExpr:
+		return assignedWhen(c.Expr, whenTrue)
+	case ast.IsPatternExpr:
+		if whenTrue {
+			return patternVariables(c.Pattern)
+		}
+	case ast.PrefixExpr:
+		if c.Operator.Kind == lexer.NOT {
+			return assignedWhen(c.Expression, !whenTrue)
+		}
+	case ast.BinaryExpr:
+		if (c.Operator.Kind == lexer.AND && whenTrue) || (c.Operator.Kind == lexer.OR && !whenTrue) {
+			return append(assignedWhen(c.Left, whenTrue), assignedWhen(c.Right, whenTrue)...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package lowering
+
+import "github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+
+// The type checker stores most checked statements as pointers inside of TypedStmt, unchecked and
+// synthesized ones are values. Returns the statement as a value without its TypedStmt wrapper.
+func unwrap(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case ast.TypedStmt:
+		return unwrap(s.Stmt)
+	case *ast.BlockStmt:
+		return *s
+	case *ast.ExpressionStmt:
+		return *s
+	case *ast.VarDeclStmt:
+		return *s
+	case *ast.MultiVarDeclStmt:
+		return *s
+	case *ast.ReturnStmt:
+		return *s
+	case *ast.IfStmt:
+		return *s
+	case *ast.WhileStmt:
+		return *s
+	case *ast.SwitchStmt:
+		return *s
+	case *ast.GotoCaseStmt:
+		return *s
+	case *ast.TryStmt:
+		return *s
+	case *ast.ThrowStmt:
+		return *s
+	case *ast.LocalFunctionStmt:
+		return *s
+	case *ast.YieldReturnStmt:
+		return *s
+	case *ast.YieldBreakStmt:
+		return *s
+	}
+	return stmt
+}
+
+// Calls visit for the statement or expression and, as long as visit returns true, for everything inside
+// of it. Checked statements are visited as they are stored, pointers included.
+func inspect(node any, visit func(any) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+	each := func(nodes ...any) {
+		for _, inner := range nodes {
+			if inner != nil {
+				inspect(inner, visit)
+			}
+		}
+	}
+	exprs := func(list []ast.Expr) {
+		for _, expr := range list {
+			each(expr)
+		}
+	}
+	initializer := func(initializer *ast.ObjectInitializer) {
+		if initializer == nil {
+			return
+		}
+		for _, element := range initializer.Elements {
+			exprs(element.Index)
+			exprs(element.Args)
+			if element.Value != nil {
+				each(element.Value)
+			}
+			if element.Nested != nil {
+				inspect(*element.Nested, visit)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case ast.TypedStmt:
+		each(n.Stmt)
+	case ast.Stmt:
+		switch s := unwrap(n).(type) {
+		case ast.BlockStmt:
+			for _, inner := range s.Body {
+				each(inner)
+			}
+		case ast.ExpressionStmt:
+			each(s.Expression)
+		case ast.VarDeclStmt:
+			each(s.Value)
+		case ast.MultiVarDeclStmt:
+			for _, decl := range s.Declarations {
+				each(decl.Value)
+			}
+		case ast.ReturnStmt:
+			each(s.Value)
+		case ast.IfStmt:
+			each(s.Condition, s.Then, s.Else)
+		case ast.WhileStmt:
+			each(s.Condition, s.Body)
+		case ast.SwitchStmt:
+			each(s.Expression)
+			for _, section := range s.Sections {
+				for _, label := range section.Labels {
+					each(label.Pattern, label.Guard)
+				}
+				each(section.Body)
+			}
+		case ast.GotoCaseStmt:
+			each(s.Value)
+		case ast.TryStmt:
+			each(s.Body)
+			for _, clause := range s.Catches {
+				each(clause.Filter, clause.Body)
+			}
+			each(s.Finally)
+		case ast.ThrowStmt:
+			each(s.Value)
+		case ast.LocalFunctionStmt:
+			each(s.Body)
+		case ast.YieldReturnStmt:
+			each(s.Value)
+		}
+	case ast.TypedExpr:
+		each(n.Expr)
+	case ast.BinaryExpr:
+		each(n.Left, n.Right)
+	case ast.PrefixExpr:
+		each(n.Expression)
+	case ast.AssignmentExpr:
+		each(n.Assignee, n.Value)
+	case ast.MethodCallExpr:
+		each(n.Receiver)
+		exprs(n.Args)
+	case ast.MemberAccessExpr:
+		each(n.Receiver)
+	case ast.ConstructorCallExpr:
+		exprs(n.Args)
+		initializer(n.Initializer)
+	case ast.ObjectInitializer:
+		initializer(&n)
+	case ast.PreIncrementExpr:
+		each(n.Operand)
+	case ast.PostIncrementExpr:
+		each(n.Operand)
+	case ast.PreDecrementExpr:
+		each(n.Operand)
+	case ast.PostDecrementExpr:
+		each(n.Operand)
+	case ast.LambdaExpr:
+		each(n.Body, n.Expression)
+	case ast.MethodGroupExpr:
+		each(n.Receiver)
+	case ast.InvocationExpr:
+		each(n.Callee)
+		exprs(n.Args)
+	case ast.ThrowExpr:
+		each(n.Value)
+	case ast.ArgumentExpr:
+		each(n.Value)
+	case ast.NullForgivingExpr:
+		each(n.Operand)
+	case ast.TupleExpr:
+		exprs(n.Elements)
+	case ast.DeconstructionExpr:
+		exprs(n.Targets)
+		each(n.Value)
+	case ast.ArrayCreationExpr:
+		exprs(n.Elements)
+	case ast.IsPatternExpr:
+		each(n.Expression, n.Pattern)
+	case ast.ElementAccessExpr:
+		each(n.Receiver)
+		exprs(n.Args)
+	case ast.CastExpr:
+		each(n.Expression)
+	case ast.WithExpr:
+		each(n.Receiver)
+		initializer(&n.Initializer)
+	case ast.EventSubscriptionExpr:
+		each(n.Receiver, n.Handler)
+	case ast.AsExpr:
+		each(n.Expression)
+	case ast.AwaitExpr:
+		each(n.Expression)
+	case ast.CheckedExpr:
+		each(n.Expression)
+	case ast.ValueHashExpr:
+		each(n.Value)
+	case ast.SwitchExpr:
+		each(n.Expression)
+		for _, arm := range n.Arms {
+			each(arm.Pattern, arm.Guard, arm.Value)
+		}
+	case ast.ConstantPattern:
+		each(n.Value)
+	case ast.RelationalPattern:
+		each(n.Value)
+	case ast.NotPattern:
+		each(n.Pattern)
+	case ast.BinaryPattern:
+		each(n.Left, n.Right)
+	case ast.PropertyPattern:
+		for _, property := range n.Properties {
+			each(property.Pattern)
+		}
+	}
+}
+
+// Returns a copy of the expression with f applied to the expressions directly inside of it, in the order
+// they are evaluated. Lambda bodies, switch expression arms and assignment targets are not included.
+func mapChildren(expr ast.Expr, f func(ast.Expr) ast.Expr) ast.Expr {
+	exprs := func(list []ast.Expr) []ast.Expr {
+		if list == nil {
+			return nil
+		}
+		mapped := make([]ast.Expr, len(list))
+		for i, inner := range list {
+			mapped[i] = f(inner)
+		}
+		return mapped
+	}
+	optional := func(inner ast.Expr) ast.Expr {
+		if inner == nil {
+			return nil
+		}
+		return f(inner)
+	}
+	var initializer func(initializer ast.ObjectInitializer) ast.ObjectInitializer
+	initializer = func(init ast.ObjectInitializer) ast.ObjectInitializer {
+		elements := make([]ast.InitializerElement, len(init.Elements))
+		for i, element := range init.Elements {
+			element.Index = exprs(element.Index)
+			element.Args = exprs(element.Args)
+			element.Value = optional(element.Value)
+			if element.Nested != nil {
+				nested := initializer(*element.Nested)
+				element.Nested = &nested
+			}
+			elements[i] = element
+		}
+		init.Elements = elements
+		return init
+	}
+
+	switch e := expr.(type) {
+	case ast.TypedExpr:
+		e.Expr = mapChildren(e.Expr, f)
+		return e
+	case ast.BinaryExpr:
+		e.Left = f(e.Left)
+		e.Right = f(e.Right)
+		return e
+	case ast.PrefixExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.AssignmentExpr:
+		e.Value = f(e.Value)
+		return e
+	case ast.MethodCallExpr:
+		e.Receiver = optional(e.Receiver)
+		e.Args = exprs(e.Args)
+		return e
+	case ast.MemberAccessExpr:
+		e.Receiver = optional(e.Receiver)
+		return e
+	case ast.ConstructorCallExpr:
+		e.Args = exprs(e.Args)
+		if e.Initializer != nil {
+			init := initializer(*e.Initializer)
+			e.Initializer = &init
+		}
+		return e
+	case ast.PreIncrementExpr:
+		e.Operand = f(e.Operand)
+		return e
+	case ast.PostIncrementExpr:
+		e.Operand = f(e.Operand)
+		return e
+	case ast.PreDecrementExpr:
+		e.Operand = f(e.Operand)
+		return e
+	case ast.PostDecrementExpr:
+		e.Operand = f(e.Operand)
+		return e
+	case ast.LambdaExpr:
+		e.Expression = optional(e.Expression)
+		return e
+	case ast.InvocationExpr:
+		e.Callee = f(e.Callee)
+		e.Args = exprs(e.Args)
+		return e
+	case ast.ThrowExpr:
+		e.Value = optional(e.Value)
+		return e
+	case ast.ArgumentExpr:
+		e.Value = f(e.Value)
+		return e
+	case ast.NullForgivingExpr:
+		e.Operand = f(e.Operand)
+		return e
+	case ast.TupleExpr:
+		e.Elements = exprs(e.Elements)
+		return e
+	case ast.DeconstructionExpr:
+		e.Value = f(e.Value)
+		return e
+	case ast.ArrayCreationExpr:
+		e.Elements = exprs(e.Elements)
+		return e
+	case ast.IsPatternExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.ElementAccessExpr:
+		e.Receiver = f(e.Receiver)
+		e.Args = exprs(e.Args)
+		return e
+	case ast.CastExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.WithExpr:
+		e.Receiver = f(e.Receiver)
+		e.Initializer = initializer(e.Initializer)
+		return e
+	case ast.EventSubscriptionExpr:
+		e.Receiver = optional(e.Receiver)
+		e.Handler = f(e.Handler)
+		return e
+	case ast.AsExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.AwaitExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.CheckedExpr:
+		e.Expression = f(e.Expression)
+		return e
+	case ast.ValueHashExpr:
+		e.Value = f(e.Value)
+		return e
+	case ast.SwitchExpr:
+		e.Expression = f(e.Expression)
+		return e
+	}
+	return expr
+}
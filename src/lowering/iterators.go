@@ -0,0 +1,217 @@
+package lowering
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// An iterator method and the state machine class it is lowered to. The state machine implements
+// IEnumerator<T>, and IEnumerable<T> for enumerable iterators. <>1__state is -2 before GetEnumerator, -1
+// when the iteration ended and otherwise the state MoveNext continues with. <>3__ fields hold the
+// arguments of enumerable iterators so that every enumerator starts with them.
+type iterator struct {
+	*machineClass
+	element      string
+	isEnumerable bool
+}
+
+// IEnumerable<T> or IEnumerator<T>
+func (l *lowerer) iteratorType(name string) (*types.Generic, bool) {
+	generic, ok := l.registry.Parse(name).(*types.Generic)
+	return generic, ok && (generic.Name == "IEnumerable" || generic.Name == "IEnumerator") && len(generic.Arguments) == 1
+}
+
+// Replaces the body of the iterator method with the creation of its state machine
+func (l *lowerer) lowerIterator(class string, method ast.MethodDeclStmt) (ast.MethodDeclStmt, ast.ClassDeclStmt) {
+	iteratorType, _ := l.iteratorType(method.ReturnType.Name)
+	l.machines++
+	simpleName := fmt.Sprintf("<%s>d__%d", method.Name, l.machines)
+	it := &iterator{
+		machineClass: l.machineClass(class, simpleName, method),
+		element:      iteratorType.Arguments[0].String(),
+		isEnumerable: iteratorType.Name == "IEnumerable",
+	}
+	machine := it.stateMachine(method)
+
+	state := 0
+	if it.isEnumerable {
+		state = -2
+	}
+	members, values := []string{}, []ast.Expr{}
+	if !it.isStatic {
+		members = append(members, "<>4__this")
+		values = append(values, it.this(class))
+	}
+	for _, param := range method.Parameters {
+		members = append(members, it.parameterField(param))
+		values = append(values, it.local(param.Identifier, param.Type.Name))
+	}
+	var initializer *ast.ObjectInitializer
+	if len(members) > 0 {
+		initializer = it.initializer(members, values)
+	}
+	creation := it.construct(it.name, it.constructor(), initializer, it.intLiteral(int64(state)))
+	creation.Type = it.registry.Parse(method.ReturnType.Name)
+	method.Body = it.block(it.returns(creation))
+	return method, machine
+}
+
+// Enumerable iterators keep their arguments in <>3__ fields that GetEnumerator copies
+func (it *iterator) parameterField(param ast.Parameter) string {
+	if it.isEnumerable {
+		return "<>3__" + param.Identifier
+	}
+	return param.Identifier
+}
+
+func (it *iterator) constructor() *ast.MethodSignature {
+	return &ast.MethodSignature{Class: it.name, Name: it.simpleName, ParameterTypes: []types.Type{types.Int}, ReturnType: types.Void}
+}
+
+func (it *iterator) stateMachine(method ast.MethodDeclStmt) ast.ClassDeclStmt {
+	m := newStateMachine(it.machineClass)
+	m.iterator = it
+	parameters := map[string]string{}
+	for _, param := range method.Parameters {
+		parameters[param.Identifier] = param.Identifier
+	}
+	m.scopes = []map[string]string{parameters}
+	m.statement(method.Body)
+	m.emit(m.finishStmts()...)
+
+	public := []lexer.TokenKind{lexer.PUBLIC}
+	private := []lexer.TokenKind{lexer.PRIVATE}
+	members := []ast.ClassMember{
+		it.field(private, "int", "<>1__state"),
+		it.field(private, it.element, "<>2__current"),
+	}
+	if !it.isStatic {
+		members = append(members, it.field(public, it.class, "<>4__this"))
+	}
+	for _, param := range method.Parameters {
+		members = append(members, it.field(public, param.Type.Name, param.Identifier))
+		if it.isEnumerable {
+			members = append(members, it.field(public, param.Type.Name, it.parameterField(param)))
+		}
+	}
+	members = append(members, m.fields...)
+	members = append(members,
+		ast.ConstructorDeclStmt{
+			Modifiers:  it.modifiers(public...),
+			Name:       it.simpleName,
+			Parameters: []ast.Parameter{{Type: it.typ("int"), Identifier: "<>1__state"}},
+			Body:       it.block(it.assign(it.state(), it.local("<>1__state", "int"))),
+			Line:       it.line,
+			Column:     it.column,
+		},
+		it.method(public, "bool", "MoveNext", nil, m.moveNext()...),
+		ast.PropertyDeclStmt{
+			Modifiers: it.modifiers(public...),
+			Type:      it.typ(it.element),
+			Name:      "Current",
+			Accessors: []ast.Accessor{{Kind: "get", Body: it.block(it.returns(it.member(it.this(it.name), "<>2__current", it.element))), Line: it.line, Column: it.column}},
+			Line:      it.line,
+			Column:    it.column,
+		},
+		it.method(public, "void", "Dispose", nil, m.dispose()...),
+		it.method(public, "void", "Reset", nil, it.throw(it.construct("NotSupportedException", &ast.MethodSignature{Class: "NotSupportedException", Name: "NotSupportedException", ReturnType: types.Void}, nil))),
+	)
+
+	baseTypes := []ast.Type{it.typ("IEnumerator<" + it.element + ">")}
+	if it.isEnumerable {
+		baseTypes = append([]ast.Type{it.typ("IEnumerable<" + it.element + ">")}, baseTypes...)
+		members = append(members, it.method(public, "IEnumerator<"+it.element+">", "GetEnumerator", nil, it.getEnumerator(method.Parameters)...))
+	}
+
+	return it.declaration(baseTypes, members, method.File)
+}
+
+// while (true) { switch (this.<>1__state) { case 0: ... } } with the local functions of the iterator in
+// front of it. If the iterator is suspended inside of a try statement an exception disposes it.
+func (m *stateMachine) moveNext() []ast.Stmt {
+	sections := []ast.SwitchSection{}
+	for _, state := range m.order {
+		sections = append(sections, m.section(m.blocks[state], m.caseLabel(state)))
+	}
+	sections = append(sections, m.section([]ast.Stmt{m.returns(m.boolLiteral(false))}, m.defaultLabel()))
+	var body ast.Stmt = m.builder.stmt(types.Void, ast.WhileStmt{Condition: m.boolLiteral(true), Body: m.block(m.builder.switchStmt(m.machine.state(), sections)), Line: m.line, Column: m.column})
+
+	if m.hasRegions() {
+		dispose := &ast.MethodSignature{Class: m.machine.name, Name: "Dispose", ReturnType: types.Void}
+		rethrow := ast.CatchClause{Body: m.block(m.expression(m.call(m.this(m.machine.name), dispose)), m.throw(nil)), Line: m.line, Column: m.column}
+		body = m.builder.stmt(types.Void, ast.TryStmt{Body: m.block(body), Catches: []ast.CatchClause{rethrow}, Line: m.line, Column: m.column})
+	}
+	return append(append([]ast.Stmt{}, m.functions...), body)
+}
+
+func (m *stateMachine) hasRegions() bool {
+	for _, regions := range m.regionsOf {
+		if len(regions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Runs the finally clauses of the try statements the iterator is suspended in, innermost first
+func (m *stateMachine) dispose() []ast.Stmt {
+	if !m.hasRegions() {
+		return []ast.Stmt{m.assign(m.machine.state(), m.intLiteral(-1))}
+	}
+	states := []int{}
+	for state, regions := range m.regionsOf {
+		if len(regions) > 0 {
+			states = append(states, state)
+		}
+	}
+	sort.Ints(states)
+
+	// States inside of the same innermost region share a section
+	sections := []ast.SwitchSection{}
+	sectionOf := map[*tryRegion]int{}
+	for _, state := range states {
+		regions := m.regionsOf[state]
+		innermost := regions[len(regions)-1]
+		if i, ok := sectionOf[innermost]; ok {
+			sections[i].Labels = append(sections[i].Labels, m.caseLabel(state))
+			continue
+		}
+		cleanup := innermost.finally
+		for i := len(regions) - 2; i >= 0; i-- {
+			cleanup = m.builder.stmt(types.Void, ast.TryStmt{Body: m.block(cleanup), Finally: regions[i].finally, Line: m.line, Column: m.column})
+		}
+		sectionOf[innermost] = len(sections)
+		sections = append(sections, m.section([]ast.Stmt{cleanup, m.breakStmt()}, m.caseLabel(state)))
+	}
+
+	stmts := append([]ast.Stmt{}, m.functions...)
+	return append(stmts,
+		m.builder.declare("state", "int", m.machine.state()),
+		m.assign(m.machine.state(), m.intLiteral(-1)),
+		m.builder.switchStmt(m.local("state", "int"), sections),
+	)
+}
+
+// An enumerable iterator is its own first enumerator, later calls create new state machines
+func (it *iterator) getEnumerator(parameters []ast.Parameter) []ast.Stmt {
+	iterator := it.local("iterator", it.name)
+	var create ast.Expr = it.construct(it.name, it.constructor(), nil, it.intLiteral(0))
+	if !it.isStatic {
+		create = it.construct(it.name, it.constructor(), it.initializer([]string{"<>4__this"}, []ast.Expr{it.member(it.this(it.name), "<>4__this", it.class)}), it.intLiteral(0))
+	}
+	stmts := []ast.Stmt{
+		it.declare("iterator", it.name, it.this(it.name)),
+		it.ifStmt(it.equals(it.state(), it.intLiteral(-2)), it.block(it.assign(it.state(), it.intLiteral(0))), it.block(it.assign(iterator, create))),
+	}
+	for _, param := range parameters {
+		field := it.member(iterator, param.Identifier, param.Type.Name)
+		stmts = append(stmts, it.assign(field, it.member(it.this(it.name), it.parameterField(param), param.Type.Name)))
+	}
+	result := iterator
+	result.Type = it.registry.NewGeneric("IEnumerator", []types.Type{it.registry.Parse(it.element)})
+	return append(stmts, it.returns(result))
+}
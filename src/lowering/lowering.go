@@ -0,0 +1,384 @@
+// Package lowering rewrites the checked syntax tree into simpler constructs that every backend can
+// execute without knowing about them. Iterators and async methods become state machine classes, local
+// iterator and async functions and async lambdas become methods first.
+//
+// The lowered program reuses the nodes of the checked program, only the bodies of the extracted local
+// functions and the expressions around extracted lambdas are replaced in place.
+package lowering
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+type lowerer struct {
+	// All classes by their qualified name, nested ones included
+	classes   map[string]ast.ClassDeclStmt
+	delegates map[string]ast.DelegateDeclStmt
+	// The types of the checked program
+	registry  *types.Registry
+	machines  int
+	functions int
+}
+
+func Lower(program ast.Program, registry *types.Registry) ast.Program {
+	l := &lowerer{classes: map[string]ast.ClassDeclStmt{}, delegates: map[string]ast.DelegateDeclStmt{}, registry: registry}
+	for _, delegate := range program.Delegates {
+		l.delegates[delegate.Name] = delegate
+	}
+	for _, class := range program.Classes {
+		l.collect(class)
+	}
+	classes := make([]ast.ClassDeclStmt, len(program.Classes))
+	for i, class := range program.Classes {
+		classes[i] = l.lowerClass(class)
+	}
+	program.Classes = classes
+	return program
+}
+
+func (l *lowerer) collect(class ast.ClassDeclStmt) {
+	l.classes[class.Name] = class
+	for _, member := range class.Body.Members {
+		switch m := member.(type) {
+		case ast.ClassDeclStmt:
+			l.collect(m)
+		case ast.DelegateDeclStmt:
+			l.delegates[m.Name] = m
+		}
+	}
+}
+
+func (l *lowerer) at(line, column int) builder {
+	return builder{registry: l.registry, line: line, column: column}
+}
+
+func hasModifier(modifiers []ast.Modifier, kind lexer.TokenKind) bool {
+	for _, modifier := range modifiers {
+		if modifier.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether name is an instance field, property or event of the class or one of its base classes
+func (l *lowerer) isInstanceMember(className, name string) bool {
+	class, ok := l.classes[className]
+	if !ok {
+		return false
+	}
+	for _, member := range class.Body.Members {
+		var modifiers []ast.Modifier
+		switch m := member.(type) {
+		case ast.FieldDeclStmt:
+			if m.Identifier != name {
+				continue
+			}
+			modifiers = m.Modifiers
+		case ast.PropertyDeclStmt:
+			if m.Name != name {
+				continue
+			}
+			modifiers = m.Modifiers
+		case ast.EventDeclStmt:
+			if m.Name != name {
+				continue
+			}
+			modifiers = m.Modifiers
+		default:
+			continue
+		}
+		return !hasModifier(modifiers, lexer.STATIC) && !hasModifier(modifiers, lexer.CONST)
+	}
+	if len(class.BaseTypes) > 0 {
+		return l.isInstanceMember(class.BaseTypes[0].Name, name)
+	}
+	return false
+}
+
+func (l *lowerer) isIterator(method ast.MethodDeclStmt) bool {
+	_, isIteratorType := l.iteratorType(method.ReturnType.Name)
+	return method.Body != nil && isIteratorType && containsYield(method.Body)
+}
+
+// Lowers the iterators and async methods of the class and its nested classes, their state machines are
+// nested classes
+func (l *lowerer) lowerClass(class ast.ClassDeclStmt) ast.ClassDeclStmt {
+	members := []ast.ClassMember{}
+	for _, member := range class.Body.Members {
+		switch m := member.(type) {
+		case ast.FieldDeclStmt:
+			var methods []ast.ClassMember
+			m.Value, methods = l.liftAsyncLambdas(class.Name, m.Identifier, hasModifier(m.Modifiers, lexer.STATIC), m.Value)
+			members = append(append(members, m), methods...)
+			continue
+		}
+		members = append(members, member)
+		switch m := member.(type) {
+		case ast.MethodDeclStmt:
+			members = append(members, l.extract(class.Name, m.Name, hasModifier(m.Modifiers, lexer.STATIC), m.Body)...)
+		case ast.ConstructorDeclStmt:
+			members = append(members, l.extract(class.Name, m.Name, hasModifier(m.Modifiers, lexer.STATIC), m.Body)...)
+		case ast.PropertyDeclStmt:
+			for _, accessor := range m.Accessors {
+				members = append(members, l.extract(class.Name, m.Name, hasModifier(m.Modifiers, lexer.STATIC), accessor.Body)...)
+			}
+		case ast.IndexerDeclStmt:
+			for _, accessor := range m.Accessors {
+				members = append(members, l.extract(class.Name, "this", false, accessor.Body)...)
+			}
+		case ast.EventDeclStmt:
+			for _, accessor := range m.Accessors {
+				members = append(members, l.extract(class.Name, m.Name, hasModifier(m.Modifiers, lexer.STATIC), accessor.Body)...)
+			}
+		}
+	}
+
+	machines := []ast.ClassMember{}
+	for i, member := range members {
+		switch m := member.(type) {
+		case ast.MethodDeclStmt:
+			if l.isIterator(m) {
+				method, machine := l.lowerIterator(class.Name, m)
+				members[i] = method
+				machines = append(machines, machine)
+			} else if isAsync(m) {
+				method, machine := l.lowerAsync(class.Name, m)
+				members[i] = method
+				machines = append(machines, machine)
+			}
+		case ast.ClassDeclStmt:
+			members[i] = l.lowerClass(m)
+		}
+	}
+	class.Body.Members = append(members, machines...)
+	return class
+}
+
+// Moves the local iterator and async functions and the async lambdas of a body into methods of the class
+func (l *lowerer) extract(class, member string, isStatic bool, body ast.Stmt) []ast.ClassMember {
+	if body == nil {
+		return nil
+	}
+	methods := l.extractLocalFunctions(class, member, isStatic, body)
+	return append(methods, l.extractAsyncLambdas(class, member, isStatic, body)...)
+}
+
+// Local iterator and async functions become private methods of the class, the values of the variables
+// they capture are passed to them as leading arguments. Captured variables are copied, an iterator does
+// not see assignments to them that happen after it was created. Returns the new methods, local functions
+// and async lambdas inside of them are extracted as well.
+func (l *lowerer) extractLocalFunctions(class, member string, isStatic bool, body ast.Stmt) []ast.ClassMember {
+	methods := []ast.ClassMember{}
+	inspect(body, func(node any) bool {
+		function, ok := node.(*ast.LocalFunctionStmt)
+		if !ok {
+			return true
+		}
+		isAsync := hasModifier(function.Modifiers, lexer.ASYNC)
+		if _, isIteratorType := l.iteratorType(function.ReturnType.Name); !isAsync && (!isIteratorType || !containsYield(function.Body)) {
+			return true
+		}
+		l.functions++
+		at := l.at(function.Line, function.Column)
+		modifiers := []lexer.TokenKind{lexer.PRIVATE}
+		if isStatic {
+			modifiers = append(modifiers, lexer.STATIC)
+		}
+		if isAsync {
+			modifiers = append(modifiers, lexer.ASYNC)
+		}
+
+		parameters, args, parameterTypes := l.capturedParameters(at, function, function.Captures)
+		for _, param := range function.Parameters {
+			parameters = append(parameters, param)
+			arg := at.local(param.Identifier, param.Type.Name)
+			args = append(args, arg)
+			parameterTypes = append(parameterTypes, arg.Type)
+		}
+		method := at.method(modifiers, function.ReturnType.Name, fmt.Sprintf("<%s>g__%s|%d", member, function.Name, l.functions), parameters)
+		method.Body = function.Body
+		methods = append(methods, method)
+		methods = append(methods, l.extract(class, member, isStatic, method.Body)...)
+
+		call := at.call(l.receiver(at, class, isStatic), &ast.MethodSignature{Class: class, Name: method.Name, ParameterTypes: parameterTypes, ReturnType: at.registry.Parse(function.ReturnType.Name), IsStatic: isStatic}, args...)
+		if call.Type == types.Void {
+			function.Body = at.block(at.expression(call))
+		} else {
+			function.Body = at.block(at.returns(call))
+		}
+		return false
+	})
+	return methods
+}
+
+// Async lambdas become private async methods of the class like local functions, the lambda that
+// replaces one calls the method
+func (l *lowerer) extractAsyncLambdas(class, member string, isStatic bool, body ast.Stmt) []ast.ClassMember {
+	methods := []ast.ClassMember{}
+	lift := func(expr ast.Expr) ast.Expr {
+		lifted, extracted := l.liftAsyncLambdas(class, member, isStatic, expr)
+		methods = append(methods, extracted...)
+		return lifted
+	}
+	inspect(body, func(node any) bool {
+		switch s := node.(type) {
+		case ast.Expr:
+			// The lambdas inside of expressions are lifted with the expression
+			return false
+		case *ast.ExpressionStmt:
+			s.Expression = lift(s.Expression)
+		case *ast.VarDeclStmt:
+			s.Value = lift(s.Value)
+		case *ast.MultiVarDeclStmt:
+			for i := range s.Declarations {
+				s.Declarations[i].Value = lift(s.Declarations[i].Value)
+			}
+		case *ast.ReturnStmt:
+			s.Value = lift(s.Value)
+		case *ast.IfStmt:
+			s.Condition = lift(s.Condition)
+		case *ast.WhileStmt:
+			s.Condition = lift(s.Condition)
+		case *ast.SwitchStmt:
+			s.Expression = lift(s.Expression)
+		case *ast.ThrowStmt:
+			s.Value = lift(s.Value)
+		case *ast.YieldReturnStmt:
+			s.Value = lift(s.Value)
+		}
+		return true
+	})
+	return methods
+}
+
+// Replaces the async lambdas inside of an expression and returns the methods they became
+func (l *lowerer) liftAsyncLambdas(class, member string, isStatic bool, expr ast.Expr) (ast.Expr, []ast.ClassMember) {
+	methods := []ast.ClassMember{}
+	var lift func(ast.Expr) ast.Expr
+	lift = func(expr ast.Expr) ast.Expr {
+		if expr == nil {
+			return nil
+		}
+		typed, isTyped := expr.(ast.TypedExpr)
+		if !isTyped {
+			return mapChildren(expr, lift)
+		}
+		switch e := typed.Expr.(type) {
+		case ast.LambdaExpr:
+			if !e.IsAsync {
+				methods = append(methods, l.extractAsyncLambdas(class, member, isStatic, e.Body)...)
+				break
+			}
+			method, replacement := l.extractAsyncLambda(class, member, isStatic, typed.Type, e)
+			methods = append(methods, method)
+			methods = append(methods, l.extract(class, member, isStatic, method.Body)...)
+			typed.Expr = replacement
+			return typed
+		case ast.SwitchExpr:
+			arms := make([]ast.SwitchExprArm, len(e.Arms))
+			for i, arm := range e.Arms {
+				arm.Guard, arm.Value = lift(arm.Guard), lift(arm.Value)
+				arms[i] = arm
+			}
+			e.Arms = arms
+			typed.Expr = e
+		}
+		return mapChildren(typed, lift)
+	}
+	return lift(expr), methods
+}
+
+func (l *lowerer) extractAsyncLambda(class, member string, isStatic bool, delegate types.Type, lambda ast.LambdaExpr) (ast.MethodDeclStmt, ast.LambdaExpr) {
+	l.functions++
+	at := l.at(lambda.Line, lambda.Column)
+	modifiers := []lexer.TokenKind{lexer.PRIVATE}
+	if isStatic {
+		modifiers = append(modifiers, lexer.STATIC)
+	}
+	modifiers = append(modifiers, lexer.ASYNC)
+	returnType := l.delegateReturnType(delegate)
+
+	parameters, args, parameterTypes := l.capturedParameters(at, lambda, lambda.Captures)
+	for _, param := range lambda.Parameters {
+		parameters = append(parameters, param)
+		arg := at.local(param.Identifier, param.Type.Name)
+		args = append(args, arg)
+		parameterTypes = append(parameterTypes, arg.Type)
+	}
+	method := at.method(modifiers, returnType.String(), fmt.Sprintf("<%s>b__%d", member, l.functions), parameters)
+	method.Body = lambda.Body
+	if lambda.Body == nil {
+		value := lambda.Expression.(ast.TypedExpr)
+		if returnType == types.Void || returnType == l.registry.NewClass("Task") {
+			method.Body = at.block(at.expression(value))
+		} else {
+			method.Body = at.block(at.returns(value))
+		}
+	}
+
+	signature := &ast.MethodSignature{Class: class, Name: method.Name, ParameterTypes: parameterTypes, ReturnType: returnType, IsStatic: isStatic}
+	replacement := ast.LambdaExpr{
+		Parameters: lambda.Parameters,
+		Expression: at.call(l.receiver(at, class, isStatic), signature, args...),
+		Captures:   lambda.Captures,
+		Line:       lambda.Line,
+		Column:     lambda.Column,
+	}
+	return method, replacement
+}
+
+// Parameters for the captured variables of a local function or lambda and the arguments that pass them
+func (l *lowerer) capturedParameters(at builder, function any, captures []string) ([]ast.Parameter, []ast.Expr, []types.Type) {
+	parameters, args, parameterTypes := []ast.Parameter{}, []ast.Expr{}, []types.Type{}
+	for _, name := range captures {
+		typ := capturedType(function, name)
+		parameters = append(parameters, ast.Parameter{Type: at.typ(typ), Identifier: name})
+		arg := at.local(name, typ)
+		args = append(args, arg)
+		parameterTypes = append(parameterTypes, arg.Type)
+	}
+	return parameters, args, parameterTypes
+}
+
+// this or the class for calls to the extracted methods
+func (l *lowerer) receiver(at builder, class string, isStatic bool) ast.Expr {
+	if isStatic {
+		return at.className(class)
+	}
+	return at.this(class)
+}
+
+// The return type of Func<int, Task<int>>, Action and the declared delegates
+func (l *lowerer) delegateReturnType(typ types.Type) types.Type {
+	if generic, ok := typ.(*types.Generic); ok {
+		switch generic.Name {
+		case "Action":
+			return types.Void
+		case "Func":
+			return generic.Arguments[len(generic.Arguments)-1]
+		}
+	}
+	if typ == l.registry.NewClass("Action") {
+		return types.Void
+	}
+	return l.registry.Parse(l.delegates[typ.String()].ReturnType.Name)
+}
+
+// The type of a captured variable as the type checker recorded it at one of its uses
+func capturedType(body any, name string) string {
+	typ := ""
+	inspect(body, func(node any) bool {
+		if typed, ok := node.(ast.TypedExpr); ok {
+			if local, ok := typed.Expr.(ast.LocalVarExpr); ok && local.Name == name {
+				typ = typed.Type.String()
+			}
+		}
+		return typ == ""
+	})
+	return typ
+}
@@ -0,0 +1,175 @@
+package lowering
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Builds typed nodes, all of them are placed at the position of the code they are lowered from
+type builder struct {
+	registry *types.Registry
+	line     int
+	column   int
+}
+
+// A builder for nodes at another position
+func (b builder) at(line, column int) builder {
+	b.line, b.column = line, column
+	return b
+}
+
+func (b builder) token(kind lexer.TokenKind, value string) lexer.Token {
+	return lexer.NewToken(kind, value, b.line, b.column)
+}
+
+func (b builder) typ(name string) ast.Type {
+	return ast.Type{Name: name, Line: b.line, Column: b.column}
+}
+
+func (b builder) typed(typ types.Type, expr ast.Expr) ast.TypedExpr {
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: b.line, Column: b.column}
+}
+
+func (b builder) this(class string) ast.TypedExpr {
+	return b.typed(b.registry.NewClass(class), ast.ThisExpr{Line: b.line, Column: b.column})
+}
+
+// A class name as receiver of a static member
+func (b builder) className(class string) ast.Expr {
+	return ast.IdentifierExpr{Name: class, Line: b.line, Column: b.column}
+}
+
+func (b builder) local(name, typ string) ast.TypedExpr {
+	return b.typed(b.registry.Parse(typ), ast.LocalVarExpr{Name: name, Line: b.line, Column: b.column})
+}
+
+func (b builder) member(receiver ast.Expr, name, typ string) ast.TypedExpr {
+	return b.typed(b.registry.Parse(typ), ast.MemberAccessExpr{Receiver: receiver, Member: name, Line: b.line, Column: b.column})
+}
+
+func (b builder) intLiteral(value int64) ast.TypedExpr {
+	return b.typed(types.Int, ast.IntLiteralExpr{Value: value, Line: b.line, Column: b.column})
+}
+
+func (b builder) nullLiteral() ast.TypedExpr {
+	return b.typed(types.Null, ast.NullLiteralExpr{Line: b.line, Column: b.column})
+}
+
+func (b builder) boolLiteral(value bool) ast.TypedExpr {
+	return b.typed(types.Bool, ast.BoolLiteralExpr{Value: value, Line: b.line, Column: b.column})
+}
+
+func (b builder) not(expr ast.Expr) ast.TypedExpr {
+	return b.typed(types.Bool, ast.PrefixExpr{Operator: b.token(lexer.NOT, "!"), Expression: expr, Line: b.line, Column: b.column})
+}
+
+func (b builder) equals(left, right ast.Expr) ast.TypedExpr {
+	return b.typed(types.Bool, ast.BinaryExpr{Left: left, Operator: b.token(lexer.EQUALS, "=="), Right: right, Line: b.line, Column: b.column})
+}
+
+func (b builder) call(receiver ast.Expr, signature *ast.MethodSignature, args ...ast.Expr) ast.TypedExpr {
+	call := ast.MethodCallExpr{Receiver: receiver, MethodName: signature.Name, Args: args, Signature: signature, Line: b.line, Column: b.column}
+	return b.typed(signature.ReturnType, call)
+}
+
+func (b builder) construct(class string, signature *ast.MethodSignature, initializer *ast.ObjectInitializer, args ...ast.Expr) ast.TypedExpr {
+	construction := ast.ConstructorCallExpr{TypeName: class, Args: args, Initializer: initializer, Signature: signature, Line: b.line, Column: b.column}
+	return b.typed(b.registry.NewClass(class), construction)
+}
+
+// { Member = value, ... } for the given members and values
+func (b builder) initializer(members []string, values []ast.Expr) *ast.ObjectInitializer {
+	initializer := &ast.ObjectInitializer{Line: b.line, Column: b.column}
+	for i, member := range members {
+		initializer.Elements = append(initializer.Elements, ast.InitializerElement{Member: member, Value: values[i], Line: b.line, Column: b.column})
+	}
+	return initializer
+}
+
+func (b builder) stmt(typ types.Type, stmt ast.Stmt) ast.TypedStmt {
+	return ast.TypedStmt{Type: typ, Stmt: stmt, Line: b.line, Column: b.column}
+}
+
+func (b builder) assign(assignee ast.TypedExpr, value ast.Expr) ast.Stmt {
+	assignment := b.typed(assignee.Type, ast.AssignmentExpr{Assignee: assignee, Operator: b.token(lexer.ASSIGNMENT, "="), Value: value, Line: b.line, Column: b.column})
+	return b.expression(assignment)
+}
+
+func (b builder) expression(expr ast.TypedExpr) ast.Stmt {
+	return b.stmt(expr.Type, ast.ExpressionStmt{Expression: expr, Line: b.line, Column: b.column})
+}
+
+func (b builder) declare(name, typ string, value ast.Expr) ast.Stmt {
+	return b.stmt(types.Void, ast.VarDeclStmt{Identifier: name, Type: b.typ(typ), Value: value, Line: b.line, Column: b.column})
+}
+
+func (b builder) block(stmts ...ast.Stmt) ast.TypedStmt {
+	return b.stmt(types.Void, ast.BlockStmt{Body: stmts, Line: b.line, Column: b.column})
+}
+
+func (b builder) returns(value ast.TypedExpr) ast.Stmt {
+	return b.stmt(value.Type, ast.ReturnStmt{Value: value, Line: b.line, Column: b.column})
+}
+
+// return; of a method without a value
+func (b builder) returnVoid() ast.Stmt {
+	return b.stmt(types.Void, ast.ReturnStmt{Line: b.line, Column: b.column})
+}
+
+func (b builder) ifStmt(condition ast.Expr, then ast.Stmt, otherwise ast.Stmt) ast.Stmt {
+	return b.stmt(types.Void, ast.IfStmt{Condition: condition, Then: then, Else: otherwise, Line: b.line, Column: b.column})
+}
+
+func (b builder) breakStmt() ast.Stmt {
+	return b.stmt(types.Void, ast.BreakStmt{Line: b.line, Column: b.column})
+}
+
+func (b builder) continueStmt() ast.Stmt {
+	return b.stmt(types.Void, ast.ContinueStmt{Line: b.line, Column: b.column})
+}
+
+func (b builder) throw(value ast.Expr) ast.Stmt {
+	return b.stmt(types.Void, ast.ThrowStmt{Value: value, Line: b.line, Column: b.column})
+}
+
+func (b builder) section(stmts []ast.Stmt, labels ...ast.SwitchLabel) ast.SwitchSection {
+	return ast.SwitchSection{Labels: labels, Body: b.block(stmts...), Line: b.line, Column: b.column}
+}
+
+func (b builder) caseLabel(value int) ast.SwitchLabel {
+	pattern := ast.ConstantPattern{Value: b.intLiteral(int64(value)), Line: b.line, Column: b.column}
+	return ast.SwitchLabel{Pattern: pattern, Line: b.line, Column: b.column}
+}
+
+func (b builder) defaultLabel() ast.SwitchLabel {
+	return ast.SwitchLabel{IsDefault: true, Line: b.line, Column: b.column}
+}
+
+func (b builder) switchStmt(expression ast.Expr, sections []ast.SwitchSection) ast.Stmt {
+	return b.stmt(types.Void, ast.SwitchStmt{Expression: expression, Sections: sections, Line: b.line, Column: b.column})
+}
+
+func (b builder) method(modifiers []lexer.TokenKind, returnType, name string, parameters []ast.Parameter, body ...ast.Stmt) ast.MethodDeclStmt {
+	return ast.MethodDeclStmt{
+		Modifiers:  b.modifiers(modifiers...),
+		ReturnType: b.typ(returnType),
+		Name:       name,
+		Parameters: parameters,
+		Body:       b.block(body...),
+		Line:       b.line,
+		Column:     b.column,
+	}
+}
+
+func (b builder) field(modifiers []lexer.TokenKind, typ, name string) ast.FieldDeclStmt {
+	return ast.FieldDeclStmt{Modifiers: b.modifiers(modifiers...), Type: b.typ(typ), Identifier: name, Line: b.line, Column: b.column}
+}
+
+func (b builder) modifiers(kinds ...lexer.TokenKind) []ast.Modifier {
+	modifiers := []ast.Modifier{}
+	for _, kind := range kinds {
+		modifiers = append(modifiers, ast.Modifier{Kind: kind})
+	}
+	return modifiers
+}
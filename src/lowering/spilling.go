@@ -0,0 +1,281 @@
+package lowering
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// An await ends the current state in the middle of an expression. Everything the expression evaluated
+// before the await is spilled into <>s__ fields so that it survives until the method resumes, the
+// await itself becomes the code that suspends the method and the result of the awaiter takes its place.
+
+// Rewrites a checked expression of the method and spills it
+func (m *stateMachine) value(expr ast.Expr) ast.TypedExpr {
+	return m.spill(m.expr(expr)).(ast.TypedExpr)
+}
+
+// Emits the code of the awaits inside of a rewritten expression and returns what is left of it
+func (m *stateMachine) spill(expr ast.Expr) ast.Expr {
+	if expr == nil || !containsAwait(expr) {
+		return expr
+	}
+	typed, _ := expr.(ast.TypedExpr)
+	inner := expr
+	if typed.Expr != nil {
+		inner = typed.Expr
+	}
+	switch e := inner.(type) {
+	case ast.AwaitExpr:
+		return m.await(m.spill(e.Expression).(ast.TypedExpr), typed.Type.String(), e.Line, e.Column)
+	case ast.BinaryExpr:
+		switch e.Operator.Kind {
+		case lexer.AND, lexer.OR, lexer.NULL_COALESCING:
+			if containsAwait(e.Right) {
+				return m.conditionalValue(typed.Type.String(), e)
+			}
+		}
+	case ast.AssignmentExpr:
+		// The target of an assignment is evaluated before its value
+		if containsAwait(e.Value) {
+			e.Assignee = m.stashTarget(e.Assignee)
+			e.Value = m.spill(e.Value)
+			return m.retyped(typed, e)
+		}
+	case ast.SwitchExpr:
+		if armsContainAwait(e) {
+			return m.switchValue(typed.Type.String(), e)
+		}
+	}
+
+	// Children before the last one that awaits are evaluated before that await
+	last := -1
+	i := 0
+	mapChildren(inner, func(child ast.Expr) ast.Expr {
+		if containsAwait(child) {
+			last = i
+		}
+		i++
+		return child
+	})
+	i = 0
+	spilled := mapChildren(inner, func(child ast.Expr) ast.Expr {
+		defer func() { i++ }()
+		switch {
+		case i < last:
+			return m.stash(m.spill(child))
+		case i == last:
+			return m.spill(child)
+		}
+		return child
+	})
+	return m.retyped(typed, spilled)
+}
+
+func (m *stateMachine) retyped(typed ast.TypedExpr, expr ast.Expr) ast.Expr {
+	if typed.Expr == nil {
+		return expr
+	}
+	typed.Expr = expr
+	return typed
+}
+
+// Stores a value in a field unless it cannot change until it is used
+func (m *stateMachine) stash(expr ast.Expr) ast.Expr {
+	if isStable(expr) {
+		return expr
+	}
+	if argument, ok := expr.(ast.ArgumentExpr); ok {
+		// ref, in and out arguments refer to variables, they are not values
+		if len(argument.Modifiers) == 0 {
+			argument.Value = m.stash(argument.Value)
+		}
+		return argument
+	}
+	typed, ok := expr.(ast.TypedExpr)
+	if !ok {
+		return expr
+	}
+	at := m.at(typed.Line, typed.Column)
+	field := m.machineField(m.temporary(typed.Type.String()), typed.Type.String(), typed.Line, typed.Column)
+	m.emit(at.assign(field, typed))
+	return field
+}
+
+// The receiver and the arguments of the member or element that is assigned
+func (m *stateMachine) stashTarget(assignee ast.Expr) ast.Expr {
+	typed, ok := assignee.(ast.TypedExpr)
+	if !ok {
+		return assignee
+	}
+	switch target := typed.Expr.(type) {
+	case ast.MemberAccessExpr:
+		if target.Receiver != nil {
+			target.Receiver = m.stash(target.Receiver)
+		}
+		typed.Expr = target
+	case ast.ElementAccessExpr:
+		target.Receiver = m.stash(target.Receiver)
+		args := make([]ast.Expr, len(target.Args))
+		for i, arg := range target.Args {
+			args[i] = m.stash(arg)
+		}
+		target.Args = args
+		typed.Expr = target
+	}
+	return typed
+}
+
+// Literals, this, class names and lambdas evaluate to the same value whenever they are evaluated
+func isStable(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case nil, ast.IntLiteralExpr, ast.RealLiteralExpr, ast.BoolLiteralExpr, ast.CharLiteralExpr, ast.StringExpr, ast.NullLiteralExpr,
+		ast.ThisExpr, ast.IdentifierExpr, ast.LambdaExpr:
+		return true
+	case ast.TypedExpr:
+		if member, ok := e.Expr.(ast.MemberAccessExpr); ok && member.Member == "<>4__this" {
+			return true
+		}
+		return isStable(e.Expr)
+	}
+	return false
+}
+
+// Adds a field for a spilled value to the state machine
+func (m *stateMachine) temporary(typ string) string {
+	m.temporaries++
+	field := fmt.Sprintf("<>s__%d", m.temporaries)
+	m.fields = append(m.fields, m.field([]lexer.TokenKind{lexer.PRIVATE}, typ, field))
+	return field
+}
+
+// Awaiters of the same type share a field, the method is suspended at one await at a time
+func (m *stateMachine) awaiter(typ string) string {
+	if field, ok := m.awaiters[typ]; ok {
+		return field
+	}
+	field := fmt.Sprintf("<>u__%d", len(m.awaiters)+1)
+	m.awaiters[typ] = field
+	m.fields = append(m.fields, m.field([]lexer.TokenKind{lexer.PRIVATE}, typ, field))
+	return field
+}
+
+// this.<>u__1 = task.GetAwaiter();
+// if (!this.<>u__1.IsCompleted) { state = resume; this.<>u__1.OnCompleted(this.MoveNext); return; }
+// and the state resume continues with this.<>u__1.GetResult()
+func (m *stateMachine) await(task ast.TypedExpr, result string, line, column int) ast.Expr {
+	at := m.at(line, column)
+	class, awaiterType := "Task", "TaskAwaiter"
+	if generic, ok := task.Type.(*types.Generic); ok && generic.Name == "Task" {
+		class, awaiterType = task.Type.String(), m.registry.NewGeneric(awaiterType, generic.Arguments).String()
+	}
+	awaiter := m.machineField(m.awaiter(awaiterType), awaiterType, line, column)
+	getAwaiter := &ast.MethodSignature{Class: class, Name: "GetAwaiter", ReturnType: awaiter.Type}
+	m.emit(at.assign(awaiter, at.call(task, getAwaiter)))
+
+	resume := m.newState()
+	moveNext := &ast.MethodSignature{Class: m.machine.name, Name: "MoveNext", ReturnType: types.Void}
+	continuation := at.typed(at.registry.NewClass("Action"), ast.MethodGroupExpr{Receiver: at.this(m.machine.name), MethodName: "MoveNext", Signature: moveNext, Line: line, Column: column})
+	onCompleted := &ast.MethodSignature{Class: awaiterType, Name: "OnCompleted", ParameterTypes: []types.Type{continuation.Type}, ReturnType: types.Void}
+	suspend := at.block(m.setState(resume), at.expression(at.call(awaiter, onCompleted, continuation)), at.returnVoid())
+	m.emit(at.ifStmt(at.not(at.member(awaiter, "IsCompleted", "bool")), suspend, nil))
+	m.enter(resume)
+
+	getResult := &ast.MethodSignature{Class: awaiterType, Name: "GetResult", ReturnType: at.registry.Parse(result)}
+	return at.call(awaiter, getResult)
+}
+
+// && , || and ?? only evaluate their right side, and only await in it, depending on their left side
+func (m *stateMachine) conditionalValue(typ string, e ast.BinaryExpr) ast.Expr {
+	at := m.at(e.Line, e.Column)
+	left := m.spill(e.Left)
+	if e.Operator.Kind == lexer.NULL_COALESCING {
+		left = m.stash(left)
+	}
+	result := m.machineField(m.temporary(typ), typ, e.Line, e.Column)
+	m.emit(at.assign(result, left))
+
+	var skip ast.Expr
+	switch e.Operator.Kind {
+	case lexer.AND:
+		skip = at.not(result)
+	case lexer.OR:
+		skip = result
+	default:
+		skip = at.not(at.equals(left, at.typed(types.Null, ast.NullLiteralExpr{Line: e.Line, Column: e.Column})))
+	}
+	after := m.newState()
+	m.emit(at.ifStmt(skip, at.block(m.jumpStmts(after, len(m.regions))...), nil))
+	m.emit(at.assign(result, m.spill(e.Right)))
+	m.enter(after)
+	return result
+}
+
+func armsContainAwait(e ast.SwitchExpr) bool {
+	for _, arm := range e.Arms {
+		if containsAwait(arm.Guard) || containsAwait(arm.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// A switch expression with an await in an arm is matched arm by arm, every arm that does not match
+// continues with the next one. The hoister leaves the arms of such switch expressions to this method
+// because their pattern variables become fields.
+func (m *stateMachine) switchValue(typ string, e ast.SwitchExpr) ast.Expr {
+	at := m.at(e.Line, e.Column)
+	input, _ := m.spill(e.Expression).(ast.TypedExpr)
+	if !isStable(input) {
+		field := m.machineField(m.temporary(input.Type.String()), input.Type.String(), input.Line, input.Column)
+		m.emit(at.assign(field, input))
+		input = field
+	}
+	result := m.machineField(m.temporary(typ), typ, e.Line, e.Column)
+	after := m.newState()
+	for _, arm := range e.Arms {
+		next := m.newState()
+		m.push()
+		pattern := m.pattern(arm.Pattern)
+		if _, isDiscard := pattern.(ast.DiscardPattern); !isDiscard {
+			matches := at.typed(types.Bool, ast.IsPatternExpr{Expression: input, Pattern: pattern, Line: e.Line, Column: e.Column})
+			m.emit(at.ifStmt(at.not(matches), at.block(m.jumpStmts(next, len(m.regions))...), nil))
+		}
+		m.emit(m.copyPatternVariables(patternVariables(arm.Pattern))...)
+		if arm.Guard != nil {
+			m.emit(at.ifStmt(at.not(m.value(arm.Guard)), at.block(m.jumpStmts(next, len(m.regions))...), nil))
+		}
+		m.emit(at.assign(result, m.value(arm.Value)))
+		m.jump(after, len(m.regions))
+		m.pop()
+		m.enter(next)
+	}
+	exception := &ast.MethodSignature{Class: "InvalidOperationException", Name: "InvalidOperationException", ReturnType: types.Void}
+	m.emit(at.throw(at.construct("InvalidOperationException", exception, nil)))
+	m.dead = true
+	m.enter(after)
+	return result
+}
+
+// Rewrites and spills the expression of a statement with an await outside of split statements
+func (m *stateMachine) spillStmt(stmt ast.Stmt) {
+	typ := types.Void
+	if typed, ok := stmt.(ast.TypedStmt); ok {
+		typ = typed.Type
+	}
+	inner := unwrap(stmt)
+	switch s := inner.(type) {
+	case ast.ExpressionStmt:
+		s.Expression = m.spill(s.Expression)
+		inner = s
+	case ast.ReturnStmt:
+		s.Value = m.spill(s.Value)
+		inner = s
+	case ast.ThrowStmt:
+		s.Value = m.spill(s.Value)
+		inner = s
+	}
+	m.emit(m.redirect(ast.TypedStmt{Type: typ, Stmt: inner, Line: inner.GetLine(), Column: inner.GetColumn()}, false, false, false, false))
+}
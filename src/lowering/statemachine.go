@@ -0,0 +1,604 @@
+package lowering
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// The class an iterator or async method is lowered to. The state machine is a nested class of the
+// method's class, <>4__this holds the instance of the method's class and the parameters are fields with
+// the same name.
+type machineClass struct {
+	builder
+	lowerer *lowerer
+	class   string
+	// Qualified name of the state machine class
+	name       string
+	simpleName string
+	isStatic   bool
+}
+
+func (l *lowerer) machineClass(class, simpleName string, method ast.MethodDeclStmt) *machineClass {
+	return &machineClass{
+		builder:    l.at(method.Line, method.Column),
+		lowerer:    l,
+		class:      class,
+		name:       class + "." + simpleName,
+		simpleName: simpleName,
+		isStatic:   hasModifier(method.Modifiers, lexer.STATIC),
+	}
+}
+
+func (c *machineClass) state() ast.TypedExpr {
+	return c.member(c.this(c.name), "<>1__state", "int")
+}
+
+func (c *machineClass) declaration(baseTypes []ast.Type, members []ast.ClassMember, file string) ast.ClassDeclStmt {
+	return ast.ClassDeclStmt{
+		Modifiers: c.modifiers(lexer.PRIVATE),
+		Kind:      lexer.CLASS,
+		Name:      c.name,
+		BaseTypes: baseTypes,
+		Body:      ast.ClassBody{Members: members, Line: c.line, Column: c.column},
+		File:      file,
+		Line:      c.line,
+		Column:    c.column,
+	}
+}
+
+// Splits the body of an iterator or async method into the states of MoveNext. Every yield return and
+// every await ends a state and the code after it becomes a new one. Loops, ifs, switches and try
+// statements that contain one are split into states as well, everything else is kept as it is and only
+// its jumps out of split statements are redirected to the states they lead to.
+type stateMachine struct {
+	hoister
+	// One of them is set
+	iterator *iterator
+	async    *asyncMethod
+	blocks   map[int][]ast.Stmt
+	order    []int
+	current  int
+	// The end of the current state cannot be reached
+	dead   bool
+	states int
+	// Split try statements the current state is inside of, innermost last
+	regions   []*tryRegion
+	regionsOf map[int][]*tryRegion
+	targets   []jumpTarget
+	// Fields with the exceptions of the catch clauses the current state is inside of, for rethrows
+	handlers []ast.TypedExpr
+	// Fields for spilled values and awaiters
+	temporaries int
+	awaiters    map[string]string
+}
+
+// A try statement with a suspension in its body. Its finally clause runs when the code leaves the try
+// statement and when the method fails or the iterator is disposed while suspended inside of it. Only
+// async methods can be suspended in a try statement with catch clauses, a try statement with both is
+// split into two regions.
+type tryRegion struct {
+	finally ast.Stmt
+	// The state while the finally clause runs, it only has the regions around this one
+	outerState int
+	catches    []catchHandler
+	// A finally clause that awaits is not run in place but has states of its own after the try statement,
+	// next is the state to continue with after it and exception the exception it rethrows
+	finallyState int
+	next         ast.TypedExpr
+	exception    ast.TypedExpr
+}
+
+func (region *tryRegion) awaitsInFinally() bool {
+	return region.next.Expr != nil
+}
+
+// A catch clause of an async method, it stores the exception and continues with the handler state
+type catchHandler struct {
+	clause    ast.CatchClause
+	exception ast.TypedExpr
+	state     int
+}
+
+// A split loop or switch statement that break, continue and goto case can jump out of
+type jumpTarget struct {
+	breakState int
+	// -1 for switch statements
+	continueState int
+	regions       int
+	cases         map[ast.ConstantKey]int
+	defaultState  int
+}
+
+func newStateMachine(machine *machineClass) *stateMachine {
+	m := &stateMachine{
+		hoister:   hoister{builder: machine.builder, machine: machine},
+		blocks:    map[int][]ast.Stmt{},
+		regionsOf: map[int][]*tryRegion{},
+		awaiters:  map[string]string{},
+	}
+	m.enter(m.newState())
+	return m
+}
+
+func (m *stateMachine) newState() int {
+	m.states++
+	return m.states - 1
+}
+
+func (m *stateMachine) emit(stmts ...ast.Stmt) {
+	if !m.dead {
+		m.blocks[m.current] = append(m.blocks[m.current], stmts...)
+	}
+}
+
+func (m *stateMachine) setState(state int) ast.Stmt {
+	return m.assign(m.machineField("<>1__state", "int", m.line, m.column), m.intLiteral(int64(state)))
+}
+
+// Continues with the given state, the code falls through into it unless it is dead
+func (m *stateMachine) enter(state int) {
+	if !m.dead && len(m.order) > 0 {
+		m.jump(state, len(m.regions))
+	}
+	m.current = state
+	m.dead = false
+	m.order = append(m.order, state)
+	m.regionsOf[state] = append([]*tryRegion{}, m.regions...)
+}
+
+// Leaves the regions above depth, innermost first, and runs the given state next
+func (m *stateMachine) jumpStmts(state, depth int) []ast.Stmt {
+	return m.leaveThen(depth, func() []ast.Stmt {
+		return []ast.Stmt{m.setState(state), m.continueStmt()}
+	})
+}
+
+func (m *stateMachine) jump(state, depth int) {
+	m.emit(m.jumpStmts(state, depth)...)
+	m.dead = true
+}
+
+func (m *stateMachine) leave(depth int) []ast.Stmt {
+	stmts := []ast.Stmt{}
+	for i := len(m.regions) - 1; i >= depth; i-- {
+		if m.regions[i].finally != nil {
+			stmts = append(stmts, m.setState(m.regions[i].outerState), m.regions[i].finally)
+		}
+	}
+	return stmts
+}
+
+// Leaves the regions above depth like leave and continues with the code of then. At a region whose
+// finally clause awaits the code stores the state that leaves the remaining regions and runs the states
+// of the finally clause instead.
+func (m *stateMachine) leaveThen(depth int, then func() []ast.Stmt) []ast.Stmt {
+	stmts := []ast.Stmt{}
+	for i := len(m.regions) - 1; i >= depth; i-- {
+		region := m.regions[i]
+		if region.awaitsInFinally() {
+			next := m.continuation(i, depth, then)
+			return append(stmts, m.assign(region.next, m.intLiteral(int64(next))), m.setState(region.finallyState), m.continueStmt())
+		}
+		if region.finally != nil {
+			stmts = append(stmts, m.setState(region.outerState), region.finally)
+		}
+	}
+	return append(stmts, then()...)
+}
+
+// A state outside of the given region that continues leaving the regions above depth
+func (m *stateMachine) continuation(region, depth int, then func() []ast.Stmt) int {
+	regions := m.regions
+	m.regions = regions[:region]
+	defer func() { m.regions = regions }()
+
+	state := m.newState()
+	m.order = append(m.order, state)
+	m.regionsOf[state] = append([]*tryRegion{}, m.regions...)
+	m.blocks[state] = m.leaveThen(depth, then)
+	return state
+}
+
+// Ends the iteration or completes the task of an async method
+func (m *stateMachine) finishStmts() []ast.Stmt {
+	if m.async != nil {
+		return m.completeStmts(nil)
+	}
+	stmts := m.leave(0)
+	return append(stmts, m.setState(-1), m.returns(m.boolLiteral(false)))
+}
+
+func (m *stateMachine) statement(stmt ast.Stmt) {
+	if stmt == nil {
+		return
+	}
+	split := false
+	switch unwrap(stmt).(type) {
+	case ast.BlockStmt, ast.YieldReturnStmt, ast.IfStmt, ast.WhileStmt, ast.SwitchStmt, ast.TryStmt:
+		split = containsSuspension(stmt)
+	}
+	if !split {
+		for _, expanded := range m.expand(stmt) {
+			if containsAwait(expanded) {
+				m.spillStmt(expanded)
+			} else {
+				m.emit(m.redirect(expanded, false, false, false, false))
+			}
+			switch unwrap(expanded).(type) {
+			case ast.BreakStmt, ast.ContinueStmt, ast.GotoCaseStmt, ast.YieldBreakStmt, ast.ThrowStmt, ast.ReturnStmt:
+				m.dead = true
+			}
+		}
+		return
+	}
+
+	switch s := unwrap(stmt).(type) {
+	case ast.BlockStmt:
+		m.push()
+		m.predeclare(s.Body)
+		for _, inner := range s.Body {
+			m.statement(inner)
+		}
+		m.pop()
+	case ast.YieldReturnStmt:
+		at := m.at(s.Line, s.Column)
+		resume := m.newState()
+		m.emit(
+			at.assign(m.machineField("<>2__current", m.iterator.element, s.Line, s.Column), m.expr(s.Value)),
+			m.setState(resume),
+			at.returns(at.boolLiteral(true)),
+		)
+		m.dead = true
+		m.enter(resume)
+	case ast.IfStmt:
+		m.ifStmt(s)
+	case ast.WhileStmt:
+		m.whileStmt(s)
+	case ast.SwitchStmt:
+		m.switchStmt(s)
+	case ast.TryStmt:
+		m.tryStmt(s)
+	}
+}
+
+func (m *stateMachine) ifStmt(s ast.IfStmt) {
+	condition := m.value(s.Condition)
+	whenTrue := m.copyPatternVariables(assignedWhen(s.Condition, true))
+	whenFalse := m.copyPatternVariables(assignedWhen(s.Condition, false))
+
+	after := m.newState()
+	otherwise := after
+	if s.Else != nil {
+		otherwise = m.newState()
+	}
+	at := m.at(s.Line, s.Column)
+	m.emit(at.ifStmt(at.not(condition), at.block(append(whenFalse, m.jumpStmts(otherwise, len(m.regions))...)...), nil))
+	m.emit(whenTrue...)
+	m.statement(s.Then)
+	if s.Else != nil {
+		m.jump(after, len(m.regions))
+		m.enter(otherwise)
+		m.statement(s.Else)
+	}
+	m.enter(after)
+}
+
+func (m *stateMachine) whileStmt(s ast.WhileStmt) {
+	head, after := m.newState(), m.newState()
+	m.enter(head)
+	m.push()
+	condition := m.value(s.Condition)
+	if literal, ok := condition.Expr.(ast.BoolLiteralExpr); !ok || !literal.Value {
+		at := m.at(s.Line, s.Column)
+		m.emit(at.ifStmt(at.not(condition), at.block(m.jumpStmts(after, len(m.regions))...), nil))
+	}
+	m.emit(m.copyPatternVariables(assignedWhen(s.Condition, true))...)
+
+	m.targets = append(m.targets, jumpTarget{breakState: after, continueState: head, regions: len(m.regions)})
+	m.statement(s.Body)
+	m.targets = m.targets[:len(m.targets)-1]
+	m.jump(head, len(m.regions))
+	m.pop()
+	m.enter(after)
+}
+
+// The sections are dispatched by a switch statement in the current state, their bodies get states
+func (m *stateMachine) switchStmt(s ast.SwitchStmt) {
+	at := m.at(s.Line, s.Column)
+	expression := m.value(s.Expression)
+	after := m.newState()
+	target := jumpTarget{breakState: after, continueState: -1, regions: len(m.regions), cases: map[ast.ConstantKey]int{}, defaultState: -1}
+
+	sections := []ast.SwitchSection{}
+	scopes := []map[string]string{}
+	states := []int{}
+	hasDefault := false
+	for _, section := range s.Sections {
+		state := m.newState()
+		m.push()
+		labels := m.labels(section.Labels)
+		variables := []patternVariable{}
+		for _, label := range section.Labels {
+			if label.IsDefault {
+				hasDefault = true
+				target.defaultState = state
+			} else if constant, ok := label.Pattern.(ast.ConstantPattern); ok {
+				target.cases[ast.ConstantKeyOf(constant.Value)] = state
+			}
+			variables = append(variables, patternVariables(label.Pattern)...)
+		}
+		stmts := append(m.copyPatternVariables(variables), m.setState(state), at.breakStmt())
+		sections = append(sections, ast.SwitchSection{Labels: labels, Body: at.block(stmts...), Line: section.Line, Column: section.Column})
+		scopes = append(scopes, m.scopes[len(m.scopes)-1])
+		states = append(states, state)
+		m.pop()
+	}
+	if !hasDefault {
+		sections = append(sections, at.section([]ast.Stmt{m.setState(after), at.breakStmt()}, at.defaultLabel()))
+	}
+	m.emit(at.switchStmt(expression, sections), at.continueStmt())
+	m.dead = true
+
+	m.targets = append(m.targets, target)
+	for i, section := range s.Sections {
+		m.scopes = append(m.scopes, scopes[i])
+		m.enter(states[i])
+		m.statement(section.Body)
+		m.jump(after, len(m.regions))
+		m.pop()
+	}
+	m.targets = m.targets[:len(m.targets)-1]
+	m.enter(after)
+}
+
+// Only try statements without catch clauses can contain a yield return. A try statement of an async
+// method with catch clauses and a finally clause is split like a try statement with a finally clause
+// around one with the catch clauses.
+func (m *stateMachine) tryStmt(s ast.TryStmt) {
+	if s.Finally != nil && containsAwait(s.Finally) {
+		m.awaitingTryStmt(s)
+		return
+	}
+	depth := len(m.regions)
+	outer := -1
+	if depth > 0 && s.Finally != nil {
+		// Never entered, Dispose uses it to run the finally clauses of the outer regions
+		outer = m.newState()
+		m.regionsOf[outer] = append([]*tryRegion{}, m.regions...)
+	}
+	after := m.newState()
+	if s.Finally != nil {
+		m.regions = append(m.regions, &tryRegion{finally: m.stmt(s.Finally), outerState: outer})
+	}
+	if len(s.Catches) > 0 {
+		m.catchRegion(s.Body, s.Catches)
+	} else {
+		m.enter(m.newState())
+		m.statement(s.Body)
+	}
+	m.jump(after, depth)
+	m.regions = m.regions[:depth]
+	m.enter(after)
+}
+
+// A finally clause that awaits gets states after the try block. The code that leaves the try block stores
+// the state to continue with after the finally clause, the region stores the exception that leaves it and
+// the finally clause rethrows it at its end.
+func (m *stateMachine) awaitingTryStmt(s ast.TryStmt) {
+	at := m.at(s.Line, s.Column)
+	depth := len(m.regions)
+	after := m.newState()
+	region := &tryRegion{
+		finallyState: m.newState(),
+		next:         m.machineField(m.temporary("int"), "int", s.Line, s.Column),
+		exception:    m.machineField(m.temporary("Exception"), "Exception", s.Line, s.Column),
+	}
+	m.emit(at.assign(region.exception, at.nullLiteral()))
+
+	m.regions = append(m.regions, region)
+	if len(s.Catches) > 0 {
+		m.catchRegion(s.Body, s.Catches)
+	} else {
+		m.enter(m.newState())
+		m.statement(s.Body)
+	}
+	m.jump(after, depth)
+	m.regions = m.regions[:depth]
+
+	m.enter(region.finallyState)
+	m.statement(s.Finally)
+	m.emit(
+		at.ifStmt(at.not(at.equals(region.exception, at.nullLiteral())), at.throw(region.exception), nil),
+		at.assign(m.machine.state(), region.next),
+		at.continueStmt(),
+	)
+	m.dead = true
+	m.enter(after)
+}
+
+// The handlers of the catch clauses get states after the try block. The region catches the exceptions,
+// stores them in fields and continues with the handler states.
+func (m *stateMachine) catchRegion(body ast.Stmt, catches []ast.CatchClause) {
+	depth := len(m.regions)
+	after := m.newState()
+	region := &tryRegion{}
+	for _, clause := range catches {
+		// The filter runs inside of the catch clause of the region where the exception is a local
+		m.push()
+		m.declare(clause.Identifier, "")
+		clause.Filter = m.expr(clause.Filter)
+		m.pop()
+		region.catches = append(region.catches, catchHandler{clause: clause, state: m.newState()})
+	}
+
+	m.regions = append(m.regions, region)
+	m.enter(m.newState())
+	m.statement(body)
+	m.jump(after, depth)
+	m.regions = m.regions[:depth]
+
+	for i, clause := range catches {
+		at := m.at(clause.Line, clause.Column)
+		typ := clause.Type.Name
+		if typ == "" {
+			typ = "Exception"
+		}
+		m.push()
+		field := ""
+		if clause.Identifier != "" && clause.Identifier != "_" {
+			field = m.hoist(clause.Identifier, typ)
+		} else {
+			field = m.temporary(typ)
+		}
+		region.catches[i].exception = m.machineField(field, typ, at.line, at.column)
+		m.enter(region.catches[i].state)
+		m.handlers = append(m.handlers, region.catches[i].exception)
+		m.statement(clause.Body)
+		m.handlers = m.handlers[:len(m.handlers)-1]
+		m.jump(after, depth)
+		m.pop()
+	}
+	m.enter(after)
+}
+
+// Turns the jumps of a statement that leave it into jumps to states. loop, breakable, inSwitch and inCatch
+// tell whether the statement is inside of a loop, a loop or switch, a switch and a catch clause that have
+// not been split.
+func (m *stateMachine) redirect(stmt ast.Stmt, loop, breakable, inSwitch, inCatch bool) ast.Stmt {
+	switch s := stmt.(type) {
+	case ast.TypedStmt:
+		redirected := m.redirect(s.Stmt, loop, breakable, inSwitch, inCatch)
+		if typed, ok := redirected.(ast.TypedStmt); ok {
+			return typed
+		}
+		s.Stmt = redirected
+		return s
+	case ast.BlockStmt:
+		body := make([]ast.Stmt, len(s.Body))
+		for i, inner := range s.Body {
+			body[i] = m.redirect(inner, loop, breakable, inSwitch, inCatch)
+		}
+		s.Body = body
+		return s
+	case ast.IfStmt:
+		s.Then = m.redirect(s.Then, loop, breakable, inSwitch, inCatch)
+		if s.Else != nil {
+			s.Else = m.redirect(s.Else, loop, breakable, inSwitch, inCatch)
+		}
+		return s
+	case ast.WhileStmt:
+		s.Body = m.redirect(s.Body, true, true, inSwitch, inCatch)
+		return s
+	case ast.SwitchStmt:
+		sections := make([]ast.SwitchSection, len(s.Sections))
+		for i, section := range s.Sections {
+			section.Body = m.redirect(section.Body, loop, true, true, inCatch)
+			sections[i] = section
+		}
+		s.Sections = sections
+		return s
+	case ast.TryStmt:
+		s.Body = m.redirect(s.Body, loop, breakable, inSwitch, inCatch)
+		catches := make([]ast.CatchClause, len(s.Catches))
+		for i, clause := range s.Catches {
+			clause.Body = m.redirect(clause.Body, loop, breakable, inSwitch, true)
+			catches[i] = clause
+		}
+		s.Catches = catches
+		if s.Finally != nil {
+			s.Finally = m.redirect(s.Finally, loop, breakable, inSwitch, inCatch)
+		}
+		return s
+	case ast.BreakStmt:
+		if breakable {
+			return s
+		}
+		target := m.targets[len(m.targets)-1]
+		return m.block(m.jumpStmts(target.breakState, target.regions)...)
+	case ast.ContinueStmt:
+		if loop {
+			return s
+		}
+		for i := len(m.targets) - 1; i >= 0; i-- {
+			if target := m.targets[i]; target.continueState >= 0 {
+				return m.block(m.jumpStmts(target.continueState, target.regions)...)
+			}
+		}
+	case ast.GotoCaseStmt:
+		if inSwitch {
+			return s
+		}
+		for i := len(m.targets) - 1; i >= 0; i-- {
+			if target := m.targets[i]; target.cases != nil {
+				state := target.defaultState
+				if s.Value != nil {
+					state = target.cases[ast.ConstantKeyOf(s.Value)]
+				}
+				return m.block(m.jumpStmts(state, target.regions)...)
+			}
+		}
+	case ast.YieldBreakStmt:
+		return m.block(m.finishStmts()...)
+	case ast.ReturnStmt:
+		if m.async != nil {
+			return m.block(m.completeStmts(s.Value)...)
+		}
+	case ast.ThrowStmt:
+		// A rethrow inside of a split catch clause throws the exception stored by the region
+		if s.Value == nil && !inCatch && len(m.handlers) > 0 {
+			s.Value = m.handlers[len(m.handlers)-1]
+			return s
+		}
+	}
+	return stmt
+}
+
+// Reports whether a checked statement contains a yield return outside of lambdas and local functions
+func containsYieldReturn(stmt ast.Stmt) bool {
+	found := false
+	inspect(stmt, func(node any) bool {
+		switch node.(type) {
+		case ast.YieldReturnStmt, *ast.YieldReturnStmt:
+			found = true
+		case ast.LocalFunctionStmt, *ast.LocalFunctionStmt, ast.Expr:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether a checked statement or expression contains an await outside of lambdas and local functions
+func containsAwait(node any) bool {
+	found := false
+	inspect(node, func(node any) bool {
+		switch node.(type) {
+		case ast.AwaitExpr:
+			found = true
+		case ast.LocalFunctionStmt, *ast.LocalFunctionStmt, ast.LambdaExpr:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether the method can be suspended inside of a checked statement
+func containsSuspension(stmt ast.Stmt) bool {
+	return containsYieldReturn(stmt) || containsAwait(stmt)
+}
+
+// Reports whether a checked statement contains yield return or yield break outside of lambdas and local functions
+func containsYield(stmt ast.Stmt) bool {
+	found := false
+	inspect(stmt, func(node any) bool {
+		switch node.(type) {
+		case ast.YieldReturnStmt, *ast.YieldReturnStmt, ast.YieldBreakStmt, *ast.YieldBreakStmt:
+			found = true
+		case ast.LocalFunctionStmt, *ast.LocalFunctionStmt, ast.Expr:
+			return false
+		}
+		return !found
+	})
+	return found
+}
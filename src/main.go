@@ -1,45 +1,103 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 
-	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
-	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/parser"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lowering"
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/typecheck"
 )
 
+// Usage: main [-library] [file or directory]...
+// Directories are searched recursively for .cs and .lang files.
+// Libraries are compiled without an entry point.
 func main() {
-	fmt.Println("=========================================")
-	fmt.Println("Reading File...")
-	fmt.Println("=========================================")
+	library := flag.Bool("library", false, "compile without an entry point")
+	flag.Parse()
 
-	bytes, _ := os.ReadFile("./examples/standardTypes.lang")
-	fmt.Println(bytes)
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"./examples/standardTypes.lang"}
+	}
 
 	fmt.Println("=========================================")
-	fmt.Println("Parsing...")
+	fmt.Println("Reading Files...")
 	fmt.Println("=========================================")
 
-	tokens := lexer.Tokenize(string(bytes))
-
-	for _, token := range tokens {
-		token.Debug()
+	files, err := collectSourceFiles(paths)
+	if err != nil {
+		fail(err)
+	}
+	for _, file := range files {
+		fmt.Println(file)
 	}
 
 	fmt.Println("=========================================")
 	fmt.Println("Parsing...")
 	fmt.Println("=========================================")
 
-	ast := parser.Parse(tokens)
-	fmt.Println(ast)
+	compilation := typecheck.NewCompilation()
+	compilation.IsLibrary = *library
+	for _, file := range files {
+		if err := compilation.AddFile(file); err != nil {
+			fail(err)
+		}
+	}
+	for _, unit := range compilation.Units {
+		fmt.Println(unit.Path)
+		fmt.Println(unit.Program)
+	}
 
 	fmt.Println("=========================================")
 	fmt.Println("Type checking...")
 	fmt.Println("=========================================")
 
-	typeChecker := typecheck.NewTypeChecker()
-	typedAst := typeChecker.CheckProgram(&ast)
+	typedAst, err := compilation.Check()
+	for _, warning := range compilation.Warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	if err != nil {
+		fail(err)
+	}
 	fmt.Println(typedAst)
+	if compilation.EntryPoint != nil {
+		fmt.Printf("Entry point: %s.Main\n", compilation.EntryPoint.Class)
+	}
+
+	fmt.Println("=========================================")
+	fmt.Println("Lowering...")
+	fmt.Println("=========================================")
+
+	fmt.Println(lowering.Lower(typedAst, compilation.Types))
+}
+
+func collectSourceFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if file == path && !entry.IsDir() {
+				files = append(files, file)
+				return nil
+			}
+			if ext := filepath.Ext(file); !entry.IsDir() && (ext == ".cs" || ext == ".lang") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
 
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
@@ -18,8 +19,8 @@ func parseExpression(p *parser, bp bindingPower) ast.Expr {
 
 	left := nud_fn(p)
 
-	for bpTable[p.currentTokenKind()] > bp {
-		tokenKind = p.currentTokenKind()
+	for bpTable[contextualKind(p, p.pos)] > bp {
+		tokenKind = contextualKind(p, p.pos)
 		led_fn, exists := ledTable[tokenKind]
 
 		if !exists {
@@ -35,13 +36,26 @@ func parseExpression(p *parser, bp bindingPower) ast.Expr {
 func parsePrimaryExpr(p *parser) ast.Expr {
 	switch p.currentTokenKind() {
 	case lexer.INTLITERAL:
-		number, _ := strconv.ParseInt(p.advance().Value, 0, 64)
-		return ast.IntLiteralExpr{Value: number, Line: p.currentToken().Line, Column: p.currentToken().Column}
+		return parseIntLiteral(p)
+	case lexer.REALLITERAL:
+		return parseRealLiteral(p)
 	case lexer.STRINGLITERAL:
 		return ast.StringExpr{Value: p.advance().Value, Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case lexer.CHARLITERAL:
 		return ast.CharLiteralExpr{Value: rune(p.advance().Value[0]), Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case lexer.IDENTIFIER:
+		switch contextualKind(p, p.pos) {
+		case lexer.AWAIT:
+			return parseAwaitExpr(p)
+		case lexer.ASYNC:
+			return parseAsyncLambdaExpr(p)
+		}
+		if p.nextTokenKind() == lexer.ARROW {
+			return parseLambdaExpr(p)
+		}
+		if isQueryAhead(p) {
+			return parseQueryExpr(p)
+		}
 		token := p.advance()
 		var expr ast.Expr = ast.IdentifierExpr{Name: token.Value, Line: token.Line, Column: token.Column}
 		if p.currentTokenKind() == lexer.OPEN_PAREN {
@@ -79,61 +93,191 @@ func parseBinaryExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
 
 	right := parseExpression(p, bp)
 
-	return ast.BinaryExpr{Left: left, Operator: operatorToken, Right: right}
+	return ast.BinaryExpr{Left: left, Operator: operatorToken, Right: right, Line: left.GetLine(), Column: left.GetColumn()}
 }
 
 func parsePrefixExpr(p *parser) ast.Expr {
 	operatorToken := p.advance()
-	expression := parseExpression(p, DEFAULT)
+	expression := parseExpression(p, UNARY)
 
-	return ast.PrefixExpr{Operator: operatorToken, Expression: expression}
+	return ast.PrefixExpr{Operator: operatorToken, Expression: expression, Line: operatorToken.Line, Column: operatorToken.Column}
 }
 
+// Compound assignments keep their operator, whether a += b adds or subscribes to an event depends on the type of a
 func parseAssignmentExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
 	operatorToken := p.advance()
 	value := parseExpression(p, bp)
 
-	if len(operatorToken.Value) > 1 && operatorToken.Value[len(operatorToken.Value)-1] == '=' {
-		var binaryOp lexer.TokenKind
-		switch operatorToken.Value[0] {
-		case '+':
-			binaryOp = lexer.PLUS
-		case '-':
-			binaryOp = lexer.MINUS
-		case '*':
-			binaryOp = lexer.MULTIPLY
-		case '/':
-			binaryOp = lexer.DIVIDE
-		case '%':
-			binaryOp = lexer.MODULUS
-		default:
-			panic("Unsupported compound assignment operator")
-		}
-
-		value = ast.BinaryExpr{
-			Left:     left,
-			Operator: lexer.Token{Kind: binaryOp, Value: string(operatorToken.Value[0]), Line: operatorToken.Line, Column: operatorToken.Column},
-			Right:    value,
-		}
-		operatorToken = lexer.Token{Kind: lexer.ASSIGNMENT, Value: "=", Line: operatorToken.Line, Column: operatorToken.Column}
-	}
-
 	return ast.AssignmentExpr{
 		Assignee: left,
 		Operator: operatorToken,
 		Value:    value,
+		Line:     left.GetLine(),
+		Column:   left.GetColumn(),
 	}
 }
 
 func parseGroupedExpr(p *parser) ast.Expr {
-	p.advance()
-	expr := parseExpression(p, DEFAULT)
+	if isLambdaAhead(p, p.pos) {
+		return parseLambdaExpr(p)
+	}
+	if isCastAhead(p) {
+		return parseCastExpr(p)
+	}
+	token := p.advance()
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.COLON {
+		return parseTupleExpr(p, nil, token)
+	}
+	first := parseTupleElement(p)
+	if p.currentTokenKind() == lexer.COMMA {
+		return parseTupleExpr(p, first, token)
+	}
+	if _, ok := first.(ast.DeclarationExpr); ok {
+		panic(fmt.Sprintf("A declaration is not allowed in a parenthesized expression at line %d, column %d", token.Line, token.Column))
+	}
 	p.expectError(lexer.CLOSE_PAREN, "Expected closing parenthesis")
 
-	return expr
+	return first
+}
+
+// Integer literals can be hexadecimal, binary and use _ as a separator, literals above the range of
+// long keep the bits of their ulong value
+func parseIntLiteral(p *parser) ast.Expr {
+	token := p.advance()
+	digits := strings.TrimRight(token.Value, "uUlL")
+	suffix := strings.ToUpper(token.Value[len(digits):])
+	if suffix == "LU" {
+		suffix = "UL"
+	}
+	base := 10
+	if len(digits) > 1 && strings.ContainsAny(digits[1:2], "xXbB") {
+		base = 0
+	} else {
+		digits = strings.ReplaceAll(digits, "_", "")
+	}
+	number, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		panic(fmt.Sprintf("Integral constant %s is too large at line %d, column %d", token.Value, token.Line, token.Column))
+	}
+	return ast.IntLiteralExpr{Value: int64(number), Suffix: suffix, Line: token.Line, Column: token.Column}
+}
+
+func parseRealLiteral(p *parser) ast.Expr {
+	token := p.advance()
+	digits := strings.TrimRight(token.Value, "fFdDmM")
+	suffix := strings.ToUpper(token.Value[len(digits):])
+	number, err := strconv.ParseFloat(strings.ReplaceAll(digits, "_", ""), 64)
+	if err != nil {
+		panic(fmt.Sprintf("Floating-point constant %s is outside the range of type double at line %d, column %d", token.Value, token.Line, token.Column))
+	}
+	return ast.RealLiteralExpr{Value: number, Suffix: suffix, Line: token.Line, Column: token.Column}
+}
+
+// checked(x) and unchecked(x)
+func parseCheckedExpr(p *parser) ast.Expr {
+	token := p.advance()
+	p.expectError(lexer.OPEN_PAREN, fmt.Sprintf("Expected '(' after '%s'", token.Value))
+	expression := parseExpression(p, DEFAULT)
+	p.expect(lexer.CLOSE_PAREN)
+	return ast.CheckedExpr{Expression: expression, Context: token.Kind, Line: token.Line, Column: token.Column}
+}
+
+// (T)x is a cast if T is a built-in type or if the token after the parenthesis can only start an operand,
+// otherwise (x) - y would be read as a cast of -y
+func isCastAhead(p *parser) bool {
+	start := p.pos + 1
+	if start >= len(p.tokens) || !(isTypeToken(p.tokens[start]) || (p.tokens[start].Kind == lexer.OPEN_PAREN && isTupleTypeAhead(p, start))) {
+		return false
+	}
+	end := skipType(p, start)
+	if end+1 >= len(p.tokens) || p.tokens[end].Kind != lexer.CLOSE_PAREN {
+		return false
+	}
+
+	switch p.tokens[end+1].Kind {
+	case lexer.IDENTIFIER, lexer.INTLITERAL, lexer.REALLITERAL, lexer.STRINGLITERAL, lexer.CHARLITERAL, lexer.OPEN_PAREN,
+		lexer.NOT, lexer.TRUE, lexer.FALSE, lexer.NULL, lexer.NEW, lexer.THIS, lexer.CHECKED, lexer.UNCHECKED:
+		return true
+	case lexer.MINUS, lexer.PLUS, lexer.INCREMENT, lexer.DECREMENT:
+		return p.tokens[start].Kind != lexer.IDENTIFIER && p.tokens[start].Kind != lexer.OPEN_PAREN
+	}
+	return false
+}
+
+func parseCastExpr(p *parser) ast.Expr {
+	token := p.expect(lexer.OPEN_PAREN)
+	typ := parseType(p)
+	p.expectError(lexer.CLOSE_PAREN, "Expected ')' after the type of a cast")
+	expression := parseExpression(p, UNARY)
+
+	return ast.CastExpr{Type: typ, Expression: expression, Line: token.Line, Column: token.Column}
+}
+
+// Parses the elements of a tuple literal after its first one, a nil first element means that
+// no element was parsed yet. Elements can be named or declare variables for a deconstruction.
+func parseTupleExpr(p *parser, first ast.Expr, token lexer.Token) ast.Expr {
+	tuple := ast.TupleExpr{Elements: []ast.Expr{}, Names: []string{}, Line: token.Line, Column: token.Column}
+	if first != nil {
+		tuple.Elements = append(tuple.Elements, first)
+		tuple.Names = append(tuple.Names, "")
+		p.expect(lexer.COMMA)
+	}
+	for {
+		name := ""
+		if p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.COLON {
+			name = p.advance().Value
+			p.advance()
+		}
+		tuple.Elements = append(tuple.Elements, parseTupleElement(p))
+		tuple.Names = append(tuple.Names, name)
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_PAREN, "Expected ')' after tuple elements")
+	if len(tuple.Elements) < 2 {
+		panic(fmt.Sprintf("A tuple must contain at least two elements at line %d, column %d", token.Line, token.Column))
+	}
+	return tuple
+}
+
+func parseTupleElement(p *parser) ast.Expr {
+	if isType(p) && isDeclarationAhead(p) {
+		line, column := p.currentToken().Line, p.currentToken().Column
+		typ := parseType(p)
+		identifier := p.expect(lexer.IDENTIFIER).Value
+		return ast.DeclarationExpr{Type: typ, Identifier: identifier, Line: line, Column: column}
+	}
+	return parseExpression(p, DEFAULT)
+}
+
+// Parses the designations of var (a, (b, _)), every name becomes a var declaration
+func parseDeconstructionDesignation(p *parser, typ ast.Type) ast.Expr {
+	token := p.currentToken()
+	if token.Kind == lexer.IDENTIFIER {
+		p.advance()
+		return ast.DeclarationExpr{Type: typ, Identifier: token.Value, Line: token.Line, Column: token.Column}
+	}
+	p.expectError(lexer.OPEN_PAREN, "Expected identifier or '(' in deconstruction")
+	tuple := ast.TupleExpr{Elements: []ast.Expr{}, Names: []string{}, Line: token.Line, Column: token.Column}
+	for {
+		tuple.Elements = append(tuple.Elements, parseDeconstructionDesignation(p, typ))
+		tuple.Names = append(tuple.Names, "")
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_PAREN, "Expected ')' after deconstruction variables")
+	if len(tuple.Elements) < 2 {
+		panic(fmt.Sprintf("A deconstruction must contain at least two variables at line %d, column %d", token.Line, token.Column))
+	}
+	return tuple
 }
 
 func parseMemberAccessOrMethodCall(p *parser, receiver ast.Expr, bp bindingPower) ast.Expr {
+	p.expect(lexer.DOT)
 	memberName := p.expect(lexer.IDENTIFIER).Value
 	if p.currentTokenKind() == lexer.OPEN_PAREN {
 		return parseMethodCallExpr(p, receiver, memberName)
@@ -146,6 +290,11 @@ func parseMemberAccessOrMethodCall(p *parser, receiver ast.Expr, bp bindingPower
 	}
 }
 
+func parseNullForgivingExpr(p *parser, operand ast.Expr, bp bindingPower) ast.Expr {
+	token := p.advance()
+	return ast.NullForgivingExpr{Operand: operand, Line: token.Line, Column: token.Column}
+}
+
 func parseMethodCallExpr(p *parser, receiver ast.Expr, methodName string) ast.Expr {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	p.expect(lexer.OPEN_PAREN)
@@ -161,6 +310,17 @@ func parseMethodCallExpr(p *parser, receiver ast.Expr, methodName string) ast.Ex
 	}
 }
 
+func parseElementAccessExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	token := p.expect(lexer.OPEN_BRACKET)
+	if p.currentTokenKind() == lexer.CLOSE_BRACKET {
+		panic(fmt.Sprintf("Expected index in element access at line %d, column %d", token.Line, token.Column))
+	}
+	args := parseArguments(p)
+	p.expectError(lexer.CLOSE_BRACKET, "Expected ']' after index")
+
+	return ast.ElementAccessExpr{Receiver: left, Args: args, Line: token.Line, Column: token.Column}
+}
+
 func parseArguments(p *parser) []ast.Expr {
 	args := []ast.Expr{}
 
@@ -169,7 +329,7 @@ func parseArguments(p *parser) []ast.Expr {
 	}
 
 	for {
-		args = append(args, parseExpression(p, DEFAULT))
+		args = append(args, parseArgument(p))
 
 		if p.currentTokenKind() != lexer.COMMA {
 			break
@@ -181,6 +341,37 @@ func parseArguments(p *parser) []ast.Expr {
 	return args
 }
 
+func parseArgument(p *parser) ast.Expr {
+	line, column := p.currentToken().Line, p.currentToken().Column
+
+	name := ""
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.COLON {
+		name = p.advance().Value
+		p.advance()
+	}
+
+	modifiers := []ast.Modifier{}
+	if p.currentTokenKind() == lexer.REF || p.currentTokenKind() == lexer.OUT || p.currentTokenKind() == lexer.IN {
+		modifiers = append(modifiers, ast.Modifier{Kind: p.advance().Kind})
+	}
+
+	var value ast.Expr
+	if len(modifiers) > 0 && modifiers[0].Kind == lexer.OUT && isType(p) && isDeclarationAhead(p) {
+		// out var x / out int x
+		declLine, declColumn := p.currentToken().Line, p.currentToken().Column
+		declType := parseType(p)
+		identifier := p.expectError(lexer.IDENTIFIER, "Expected identifier in out variable declaration").Value
+		value = ast.DeclarationExpr{Type: declType, Identifier: identifier, Line: declLine, Column: declColumn}
+	} else {
+		value = parseExpression(p, DEFAULT)
+	}
+
+	if name == "" && len(modifiers) == 0 {
+		return value
+	}
+	return ast.ArgumentExpr{Name: name, Modifiers: modifiers, Value: value, Line: line, Column: column}
+}
+
 func parseThisExpr(p *parser) ast.Expr {
 	token := p.advance()
 	var expr ast.Expr = ast.ThisExpr{Line: token.Line, Column: token.Column}
@@ -213,15 +404,143 @@ func parseNullExpr(p *parser) ast.Expr {
 	return ast.NullLiteralExpr{Line: token.Line, Column: token.Column}
 }
 
+func parseThrowExpr(p *parser) ast.Expr {
+	token := p.advance()
+	value := parseExpression(p, DEFAULT)
+	return ast.ThrowExpr{Value: value, Line: token.Line, Column: token.Column}
+}
+
+// await binds like a unary operator, await a.F() awaits the result of the call
+func parseAwaitExpr(p *parser) ast.Expr {
+	token := p.advance()
+	expression := parseExpression(p, UNARY)
+	return ast.AwaitExpr{Expression: expression, Line: token.Line, Column: token.Column}
+}
+
+// async x => expr or async (a, b) => { ... }
+func parseAsyncLambdaExpr(p *parser) ast.Expr {
+	token := p.advance()
+	isLambda := p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.ARROW
+	if !isLambda && (p.currentTokenKind() != lexer.OPEN_PAREN || !isLambdaAhead(p, p.pos)) {
+		panic(fmt.Sprintf("Expected a lambda expression after 'async' at line %d, column %d", token.Line, token.Column))
+	}
+	lambda := parseLambdaExpr(p).(ast.LambdaExpr)
+	lambda.IsAsync = true
+	lambda.Line, lambda.Column = token.Line, token.Column
+	return lambda
+}
+
 func parseConstructorCallExpr(p *parser) ast.Expr {
-	// new className(Args)
+	// new className(Args) { initializer }, new() or new elementType[] { elements }
 	line, column := p.currentToken().Line, p.currentToken().Column
 	p.advance()
-	className := p.expect(lexer.IDENTIFIER).Value
-	p.expect(lexer.OPEN_PAREN)
-	Args := parseArguments(p)
-	p.expect(lexer.CLOSE_PAREN)
-	return ast.ConstructorCallExpr{TypeName: className, Args: Args, Line: line, Column: column}
+	if isType(p) && p.nextTokenKind() == lexer.OPEN_BRACKET {
+		return parseArrayCreationExpr(p, parseType(p), line, column)
+	}
+
+	// The type of new() is taken from the target it is converted to
+	typeName := ""
+	if p.currentTokenKind() != lexer.OPEN_PAREN {
+		if p.currentTokenKind() != lexer.IDENTIFIER {
+			p.expect(lexer.IDENTIFIER)
+		}
+		typ := parseType(p)
+		if strings.HasSuffix(typ.Name, "[]") {
+			return parseArrayCreationExpr(p, typ, line, column)
+		}
+		typeName = typ.Name
+	}
+
+	expr := ast.ConstructorCallExpr{TypeName: typeName, Args: []ast.Expr{}, Line: line, Column: column}
+	if p.currentTokenKind() == lexer.OPEN_PAREN || typeName == "" {
+		p.expect(lexer.OPEN_PAREN)
+		expr.Args = parseArguments(p)
+		p.expect(lexer.CLOSE_PAREN)
+	} else if p.currentTokenKind() != lexer.OPEN_BRACE {
+		panic(fmt.Sprintf("Expected '(' or '{' after type in object creation at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
+	}
+	if p.currentTokenKind() == lexer.OPEN_BRACE {
+		initializer := parseObjectInitializer(p)
+		expr.Initializer = &initializer
+	}
+	return expr
+}
+
+// Parses "receiver with { Member = value, ... }"
+func parseWithExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	token := p.advance()
+	if p.currentTokenKind() != lexer.OPEN_BRACE {
+		panic(fmt.Sprintf("Expected '{' after with at line %d, column %d", token.Line, token.Column))
+	}
+	return ast.WithExpr{Receiver: left, Initializer: parseObjectInitializer(p), Line: token.Line, Column: token.Column}
+}
+
+func parseObjectInitializer(p *parser) ast.ObjectInitializer {
+	brace := p.expect(lexer.OPEN_BRACE)
+	initializer := ast.ObjectInitializer{Elements: []ast.InitializerElement{}, Line: brace.Line, Column: brace.Column}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		initializer.Elements = append(initializer.Elements, parseInitializerElement(p))
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_BRACE, "Expected '}' after initializer")
+	return initializer
+}
+
+func parseInitializerElement(p *parser) ast.InitializerElement {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	element := ast.InitializerElement{Line: line, Column: column}
+
+	switch {
+	case p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.ASSIGNMENT:
+		element.Member = p.advance().Value
+	case p.currentTokenKind() == lexer.OPEN_BRACKET:
+		p.advance()
+		element.Index = parseArguments(p)
+		p.expectError(lexer.CLOSE_BRACKET, "Expected ']' after index")
+		if p.currentTokenKind() != lexer.ASSIGNMENT {
+			panic(fmt.Sprintf("Expected '=' after index initializer at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
+		}
+	case p.currentTokenKind() == lexer.OPEN_BRACE:
+		// { key, value } passes several arguments to Add
+		p.advance()
+		element.Args = parseArguments(p)
+		p.expectError(lexer.CLOSE_BRACE, "Expected '}' after collection element")
+		return element
+	default:
+		element.Args = []ast.Expr{parseExpression(p, ASSIGNMENT)}
+		return element
+	}
+
+	p.expect(lexer.ASSIGNMENT)
+	if p.currentTokenKind() == lexer.OPEN_BRACE {
+		nested := parseObjectInitializer(p)
+		element.Nested = &nested
+	} else {
+		element.Value = parseExpression(p, ASSIGNMENT)
+	}
+	return element
+}
+
+func parseArrayCreationExpr(p *parser, arrayType ast.Type, line, column int) ast.Expr {
+	if !strings.HasSuffix(arrayType.Name, "[]") {
+		panic(fmt.Sprintf("Expected '[]' after array element type at line %d, column %d", arrayType.Line, arrayType.Column))
+	}
+	elementType := ast.Type{Name: strings.TrimSuffix(arrayType.Name, "[]"), TypeArguments: arrayType.TypeArguments, Line: arrayType.Line, Column: arrayType.Column}
+
+	p.expectError(lexer.OPEN_BRACE, "Expected '{' after array type")
+	elements := []ast.Expr{}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		elements = append(elements, parseExpression(p, ASSIGNMENT))
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_BRACE, "Expected '}' after array elements")
+	return ast.ArrayCreationExpr{ElementType: elementType, Elements: elements, Line: line, Column: column}
 }
 
 func parseUnaryExpr(p *parser) ast.Expr {
@@ -234,3 +553,62 @@ func parseUnaryExpr(p *parser) ast.Expr {
 	}
 	panic(fmt.Sprintf("Unsupported unary operator %s at Line: %d, Column: %d\n", operatorToken.Value, operatorToken.Line, operatorToken.Column))
 }
+
+// A parenthesized expression is a lambda if the matching closing parenthesis is followed by =>
+func isLambdaAhead(p *parser, pos int) bool {
+	depth := 0
+	for i := pos; i < len(p.tokens); i++ {
+		switch p.tokens[i].Kind {
+		case lexer.OPEN_PAREN:
+			depth++
+		case lexer.CLOSE_PAREN:
+			depth--
+			if depth == 0 {
+				return i+1 < len(p.tokens) && p.tokens[i+1].Kind == lexer.ARROW
+			}
+		case lexer.EOF, lexer.SEMICOLON, lexer.OPEN_BRACE:
+			return false
+		}
+	}
+	return false
+}
+
+func parseLambdaExpr(p *parser) ast.Expr {
+	// x => expr | (a, b) => expr | (int a, int b) => { ... }
+	line, column := p.currentToken().Line, p.currentToken().Column
+	parameters := []ast.Parameter{}
+
+	if p.currentTokenKind() == lexer.IDENTIFIER {
+		parameters = append(parameters, ast.Parameter{Identifier: p.advance().Value})
+	} else {
+		p.expect(lexer.OPEN_PAREN)
+		for p.currentTokenKind() != lexer.CLOSE_PAREN {
+			var paramType ast.Type
+			if p.nextTokenKind() != lexer.COMMA && p.nextTokenKind() != lexer.CLOSE_PAREN {
+				paramType = parseType(p)
+			}
+			paramIdentifier := p.expectError(lexer.IDENTIFIER, "Expected lambda parameter name").Value
+
+			if len(parameters) > 0 && (parameters[0].Type.Name == "") != (paramType.Name == "") {
+				panic(fmt.Sprintf("Cannot mix implicitly and explicitly typed lambda parameters at line %d, column %d", line, column))
+			}
+			parameters = append(parameters, ast.Parameter{Type: paramType, Identifier: paramIdentifier})
+
+			if p.currentTokenKind() == lexer.COMMA {
+				p.advance()
+			}
+		}
+		p.expect(lexer.CLOSE_PAREN)
+	}
+
+	p.expect(lexer.ARROW)
+
+	lambda := ast.LambdaExpr{Parameters: parameters, Line: line, Column: column}
+	if p.currentTokenKind() == lexer.OPEN_BRACE {
+		lambda.Body = parseBlockStmt(p)
+	} else {
+		lambda.Expression = parseExpression(p, DEFAULT)
+	}
+
+	return lambda
+}
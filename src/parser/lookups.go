@@ -11,7 +11,9 @@ const (
 	DEFAULT bindingPower = iota
 	COMMA
 	ASSIGNMENT
+	COALESCING
 	LOGICAL
+	BITWISE
 	RELATIONAL
 	ADDITIVE
 	MULTIPLICATIVE
@@ -57,10 +59,16 @@ func createTokenLookups() {
 	led(lexer.DIVIDE_EQUALS, ASSIGNMENT, parseAssignmentExpr)
 	led(lexer.MODULUS_EQUALS, ASSIGNMENT, parseAssignmentExpr)
 
+	led(lexer.NULL_COALESCING, COALESCING, parseBinaryExpr)
+
 	// Logical
 	led(lexer.AND, LOGICAL, parseBinaryExpr)
 	led(lexer.OR, LOGICAL, parseBinaryExpr)
 
+	// Bitwise
+	led(lexer.BITWISE_OR, BITWISE, parseBinaryExpr)
+	led(lexer.BITWISE_AND, BITWISE, parseBinaryExpr)
+
 	// Relational
 	led(lexer.EQUALS, RELATIONAL, parseBinaryExpr)
 	led(lexer.NOT_EQUALS, RELATIONAL, parseBinaryExpr)
@@ -68,6 +76,8 @@ func createTokenLookups() {
 	led(lexer.LESS_THAN_OR_EQUAL, RELATIONAL, parseBinaryExpr)
 	led(lexer.GREATER_THAN, RELATIONAL, parseBinaryExpr)
 	led(lexer.GREATER_THAN_OR_EQUAL, RELATIONAL, parseBinaryExpr)
+	led(lexer.IS, RELATIONAL, parseIsPatternExpr)
+	led(lexer.AS, RELATIONAL, parseAsExpr)
 
 	// Additive & Multiplicative
 	led(lexer.PLUS, ADDITIVE, parseBinaryExpr)
@@ -81,17 +91,25 @@ func createTokenLookups() {
 	nud(lexer.INTLITERAL, parsePrimaryExpr)
 	nud(lexer.STRINGLITERAL, parsePrimaryExpr)
 	nud(lexer.CHARLITERAL, parsePrimaryExpr)
+	nud(lexer.REALLITERAL, parsePrimaryExpr)
 	nud(lexer.IDENTIFIER, parsePrimaryExpr)
 
 	nud(lexer.NULL, parseNullExpr)
 	nud(lexer.MINUS, parsePrefixExpr)
+	nud(lexer.PLUS, parsePrefixExpr)
+	nud(lexer.NOT, parsePrefixExpr)
 	nud(lexer.OPEN_PAREN, parseGroupedExpr)
 	nud(lexer.THIS, parseThisExpr)
 	nud(lexer.TRUE, parseBooleanExpr)
 	nud(lexer.FALSE, parseBooleanExpr)
+	nud(lexer.THROW, parseThrowExpr)
+	nud(lexer.CHECKED, parseCheckedExpr)
+	nud(lexer.UNCHECKED, parseCheckedExpr)
 
 	// Handle member access and method calls
 	led(lexer.DOT, MEMBER, parseMemberAccessOrMethodCall)
+	led(lexer.OPEN_BRACKET, MEMBER, parseElementAccessExpr)
+	led(lexer.NOT, MEMBER, parseNullForgivingExpr)
 	nud(lexer.NEW, parseConstructorCallExpr)
 
 	nud(lexer.INCREMENT, parseUnaryExpr)
@@ -105,12 +123,22 @@ func createTokenLookups() {
 	stmt(lexer.CHAR, parseVarDeclStmt)
 	stmt(lexer.DOUBLE, parseVarDeclStmt)
 	stmt(lexer.FLOAT, parseVarDeclStmt)
+	stmt(lexer.LONG, parseVarDeclStmt)
+	stmt(lexer.SHORT, parseVarDeclStmt)
+	stmt(lexer.BYTE, parseVarDeclStmt)
+	stmt(lexer.SBYTE, parseVarDeclStmt)
+	stmt(lexer.UINT, parseVarDeclStmt)
+	stmt(lexer.ULONG, parseVarDeclStmt)
+	stmt(lexer.USHORT, parseVarDeclStmt)
+	stmt(lexer.DECIMAL, parseVarDeclStmt)
+	stmt(lexer.OBJECT, parseVarDeclStmt)
 
 	stmt(lexer.PUBLIC, parseVarDeclStmt)
 	stmt(lexer.PRIVATE, parseVarDeclStmt)
 	stmt(lexer.PROTECTED, parseVarDeclStmt)
 	stmt(lexer.STATIC, parseVarDeclStmt)
-	stmt(lexer.FINAL, parseVarDeclStmt)
+	stmt(lexer.READONLY, parseVarDeclStmt)
+	stmt(lexer.CONST, parseVarDeclStmt)
 	stmt(lexer.CLASS, parseClassDeclStmt)
 	stmt(lexer.RETURN, parseReturnStmt)
 
@@ -118,8 +146,16 @@ func createTokenLookups() {
 	stmt(lexer.BREAK, parseBreakStmt)
 	stmt(lexer.IF, parseIfStmt)
 	stmt(lexer.SWITCH, parseSwitchStmt)
+	stmt(lexer.GOTO, parseGotoCaseStmt)
+	stmt(lexer.TRY, parseTryStmt)
+	stmt(lexer.THROW, parseThrowStmt)
 
 	// control flow
 	stmt(lexer.WHILE, parseWhileStmt)
 	stmt(lexer.FOR, parseForStmt)
+
+	// A switch at the start of a statement is a switch statement, after an expression it is a switch expression.
+	// Registered last because stmt resets the binding power.
+	led(lexer.SWITCH, CALL, parseSwitchExpr)
+	led(lexer.WITH, CALL, parseWithExpr)
 }
@@ -10,18 +10,93 @@ import (
 type parser struct {
 	tokens []lexer.Token
 	pos    int
+	// Path of the parsed source file, recorded on every declaration
+	file string
+	// Directives are taken out of the token stream since they can appear between any two tokens
+	nullableDirectives []ast.NullableDirective
+	// Namespace of the declarations being parsed and the namespaces around the open namespace blocks
+	namespace      string
+	outerNamespace []string
 }
 
-func createParser(tokenstream []lexer.Token) *parser {
+func createParser(tokenstream []lexer.Token, file string) *parser {
 	createTokenLookups()
-	return &parser{tokenstream, 0}
+	tokens := make([]lexer.Token, 0, len(tokenstream))
+	directives := []ast.NullableDirective{}
+	for _, token := range tokenstream {
+		if token.Kind == lexer.NULLABLE_DIRECTIVE {
+			directives = append(directives, ast.NullableDirective{Setting: token.Value, Line: token.Line})
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return &parser{tokens: tokens, file: file, nullableDirectives: directives}
 }
 
 func Parse(tokenstream []lexer.Token) ast.Program {
+	return parseProgram(createParser(tokenstream, ""))
+}
+
+// Parses a single source file. Parse errors are prefixed with the path of the file.
+func ParseFile(path string, tokenstream []lexer.Token) ast.CompilationUnit {
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("%s: %v", path, r))
+		}
+	}()
+
+	return ast.CompilationUnit{Path: path, Program: parseProgram(createParser(tokenstream, path))}
+}
+
+func parseProgram(p *parser) ast.Program {
 	classes := make([]ast.ClassDeclStmt, 0)
-	p := createParser(tokenstream)
+	enums := make([]ast.EnumDeclStmt, 0)
+	delegates := make([]ast.DelegateDeclStmt, 0)
+	statements := make([]ast.Stmt, 0)
+	usings := make([]ast.UsingDirective, 0)
+	hasMembers := func() bool {
+		return len(classes) > 0 || len(enums) > 0 || len(delegates) > 0 || len(statements) > 0 || p.namespace != ""
+	}
 
 	for p.hasTokensLeft() {
+		token := p.currentToken()
+		switch {
+		case token.Kind == lexer.CLOSE_BRACE && len(p.outerNamespace) > 0:
+			p.advance()
+			p.namespace, p.outerNamespace = p.outerNamespace[len(p.outerNamespace)-1], p.outerNamespace[:len(p.outerNamespace)-1]
+			continue
+		case token.Kind == lexer.USING && p.nextTokenKind() == lexer.IDENTIFIER:
+			if hasMembers() {
+				panic(fmt.Sprintf("A using directive must precede all other elements defined in the file at line %d, column %d", token.Line, token.Column))
+			}
+			p.advance()
+			usings = append(usings, ast.UsingDirective{Namespace: parseQualifiedName(p), Line: token.Line, Column: token.Column})
+			p.expect(lexer.SEMICOLON)
+			continue
+		case token.Kind == lexer.NAMESPACE:
+			parseNamespace(p, hasMembers())
+			continue
+		}
+
+		switch p.kindAfterModifiers() {
+		case lexer.DELEGATE:
+			delegates = append(delegates, parseDelegateDeclStmt(p).(ast.DelegateDeclStmt))
+			continue
+		case lexer.ENUM:
+			enums = append(enums, parseEnumDeclStmt(p).(ast.EnumDeclStmt))
+			continue
+		case lexer.CLASS, lexer.STRUCT, lexer.RECORD:
+		default:
+			if p.namespace != "" {
+				panic(fmt.Sprintf("Expected a type declaration inside of namespace %s at line %d, column %d", p.namespace, token.Line, token.Column))
+			}
+			if len(classes) > 0 || len(enums) > 0 || len(delegates) > 0 {
+				panic(fmt.Sprintf("Top-level statements must precede type declarations at line %d, column %d", token.Line, token.Column))
+			}
+			statements = append(statements, parseStatement(p))
+			continue
+		}
+
 		classStmt := parseClassDeclStmt(p)
 		if class, ok := classStmt.(ast.ClassDeclStmt); ok {
 			classes = append(classes, class)
@@ -30,7 +105,43 @@ func Parse(tokenstream []lexer.Token) ast.Program {
 		}
 	}
 
-	return ast.Program{Classes: classes}
+	if len(p.outerNamespace) > 0 {
+		panic(fmt.Sprintf("Expected '}' to close namespace %s at the end of the file", p.namespace))
+	}
+
+	return ast.Program{Classes: classes, Enums: enums, Delegates: delegates, Statements: statements, NullableDirectives: p.nullableDirectives, Usings: usings}
+}
+
+// Parses "namespace A.B {" which is closed by the matching "}" or a file-scoped "namespace A.B;"
+// which has to come before all declarations of the file
+func parseNamespace(p *parser, hasMembers bool) {
+	token := p.expect(lexer.NAMESPACE)
+	name := parseQualifiedName(p)
+	if p.namespace != "" {
+		name = p.namespace + "." + name
+	}
+
+	if p.currentTokenKind() == lexer.SEMICOLON {
+		p.advance()
+		if hasMembers {
+			panic(fmt.Sprintf("A file-scoped namespace must precede all other members in a file at line %d, column %d", token.Line, token.Column))
+		}
+		p.namespace = name
+		return
+	}
+	p.expectError(lexer.OPEN_BRACE, "Expected '{' or ';' after namespace name")
+	p.outerNamespace = append(p.outerNamespace, p.namespace)
+	p.namespace = name
+}
+
+// Parses a dotted name like System.Collections.Generic
+func parseQualifiedName(p *parser) string {
+	name := p.expectError(lexer.IDENTIFIER, "Expected identifier").Value
+	for p.currentTokenKind() == lexer.DOT {
+		p.advance()
+		name += "." + p.expectError(lexer.IDENTIFIER, "Expected identifier after '.'").Value
+	}
+	return name
 }
 
 // HELPER METHODS
@@ -78,3 +189,69 @@ func (p *parser) nextTokenKind() lexer.TokenKind {
 	}
 	return lexer.EOF
 }
+
+func (p *parser) kindAfterModifiers() lexer.TokenKind {
+	pos := skipAttributes(p, p.pos)
+	for pos < len(p.tokens) && isModifier(contextualKind(p, pos)) {
+		pos++
+	}
+	if pos < len(p.tokens) {
+		return contextualKind(p, pos)
+	}
+	return lexer.EOF
+}
+
+// record, partial, async, await and with are contextual keywords like the words of query expressions.
+// They stay identifiers unless they are followed by what only the keyword can be followed by, so they
+// can still be used as names. Returns the kind of the keyword or the kind of the token at pos.
+func contextualKind(p *parser, pos int) lexer.TokenKind {
+	token := p.tokens[pos]
+	if token.Kind != lexer.IDENTIFIER || pos+1 >= len(p.tokens) || (pos > 0 && p.tokens[pos-1].Kind == lexer.DOT) {
+		return token.Kind
+	}
+	next := p.tokens[pos+1]
+	switch token.Value {
+	case "record":
+		// record R(...), record R { ... }, record R;, record R : B, record R<T>, record class R and record struct R
+		if next.Kind == lexer.CLASS || next.Kind == lexer.STRUCT {
+			return lexer.RECORD
+		}
+		if next.Kind == lexer.IDENTIFIER && pos+2 < len(p.tokens) {
+			switch p.tokens[pos+2].Kind {
+			case lexer.OPEN_PAREN, lexer.OPEN_BRACE, lexer.SEMICOLON, lexer.COLON, lexer.LESS_THAN:
+				return lexer.RECORD
+			}
+		}
+	case "partial":
+		if next.Kind == lexer.CLASS || next.Kind == lexer.STRUCT || next.Kind == lexer.INTERFACE || next.Kind == lexer.VOID || contextualKind(p, pos+1) == lexer.RECORD {
+			return lexer.PARTIAL
+		}
+	case "async":
+		// Modifiers of methods and local functions like async Task<int> F( and async lambdas
+		if isModifier(contextualKind(p, pos+1)) || next.Kind == lexer.VOID {
+			return lexer.ASYNC
+		}
+		if (next.Kind == lexer.IDENTIFIER && pos+2 < len(p.tokens) && p.tokens[pos+2].Kind == lexer.ARROW) || (next.Kind == lexer.OPEN_PAREN && isLambdaAhead(p, pos+1)) {
+			return lexer.ASYNC
+		}
+		if isTypeToken(next) {
+			after := skipType(p, pos+1)
+			if after+1 < len(p.tokens) && p.tokens[after].Kind == lexer.IDENTIFIER && p.tokens[after+1].Kind == lexer.OPEN_PAREN {
+				return lexer.ASYNC
+			}
+		}
+	case "await":
+		// An identifier named await is followed by an operator or ends the expression, await by its operand
+		switch next.Kind {
+		case lexer.IDENTIFIER, lexer.THIS, lexer.BASE, lexer.NEW, lexer.OPEN_PAREN, lexer.INTLITERAL, lexer.REALLITERAL, lexer.STRINGLITERAL,
+			lexer.CHARLITERAL, lexer.TRUE, lexer.FALSE, lexer.NULL:
+			return lexer.AWAIT
+		}
+	case "with":
+		// Only asked for after an expression, where an identifier can not follow
+		if next.Kind == lexer.OPEN_BRACE || next.Kind == lexer.IDENTIFIER {
+			return lexer.WITH
+		}
+	}
+	return token.Kind
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// Parses the pattern of an is expression, a case label or a switch expression arm.
+// or binds weaker than and, which binds weaker than not.
+func parsePattern(p *parser) ast.Pattern {
+	left := parseConjunctivePattern(p)
+	for isContextualKeyword(p.currentToken(), "or") {
+		token := p.advance()
+		right := parseConjunctivePattern(p)
+		left = ast.BinaryPattern{Left: left, Operator: "or", Right: right, Line: token.Line, Column: token.Column}
+	}
+	return left
+}
+
+func parseConjunctivePattern(p *parser) ast.Pattern {
+	left := parseNegatedPattern(p)
+	for isContextualKeyword(p.currentToken(), "and") {
+		token := p.advance()
+		right := parseNegatedPattern(p)
+		left = ast.BinaryPattern{Left: left, Operator: "and", Right: right, Line: token.Line, Column: token.Column}
+	}
+	return left
+}
+
+func parseNegatedPattern(p *parser) ast.Pattern {
+	if isContextualKeyword(p.currentToken(), "not") {
+		token := p.advance()
+		return ast.NotPattern{Pattern: parseNegatedPattern(p), Line: token.Line, Column: token.Column}
+	}
+	return parsePrimaryPattern(p)
+}
+
+func parsePrimaryPattern(p *parser) ast.Pattern {
+	token := p.currentToken()
+
+	switch {
+	case token.Kind == lexer.OPEN_PAREN:
+		p.advance()
+		pattern := parsePattern(p)
+		p.expectError(lexer.CLOSE_PAREN, "Expected ')' after pattern")
+		return pattern
+	case isRelationalOperator(token.Kind):
+		p.advance()
+		value := parseExpression(p, RELATIONAL)
+		return ast.RelationalPattern{Operator: token, Value: value, Line: token.Line, Column: token.Column}
+	case token.Kind == lexer.OPEN_BRACE:
+		return parsePropertyPattern(p, ast.Type{Line: token.Line, Column: token.Column})
+	case token.Kind == lexer.IDENTIFIER && token.Value == "_":
+		p.advance()
+		return ast.DiscardPattern{Line: token.Line, Column: token.Column}
+	case isType(p) && p.tokens[skipType(p, p.pos)].Kind == lexer.OPEN_BRACE:
+		return parsePropertyPattern(p, parseType(p))
+	case isType(p) && isDesignationAhead(p):
+		typ := parseType(p)
+		identifier := p.expect(lexer.IDENTIFIER).Value
+		return ast.DeclarationPattern{Type: typ, Identifier: identifier, Line: token.Line, Column: token.Column}
+	case isType(p) && token.Kind != lexer.IDENTIFIER:
+		// Type patterns of user defined types look like constants and are told apart by the type checker
+		return ast.TypePattern{Type: parseType(p), Line: token.Line, Column: token.Column}
+	case token.Kind == lexer.IDENTIFIER && p.nextTokenKind() == lexer.ARROW:
+		// Not a lambda but a constant in front of the arrow of a switch expression arm
+		p.advance()
+		value := ast.IdentifierExpr{Name: token.Value, Line: token.Line, Column: token.Column}
+		return ast.ConstantPattern{Value: value, Line: token.Line, Column: token.Column}
+	}
+
+	value := parseExpression(p, RELATIONAL)
+	return ast.ConstantPattern{Value: value, Line: token.Line, Column: token.Column}
+}
+
+func parsePropertyPattern(p *parser, typ ast.Type) ast.Pattern {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	if typ.Name != "" {
+		line, column = typ.Line, typ.Column
+	}
+	p.expect(lexer.OPEN_BRACE)
+
+	properties := []ast.PropertySubpattern{}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		member := p.expect(lexer.IDENTIFIER)
+		p.expectError(lexer.COLON, "Expected ':' after member name in property pattern")
+		pattern := parsePattern(p)
+		properties = append(properties, ast.PropertySubpattern{Member: member.Value, Pattern: pattern, Line: member.Line, Column: member.Column})
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_BRACE, "Expected '}' after property pattern")
+
+	identifier := ""
+	if p.currentTokenKind() == lexer.IDENTIFIER && !isPatternKeyword(p.currentToken()) {
+		identifier = p.advance().Value
+	}
+	return ast.PropertyPattern{Type: typ, Properties: properties, Identifier: identifier, Line: line, Column: column}
+}
+
+func isRelationalOperator(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.LESS_THAN, lexer.LESS_THAN_OR_EQUAL, lexer.GREATER_THAN, lexer.GREATER_THAN_OR_EQUAL:
+		return true
+	}
+	return false
+}
+
+// A type followed by a variable name like Dog d
+func isDesignationAhead(p *parser) bool {
+	pos := skipType(p, p.pos)
+	return pos < len(p.tokens) && p.tokens[pos].Kind == lexer.IDENTIFIER && !isPatternKeyword(p.tokens[pos])
+}
+
+// when, and, or and not are only keywords inside of patterns
+func isPatternKeyword(token lexer.Token) bool {
+	return isContextualKeyword(token, "when") || isContextualKeyword(token, "and") || isContextualKeyword(token, "or") || isContextualKeyword(token, "not")
+}
+
+func isContextualKeyword(token lexer.Token, keyword string) bool {
+	return token.Kind == lexer.IDENTIFIER && token.Value == keyword
+}
+
+func parseIsPatternExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	token := p.advance()
+	pattern := parsePattern(p)
+	return ast.IsPatternExpr{Expression: left, Pattern: pattern, Line: token.Line, Column: token.Column}
+}
+
+func parseAsExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	token := p.advance()
+	typ := parseType(p)
+	return ast.AsExpr{Expression: left, Type: typ, Line: token.Line, Column: token.Column}
+}
+
+// Parses an optional when clause after a pattern
+func parseGuard(p *parser) ast.Expr {
+	if !isContextualKeyword(p.currentToken(), "when") {
+		return nil
+	}
+	p.advance()
+	return parseExpression(p, ASSIGNMENT)
+}
+
+func parseSwitchExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance()
+	p.expectError(lexer.OPEN_BRACE, "Expected '{' after switch")
+
+	arms := []ast.SwitchExprArm{}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		armLine, armColumn := p.currentToken().Line, p.currentToken().Column
+		pattern := parsePattern(p)
+		guard := parseGuard(p)
+		p.expectError(lexer.ARROW, "Expected '=>' after switch expression pattern")
+		value := parseExpression(p, ASSIGNMENT)
+		arms = append(arms, ast.SwitchExprArm{Pattern: pattern, Guard: guard, Value: value, Line: armLine, Column: armColumn})
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_BRACE, "Expected '}' after switch expression arms")
+
+	return ast.SwitchExpr{Expression: left, Arms: arms, Line: line, Column: column}
+}
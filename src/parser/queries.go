@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// The words of query expressions are only keywords inside of a query, from starts a query if it is
+// followed by a range variable and in like from x in or from int x in. The expressions of the clauses
+// end at the next clause since an identifier can not continue an expression.
+
+func isQueryAhead(p *parser) bool {
+	if !isContextualKeyword(p.currentToken(), "from") || p.pos+1 >= len(p.tokens) {
+		return false
+	}
+	pos := p.pos + 1
+	if p.tokens[pos].Kind != lexer.IDENTIFIER || (pos+1 < len(p.tokens) && p.tokens[pos+1].Kind != lexer.IN) {
+		if !isTypeToken(p.tokens[pos]) && p.tokens[pos].Kind != lexer.OPEN_PAREN {
+			return false
+		}
+		pos = skipType(p, pos)
+	}
+	return pos+1 < len(p.tokens) && p.tokens[pos].Kind == lexer.IDENTIFIER && p.tokens[pos+1].Kind == lexer.IN
+}
+
+func parseQueryExpr(p *parser) ast.Expr {
+	token := p.currentToken()
+	query := ast.QueryExpr{Clauses: []ast.QueryClause{parseFromClause(p)}, Line: token.Line, Column: token.Column}
+	for {
+		clause := p.currentToken()
+		switch {
+		case isContextualKeyword(clause, "from"):
+			query.Clauses = append(query.Clauses, parseFromClause(p))
+		case isContextualKeyword(clause, "let"):
+			p.advance()
+			identifier := p.expectError(lexer.IDENTIFIER, "Expected range variable after 'let'").Value
+			p.expectError(lexer.ASSIGNMENT, "Expected '=' after the range variable of a let clause")
+			value := parseExpression(p, DEFAULT)
+			query.Clauses = append(query.Clauses, ast.LetClause{Identifier: identifier, Value: value, Line: clause.Line, Column: clause.Column})
+		case isContextualKeyword(clause, "where"):
+			p.advance()
+			condition := parseExpression(p, DEFAULT)
+			query.Clauses = append(query.Clauses, ast.WhereClause{Condition: condition, Line: clause.Line, Column: clause.Column})
+		case isContextualKeyword(clause, "join"):
+			query.Clauses = append(query.Clauses, parseJoinClause(p))
+		case isContextualKeyword(clause, "orderby"):
+			query.Clauses = append(query.Clauses, parseOrderByClause(p))
+		case isContextualKeyword(clause, "select"):
+			p.advance()
+			value := parseExpression(p, DEFAULT)
+			query.Clauses = append(query.Clauses, ast.SelectClause{Value: value, Line: clause.Line, Column: clause.Column})
+			if !parseQueryContinuation(p, &query) {
+				return query
+			}
+		case isContextualKeyword(clause, "group"):
+			p.advance()
+			element := parseExpression(p, DEFAULT)
+			if !isContextualKeyword(p.currentToken(), "by") {
+				panic(fmt.Sprintf("Expected 'by' after the element of a group clause at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
+			}
+			p.advance()
+			key := parseExpression(p, DEFAULT)
+			query.Clauses = append(query.Clauses, ast.GroupClause{Element: element, Key: key, Line: clause.Line, Column: clause.Column})
+			if !parseQueryContinuation(p, &query) {
+				return query
+			}
+		default:
+			panic(fmt.Sprintf("A query body must end with a select clause or a group clause at line %d, column %d", clause.Line, clause.Column))
+		}
+	}
+}
+
+// from T x in source
+func parseFromClause(p *parser) ast.QueryClause {
+	token := p.advance()
+	typ, identifier := parseRangeVariable(p)
+	p.expectError(lexer.IN, "Expected 'in' after the range variable of a from clause")
+	source := parseExpression(p, DEFAULT)
+	return ast.FromClause{Type: typ, Identifier: identifier, Source: source, Line: token.Line, Column: token.Column}
+}
+
+// join T x in source on outerKey equals innerKey into group
+func parseJoinClause(p *parser) ast.QueryClause {
+	token := p.advance()
+	typ, identifier := parseRangeVariable(p)
+	p.expectError(lexer.IN, "Expected 'in' after the range variable of a join clause")
+	join := ast.JoinClause{Type: typ, Identifier: identifier, Source: parseExpression(p, DEFAULT), Line: token.Line, Column: token.Column}
+	if !isContextualKeyword(p.currentToken(), "on") {
+		panic(fmt.Sprintf("Expected 'on' after the source of a join clause at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
+	}
+	p.advance()
+	join.OuterKey = parseExpression(p, DEFAULT)
+	if !isContextualKeyword(p.currentToken(), "equals") {
+		panic(fmt.Sprintf("Expected 'equals' between the keys of a join clause at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
+	}
+	p.advance()
+	join.InnerKey = parseExpression(p, DEFAULT)
+	if isContextualKeyword(p.currentToken(), "into") {
+		p.advance()
+		join.Into = p.expectError(lexer.IDENTIFIER, "Expected identifier after 'into'").Value
+	}
+	return join
+}
+
+// orderby key ascending, key descending
+func parseOrderByClause(p *parser) ast.QueryClause {
+	token := p.advance()
+	clause := ast.OrderByClause{Line: token.Line, Column: token.Column}
+	for {
+		line, column := p.currentToken().Line, p.currentToken().Column
+		ordering := ast.Ordering{Key: parseExpression(p, DEFAULT), Line: line, Column: column}
+		if isContextualKeyword(p.currentToken(), "ascending") {
+			p.advance()
+		} else if isContextualKeyword(p.currentToken(), "descending") {
+			p.advance()
+			ordering.Descending = true
+		}
+		clause.Orderings = append(clause.Orderings, ordering)
+		if p.currentTokenKind() != lexer.COMMA {
+			return clause
+		}
+		p.advance()
+	}
+}
+
+// The range variable of a from or join clause with an optional type
+func parseRangeVariable(p *parser) (ast.Type, string) {
+	typ := ast.Type{}
+	if p.nextTokenKind() != lexer.IN {
+		typ = parseType(p)
+	}
+	identifier := p.expectError(lexer.IDENTIFIER, "Expected range variable").Value
+	return typ, identifier
+}
+
+// into x after a select or group clause continues the query, reports whether it does
+func parseQueryContinuation(p *parser, query *ast.QueryExpr) bool {
+	token := p.currentToken()
+	if !isContextualKeyword(token, "into") {
+		return false
+	}
+	p.advance()
+	identifier := p.expectError(lexer.IDENTIFIER, "Expected identifier after 'into'").Value
+	query.Clauses = append(query.Clauses, ast.IntoClause{Identifier: identifier, Line: token.Line, Column: token.Column})
+	return true
+}
@@ -0,0 +1,283 @@
+package parser
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// Records are classes or structs whose members the parser completes like it adds the standard constructor.
+// Each positional parameter becomes a public init-only property that the primary constructor assigns, and
+// the record gets value equality and a matching GetHashCode over its instance data, a ToString that lists its public data and a
+// Deconstruct for the positional parameters. Members the record declares itself are never synthesized.
+
+// Builds the synthesized members, all of them are placed at the position of the record declaration
+type recordBuilder struct {
+	p      *parser
+	line   int
+	column int
+}
+
+func (b recordBuilder) token(kind lexer.TokenKind, value string) lexer.Token {
+	return lexer.NewToken(kind, value, b.line, b.column)
+}
+
+func (b recordBuilder) identifier(name string) ast.Expr {
+	return ast.IdentifierExpr{Name: name, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) member(receiver ast.Expr, name string) ast.Expr {
+	return ast.MemberAccessExpr{Receiver: receiver, Member: name, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) this() ast.Expr {
+	return ast.ThisExpr{Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) binary(left ast.Expr, kind lexer.TokenKind, value string, right ast.Expr) ast.Expr {
+	return ast.BinaryExpr{Left: left, Operator: b.token(kind, value), Right: right, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) valueEquals(left, right ast.Expr) ast.Expr {
+	return ast.ValueEqualsExpr{Left: left, Right: right, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) valueHash(value ast.Expr) ast.Expr {
+	return ast.ValueHashExpr{Value: value, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) not(expression ast.Expr) ast.Expr {
+	return ast.PrefixExpr{Operator: b.token(lexer.NOT, "!"), Expression: expression, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) isNull(expression ast.Expr) ast.Expr {
+	null := ast.NullLiteralExpr{Line: b.line, Column: b.column}
+	pattern := ast.ConstantPattern{Value: null, Line: b.line, Column: b.column}
+	return ast.IsPatternExpr{Expression: expression, Pattern: pattern, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) block(stmts ...ast.Stmt) ast.BlockStmt {
+	return ast.BlockStmt{Body: stmts, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) returns(value ast.Expr) ast.Stmt {
+	return ast.ReturnStmt{Value: value, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) assign(assignee, value ast.Expr) ast.Stmt {
+	assignment := ast.AssignmentExpr{Assignee: assignee, Operator: b.token(lexer.ASSIGNMENT, "="), Value: value, Line: b.line, Column: b.column}
+	return ast.ExpressionStmt{Expression: assignment, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) typ(name string) ast.Type {
+	return ast.Type{Name: name, Line: b.line, Column: b.column}
+}
+
+func (b recordBuilder) method(modifiers []ast.Modifier, returnType, name string, parameters []ast.Parameter, body ast.BlockStmt) ast.MethodDeclStmt {
+	return ast.MethodDeclStmt{
+		Modifiers:  modifiers,
+		ReturnType: b.typ(returnType),
+		Name:       name,
+		Parameters: parameters,
+		Body:       body,
+		File:       b.p.file,
+		Line:       b.line,
+		Column:     b.column,
+	}
+}
+
+// The instance data of a record that equality compares, fields and auto-implemented properties
+type recordValue struct {
+	name     string
+	isPublic bool
+}
+
+func recordValues(members []ast.ClassMember) []recordValue {
+	values := []recordValue{}
+	for _, member := range members {
+		switch m := member.(type) {
+		case ast.FieldDeclStmt:
+			if !hasModifierKind(m.Modifiers, lexer.STATIC) && !hasModifierKind(m.Modifiers, lexer.CONST) {
+				values = append(values, recordValue{name: m.Identifier, isPublic: hasModifierKind(m.Modifiers, lexer.PUBLIC)})
+			}
+		case ast.PropertyDeclStmt:
+			if !hasModifierKind(m.Modifiers, lexer.STATIC) && isAutoProperty(m) {
+				values = append(values, recordValue{name: m.Name, isPublic: hasModifierKind(m.Modifiers, lexer.PUBLIC)})
+			}
+		}
+	}
+	return values
+}
+
+func isAutoProperty(property ast.PropertyDeclStmt) bool {
+	for _, accessor := range property.Accessors {
+		if accessor.Body != nil {
+			return false
+		}
+	}
+	return len(property.Accessors) > 0
+}
+
+func hasModifierKind(modifiers []ast.Modifier, kind lexer.TokenKind) bool {
+	for _, modifier := range modifiers {
+		if modifier.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the record declares a member with the name, for methods only if it also takes that many parameters
+func declaresMember(members []ast.ClassMember, name string, parameters int) bool {
+	for _, member := range members {
+		switch m := member.(type) {
+		case ast.FieldDeclStmt:
+			if m.Identifier == name && parameters < 0 {
+				return true
+			}
+		case ast.PropertyDeclStmt:
+			if m.Name == name && parameters < 0 {
+				return true
+			}
+		case ast.MethodDeclStmt:
+			if m.Name == name && len(m.Parameters) == parameters {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func synthesizeRecordMembers(p *parser, name string, kind lexer.TokenKind, modifiers []ast.Modifier, parameters []ast.Parameter, members []ast.ClassMember, line, column int) []ast.ClassMember {
+	b := recordBuilder{p: p, line: line, column: column}
+	isStruct := kind == lexer.STRUCT
+	public := []ast.Modifier{{Kind: lexer.PUBLIC}}
+
+	// Mutable record structs get settable positional properties
+	setter := "init"
+	if isStruct && !hasModifierKind(modifiers, lexer.READONLY) {
+		setter = "set"
+	}
+	for _, param := range parameters {
+		if declaresMember(members, param.Identifier, -1) {
+			continue
+		}
+		members = append(members, ast.PropertyDeclStmt{
+			Modifiers: public,
+			Type:      param.Type,
+			Name:      param.Identifier,
+			Accessors: []ast.Accessor{{Kind: "get", Line: line, Column: column}, {Kind: setter, Line: line, Column: column}},
+			File:      p.file,
+			Line:      line,
+			Column:    column,
+		})
+	}
+
+	if len(parameters) > 0 {
+		constructor := b.block()
+		deconstruct := b.block()
+		outParameters := []ast.Parameter{}
+		for _, param := range parameters {
+			constructor.Body = append(constructor.Body, b.assign(b.member(b.this(), param.Identifier), b.identifier(param.Identifier)))
+			deconstruct.Body = append(deconstruct.Body, b.assign(b.identifier(param.Identifier), b.member(b.this(), param.Identifier)))
+			outParameters = append(outParameters, ast.Parameter{Modifiers: []ast.Modifier{{Kind: lexer.OUT}}, Type: param.Type, Identifier: param.Identifier})
+		}
+		members = append(members, ast.ConstructorDeclStmt{
+			Modifiers:  public,
+			Name:       name,
+			Parameters: parameters,
+			Body:       constructor,
+			File:       p.file,
+			Line:       line,
+			Column:     column,
+		})
+		if !declaresMember(members, "Deconstruct", len(parameters)) {
+			members = append(members, b.method(public, "void", "Deconstruct", outParameters, deconstruct))
+		}
+	}
+
+	values := recordValues(members)
+	left, right, other := b.identifier("left"), b.identifier("right"), b.identifier("other")
+
+	// Equals(other) compares the instance data member by member like EqualityComparer<T>.Default does,
+	// a record class is never equal to null
+	if !declaresMember(members, "Equals", 1) {
+		var equal ast.Expr = ast.BoolLiteralExpr{Value: true, Line: line, Column: column}
+		if !isStruct {
+			equal = b.not(b.isNull(other))
+		}
+		for i, value := range values {
+			comparison := b.valueEquals(b.member(b.this(), value.name), b.member(other, value.name))
+			if i == 0 && isStruct {
+				equal = comparison
+			} else {
+				equal = b.binary(equal, lexer.AND, "&&", comparison)
+			}
+		}
+		otherParameter := []ast.Parameter{{Type: b.typ(name), Identifier: "other"}}
+		members = append(members, b.method(public, "bool", "Equals", otherParameter, b.block(b.returns(equal))))
+	}
+
+	// GetHashCode combines the hash codes of the members Equals compares like the C# compiler does
+	if !declaresMember(members, "GetHashCode", 0) {
+		var hash ast.Expr = ast.IntLiteralExpr{Value: 0, Line: line, Column: column}
+		for i, value := range values {
+			memberHash := b.valueHash(b.member(b.this(), value.name))
+			if i == 0 {
+				hash = memberHash
+			} else {
+				factor := ast.PrefixExpr{Operator: b.token(lexer.MINUS, "-"), Expression: ast.IntLiteralExpr{Value: 1521134295, Line: line, Column: column}, Line: line, Column: column}
+				hash = b.binary(b.binary(hash, lexer.MULTIPLY, "*", factor), lexer.PLUS, "+", memberHash)
+			}
+		}
+		members = append(members, b.method(public, "int", "GetHashCode", []ast.Parameter{}, b.block(b.returns(hash))))
+	}
+
+	// == and != go through Equals, two null record classes are equal
+	operands := []ast.Parameter{{Type: b.typ(name), Identifier: "left"}, {Type: b.typ(name), Identifier: "right"}}
+	publicStatic := []ast.Modifier{{Kind: lexer.PUBLIC}, {Kind: lexer.STATIC}}
+	if !declaresMember(members, ast.OperatorMethodName(lexer.EQUALS, 2), 2) {
+		equals := ast.MethodCallExpr{Receiver: left, MethodName: "Equals", Args: []ast.Expr{right}, Line: line, Column: column}
+		var equal ast.Expr = equals
+		if !isStruct {
+			bothNull := b.binary(b.isNull(left), lexer.AND, "&&", b.isNull(right))
+			equal = b.binary(bothNull, lexer.OR, "||", b.binary(b.not(b.isNull(left)), lexer.AND, "&&", equals))
+		}
+		operator := b.method(publicStatic, "bool", ast.OperatorMethodName(lexer.EQUALS, 2), operands, b.block(b.returns(equal)))
+		operator.Operator = "=="
+		members = append(members, operator)
+	}
+	if !declaresMember(members, ast.OperatorMethodName(lexer.NOT_EQUALS, 2), 2) {
+		notEqual := b.not(b.binary(left, lexer.EQUALS, "==", right))
+		operator := b.method(publicStatic, "bool", ast.OperatorMethodName(lexer.NOT_EQUALS, 2), operands, b.block(b.returns(notEqual)))
+		operator.Operator = "!="
+		members = append(members, operator)
+	}
+
+	// ToString prints the public data like "Person { Name = Ada, Age = 36 }"
+	if !declaresMember(members, "ToString", 0) {
+		text := name + " { "
+		var result ast.Expr
+		separator := ""
+		for _, value := range values {
+			if !value.isPublic {
+				continue
+			}
+			text += separator + value.name + " = "
+			label := ast.StringExpr{Value: text, Line: line, Column: column}
+			if result == nil {
+				result = label
+			} else {
+				result = b.binary(result, lexer.PLUS, "+", label)
+			}
+			result = b.binary(result, lexer.PLUS, "+", b.member(b.this(), value.name))
+			text, separator = "", ", "
+		}
+		if result == nil {
+			result = ast.StringExpr{Value: name + " { }", Line: line, Column: column}
+		} else {
+			result = b.binary(result, lexer.PLUS, "+", ast.StringExpr{Value: " }", Line: line, Column: column})
+		}
+		members = append(members, b.method(public, "string", "ToString", []ast.Parameter{}, b.block(b.returns(result))))
+	}
+	return members
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
@@ -9,6 +10,13 @@ import (
 
 func parseStatement(p *parser) ast.Stmt {
 	line, column := p.currentToken().Line, p.currentToken().Column
+	if isLocalFunctionAhead(p) {
+		return parseLocalFunctionStmt(p)
+	}
+	if p.currentTokenKind() == lexer.VAR && p.nextTokenKind() == lexer.OPEN_PAREN {
+		return parseDeconstructionStmt(p)
+	}
+
 	stmt_fn, exists := stmtTable[p.currentTokenKind()]
 	if exists {
 		return stmt_fn(p)
@@ -17,8 +25,17 @@ func parseStatement(p *parser) ast.Stmt {
 	if p.currentTokenKind() == lexer.RETURN {
 		return parseReturnStmt(p)
 	}
+	if (p.currentTokenKind() == lexer.CHECKED || p.currentTokenKind() == lexer.UNCHECKED) && p.nextTokenKind() == lexer.OPEN_BRACE {
+		context := p.advance().Kind
+		block := parseBlockStmt(p)
+		block.Context = context
+		return block
+	}
+	if isContextualKeyword(p.currentToken(), "yield") && (p.nextTokenKind() == lexer.RETURN || p.nextTokenKind() == lexer.BREAK) {
+		return parseYieldStmt(p)
+	}
 
-	if isType(p) {
+	if isType(p) && isDeclarationAhead(p) {
 		return parseVarDeclStmt(p)
 	}
 
@@ -39,6 +56,21 @@ func parseStatement(p *parser) ast.Stmt {
 	}
 }
 
+// var (a, b) = value deconstructs value into new variables like (var a, var b) = value
+func parseDeconstructionStmt(p *parser) ast.Stmt {
+	token := p.expect(lexer.VAR)
+	targets := parseDeconstructionDesignation(p, ast.Type{Name: "var", Line: token.Line, Column: token.Column})
+	operator := p.expectError(lexer.ASSIGNMENT, "Expected '=' after deconstruction variables")
+	value := parseExpression(p, ASSIGNMENT)
+	p.expect(lexer.SEMICOLON)
+
+	return ast.ExpressionStmt{
+		Expression: ast.AssignmentExpr{Assignee: targets, Operator: operator, Value: value, Line: token.Line, Column: token.Column},
+		Line:       token.Line,
+		Column:     token.Column,
+	}
+}
+
 func parseReturnStmt(p *parser) ast.Stmt {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	p.advance() // consume 'return'
@@ -67,46 +99,115 @@ func parseVarDeclStmt(p *parser) ast.Stmt {
 	}
 	dataType := parseType(p)
 
-	identifier := p.expectError(lexer.IDENTIFIER, "Expected identifier after type declaration").Value
+	declarations := []ast.VarDeclStmt{}
+	for {
+		declLine, declColumn := line, column
+		if len(declarations) > 0 {
+			declLine, declColumn = p.currentToken().Line, p.currentToken().Column
+		}
+		identifier := p.expectError(lexer.IDENTIFIER, "Expected identifier after type declaration").Value
+		declarations = append(declarations, ast.VarDeclStmt{
+			Modifiers:       modifiers,
+			Identifier:      identifier,
+			Type:            dataType,
+			IsUninitialized: p.currentTokenKind() != lexer.ASSIGNMENT,
+			Value:           parseInitializer(p, dataType, modifiers),
+			Line:            declLine,
+			Column:          declColumn,
+		})
 
-	var assignedValue ast.Expr
-	if p.currentTokenKind() == lexer.ASSIGNMENT {
-		p.advance() // consume '='
-		assignedValue = parseExpression(p, ASSIGNMENT)
-	} else {
-		assignedValue = assignStandardType(dataType, p)
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
 	}
 
 	p.expect(lexer.SEMICOLON)
 
-	return ast.VarDeclStmt{
-		Modifiers:  modifiers,
-		Identifier: identifier,
-		Type:       dataType,
-		Value:      assignedValue,
-		Line:       line,
-		Column:     column,
+	if len(declarations) == 1 {
+		return declarations[0]
 	}
+	return ast.MultiVarDeclStmt{Declarations: declarations, Line: line, Column: column}
+}
+
+// Parses the optional initializer of a declarator. Constants without one get no value so that
+// the type checker can report them, everything else is initialized with its default value.
+func parseInitializer(p *parser, dataType ast.Type, modifiers []ast.Modifier) ast.Expr {
+	if p.currentTokenKind() == lexer.ASSIGNMENT {
+		p.advance() // consume '='
+		return parseExpression(p, ASSIGNMENT)
+	}
+	for _, modifier := range modifiers {
+		if modifier.Kind == lexer.CONST {
+			return nil
+		}
+	}
+	return assignStandardType(dataType, p)
 }
 
 func parseClassDeclStmt(p *parser) ast.Stmt {
-	line, column := p.currentToken().Line, p.currentToken().Column
+	attributes := parseAttributes(p)
 	modifiers := parseModifiers(p)
-	p.expect(lexer.CLASS)
+	return parseClass(p, attributes, modifiers)
+}
+
+// Parses a class, struct or record declaration after its modifiers
+func parseClass(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.ClassDeclStmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	kind := contextualKind(p, p.pos)
+	isRecord := kind == lexer.RECORD
+	if isRecord {
+		// record is short for record class
+		p.advance()
+		kind = lexer.CLASS
+		if p.currentTokenKind() == lexer.CLASS || p.currentTokenKind() == lexer.STRUCT {
+			kind = p.advance().Kind
+		}
+	} else if kind != lexer.STRUCT {
+		p.expect(lexer.CLASS)
+	} else {
+		p.advance()
+	}
 	className := p.expectError(lexer.IDENTIFIER, "Expected class name").Value
-	p.expect(lexer.OPEN_BRACE)
-	members := []ast.ClassMember{}
 
-	for p.currentTokenKind() != lexer.CLOSE_BRACE {
-		members = append(members, parseClassMember(p, className))
+	var parameters []ast.Parameter
+	if isRecord && p.currentTokenKind() == lexer.OPEN_PAREN {
+		p.advance()
+		parameters = parseParameterList(p, lexer.CLOSE_PAREN)
+		p.expectError(lexer.CLOSE_PAREN, "Expected ')' after record parameters")
 	}
 
-	p.expect(lexer.CLOSE_BRACE)
+	baseTypes := []ast.Type{}
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance()
+		for {
+			baseTypes = append(baseTypes, parseType(p))
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+	}
 
-	// Check if a constructor declaration exists
+	members := []ast.ClassMember{}
+	if isRecord && p.currentTokenKind() == lexer.SEMICOLON {
+		// A positional record like "record Person(string Name);" does not need a body
+		p.advance()
+	} else {
+		p.expect(lexer.OPEN_BRACE)
+		for p.currentTokenKind() != lexer.CLOSE_BRACE {
+			members = append(members, parseClassMember(p, className)...)
+		}
+		p.expect(lexer.CLOSE_BRACE)
+	}
+	if isRecord {
+		members = synthesizeRecordMembers(p, className, kind, modifiers, parameters, members, line, column)
+	}
+
+	// Check if an instance constructor declaration exists
 	hasConstructor := false
 	for _, member := range members {
-		if _, ok := member.(ast.ConstructorDeclStmt); ok {
+		if constructor, ok := member.(ast.ConstructorDeclStmt); ok && !hasModifierKind(constructor.Modifiers, lexer.STATIC) {
 			hasConstructor = true
 			break
 		}
@@ -115,10 +216,12 @@ func parseClassDeclStmt(p *parser) ast.Stmt {
 	// Add a standard constructor if no constructor declaration exists
 	if !hasConstructor {
 		standardConstructor := ast.ConstructorDeclStmt{
-			Modifiers:  modifiers,
+			Modifiers:  []ast.Modifier{{Kind: lexer.PUBLIC}},
 			Name:       className,
 			Parameters: []ast.Parameter{},
 			Body:       ast.BlockStmt{Body: []ast.Stmt{}},
+			IsImplicit: true,
+			File:       p.file,
 			Line:       0,
 			Column:     0,
 		}
@@ -126,59 +229,137 @@ func parseClassDeclStmt(p *parser) ast.Stmt {
 	}
 
 	return ast.ClassDeclStmt{
-		Modifiers: modifiers,
-		Name:      className,
-		Body:      ast.ClassBody{Members: members},
-		Line:      line,
-		Column:    column,
+		Attributes: attributes,
+		Modifiers:  modifiers,
+		Kind:       kind,
+		Name:       className,
+		BaseTypes:  baseTypes,
+		Body:       ast.ClassBody{Members: members},
+		IsRecord:   isRecord,
+		Parameters: parameters,
+		Namespace:  p.namespace,
+		File:       p.file,
+		Line:       line,
+		Column:     column,
+	}
+}
+
+func parseEnumDeclStmt(p *parser) ast.Stmt {
+	attributes := parseAttributes(p)
+	modifiers := parseModifiers(p)
+	return parseEnum(p, attributes, modifiers)
+}
+
+// Parses an enum declaration like "enum Color { Red, Green = 5, Blue }" after its modifiers
+func parseEnum(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.EnumDeclStmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.expect(lexer.ENUM)
+	name := p.expectError(lexer.IDENTIFIER, "Expected enum name").Value
+	p.expect(lexer.OPEN_BRACE)
+
+	members := []ast.EnumMember{}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		memberAttributes := parseAttributes(p)
+		token := p.expectError(lexer.IDENTIFIER, "Expected enum member name")
+		member := ast.EnumMember{Attributes: memberAttributes, Name: token.Value, Line: token.Line, Column: token.Column}
+		if p.currentTokenKind() == lexer.ASSIGNMENT {
+			p.advance()
+			member.Value = parseExpression(p, ASSIGNMENT)
+		}
+		members = append(members, member)
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expect(lexer.CLOSE_BRACE)
+
+	return ast.EnumDeclStmt{
+		Attributes: attributes,
+		Modifiers:  modifiers,
+		Name:       name,
+		Members:    members,
+		File:       p.file,
+		Line:       line,
+		Column:     column,
 	}
 }
 
-func parseClassMember(p *parser, className string) ast.ClassMember {
+// Returns several members for field declarations with more than one declarator
+func parseClassMember(p *parser, className string) []ast.ClassMember {
 	line, column := p.currentToken().Line, p.currentToken().Column
+	attributes := parseAttributes(p)
 	modifiers := parseModifiers(p)
 
-	if isType(p) {
-		return parseFieldOrMethod(p, modifiers)
-	} else if p.currentTokenKind() == lexer.IDENTIFIER && p.currentToken().Value == className {
+	switch contextualKind(p, p.pos) {
+	case lexer.CLASS, lexer.STRUCT, lexer.RECORD:
+		return []ast.ClassMember{parseClass(p, attributes, modifiers)}
+	case lexer.ENUM:
+		return []ast.ClassMember{parseEnum(p, attributes, modifiers)}
+	case lexer.DELEGATE:
+		return []ast.ClassMember{parseDelegate(p, attributes, modifiers)}
+	case lexer.EVENT:
+		return []ast.ClassMember{parseEvent(p, attributes, modifiers)}
+	case lexer.IMPLICIT, lexer.EXPLICIT:
+		return []ast.ClassMember{parseConversionOperator(p, attributes, modifiers)}
+	}
+
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.currentToken().Value == className && p.nextTokenKind() == lexer.OPEN_PAREN {
 		// Possible constructor
-		return parseConstructor(p, modifiers)
+		return []ast.ClassMember{parseConstructor(p, attributes, modifiers)}
+	} else if isType(p) {
+		return parseFieldOrMethod(p, attributes, modifiers)
 	}
 
 	panic(fmt.Sprintf("Expected type or constructor but got %s at line %d, column %d", lexer.TokenKindString(p.currentTokenKind()), line, column))
 }
 
-func parseFieldOrMethod(p *parser, modifiers []ast.Modifier) ast.ClassMember {
+func parseFieldOrMethod(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) []ast.ClassMember {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	dataType := parseType(p)
+	if p.currentTokenKind() == lexer.OPERATOR {
+		return []ast.ClassMember{parseOperator(p, attributes, modifiers, dataType)}
+	}
+	if p.currentTokenKind() == lexer.THIS {
+		return []ast.ClassMember{parseIndexer(p, attributes, modifiers, dataType)}
+	}
 	identifier := p.expectError(lexer.IDENTIFIER, "Expected identifier").Value
 
 	if p.currentTokenKind() == lexer.OPEN_PAREN {
 		// It's a method
-		return parseMethod(p, modifiers, dataType, identifier)
-	} else {
-		// It's a field
-		var assignedValue ast.Expr
-		if p.currentTokenKind() == lexer.ASSIGNMENT {
-			p.advance() // consume '='
-			assignedValue = parseExpression(p, ASSIGNMENT)
-		} else {
-			assignedValue = assignStandardType(dataType, p)
-		}
+		return []ast.ClassMember{parseMethod(p, attributes, modifiers, dataType, identifier)}
+	}
+	if p.currentTokenKind() == lexer.OPEN_BRACE || p.currentTokenKind() == lexer.ARROW {
+		return []ast.ClassMember{parseProperty(p, attributes, modifiers, dataType, identifier, line, column)}
+	}
 
-		p.expect(lexer.SEMICOLON)
-		return ast.FieldDeclStmt{
+	// It's a field, possibly followed by more declarators
+	fields := []ast.ClassMember{}
+	for {
+		fields = append(fields, ast.FieldDeclStmt{
+			Attributes: attributes,
 			Modifiers:  modifiers,
 			Type:       dataType,
 			Identifier: identifier,
-			Value:      assignedValue,
+			Value:      parseInitializer(p, dataType, modifiers),
+			File:       p.file,
 			Line:       line,
 			Column:     column,
+		})
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
 		}
+		p.advance()
+		line, column = p.currentToken().Line, p.currentToken().Column
+		identifier = p.expectError(lexer.IDENTIFIER, "Expected identifier").Value
 	}
+
+	p.expect(lexer.SEMICOLON)
+	return fields
 }
 
-func parseConstructor(p *parser, modifiers []ast.Modifier) ast.ClassMember {
+func parseConstructor(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.ClassMember {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	name := p.expectError(lexer.IDENTIFIER, "Expected constructor name").Value
 	p.expect(lexer.OPEN_PAREN)
@@ -187,16 +368,18 @@ func parseConstructor(p *parser, modifiers []ast.Modifier) ast.ClassMember {
 	body := parseBlockStmt(p)
 
 	return ast.ConstructorDeclStmt{
+		Attributes: attributes,
 		Modifiers:  modifiers,
 		Name:       name,
 		Parameters: parameters,
 		Body:       body,
+		File:       p.file,
 		Line:       line,
 		Column:     column,
 	}
 }
 
-func parseMethod(p *parser, modifiers []ast.Modifier, returnType ast.Type, name string) ast.ClassMember {
+func parseMethod(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier, returnType ast.Type, name string) ast.ClassMember {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	p.expect(lexer.OPEN_PAREN)
 	parameters := parseParameters(p)
@@ -204,25 +387,249 @@ func parseMethod(p *parser, modifiers []ast.Modifier, returnType ast.Type, name
 	body := parseBlockStmt(p)
 
 	return ast.MethodDeclStmt{
+		Attributes: attributes,
 		Modifiers:  modifiers,
 		ReturnType: returnType,
 		Name:       name,
 		Parameters: parameters,
 		Body:       body,
+		File:       p.file,
+		Line:       line,
+		Column:     column,
+	}
+}
+
+// Parses "operator +(T a, T b) { ... }" after the return type of an operator declaration
+func parseOperator(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier, returnType ast.Type) ast.ClassMember {
+	p.expect(lexer.OPERATOR)
+	token := p.advance()
+	method := parseMethod(p, attributes, modifiers, returnType, "").(ast.MethodDeclStmt)
+	method.Operator = token.Value
+	method.Name = ast.OperatorMethodName(token.Kind, len(method.Parameters))
+	if method.Name == "" || token.Kind == lexer.IMPLICIT || token.Kind == lexer.EXPLICIT {
+		if ast.OperatorMethodName(token.Kind, 1) == "" && ast.OperatorMethodName(token.Kind, 2) == "" {
+			panic(fmt.Sprintf("Overloadable operator expected at line %d, column %d", token.Line, token.Column))
+		}
+		panic(fmt.Sprintf("Overloaded operator %s can not take %d parameters at line %d, column %d", token.Value, len(method.Parameters), token.Line, token.Column))
+	}
+	return method
+}
+
+// Parses "this[int i] { get { ... } set { ... } }" or "this[int i] => expr;" after the type of an indexer
+func parseIndexer(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier, typ ast.Type) ast.ClassMember {
+	token := p.expect(lexer.THIS)
+	p.expectError(lexer.OPEN_BRACKET, "Expected '[' after this in indexer declaration")
+	parameters := parseParameterList(p, lexer.CLOSE_BRACKET)
+	p.expectError(lexer.CLOSE_BRACKET, "Expected ']' after indexer parameters")
+	if len(parameters) == 0 {
+		panic(fmt.Sprintf("Indexers must have at least one parameter at line %d, column %d", token.Line, token.Column))
+	}
+
+	indexer := ast.IndexerDeclStmt{Attributes: attributes, Modifiers: modifiers, Type: typ, Parameters: parameters, File: p.file, Line: token.Line, Column: token.Column}
+	if p.currentTokenKind() == lexer.ARROW {
+		arrow := p.advance()
+		indexer.Accessors = []ast.Accessor{{Kind: "get", Body: parseAccessorExpressionBody(p, "get"), Line: arrow.Line, Column: arrow.Column}}
+		return indexer
+	}
+
+	p.expectError(lexer.OPEN_BRACE, "Expected '{' or '=>' after indexer parameters")
+	indexer.Accessors = parseAccessors(p, "get or set", "get", "set")
+	return indexer
+}
+
+// Parses "{ get; init; } = value;" or "=> expr;" after the name of a property
+func parseProperty(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier, typ ast.Type, name string, line, column int) ast.ClassMember {
+	property := ast.PropertyDeclStmt{Attributes: attributes, Modifiers: modifiers, Type: typ, Name: name, File: p.file, Line: line, Column: column}
+	if p.currentTokenKind() == lexer.ARROW {
+		arrow := p.advance()
+		property.Accessors = []ast.Accessor{{Kind: "get", Body: parseAccessorExpressionBody(p, "get"), Line: arrow.Line, Column: arrow.Column}}
+		return property
+	}
+
+	p.expect(lexer.OPEN_BRACE)
+	property.Accessors = parseAccessors(p, "get, set or init", "get", "set", "init")
+	if p.currentTokenKind() == lexer.ASSIGNMENT {
+		p.advance()
+		property.Value = parseExpression(p, ASSIGNMENT)
+		p.expect(lexer.SEMICOLON)
+	}
+	return property
+}
+
+// Parses the accessors of an indexer or property up to the closing brace, each is "get;", "get => expr;" or "get { ... }"
+func parseAccessors(p *parser, expected string, kinds ...string) []ast.Accessor {
+	accessors := []ast.Accessor{}
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		name := p.expectError(lexer.IDENTIFIER, fmt.Sprintf("Expected %s accessor", expected))
+		if !slices.Contains(kinds, name.Value) {
+			panic(fmt.Sprintf("Expected %s accessor but got %s at line %d, column %d", expected, name.Value, name.Line, name.Column))
+		}
+		accessor := ast.Accessor{Kind: name.Value, Line: name.Line, Column: name.Column}
+		switch p.currentTokenKind() {
+		case lexer.SEMICOLON:
+			p.advance()
+		case lexer.ARROW:
+			p.advance()
+			accessor.Body = parseAccessorExpressionBody(p, name.Value)
+		default:
+			accessor.Body = parseBlockStmt(p)
+		}
+		accessors = append(accessors, accessor)
+	}
+	p.expect(lexer.CLOSE_BRACE)
+	return accessors
+}
+
+// get => expr; returns expr while set => expr; evaluates it
+func parseAccessorExpressionBody(p *parser, kind string) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	expression := parseExpression(p, DEFAULT)
+	p.expect(lexer.SEMICOLON)
+
+	var stmt ast.Stmt = ast.ExpressionStmt{Expression: expression, Line: line, Column: column}
+	if kind == "get" {
+		stmt = ast.ReturnStmt{Value: expression, Line: line, Column: column}
+	}
+	return ast.BlockStmt{Body: []ast.Stmt{stmt}, Line: line, Column: column}
+}
+
+// Parses "event EventHandler Clicked;" or "event EventHandler Clicked { add { ... } remove { ... } }"
+func parseEvent(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.ClassMember {
+	p.expect(lexer.EVENT)
+	typ := parseType(p)
+	name := p.expectError(lexer.IDENTIFIER, "Expected event name")
+
+	event := ast.EventDeclStmt{Attributes: attributes, Modifiers: modifiers, Type: typ, Name: name.Value, File: p.file, Line: name.Line, Column: name.Column}
+	if p.currentTokenKind() == lexer.SEMICOLON {
+		p.advance()
+		return event
+	}
+
+	p.expectError(lexer.OPEN_BRACE, "Expected ';' or '{' after event name")
+	for p.currentTokenKind() != lexer.CLOSE_BRACE {
+		accessor := p.expectError(lexer.IDENTIFIER, "Expected add or remove accessor")
+		if accessor.Value != "add" && accessor.Value != "remove" {
+			panic(fmt.Sprintf("Expected add or remove accessor but got %s at line %d, column %d", accessor.Value, accessor.Line, accessor.Column))
+		}
+		if p.currentTokenKind() != lexer.OPEN_BRACE {
+			panic(fmt.Sprintf("An add or remove accessor must have a body at line %d, column %d", accessor.Line, accessor.Column))
+		}
+		event.Accessors = append(event.Accessors, ast.Accessor{Kind: accessor.Value, Body: parseBlockStmt(p), Line: accessor.Line, Column: accessor.Column})
+	}
+	p.expect(lexer.CLOSE_BRACE)
+	return event
+}
+
+// Parses "implicit operator T(U value) { ... }" after the modifiers of a conversion operator
+func parseConversionOperator(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.ClassMember {
+	token := p.advance()
+	p.expectError(lexer.OPERATOR, "Expected 'operator' after implicit or explicit")
+	returnType := parseType(p)
+	method := parseMethod(p, attributes, modifiers, returnType, ast.OperatorMethodName(token.Kind, 1)).(ast.MethodDeclStmt)
+	method.Operator = token.Value
+	return method
+}
+
+// Local functions look like "[static] [async] Type Name(" and may appear anywhere inside of a block
+func isLocalFunctionAhead(p *parser) bool {
+	pos := p.pos
+	for p.tokens[pos].Kind == lexer.STATIC || contextualKind(p, pos) == lexer.ASYNC {
+		pos++
+	}
+	switch p.tokens[pos].Kind {
+	case lexer.IDENTIFIER, lexer.VOID, lexer.INT, lexer.BOOL, lexer.CHAR, lexer.FLOAT, lexer.DOUBLE, lexer.STRING,
+		lexer.LONG, lexer.SHORT, lexer.BYTE, lexer.SBYTE, lexer.UINT, lexer.ULONG, lexer.USHORT, lexer.DECIMAL, lexer.OBJECT:
+	default:
+		return false
+	}
+	pos = skipType(p, pos)
+	return pos+1 < len(p.tokens) && p.tokens[pos].Kind == lexer.IDENTIFIER && p.tokens[pos+1].Kind == lexer.OPEN_PAREN
+}
+
+func parseLocalFunctionStmt(p *parser) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	modifiers := []ast.Modifier{}
+	for p.currentTokenKind() == lexer.STATIC || contextualKind(p, p.pos) == lexer.ASYNC {
+		modifiers = append(modifiers, ast.Modifier{Kind: contextualKind(p, p.pos)})
+		p.advance()
+	}
+	returnType := parseType(p)
+	name := p.expectError(lexer.IDENTIFIER, "Expected local function name").Value
+	p.expect(lexer.OPEN_PAREN)
+	parameters := parseParameters(p)
+	p.expect(lexer.CLOSE_PAREN)
+	body := parseBlockStmt(p)
+
+	return ast.LocalFunctionStmt{
+		Modifiers:  modifiers,
+		ReturnType: returnType,
+		Name:       name,
+		Parameters: parameters,
+		Body:       body,
+		Line:       line,
+		Column:     column,
+	}
+}
+
+func parseDelegateDeclStmt(p *parser) ast.Stmt {
+	attributes := parseAttributes(p)
+	modifiers := parseModifiers(p)
+	return parseDelegate(p, attributes, modifiers)
+}
+
+func parseDelegate(p *parser, attributes []ast.Attribute, modifiers []ast.Modifier) ast.DelegateDeclStmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.expect(lexer.DELEGATE)
+	returnType := parseType(p)
+	name := p.expectError(lexer.IDENTIFIER, "Expected delegate name").Value
+	p.expect(lexer.OPEN_PAREN)
+	parameters := parseParameters(p)
+	p.expect(lexer.CLOSE_PAREN)
+	p.expect(lexer.SEMICOLON)
+
+	return ast.DelegateDeclStmt{
+		Attributes: attributes,
+		Modifiers:  modifiers,
+		ReturnType: returnType,
+		Name:       name,
+		Parameters: parameters,
+		File:       p.file,
 		Line:       line,
 		Column:     column,
 	}
 }
 
 func parseParameters(p *parser) []ast.Parameter {
+	return parseParameterList(p, lexer.CLOSE_PAREN)
+}
+
+// Parses parameters up to the closing token, which is ] for indexers
+func parseParameterList(p *parser, closing lexer.TokenKind) []ast.Parameter {
 	parameters := []ast.Parameter{}
 
-	for p.currentTokenKind() != lexer.CLOSE_PAREN {
+	for p.currentTokenKind() != closing {
+		attributes := parseAttributes(p)
+		modifiers := []ast.Modifier{}
+		for isParameterModifier(p.currentTokenKind()) {
+			modifiers = append(modifiers, ast.Modifier{Kind: p.advance().Kind})
+		}
+
 		paramType := parseType(p)
 		paramIdentifier := p.expectError(lexer.IDENTIFIER, "Expected parameter name").Value
+
+		// Optional parameter with a default value
+		var defaultValue ast.Expr
+		if p.currentTokenKind() == lexer.ASSIGNMENT {
+			p.advance()
+			defaultValue = parseExpression(p, ASSIGNMENT)
+		}
+
 		parameters = append(parameters, ast.Parameter{
+			Attributes: attributes,
+			Modifiers:  modifiers,
 			Type:       paramType,
 			Identifier: paramIdentifier,
+			Default:    defaultValue,
 		})
 
 		if p.currentTokenKind() == lexer.COMMA {
@@ -300,6 +707,23 @@ func parseContinueStmt(p *parser) ast.Stmt {
 	return ast.ContinueStmt{Line: line, Column: column}
 }
 
+// yield return value; or yield break; where yield is a contextual keyword
+func parseYieldStmt(p *parser) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance() // consume 'yield'
+
+	if p.currentTokenKind() == lexer.BREAK {
+		p.advance()
+		p.expect(lexer.SEMICOLON)
+		return ast.YieldBreakStmt{Line: line, Column: column}
+	}
+
+	p.expect(lexer.RETURN)
+	value := parseExpression(p, DEFAULT)
+	p.expect(lexer.SEMICOLON)
+	return ast.YieldReturnStmt{Value: value, Line: line, Column: column}
+}
+
 func parseBreakStmt(p *parser) ast.Stmt {
 	line, column := p.currentToken().Line, p.currentToken().Column
 	p.advance()
@@ -332,57 +756,136 @@ func parseSwitchStmt(p *parser) ast.Stmt {
 	p.expect(lexer.CLOSE_PAREN)
 	p.expect(lexer.OPEN_BRACE)
 
-	cases := []ast.SwitchCase{}
-	var defaultCase ast.Stmt
+	sections := []ast.SwitchSection{}
 	for p.currentTokenKind() != lexer.CLOSE_BRACE {
-		if p.currentTokenKind() == lexer.CASE {
-			cases = append(cases, parseSwitchCase(p))
-		} else if p.currentTokenKind() == lexer.DEFAULT {
-			defaultCase = parseDefaultCase(p)
-		} else {
-			panic(fmt.Sprintf("Expected case or default but got %s at line %d, column %d", lexer.TokenKindString(p.currentTokenKind()), p.currentToken().Line, p.currentToken().Column))
-		}
+		sections = append(sections, parseSwitchSection(p))
 	}
 
 	p.expect(lexer.CLOSE_BRACE)
 
-	return ast.SwitchStmt{Expression: expression, Cases: cases, Default: defaultCase, Line: line, Column: column}
+	return ast.SwitchStmt{Expression: expression, Sections: sections, Line: line, Column: column}
 }
 
-func parseSwitchCase(p *parser) ast.SwitchCase {
+func isSwitchLabelAhead(p *parser) bool {
+	return p.currentTokenKind() == lexer.CASE || p.currentTokenKind() == lexer.DEFAULT
+}
+
+func parseSwitchSection(p *parser) ast.SwitchSection {
 	line, column := p.currentToken().Line, p.currentToken().Column
-	p.advance()
-	value := parseExpression(p, DEFAULT)
-	p.expect(lexer.COLON)
-	body := []ast.Stmt{}
+	if !isSwitchLabelAhead(p) {
+		panic(fmt.Sprintf("Expected case or default but got %s at line %d, column %d", lexer.TokenKindString(p.currentTokenKind()), line, column))
+	}
+
+	// Stacked labels share the statements that follow them
+	labels := []ast.SwitchLabel{}
+	for isSwitchLabelAhead(p) {
+		labels = append(labels, parseSwitchLabel(p))
+	}
 
-	for p.currentTokenKind() != lexer.CASE && p.currentTokenKind() != lexer.DEFAULT && p.currentTokenKind() != lexer.CLOSE_BRACE {
+	body := []ast.Stmt{}
+	for !isSwitchLabelAhead(p) && p.currentTokenKind() != lexer.CLOSE_BRACE {
 		body = append(body, parseStatement(p))
 	}
 
 	bodyBlock := ast.BlockStmt{Body: body, Line: line, Column: column}
 
-	return ast.SwitchCase{Value: value, Body: bodyBlock, Line: line, Column: column}
+	return ast.SwitchSection{Labels: labels, Body: bodyBlock, Line: line, Column: column}
 }
 
-func parseDefaultCase(p *parser) ast.Stmt {
+func parseSwitchLabel(p *parser) ast.SwitchLabel {
 	line, column := p.currentToken().Line, p.currentToken().Column
-	p.advance()
+	if p.advance().Kind == lexer.DEFAULT {
+		p.expect(lexer.COLON)
+		return ast.SwitchLabel{IsDefault: true, Line: line, Column: column}
+	}
+
+	pattern := parsePattern(p)
+	guard := parseGuard(p)
 	p.expect(lexer.COLON)
-	body := []ast.Stmt{}
+	return ast.SwitchLabel{Pattern: pattern, Guard: guard, Line: line, Column: column}
+}
 
-	for p.currentTokenKind() != lexer.CLOSE_BRACE {
-		body = append(body, parseStatement(p))
+func parseGotoCaseStmt(p *parser) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance()
+
+	var value ast.Expr
+	if p.currentTokenKind() == lexer.DEFAULT {
+		p.advance()
+	} else {
+		p.expectError(lexer.CASE, "Expected case or default after goto")
+		value = parseExpression(p, DEFAULT)
 	}
+	p.expect(lexer.SEMICOLON)
 
-	bodyBlock := ast.BlockStmt{Body: body, Line: line, Column: column}
+	return ast.GotoCaseStmt{Value: value, Line: line, Column: column}
+}
+
+func parseTryStmt(p *parser) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance()
+	body := parseBlockStmt(p)
+
+	catches := []ast.CatchClause{}
+	for p.currentTokenKind() == lexer.CATCH {
+		catches = append(catches, parseCatchClause(p))
+	}
+
+	var finally ast.Stmt
+	if p.currentTokenKind() == lexer.FINALLY {
+		p.advance()
+		finally = parseBlockStmt(p)
+	}
+
+	if len(catches) == 0 && finally == nil {
+		panic(fmt.Sprintf("Expected catch or finally after try block at line %d, column %d", line, column))
+	}
+
+	return ast.TryStmt{Body: body, Catches: catches, Finally: finally, Line: line, Column: column}
+}
+
+func parseCatchClause(p *parser) ast.CatchClause {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance()
+	clause := ast.CatchClause{Line: line, Column: column}
+
+	if p.currentTokenKind() == lexer.OPEN_PAREN {
+		p.advance()
+		clause.Type = parseType(p)
+		if p.currentTokenKind() == lexer.IDENTIFIER {
+			clause.Identifier = p.advance().Value
+		}
+		p.expect(lexer.CLOSE_PAREN)
+	}
+
+	// 'when' is a contextual keyword and therefore lexed as an identifier
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.currentToken().Value == "when" {
+		p.advance()
+		p.expect(lexer.OPEN_PAREN)
+		clause.Filter = parseExpression(p, DEFAULT)
+		p.expect(lexer.CLOSE_PAREN)
+	}
+
+	clause.Body = parseBlockStmt(p)
+	return clause
+}
+
+func parseThrowStmt(p *parser) ast.Stmt {
+	line, column := p.currentToken().Line, p.currentToken().Column
+	p.advance()
+
+	var value ast.Expr
+	if p.currentTokenKind() != lexer.SEMICOLON {
+		value = parseExpression(p, DEFAULT)
+	}
+	p.expect(lexer.SEMICOLON)
 
-	return bodyBlock
+	return ast.ThrowStmt{Value: value, Line: line, Column: column}
 }
 
 func isAllowedExprType(expr ast.Expr) bool {
 	switch expr.(type) {
-	case ast.AssignmentExpr, ast.MethodCallExpr, ast.PostDecrementExpr, ast.PreDecrementExpr, ast.PostIncrementExpr, ast.PreIncrementExpr, ast.ConstructorCallExpr:
+	case ast.AssignmentExpr, ast.MethodCallExpr, ast.PostDecrementExpr, ast.PreDecrementExpr, ast.PostIncrementExpr, ast.PreIncrementExpr, ast.ConstructorCallExpr, ast.AwaitExpr:
 		return true
 	default:
 		return false
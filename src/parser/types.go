@@ -2,46 +2,222 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
 )
 
 var builtInTypes = map[string]bool{
-	"int":    true,
-	"string": true,
-	"float":  true,
-	"bool":   true,
-	"var":    true,
-	"char":   true,
-	"double": true,
-	"void":   true,
+	"int":     true,
+	"string":  true,
+	"float":   true,
+	"bool":    true,
+	"var":     true,
+	"char":    true,
+	"double":  true,
+	"void":    true,
+	"long":    true,
+	"short":   true,
+	"byte":    true,
+	"sbyte":   true,
+	"uint":    true,
+	"ulong":   true,
+	"ushort":  true,
+	"decimal": true,
+	"object":  true,
 }
 
 func isType(p *parser) bool {
 	token := p.currentToken()
-	// Assuming built-in types are all lowercase and user-defined types start with an uppercase letter
-	if (token.Kind == lexer.IDENTIFIER && token.Value[0] >= 'A' && token.Value[0] <= 'Z') || builtInTypes[token.Value] {
-		if p.nextTokenKind() != lexer.OPEN_PAREN {
-			return true
+	if token.Kind == lexer.OPEN_PAREN {
+		return isTupleTypeAhead(p, p.pos)
+	}
+	return isTypeToken(token) && p.nextTokenKind() != lexer.OPEN_PAREN
+}
+
+// Assuming built-in types are all lowercase and user-defined types start with an uppercase letter
+func isTypeToken(token lexer.Token) bool {
+	return (token.Kind == lexer.IDENTIFIER && token.Value[0] >= 'A' && token.Value[0] <= 'Z') || (builtInTypes[token.Value] && token.Kind != lexer.IDENTIFIER && token.Kind != lexer.STRINGLITERAL)
+}
+
+// Tells tuple types like (int, string) and (int Count, string Name) apart from parenthesized expressions
+// and tuple literals: every element has to start like a type and may only be followed by its name
+func isTupleTypeAhead(p *parser, pos int) bool {
+	elements := 0
+	for pos++; pos < len(p.tokens); pos++ {
+		if p.tokens[pos].Kind == lexer.OPEN_PAREN {
+			if !isTupleTypeAhead(p, pos) {
+				return false
+			}
+		} else if !isTypeToken(p.tokens[pos]) {
+			return false
 		}
+		pos = skipType(p, pos)
+		if pos < len(p.tokens) && p.tokens[pos].Kind == lexer.IDENTIFIER {
+			pos++
+		}
+		elements++
+		if pos >= len(p.tokens) || p.tokens[pos].Kind != lexer.COMMA {
+			break
+		}
+	}
+	return elements > 1 && pos < len(p.tokens) && p.tokens[pos].Kind == lexer.CLOSE_PAREN
+}
+
+// Distinguishes declarations like "Foo bar = ..." from expressions like "Foo = ..." or "Foo.Bar()"
+func isDeclarationAhead(p *parser) bool {
+	pos := skipType(p, p.pos)
+	return pos < len(p.tokens) && p.tokens[pos].Kind == lexer.IDENTIFIER
+}
+
+// Returns the position of the first token after the type starting at pos
+func skipType(p *parser, pos int) int {
+	if p.tokens[pos].Kind == lexer.OPEN_PAREN {
+		for depth := 0; pos < len(p.tokens); pos++ {
+			if p.tokens[pos].Kind == lexer.OPEN_PAREN {
+				depth++
+			} else if p.tokens[pos].Kind == lexer.CLOSE_PAREN {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+	}
+	pos++
+	for pos+1 < len(p.tokens) && p.tokens[pos].Kind == lexer.DOT && p.tokens[pos+1].Kind == lexer.IDENTIFIER {
+		pos += 2
+	}
+	if pos < len(p.tokens) && p.tokens[pos].Kind == lexer.LESS_THAN {
+		depth := 0
+		for ; pos < len(p.tokens); pos++ {
+			switch p.tokens[pos].Kind {
+			case lexer.LESS_THAN:
+				depth++
+			case lexer.GREATER_THAN:
+				depth--
+			case lexer.IDENTIFIER, lexer.COMMA, lexer.OPEN_BRACKET, lexer.CLOSE_BRACKET, lexer.OPEN_PAREN, lexer.CLOSE_PAREN, lexer.QUESTION, lexer.INT, lexer.BOOL, lexer.CHAR, lexer.FLOAT, lexer.DOUBLE, lexer.STRING, lexer.VOID,
+				lexer.LONG, lexer.SHORT, lexer.BYTE, lexer.SBYTE, lexer.UINT, lexer.ULONG, lexer.USHORT, lexer.DECIMAL, lexer.OBJECT:
+			default:
+				return pos
+			}
+			if depth == 0 {
+				pos++
+				break
+			}
+		}
+	}
+	if pos < len(p.tokens) && p.tokens[pos].Kind == lexer.QUESTION {
+		pos++
+	}
+	for pos+1 < len(p.tokens) && p.tokens[pos].Kind == lexer.OPEN_BRACKET && p.tokens[pos+1].Kind == lexer.CLOSE_BRACKET {
+		pos += 2
+	}
+	return pos
+}
+
+func isParameterModifier(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.REF, lexer.OUT, lexer.IN, lexer.PARAMS, lexer.THIS:
+		return true
 	}
 	return false
 }
 
 func isModifier(kind lexer.TokenKind) bool {
 	switch kind {
-	case lexer.PUBLIC, lexer.PRIVATE, lexer.PROTECTED, lexer.STATIC, lexer.FINAL:
+	case lexer.PUBLIC, lexer.PRIVATE, lexer.PROTECTED, lexer.INTERNAL, lexer.STATIC, lexer.PARTIAL, lexer.ASYNC, lexer.READONLY, lexer.CONST:
 		return true
 	}
 	return false
 }
 
+// Parses attribute sections like [Obsolete("use X"), Test] and [return: Test]
+func parseAttributes(p *parser) []ast.Attribute {
+	attributes := []ast.Attribute{}
+
+	for p.currentTokenKind() == lexer.OPEN_BRACKET {
+		p.advance()
+		target := ""
+		if (p.currentTokenKind() == lexer.IDENTIFIER || p.currentTokenKind() == lexer.RETURN) && p.nextTokenKind() == lexer.COLON {
+			target = p.advance().Value
+			p.advance()
+		}
+
+		for {
+			attributes = append(attributes, parseAttribute(p, target))
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+		p.expect(lexer.CLOSE_BRACKET)
+	}
+
+	return attributes
+}
+
+func parseAttribute(p *parser, target string) ast.Attribute {
+	token := p.expectError(lexer.IDENTIFIER, "Expected attribute name")
+	attribute := ast.Attribute{Target: target, Name: token.Value, Args: []ast.Expr{}, NamedArgs: []ast.NamedAttributeArg{}, Line: token.Line, Column: token.Column}
+	for p.currentTokenKind() == lexer.DOT {
+		p.advance()
+		attribute.Name += "." + p.expectError(lexer.IDENTIFIER, "Expected attribute name").Value
+	}
+
+	if p.currentTokenKind() != lexer.OPEN_PAREN {
+		return attribute
+	}
+	p.advance()
+
+	for p.currentTokenKind() != lexer.CLOSE_PAREN {
+		line, column := p.currentToken().Line, p.currentToken().Column
+		if p.currentTokenKind() == lexer.IDENTIFIER && p.nextTokenKind() == lexer.ASSIGNMENT {
+			name := p.advance().Value
+			p.advance()
+			attribute.NamedArgs = append(attribute.NamedArgs, ast.NamedAttributeArg{Name: name, Value: parseExpression(p, ASSIGNMENT), Line: line, Column: column})
+		} else if len(attribute.NamedArgs) > 0 {
+			panic(fmt.Sprintf("Named attribute arguments must come after positional arguments at line %d, column %d", line, column))
+		} else {
+			attribute.Args = append(attribute.Args, parseArgument(p))
+		}
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expect(lexer.CLOSE_PAREN)
+
+	return attribute
+}
+
+// Returns the position after the attribute sections starting at pos
+func skipAttributes(p *parser, pos int) int {
+	for pos < len(p.tokens) && p.tokens[pos].Kind == lexer.OPEN_BRACKET {
+		depth := 0
+		for ; pos < len(p.tokens); pos++ {
+			if p.tokens[pos].Kind == lexer.OPEN_BRACKET {
+				depth++
+			} else if p.tokens[pos].Kind == lexer.CLOSE_BRACKET {
+				depth--
+				if depth == 0 {
+					pos++
+					break
+				}
+			}
+		}
+	}
+	return pos
+}
+
 func parseModifiers(p *parser) []ast.Modifier {
 	modifiers := []ast.Modifier{}
 
-	for isModifier(p.currentTokenKind()) {
-		modifiers = append(modifiers, ast.Modifier{Kind: p.advance().Kind})
+	for isModifier(contextualKind(p, p.pos)) {
+		modifiers = append(modifiers, ast.Modifier{Kind: contextualKind(p, p.pos)})
+		p.advance()
 	}
 
 	if len(modifiers) == 0 {
@@ -52,19 +228,92 @@ func parseModifiers(p *parser) []ast.Modifier {
 }
 
 func parseType(p *parser) ast.Type {
+	if p.currentTokenKind() == lexer.OPEN_PAREN {
+		return parseTypeSuffixes(p, parseTupleType(p))
+	}
 	token := p.advance()
-	return ast.Type{Name: token.Value, Line: token.Line, Column: token.Column}
+	typ := ast.Type{Name: token.Value, Line: token.Line, Column: token.Column}
+
+	// Qualified names of nested types like Outer.Inner
+	for p.currentTokenKind() == lexer.DOT && p.nextTokenKind() == lexer.IDENTIFIER {
+		p.advance()
+		typ.Name += "." + p.advance().Value
+	}
+	name := typ.Name
+
+	// Generic type arguments like Func<int, bool>
+	if p.currentTokenKind() == lexer.LESS_THAN {
+		p.advance()
+		names := []string{}
+		for {
+			argument := parseType(p)
+			typ.TypeArguments = append(typ.TypeArguments, argument)
+			names = append(names, argument.Name)
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+		p.expectError(lexer.GREATER_THAN, "Expected '>' after type arguments")
+		typ.Name = fmt.Sprintf("%s<%s>", name, strings.Join(names, ", "))
+	}
+
+	return parseTypeSuffixes(p, typ)
+}
+
+func parseTypeSuffixes(p *parser, typ ast.Type) ast.Type {
+	// Nullable types like int? and string?
+	if p.currentTokenKind() == lexer.QUESTION {
+		p.advance()
+		typ.Name += "?"
+	}
+
+	// Array types like int[]
+	for p.currentTokenKind() == lexer.OPEN_BRACKET && p.nextTokenKind() == lexer.CLOSE_BRACKET {
+		p.advance()
+		p.advance()
+		typ.Name += "[]"
+	}
+
+	return typ
+}
+
+// Parses a tuple type like (int, string) or (int Count, string Name), the element
+// types are kept as type arguments and the element names are part of the type name
+func parseTupleType(p *parser) ast.Type {
+	token := p.expect(lexer.OPEN_PAREN)
+	typ := ast.Type{Line: token.Line, Column: token.Column}
+	elements := []string{}
+	for {
+		element := parseType(p)
+		typ.TypeArguments = append(typ.TypeArguments, element)
+		if p.currentTokenKind() == lexer.IDENTIFIER {
+			elements = append(elements, element.Name+" "+p.advance().Value)
+		} else {
+			elements = append(elements, element.Name)
+		}
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expectError(lexer.CLOSE_PAREN, "Expected ')' after tuple element types")
+	if len(elements) < 2 {
+		panic(fmt.Sprintf("A tuple must contain at least two elements at line %d, column %d", token.Line, token.Column))
+	}
+	typ.Name = "(" + strings.Join(elements, ", ") + ")"
+	return typ
 }
 
 func assignStandardType(dataType ast.Type, p *parser) ast.Expr {
 	var assignedValue ast.Expr
 	switch dataType.Name {
-	case "int":
+	case "int", "long", "short", "byte", "sbyte", "uint", "ulong", "ushort", "float", "double", "decimal":
 		assignedValue = ast.IntLiteralExpr{Value: 0, Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case "bool":
 		assignedValue = ast.BoolLiteralExpr{Value: false, Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case "string":
-		assignedValue = ast.NullLiteralExpr{Line: p.currentToken().Line, Column: p.currentToken().Column}
+		assignedValue = ast.NullLiteralExpr{IsImplicit: true, Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case "char":
 		assignedValue = ast.CharLiteralExpr{Value: rune(0), Line: p.currentToken().Line, Column: p.currentToken().Column}
 	case "void":
@@ -72,7 +321,7 @@ func assignStandardType(dataType ast.Type, p *parser) ast.Expr {
 	case "var":
 		panic(fmt.Sprintf("Cannot use var without assigning a value at line %d, column %d", p.currentToken().Line, p.currentToken().Column))
 	default:
-		assignedValue = ast.NullLiteralExpr{Line: p.currentToken().Line, Column: p.currentToken().Column}
+		assignedValue = ast.NullLiteralExpr{IsImplicit: true, Line: p.currentToken().Line, Column: p.currentToken().Column}
 	}
 	return assignedValue
 }
@@ -0,0 +1,193 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// Definite assignment analysis for out parameters. Every out parameter has to be assigned
+// on every path that leaves the method normally, i.e. at each return and at the end of the body.
+type assignmentAnalysis struct {
+	tc       *TypeChecker
+	tracked  []string
+	function string
+}
+
+// The set of tracked variables that are definitely assigned at a point of the program
+type assignedSet map[string]bool
+
+func (set assignedSet) copy() assignedSet {
+	result := assignedSet{}
+	for name := range set {
+		result[name] = true
+	}
+	return result
+}
+
+func intersect(a, b assignedSet) assignedSet {
+	result := assignedSet{}
+	for name := range a {
+		if b[name] {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+func (tc *TypeChecker) checkOutParameters(function string, parameters []ast.Parameter, body ast.Stmt, line, column int) {
+	analysis := assignmentAnalysis{tc: tc, function: function}
+	for _, param := range parameters {
+		if hasModifier(param.Modifiers, lexer.OUT) {
+			analysis.tracked = append(analysis.tracked, param.Identifier)
+		}
+	}
+	if len(analysis.tracked) == 0 {
+		return
+	}
+
+	assigned, reachable := analysis.stmt(body, assignedSet{})
+	if reachable {
+		analysis.requireAssigned(assigned, line, column)
+	}
+}
+
+func (analysis *assignmentAnalysis) requireAssigned(assigned assignedSet, line, column int) {
+	for _, name := range analysis.tracked {
+		if !assigned[name] {
+			analysis.tc.errorf(line, column, "the out parameter %s must be assigned before control leaves %s", name, analysis.function)
+		}
+	}
+}
+
+func (analysis *assignmentAnalysis) isTracked(name string) bool {
+	for _, tracked := range analysis.tracked {
+		if tracked == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the assigned set after the statement and whether the end of the statement is reachable
+func (analysis *assignmentAnalysis) stmt(stmt ast.Stmt, assigned assignedSet) (assignedSet, bool) {
+	switch s := stmt.(type) {
+	case ast.BlockStmt:
+		for _, inner := range s.Body {
+			var reachable bool
+			if assigned, reachable = analysis.stmt(inner, assigned); !reachable {
+				return assigned, false
+			}
+		}
+		return assigned, true
+	case ast.ExpressionStmt:
+		return analysis.expr(s.Expression, assigned), true
+	case ast.VarDeclStmt:
+		return analysis.expr(s.Value, assigned), true
+	case ast.MultiVarDeclStmt:
+		for _, decl := range s.Declarations {
+			assigned = analysis.expr(decl.Value, assigned)
+		}
+		return assigned, true
+	case ast.ReturnStmt:
+		assigned = analysis.expr(s.Value, assigned)
+		analysis.requireAssigned(assigned, s.Line, s.Column)
+		return assigned, false
+	case ast.ThrowStmt:
+		return analysis.expr(s.Value, assigned), false
+	case ast.BreakStmt, ast.ContinueStmt, ast.YieldBreakStmt:
+		return assigned, false
+	case ast.YieldReturnStmt:
+		return analysis.expr(s.Value, assigned), true
+	case ast.IfStmt:
+		assigned = analysis.expr(s.Condition, assigned)
+		thenAssigned, thenReachable := analysis.stmt(s.Then, assigned.copy())
+		elseAssigned, elseReachable := assigned, true
+		if s.Else != nil {
+			elseAssigned, elseReachable = analysis.stmt(s.Else, assigned.copy())
+		}
+		return analysis.join(thenAssigned, thenReachable, elseAssigned, elseReachable)
+	case ast.WhileStmt:
+		// The body might not run at all, but returns inside of it still have to be checked
+		assigned = analysis.expr(s.Condition, assigned)
+		analysis.stmt(s.Body, assigned.copy())
+		return assigned, true
+	case ast.TryStmt:
+		result, reachable := analysis.stmt(s.Body, assigned.copy())
+		for _, clause := range s.Catches {
+			catchAssigned, catchReachable := analysis.stmt(clause.Body, assigned.copy())
+			result, reachable = analysis.join(result, reachable, catchAssigned, catchReachable)
+		}
+		if s.Finally != nil {
+			finallyAssigned, finallyReachable := analysis.stmt(s.Finally, assigned.copy())
+			for name := range finallyAssigned {
+				result[name] = true
+			}
+			reachable = reachable && finallyReachable
+		}
+		return result, reachable
+	case ast.SwitchStmt:
+		assigned = analysis.expr(s.Expression, assigned)
+		for _, section := range s.Sections {
+			analysis.stmt(section.Body, assigned.copy())
+		}
+		return assigned, true
+	case ast.GotoCaseStmt:
+		return assigned, false
+	}
+	return assigned, true
+}
+
+func (analysis *assignmentAnalysis) join(a assignedSet, aReachable bool, b assignedSet, bReachable bool) (assignedSet, bool) {
+	switch {
+	case aReachable && bReachable:
+		return intersect(a, b), true
+	case aReachable:
+		return a, true
+	case bReachable:
+		return b, true
+	}
+	return intersect(a, b), false
+}
+
+// Collects assignments to tracked variables inside of an expression.
+// Lambda bodies and the right side of && and || do not run unconditionally and are skipped.
+func (analysis *assignmentAnalysis) expr(expr ast.Expr, assigned assignedSet) assignedSet {
+	switch e := expr.(type) {
+	case ast.AssignmentExpr:
+		assigned = analysis.expr(e.Value, assigned)
+		if id, ok := e.Assignee.(ast.IdentifierExpr); ok && analysis.isTracked(id.Name) {
+			assigned[id.Name] = true
+		}
+	case ast.ArgumentExpr:
+		if id, ok := e.Value.(ast.IdentifierExpr); ok && hasModifier(e.Modifiers, lexer.OUT) && analysis.isTracked(id.Name) {
+			assigned[id.Name] = true
+		} else {
+			assigned = analysis.expr(e.Value, assigned)
+		}
+	case ast.BinaryExpr:
+		assigned = analysis.expr(e.Left, assigned)
+		if e.Operator.Kind != lexer.AND && e.Operator.Kind != lexer.OR {
+			assigned = analysis.expr(e.Right, assigned)
+		}
+	case ast.PrefixExpr:
+		assigned = analysis.expr(e.Expression, assigned)
+	case ast.MethodCallExpr:
+		assigned = analysis.expr(e.Receiver, assigned)
+		for _, arg := range e.Args {
+			assigned = analysis.expr(arg, assigned)
+		}
+	case ast.ConstructorCallExpr:
+		for _, arg := range e.Args {
+			assigned = analysis.expr(arg, assigned)
+		}
+	case ast.MemberAccessExpr:
+		assigned = analysis.expr(e.Receiver, assigned)
+	case ast.ThrowExpr:
+		assigned = analysis.expr(e.Value, assigned)
+	case ast.IsPatternExpr:
+		assigned = analysis.expr(e.Expression, assigned)
+	case ast.AsExpr:
+		assigned = analysis.expr(e.Expression, assigned)
+	}
+	return assigned
+}
@@ -0,0 +1,87 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Methods, local functions and lambdas marked async return void, Task or Task<T>. Their returns are
+// checked against the unwrapped type, an async Task<int> method returns an int, and await suspends them
+// until a task completed. The lowering pass turns async methods into state machine classes.
+
+// The type returns of an async body are checked against: void for void and Task, T for Task<T>
+func (tc *TypeChecker) asyncReturnType(returnType types.Type, line, column int) types.Type {
+	if returnType == types.Void || returnType == tc.registry.NewClass("Task") {
+		return types.Void
+	}
+	if generic, ok := returnType.(*types.Generic); ok && generic.Name == "Task" && len(generic.Arguments) == 1 {
+		// The lowered state machine completes the task with the builder
+		tc.instantiateCollection(tc.registry.NewGeneric("AsyncTaskMethodBuilder", generic.Arguments))
+		return generic.Arguments[0]
+	}
+	tc.errorf(line, column, "the return type of an async method must be void, Task or Task<T>")
+	return types.Error
+}
+
+// Enters an async body and returns the type its returns are checked against
+func (tc *TypeChecker) enterAsync(returnType types.Type, parameters []ast.Parameter, line, column int) types.Type {
+	for _, param := range parameters {
+		if referenceModifier(param.Modifiers) != "" {
+			tc.errorf(param.Type.Line, param.Type.Column, "async methods cannot have ref, in or out parameters")
+		}
+	}
+	tc.inAsync, tc.awaited = true, false
+	return tc.asyncReturnType(returnType, line, column)
+}
+
+// Async bodies without await run synchronously to the end before they return their task
+func (tc *TypeChecker) checkAwaited(line, column int) {
+	if !tc.awaited {
+		tc.warnf(line, column, "this async method lacks 'await' operators and will run synchronously")
+	}
+}
+
+// Top-level statements that await become an async Main returning Task or Task<int>
+func (tc *TypeChecker) makeTopLevelAsync(method *ast.MethodDeclStmt) {
+	returnType := tc.registry.NewClass("Task")
+	if tc.registry.Parse(method.ReturnType.Name) == types.Int {
+		returnType = tc.registry.NewGeneric("Task", []types.Type{types.Int})
+		tc.instantiateCollection(returnType)
+		tc.instantiateCollection(tc.registry.NewGeneric("AsyncTaskMethodBuilder", []types.Type{types.Int}))
+	}
+	method.Modifiers = append(method.Modifiers, ast.Modifier{Kind: lexer.ASYNC})
+	method.ReturnType.Name = returnType.String()
+	for _, symbol := range tc.classes[tc.currentClassName()].Methods[method.Name] {
+		if symbol.ReturnType == types.Void || symbol.ReturnType == types.Int {
+			symbol.ReturnType = returnType
+		}
+	}
+}
+
+func (tc *TypeChecker) checkNotAsync(modifiers []ast.Modifier, line, column int) {
+	if hasModifier(modifiers, lexer.ASYNC) {
+		tc.errorf(line, column, "the modifier async is not valid for this item")
+	}
+}
+
+// await task has type void, await of a Task<T> has type T
+func (tc *TypeChecker) CheckAwaitExpr(expr ast.AwaitExpr) ast.TypedExpr {
+	if !tc.inAsync {
+		tc.errorf(expr.Line, expr.Column, "the 'await' operator can only be used within an async method or lambda expression")
+	}
+	if tc.inFilter {
+		tc.errorf(expr.Line, expr.Column, "cannot await in the filter expression of a catch clause")
+	}
+	tc.awaited = true
+
+	task := tc.CheckExpr(expr.Expression)
+	expr.Expression = task
+	typ := types.Void
+	if generic, ok := task.Type.(*types.Generic); ok && generic.Name == "Task" && len(generic.Arguments) == 1 {
+		typ = generic.Arguments[0]
+	} else if task.Type == types.Null || !tc.isTypeCompatible(tc.registry.NewClass("Task"), task.Type) {
+		tc.errorf(expr.Line, expr.Column, "cannot await '%s'", task.Type)
+	}
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
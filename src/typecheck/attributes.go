@@ -0,0 +1,295 @@
+package typecheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// Attribute targets are named like the members of AttributeTargets
+const (
+	targetClass       = "Class"
+	targetStruct      = "Struct"
+	targetEnum        = "Enum"
+	targetConstructor = "Constructor"
+	targetMethod      = "Method"
+	targetProperty    = "Property"
+	targetField       = "Field"
+	targetEvent       = "Event"
+	targetParameter   = "Parameter"
+	targetDelegate    = "Delegate"
+	targetReturnValue = "ReturnValue"
+)
+
+// Explicit locations like [return: X] that are valid on each kind of declaration and the target they select
+var attributeLocations = map[string]map[string]string{
+	targetClass:       {"type": targetClass},
+	targetStruct:      {"type": targetStruct},
+	targetEnum:        {"type": targetEnum},
+	targetConstructor: {"method": targetConstructor},
+	targetMethod:      {"method": targetMethod, "return": targetReturnValue},
+	targetProperty:    {"property": targetProperty},
+	targetField:       {"field": targetField},
+	targetEvent:       {"event": targetEvent},
+	targetParameter:   {"param": targetParameter},
+	targetDelegate:    {"type": targetDelegate, "return": targetReturnValue},
+}
+
+// Validates the attributes of a declaration of the given target kind: the attribute classes have to exist,
+// accept the target and the arguments have to be constants matching a constructor or public fields.
+func (tc *TypeChecker) checkAttributes(attributes []ast.Attribute, target string) []ast.Attribute {
+	applied := map[string]bool{}
+	for i, attribute := range attributes {
+		attribute.Name = tc.resolveAttributeName(attribute)
+		actualTarget := tc.attributeTarget(attribute, target)
+
+		validOn, allowMultiple := tc.attributeUsage(attribute.Name)
+		if !validOn[actualTarget] && !validOn["All"] {
+			tc.errorf(attribute.Line, attribute.Column, "attribute %s is not valid on this declaration type; it is only valid on %s declarations",
+				attribute.Name, strings.ToLower(strings.Join(sortedKeys(validOn), ", ")))
+		}
+		key := actualTarget + " " + attribute.Name
+		if applied[key] && !allowMultiple {
+			tc.errorf(attribute.Line, attribute.Column, "duplicate %s attribute", attribute.Name)
+		}
+		applied[key] = true
+
+		attributes[i] = tc.checkAttributeArgs(attribute)
+	}
+	return attributes
+}
+
+func (tc *TypeChecker) checkDelegateAttributes(delegate *ast.DelegateDeclStmt) {
+	tc.file = delegate.File
+	delegate.Attributes = tc.checkAttributes(delegate.Attributes, targetDelegate)
+	tc.checkParameterAttributes(delegate.Parameters)
+}
+
+func (tc *TypeChecker) checkParameterAttributes(parameters []ast.Parameter) {
+	for i := range parameters {
+		parameters[i].Attributes = tc.checkAttributes(parameters[i].Attributes, targetParameter)
+	}
+}
+
+// An attribute class can be named with or without its Attribute suffix
+func (tc *TypeChecker) resolveAttributeName(attribute ast.Attribute) string {
+	scope := tc.currentClassName()
+	full, hasFull := tc.lookupTypeName(attribute.Name+"Attribute", scope)
+	short, hasShort := tc.lookupTypeName(attribute.Name, scope)
+	hasFull = hasFull && tc.isAttributeClass(full)
+	hasShort = hasShort && tc.isAttributeClass(short)
+
+	switch {
+	case hasFull && hasShort && full != short:
+		tc.errorf(attribute.Line, attribute.Column, "%s is ambiguous between %s and %s", attribute.Name, short, full)
+	case hasFull:
+		return full
+	case hasShort:
+		return short
+	case tc.isClassName(short):
+		tc.errorf(attribute.Line, attribute.Column, "%s is not an attribute class", short)
+	}
+	tc.errorf(attribute.Line, attribute.Column, "the attribute type %s could not be found", attribute.Name)
+	return ""
+}
+
+func (tc *TypeChecker) isAttributeClass(typ string) bool {
+	return tc.isClassName(typ) && tc.isSubclassOf(typ, "Attribute")
+}
+
+func (tc *TypeChecker) attributeTarget(attribute ast.Attribute, target string) string {
+	if attribute.Target == "" {
+		return target
+	}
+	locations := attributeLocations[target]
+	if actual, ok := locations[attribute.Target]; ok {
+		return actual
+	}
+	tc.errorf(attribute.Line, attribute.Column, "%s is not a valid attribute location for this declaration; valid attribute locations for this declaration are %s",
+		attribute.Target, strings.Join(sortedKeys(locations), ", "))
+	return ""
+}
+
+// Reads the AttributeUsage of an attribute class or of its closest base class that has one.
+// Without AttributeUsage an attribute is valid on everything but can only be applied once.
+func (tc *TypeChecker) attributeUsage(className string) (map[string]bool, bool) {
+	for current, ok := className, true; ok; current, ok = tc.baseClassOf(current) {
+		for _, attribute := range tc.classes[current].Decl.Attributes {
+			if tc.attributeClassName(attribute) != "AttributeUsageAttribute" || len(attribute.Args) == 0 {
+				continue
+			}
+			validOn := map[string]bool{}
+			collectTargetNames(attribute.Args[0], validOn)
+			allowMultiple := false
+			for _, arg := range attribute.NamedArgs {
+				if literal, ok := unwrapAttributeArg(arg.Value).(ast.BoolLiteralExpr); ok && arg.Name == "AllowMultiple" {
+					allowMultiple = literal.Value
+				}
+			}
+			return validOn, allowMultiple
+		}
+	}
+	return map[string]bool{"All": true}, false
+}
+
+// Collects the members named in a target expression like AttributeTargets.Class | AttributeTargets.Struct
+func collectTargetNames(expr ast.Expr, names map[string]bool) {
+	switch e := unwrapAttributeArg(expr).(type) {
+	case ast.MemberAccessExpr:
+		names[e.Member] = true
+	case ast.BinaryExpr:
+		collectTargetNames(e.Left, names)
+		collectTargetNames(e.Right, names)
+	}
+}
+
+// Resolves the name of an attribute outside of the checking of its declaration, unknown names are returned as they are
+func (tc *TypeChecker) attributeClassName(attribute ast.Attribute) string {
+	if tc.isAttributeClass(attribute.Name) {
+		return attribute.Name
+	}
+	if tc.isAttributeClass(attribute.Name + "Attribute") {
+		return attribute.Name + "Attribute"
+	}
+	return attribute.Name
+}
+
+func unwrapAttributeArg(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case ast.TypedExpr:
+		return unwrapAttributeArg(e.Expr)
+	case ast.ArgumentExpr:
+		return unwrapAttributeArg(e.Value)
+	}
+	return expr
+}
+
+func (tc *TypeChecker) checkAttributeArgs(attribute ast.Attribute) ast.Attribute {
+	for _, arg := range attribute.Args {
+		if value := unwrapAttributeArg(arg); !tc.isConstant(value) {
+			tc.errorf(value.GetLine(), value.GetColumn(), "an attribute argument must be a constant expression")
+		}
+	}
+
+	candidates := []*MethodSymbol{}
+	for _, constructor := range tc.classes[attribute.Name].Constructors {
+		if tc.isAccessible(constructor) {
+			candidates = append(candidates, constructor)
+		}
+	}
+	if len(candidates) == 0 {
+		tc.errorf(attribute.Line, attribute.Column, "%s does not have an accessible constructor", attribute.Name)
+	}
+	_, args := tc.resolveOverload(attribute.Name, candidates, attribute.Args, attribute.Line, attribute.Column)
+	attribute.Args = args
+
+	for i, arg := range attribute.NamedArgs {
+		field, _, ok := tc.lookupField(attribute.Name, arg.Name)
+		if !ok || !hasModifier(field.Modifiers, lexer.PUBLIC) || hasModifier(field.Modifiers, lexer.STATIC) ||
+			hasModifier(field.Modifiers, lexer.READONLY) || hasModifier(field.Modifiers, lexer.CONST) {
+			tc.errorf(arg.Line, arg.Column, "%s is not a valid named attribute argument", arg.Name)
+		}
+		if !tc.isConstant(arg.Value) {
+			tc.errorf(arg.Value.GetLine(), arg.Value.GetColumn(), "an attribute argument must be a constant expression")
+		}
+		value := tc.CheckTargetTypedExpr(arg.Value, tc.registry.Parse(field.Type.Name))
+		if !tc.isTypeCompatible(tc.registry.Parse(field.Type.Name), value.Type) {
+			tc.errorf(arg.Line, arg.Column, "type mismatch: expected %s, got %s", field.Type.Name, value.Type)
+		}
+		attribute.NamedArgs[i].Value = value
+	}
+	return attribute
+}
+
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reports whether a declaration is marked with [Obsolete]
+func (tc *TypeChecker) isObsolete(attributes []ast.Attribute) bool {
+	for _, attribute := range attributes {
+		if attribute.Target == "" && tc.attributeClassName(attribute) == "ObsoleteAttribute" {
+			return true
+		}
+	}
+	return false
+}
+
+// Uses of obsolete declarations produce a warning, or an error if the second argument of Obsolete is true.
+// Nothing is reported inside of declarations that are obsolete themselves.
+func (tc *TypeChecker) checkObsolete(attributes []ast.Attribute, name string, line, column int) {
+	if tc.inObsolete {
+		return
+	}
+	for _, attribute := range attributes {
+		if attribute.Target != "" || tc.attributeClassName(attribute) != "ObsoleteAttribute" {
+			continue
+		}
+		message := fmt.Sprintf("%s is obsolete", name)
+		if len(attribute.Args) > 0 {
+			if text, ok := unwrapAttributeArg(attribute.Args[0]).(ast.StringExpr); ok {
+				message = fmt.Sprintf("%s: %s", message, text.Value)
+			}
+		}
+		if len(attribute.Args) > 1 {
+			if isError, ok := unwrapAttributeArg(attribute.Args[1]).(ast.BoolLiteralExpr); ok && isError.Value {
+				tc.errorf(line, column, "%s", message)
+			}
+		}
+		tc.warnf(line, column, "%s", message)
+	}
+}
+
+// Fields are found in the current class, its base classes and for statics in the enclosing classes
+func (tc *TypeChecker) checkFieldObsolete(expr ast.IdentifierExpr) {
+	for class := tc.currentClassName(); class != ""; class = enclosingTypeName(class) {
+		if field, declaring, ok := tc.lookupField(class, expr.Name); ok {
+			tc.checkObsolete(field.Attributes, declaring+"."+field.Identifier, expr.Line, expr.Column)
+			return
+		}
+	}
+}
+
+// The attributes of the class or enum a type name refers to
+func (tc *TypeChecker) typeAttributes(typ string) []ast.Attribute {
+	name := baseTypeName(typ)
+	if class, ok := tc.classes[name]; ok {
+		return class.Decl.Attributes
+	}
+	if enum, ok := tc.enums[name]; ok {
+		return enum.Attributes
+	}
+	return nil
+}
+
+func memberAttributes(member ast.ClassMember) []ast.Attribute {
+	switch member := member.(type) {
+	case ast.FieldDeclStmt:
+		return member.Attributes
+	case ast.MethodDeclStmt:
+		return member.Attributes
+	case ast.ConstructorDeclStmt:
+		return member.Attributes
+	case ast.IndexerDeclStmt:
+		return member.Attributes
+	case ast.EventDeclStmt:
+		return member.Attributes
+	case ast.PropertyDeclStmt:
+		return member.Attributes
+	case ast.ClassDeclStmt:
+		return member.Attributes
+	case ast.EnumDeclStmt:
+		return member.Attributes
+	case ast.DelegateDeclStmt:
+		return member.Attributes
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// List<T> and Dictionary<TKey, TValue> are built in since classes can not declare type parameters, so are
+// IEnumerable<T> and IEnumerator<T> that iterators return, the ordered sequences and groups of query
+// operators and Task<T> with the awaiter and method builder of async methods. Every use of a collection
+// type with new type arguments adds a class with the substituted members.
+func (tc *TypeChecker) instantiateCollection(typ types.Type) {
+	generic, ok := typ.(*types.Generic)
+	if !ok || tc.isUserObject(typ) {
+		return
+	}
+
+	name, arguments := generic.Name, generic.Arguments
+	instance := func(base string, arguments ...types.Type) types.Type {
+		return tc.registry.NewGeneric(base, arguments)
+	}
+	methods := map[string][]*MethodSymbol{}
+	public := []ast.Modifier{{Kind: lexer.PUBLIC}}
+	method := func(methodName string, returnType types.Type, parameters ...ast.Parameter) {
+		methods[methodName] = append(methods[methodName], &MethodSymbol{Class: typ.String(), Name: methodName, Modifiers: public, Parameters: parameters, ParameterTypes: tc.parameterTypes(parameters), ReturnType: returnType})
+	}
+
+	indexer := func(key, element types.Type) {
+		methods["this"] = []*MethodSymbol{{Class: typ.String(), Name: "this", Modifiers: public, Parameters: []ast.Parameter{collectionParameter(key, "key")}, ParameterTypes: []types.Type{key}, ReturnType: element, HasGetter: true, HasSetter: true}}
+	}
+
+	properties := map[string]ast.PropertyDeclStmt{}
+	fields := map[string]ast.FieldDeclStmt{}
+	getter := func(propertyName string, propertyType types.Type) {
+		property := ast.PropertyDeclStmt{Modifiers: public, Type: ast.Type{Name: propertyType.String()}, Name: propertyName, Accessors: []ast.Accessor{{Kind: "get"}}}
+		properties[propertyName] = property
+		fields[propertyName] = propertyField(property)
+	}
+
+	hasConstructor := true
+	baseTypes := []ast.Type{}
+	switch {
+	case name == "IEnumerable" && len(arguments) == 1:
+		tc.instantiateCollection(instance("IEnumerator", arguments[0]))
+		method("GetEnumerator", instance("IEnumerator", arguments[0]))
+		hasConstructor = false
+	case name == "IEnumerator" && len(arguments) == 1:
+		getter("Current", arguments[0])
+		method("MoveNext", types.Bool)
+		method("Reset", types.Void)
+		method("Dispose", types.Void)
+		hasConstructor = false
+	case name == "IOrderedEnumerable" && len(arguments) == 1:
+		tc.instantiateCollection(instance("IEnumerable", arguments[0]))
+		baseTypes = append(baseTypes, ast.Type{Name: instance("IEnumerable", arguments[0]).String()})
+		method("GetEnumerator", instance("IEnumerator", arguments[0]))
+		hasConstructor = false
+	case name == "IGrouping" && len(arguments) == 2:
+		tc.instantiateCollection(instance("IEnumerable", arguments[1]))
+		baseTypes = append(baseTypes, ast.Type{Name: instance("IEnumerable", arguments[1]).String()})
+		getter("Key", arguments[0])
+		method("GetEnumerator", instance("IEnumerator", arguments[1]))
+		hasConstructor = false
+	case name == "Task" && len(arguments) == 1:
+		tc.instantiateCollection(instance("TaskAwaiter", arguments[0]))
+		baseTypes = append(baseTypes, ast.Type{Name: "Task"})
+		getter("Result", arguments[0])
+		method("GetAwaiter", instance("TaskAwaiter", arguments[0]))
+		hasConstructor = false
+	case name == "TaskAwaiter" && len(arguments) == 1:
+		getter("IsCompleted", types.Bool)
+		method("GetResult", arguments[0])
+		method("OnCompleted", types.Void, collectionParameter(tc.registry.NewClass("Action"), "continuation"))
+		hasConstructor = false
+	case name == "AsyncTaskMethodBuilder" && len(arguments) == 1:
+		tc.instantiateCollection(instance("Task", arguments[0]))
+		getter("Task", instance("Task", arguments[0]))
+		method("SetResult", types.Void, collectionParameter(arguments[0], "result"))
+		method("SetException", types.Void, collectionParameter(tc.registry.NewClass("Exception"), "exception"))
+	case name == "List" && len(arguments) == 1:
+		tc.instantiateCollection(instance("IEnumerable", arguments[0]))
+		baseTypes = append(baseTypes, ast.Type{Name: instance("IEnumerable", arguments[0]).String()})
+		method("GetEnumerator", instance("IEnumerator", arguments[0]))
+		indexer(types.Int, arguments[0])
+		item := collectionParameter(arguments[0], "item")
+		method("Add", types.Void, item)
+		method("Contains", types.Bool, item)
+		method("Remove", types.Bool, item)
+		method("Clear", types.Void)
+	case name == "Dictionary" && len(arguments) == 2:
+		indexer(arguments[0], arguments[1])
+		key := collectionParameter(arguments[0], "key")
+		method("Add", types.Void, key, collectionParameter(arguments[1], "value"))
+		method("ContainsKey", types.Bool, key)
+		method("Remove", types.Bool, key)
+		method("Clear", types.Void)
+	default:
+		return
+	}
+
+	constructors := []*MethodSymbol{}
+	if hasConstructor {
+		constructors = append(constructors, &MethodSymbol{Class: typ.String(), Name: name, Modifiers: public, Parameters: []ast.Parameter{}, ParameterTypes: []types.Type{}, ReturnType: types.Void, IsConstructor: true})
+	}
+	tc.classes[typ.String()] = &ClassSymbol{
+		Decl:         ast.ClassDeclStmt{Modifiers: public, Kind: lexer.CLASS, Name: typ.String(), BaseTypes: baseTypes},
+		Fields:       fields,
+		Properties:   properties,
+		Methods:      methods,
+		Constructors: constructors,
+	}
+}
+
+func collectionParameter(typ types.Type, identifier string) ast.Parameter {
+	return ast.Parameter{Type: ast.Type{Name: typ.String()}, Identifier: identifier}
+}
@@ -0,0 +1,100 @@
+package typecheck
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/parser"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// A compilation type checks the compilation units of a project together,
+// so the declarations of one file can use the types declared in any other file
+type Compilation struct {
+	Units []ast.CompilationUnit
+	// Libraries do not need an entry point
+	IsLibrary bool
+
+	// Set by Check
+	EntryPoint *EntryPoint
+	Warnings   []string
+	Types      *types.Registry
+}
+
+func NewCompilation(units ...ast.CompilationUnit) *Compilation {
+	return &Compilation{Units: units}
+}
+
+// Reads and parses a source file and adds it to the compilation
+func (c *Compilation) AddFile(path string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.AddSource(path, string(source))
+}
+
+// Parses source as the file at path and adds it to the compilation.
+// Lexer and parser errors are returned prefixed with the path.
+func (c *Compilation) AddSource(path, source string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	c.Units = append(c.Units, parser.ParseFile(path, tokenize(path, source)))
+	return nil
+}
+
+// The lexer does not know the file it is reading
+func tokenize(path, source string) []lexer.Token {
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("%s: %v", path, r))
+		}
+	}()
+
+	return lexer.Tokenize(source)
+}
+
+// Type checks all units as a single program and selects the entry point unless the compilation is a library.
+// Partial classes may be spread over several files, top-level statements are only allowed in one of them.
+// The returned error names the file the declaration containing the error comes from.
+func (c *Compilation) Check() (program ast.Program, err error) {
+	tc := NewTypeChecker()
+	c.Types = tc.registry
+	defer func() {
+		c.Warnings = tc.warnings
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	topLevel := false
+	for _, unit := range c.Units {
+		program.Classes = append(program.Classes, unit.Program.Classes...)
+		program.Enums = append(program.Enums, unit.Program.Enums...)
+		program.Delegates = append(program.Delegates, unit.Program.Delegates...)
+		tc.nullableDirectives[unit.Path] = unit.Program.NullableDirectives
+		tc.usings[unit.Path] = unit.Program.Usings
+
+		if statements := unit.Program.Statements; len(statements) > 0 {
+			if topLevel {
+				tc.file = unit.Path
+				tc.errorf(statements[0].GetLine(), statements[0].GetColumn(), "only one compilation unit can have top-level statements")
+			}
+			topLevel = true
+			program.Classes = append(program.Classes, topLevelClass(statements, unit.Path))
+		}
+	}
+
+	program = tc.CheckProgram(&program)
+	if !c.IsLibrary {
+		entryPoint := tc.findEntryPoint(program)
+		c.EntryPoint = &entryPoint
+	}
+	return program, nil
+}
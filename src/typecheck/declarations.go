@@ -0,0 +1,469 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Merges partial classes, gives nested types their full name like Outer.Inner and registers
+// all classes and enums. Member types are resolved once every type name is known.
+func (tc *TypeChecker) declareTypes(classes []ast.ClassDeclStmt, enums []ast.EnumDeclStmt) []ast.ClassDeclStmt {
+	classes = tc.mergePartialClasses(classes)
+	for i := range classes {
+		tc.registerClass(&classes[i], "")
+	}
+	for _, enum := range enums {
+		tc.registerEnum(enum)
+	}
+
+	for i := range classes {
+		tc.resolveMemberTypes(&classes[i], "")
+	}
+	tc.declareClasses(classes)
+	return classes
+}
+
+// Partial declarations of the same class are merged into the first one
+func (tc *TypeChecker) mergePartialClasses(classes []ast.ClassDeclStmt) []ast.ClassDeclStmt {
+	merged := []ast.ClassDeclStmt{}
+	index := map[string]int{}
+
+	for _, class := range classes {
+		i, exists := index[class.Name]
+		if !exists {
+			index[class.Name] = len(merged)
+			merged = append(merged, class)
+			continue
+		}
+
+		tc.file = class.File
+		first := &merged[i]
+		firstPartial, partial := hasModifier(first.Modifiers, lexer.PARTIAL), hasModifier(class.Modifiers, lexer.PARTIAL)
+		if !firstPartial && !partial {
+			tc.errorf(class.Line, class.Column, "the namespace already contains a definition for %s", class.Name)
+		} else if !firstPartial || !partial {
+			tc.errorf(class.Line, class.Column, "missing partial modifier on declaration of type %s; another partial declaration of this type exists", class.Name)
+		}
+		if first.Kind != class.Kind {
+			tc.errorf(class.Line, class.Column, "partial declarations of %s must be all classes or all structs", class.Name)
+		}
+
+		firstAccess, access := accessModifiers(first.Modifiers), accessModifiers(class.Modifiers)
+		if len(firstAccess) > 0 && len(access) > 0 && !sameModifiers(firstAccess, access) {
+			tc.errorf(class.Line, class.Column, "partial declarations of %s have conflicting accessibility modifiers", class.Name)
+		} else if len(firstAccess) == 0 {
+			first.Modifiers = append(first.Modifiers, access...)
+		}
+		if hasModifier(class.Modifiers, lexer.STATIC) && !hasModifier(first.Modifiers, lexer.STATIC) {
+			first.Modifiers = append(first.Modifiers, ast.Modifier{Kind: lexer.STATIC})
+		}
+
+		if len(class.BaseTypes) > 0 {
+			if len(first.BaseTypes) > 0 && first.BaseTypes[0].Name != class.BaseTypes[0].Name {
+				tc.errorf(class.Line, class.Column, "partial declarations of %s must not specify different base classes", class.Name)
+			}
+			first.BaseTypes = class.BaseTypes
+		}
+
+		first.Body.Members = append(first.Body.Members, class.Body.Members...)
+	}
+
+	for i := range merged {
+		merged[i].Body.Members = dropImplicitConstructors(merged[i].Body.Members)
+	}
+	return merged
+}
+
+// Every partial declaration without a constructor got a standard constructor from the parser.
+// Only one of them is kept and only if no declaration defines a constructor itself.
+func dropImplicitConstructors(members []ast.ClassMember) []ast.ClassMember {
+	explicit := false
+	for _, member := range members {
+		if constructor, ok := member.(ast.ConstructorDeclStmt); ok && !constructor.IsImplicit && !hasModifier(constructor.Modifiers, lexer.STATIC) {
+			explicit = true
+		}
+	}
+
+	result := []ast.ClassMember{}
+	kept := false
+	for _, member := range members {
+		if constructor, ok := member.(ast.ConstructorDeclStmt); ok && constructor.IsImplicit {
+			if explicit || kept {
+				continue
+			}
+			kept = true
+		}
+		result = append(result, member)
+	}
+	return result
+}
+
+func accessModifiers(modifiers []ast.Modifier) []ast.Modifier {
+	access := []ast.Modifier{}
+	for _, modifier := range modifiers {
+		switch modifier.Kind {
+		case lexer.PUBLIC, lexer.PRIVATE, lexer.PROTECTED, lexer.INTERNAL:
+			access = append(access, modifier)
+		}
+	}
+	return access
+}
+
+func sameModifiers(a, b []ast.Modifier) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, modifier := range a {
+		if !hasModifier(b, modifier.Kind) {
+			return false
+		}
+	}
+	return true
+}
+
+// Registers a class and its nested types under their full names. The nested declarations
+// inside of the class body are renamed as well, so checking them later sees the full name.
+func (tc *TypeChecker) registerClass(class *ast.ClassDeclStmt, outer string) {
+	tc.file = class.File
+	if outer != "" {
+		if class.Name == simpleTypeName(outer) {
+			tc.errorf(class.Line, class.Column, "%s: member names cannot be the same as their enclosing type", class.Name)
+		}
+		class.Name = outer + "." + class.Name
+	}
+	tc.checkTypeNameUnused(class.Name, class.Line, class.Column)
+	tc.classes[class.Name] = &ClassSymbol{Decl: *class}
+
+	nested := []ast.ClassDeclStmt{}
+	for _, member := range class.Body.Members {
+		if inner, ok := member.(ast.ClassDeclStmt); ok {
+			nested = append(nested, inner)
+		}
+	}
+	nested = tc.mergePartialClasses(nested)
+
+	members := []ast.ClassMember{}
+	for _, member := range class.Body.Members {
+		switch member := member.(type) {
+		case ast.ClassDeclStmt:
+			continue
+		case ast.EnumDeclStmt:
+			tc.file = member.File
+			if member.Name == simpleTypeName(class.Name) {
+				tc.errorf(member.Line, member.Column, "%s: member names cannot be the same as their enclosing type", member.Name)
+			}
+			member.Name = class.Name + "." + member.Name
+			tc.registerEnum(member)
+			members = append(members, member)
+		default:
+			members = append(members, member)
+		}
+	}
+	for i := range nested {
+		tc.registerClass(&nested[i], class.Name)
+		members = append(members, nested[i])
+	}
+	class.Body.Members = members
+}
+
+func (tc *TypeChecker) registerEnum(enum ast.EnumDeclStmt) {
+	tc.file = enum.File
+	tc.checkTypeNameUnused(enum.Name, enum.Line, enum.Column)
+	tc.enums[enum.Name] = enum
+}
+
+func (tc *TypeChecker) checkTypeNameUnused(name string, line, column int) {
+	_, isClass := tc.classes[name]
+	_, isEnum := tc.enums[name]
+	if !isClass && !isEnum {
+		return
+	}
+	if outer := enclosingTypeName(name); outer != "" {
+		tc.errorf(line, column, "the type %s already contains a definition for %s", outer, simpleTypeName(name))
+	}
+	tc.errorf(line, column, "the namespace already contains a definition for %s", name)
+}
+
+// Resolves the types of base classes, fields, methods and constructors of a class and its nested classes
+func (tc *TypeChecker) resolveMemberTypes(class *ast.ClassDeclStmt, outer string) {
+	tc.file = class.File
+	outerObsolete := tc.inObsolete
+	defer func() { tc.inObsolete = outerObsolete }()
+	tc.inObsolete = outerObsolete || tc.isObsolete(class.Attributes)
+	classObsolete := tc.inObsolete
+
+	for i := range class.BaseTypes {
+		class.BaseTypes[i] = tc.resolveType(class.BaseTypes[i], outer)
+	}
+	class.Parameters = tc.resolveParameterTypes(class.Parameters, class.Name)
+
+	scope := class.Name
+	for i, member := range class.Body.Members {
+		tc.file = member.GetFile()
+		tc.inObsolete = classObsolete || tc.isObsolete(memberAttributes(member))
+		switch member := member.(type) {
+		case ast.FieldDeclStmt:
+			member.Type = tc.resolveType(member.Type, scope)
+			class.Body.Members[i] = member
+		case ast.MethodDeclStmt:
+			member.ReturnType = tc.resolveType(member.ReturnType, scope)
+			member.Parameters = tc.resolveParameterTypes(member.Parameters, scope)
+			class.Body.Members[i] = member
+		case ast.ConstructorDeclStmt:
+			member.Parameters = tc.resolveParameterTypes(member.Parameters, scope)
+			class.Body.Members[i] = member
+		case ast.IndexerDeclStmt:
+			member.Type = tc.resolveType(member.Type, scope)
+			member.Parameters = tc.resolveParameterTypes(member.Parameters, scope)
+			class.Body.Members[i] = member
+		case ast.EventDeclStmt:
+			member.Type = tc.resolveType(member.Type, scope)
+			class.Body.Members[i] = member
+		case ast.PropertyDeclStmt:
+			member.Type = tc.resolveType(member.Type, scope)
+			class.Body.Members[i] = member
+		case ast.ClassDeclStmt:
+			tc.resolveMemberTypes(&member, scope)
+			class.Body.Members[i] = member
+		}
+	}
+	tc.classes[class.Name].Decl = *class
+}
+
+func (tc *TypeChecker) resolveParameterTypes(parameters []ast.Parameter, scope string) []ast.Parameter {
+	resolved := make([]ast.Parameter, len(parameters))
+	for i, param := range parameters {
+		param.Type = tc.resolveType(param.Type, scope)
+		resolved[i] = param
+	}
+	return resolved
+}
+
+// Builds the member tables of the classes and their nested classes
+func (tc *TypeChecker) declareClasses(classes []ast.ClassDeclStmt) {
+	for _, class := range classes {
+		tc.classes[class.Name] = tc.declareClass(class)
+
+		nested := []ast.ClassDeclStmt{}
+		for _, member := range class.Body.Members {
+			if inner, ok := member.(ast.ClassDeclStmt); ok {
+				nested = append(nested, inner)
+			}
+		}
+		tc.declareClasses(nested)
+	}
+}
+
+// Resolves a type as written inside of scope to its full name and checks that it is accessible from there.
+// Unknown names are left as they are so that the caller can report them.
+func (tc *TypeChecker) resolveType(typ ast.Type, scope string) ast.Type {
+	name, ok := tc.lookupTypeName(typ.Name, scope)
+	if !ok {
+		return typ
+	}
+	if underlying, isNullable := strings.CutSuffix(name, "?"); isNullable && !tc.isValueType(tc.registry.Parse(underlying)) {
+		name = tc.annotateNullable(&typ, underlying)
+	}
+	tc.checkObsolete(tc.typeAttributes(name), baseTypeName(name), typ.Line, typ.Column)
+	tc.checkTupleElementNames(tc.registry.Parse(name), typ.Line, typ.Column)
+	if declaring := enclosingTypeName(baseTypeName(name)); declaring != "" && isPrivate(tc.typeModifiers(baseTypeName(name))) &&
+		scope != declaring && !strings.HasPrefix(scope, declaring+".") {
+		tc.errorf(typ.Line, typ.Column, "%s is inaccessible due to its protection level", baseTypeName(name))
+	}
+	typ.Name = name
+	return typ
+}
+
+// Resolves a type used inside of the body of the current class
+func (tc *TypeChecker) resolveLocalType(typ ast.Type) ast.Type {
+	return tc.resolveType(typ, tc.currentClassName())
+}
+
+// Nested types are searched in the enclosing classes from the inside out
+func (tc *TypeChecker) lookupTypeName(name, scope string) (string, bool) {
+	if strings.HasSuffix(name, "[]") {
+		element, ok := tc.lookupTypeName(strings.TrimSuffix(name, "[]"), scope)
+		return tc.withoutAnnotation(element) + "[]", ok
+	}
+	if strings.HasSuffix(name, "?") {
+		underlying, ok := tc.lookupTypeName(strings.TrimSuffix(name, "?"), scope)
+		return underlying + "?", ok
+	}
+	if tuple, isTuple := tc.registry.Parse(name).(*types.Tuple); isTuple {
+		known := true
+		elements := make([]types.Type, len(tuple.Elements))
+		for i, element := range tuple.Elements {
+			resolved, ok := tc.lookupTypeName(element.String(), scope)
+			elements[i] = tc.registry.Parse(tc.withoutAnnotation(resolved))
+			known = known && ok
+		}
+		return tc.registry.NewTuple(elements, tuple.Names).String(), known
+	}
+	if generic, isGeneric := tc.registry.Parse(name).(*types.Generic); isGeneric {
+		arguments := make([]types.Type, len(generic.Arguments))
+		for i, argument := range generic.Arguments {
+			resolved, _ := tc.lookupTypeName(argument.String(), scope)
+			if generic.Name != "Nullable" {
+				resolved = tc.withoutAnnotation(resolved)
+			}
+			arguments[i] = tc.registry.Parse(resolved)
+		}
+		// Nullable<T> is the same type as T?
+		if generic.Name == "Nullable" && len(arguments) == 1 {
+			return arguments[0].String() + "?", tc.isKnownType(arguments[0].String())
+		}
+		resolved := tc.registry.NewGeneric(generic.Name, arguments)
+		tc.instantiateCollection(resolved)
+		return resolved.String(), true
+	}
+
+	head, rest := name, ""
+	if dot := strings.Index(name, "."); dot >= 0 {
+		head, rest = name[:dot], name[dot:]
+	}
+	for current := scope; ; current = enclosingTypeName(current) {
+		candidate := head
+		if current != "" {
+			candidate = current + "." + head
+		}
+		if tc.isKnownType(candidate) {
+			return candidate + rest, tc.isKnownType(candidate + rest)
+		}
+		if current == "" {
+			return name, false
+		}
+	}
+}
+
+// Returns the full name of the type an expression like Outer or Outer.Inner refers to
+func (tc *TypeChecker) typeNameOf(expr ast.Expr) (string, bool) {
+	name := qualifiedName(expr)
+	if name == "" {
+		return "", false
+	}
+	head := strings.Split(name, ".")[0]
+	if _, isVariable := tc.env.Lookup(head); isVariable {
+		return "", false
+	}
+	full, ok := tc.lookupTypeName(name, tc.currentClassName())
+	if !ok || (!tc.isClassName(full) && !tc.isEnumName(full)) {
+		return "", false
+	}
+	return full, true
+}
+
+// Returns the dotted name of an expression like a.b.c or an empty string
+func qualifiedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case ast.IdentifierExpr:
+		return e.Name
+	case ast.MemberAccessExpr:
+		if receiver := qualifiedName(e.Receiver); receiver != "" {
+			return receiver + "." + e.Member
+		}
+	}
+	return ""
+}
+
+func (tc *TypeChecker) typeModifiers(name string) []ast.Modifier {
+	if class, ok := tc.classes[name]; ok {
+		return class.Decl.Modifiers
+	}
+	return tc.enums[name].Modifiers
+}
+
+func (tc *TypeChecker) isEnum(typ types.Type) bool {
+	class, ok := typ.(*types.Class)
+	return ok && tc.isEnumName(class.Name)
+}
+
+// Like isEnum for the qualified name of a type
+func (tc *TypeChecker) isEnumName(name string) bool {
+	_, ok := tc.enums[name]
+	return ok
+}
+
+func (tc *TypeChecker) isStruct(typ types.Type) bool {
+	class, ok := tc.classSymbol(typ)
+	return ok && class.Decl.Kind == lexer.STRUCT
+}
+
+// Like isStruct for the qualified name of a type
+func (tc *TypeChecker) isStructName(name string) bool {
+	class, ok := tc.classes[name]
+	return ok && class.Decl.Kind == lexer.STRUCT
+}
+
+// Enum members like Color.Red are constants of their enum type
+func (tc *TypeChecker) isEnumMember(expr ast.Expr) bool {
+	access, ok := expr.(ast.MemberAccessExpr)
+	if !ok {
+		return false
+	}
+	enum, ok := tc.typeNameOf(access.Receiver)
+	return ok && tc.isEnumName(enum)
+}
+
+// Strips array suffixes and type arguments from a type name
+func baseTypeName(typ string) string {
+	typ = strings.TrimRight(typ, "[]")
+	if start := strings.Index(typ, "<"); start >= 0 {
+		return typ[:start]
+	}
+	return typ
+}
+
+// Returns Outer for Outer.Inner and an empty string for types that are not nested
+func enclosingTypeName(typ string) string {
+	if dot := strings.LastIndex(typ, "."); dot >= 0 {
+		return typ[:dot]
+	}
+	return ""
+}
+
+// Returns Inner for Outer.Inner
+func simpleTypeName(typ string) string {
+	return typ[strings.LastIndex(typ, ".")+1:]
+}
+
+func (tc *TypeChecker) CheckEnumDeclStmt(enum *ast.EnumDeclStmt) {
+	tc.file = enum.File
+	enum.Attributes = tc.checkAttributes(enum.Attributes, targetEnum)
+	names := map[string]bool{}
+	for i := range enum.Members {
+		member := &enum.Members[i]
+		if names[member.Name] {
+			tc.errorf(member.Line, member.Column, "the type %s already contains a definition for %s", enum.Name, member.Name)
+		}
+		names[member.Name] = true
+		member.Attributes = tc.checkAttributes(member.Attributes, targetField)
+
+		if member.Value == nil {
+			continue
+		}
+		if !tc.isConstant(member.Value) {
+			tc.errorf(member.Value.GetLine(), member.Value.GetColumn(), "the value of enum member %s must be a compile-time constant", member.Name)
+		}
+		value := tc.CheckExpr(member.Value)
+		if value.Type != types.Int {
+			tc.errorf(member.Value.GetLine(), member.Value.GetColumn(), "cannot implicitly convert type %s to int", value.Type)
+		}
+		member.Value = value
+	}
+}
+
+// Types member access expressions on enums like Color.Red
+func (tc *TypeChecker) checkEnumMemberAccess(expr ast.MemberAccessExpr, enumName string) ast.TypedExpr {
+	tc.checkObsolete(tc.enums[enumName].Attributes, enumName, expr.Line, expr.Column)
+	for _, member := range tc.enums[enumName].Members {
+		if member.Name == expr.Member {
+			tc.checkObsolete(member.Attributes, enumName+"."+member.Name, expr.Line, expr.Column)
+			return ast.TypedExpr{Type: tc.registry.NewClass(enumName), Expr: expr, Line: expr.Line, Column: expr.Column}
+		}
+	}
+	tc.errorf(expr.Line, expr.Column, "%s does not contain a definition for %s", enumName, expr.Member)
+	return ast.TypedExpr{Type: types.Error}
+}
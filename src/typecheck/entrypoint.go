@@ -0,0 +1,157 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// The method a program starts with
+type EntryPoint struct {
+	Class  string
+	Method ast.MethodDeclStmt
+}
+
+// Top-level statements become the static Main method of the partial class Program, so a
+// declaration of partial class Program can add further members. Main returns int if any
+// of the statements returns a value, statements that await make it return Task or Task<int>.
+func topLevelClass(statements []ast.Stmt, file string) ast.ClassDeclStmt {
+	line, column := statements[0].GetLine(), statements[0].GetColumn()
+
+	returnType := "void"
+	for _, stmt := range statements {
+		if returnsValue(stmt) {
+			returnType = "int"
+		}
+	}
+
+	main := ast.MethodDeclStmt{
+		Modifiers:  []ast.Modifier{{Kind: lexer.STATIC}},
+		ReturnType: ast.Type{Name: returnType, Line: line, Column: column},
+		Name:       "Main",
+		Parameters: []ast.Parameter{{Type: ast.Type{Name: "string[]", Line: line, Column: column}, Identifier: "args"}},
+		Body:       ast.BlockStmt{Body: statements, Line: line, Column: column},
+		IsTopLevel: true,
+		File:       file,
+		Line:       line,
+		Column:     column,
+	}
+	constructor := ast.ConstructorDeclStmt{
+		Modifiers:  []ast.Modifier{{Kind: lexer.PUBLIC}},
+		Name:       "Program",
+		Parameters: []ast.Parameter{},
+		Body:       ast.BlockStmt{Body: []ast.Stmt{}},
+		IsImplicit: true,
+		File:       file,
+	}
+
+	return ast.ClassDeclStmt{
+		Modifiers: []ast.Modifier{{Kind: lexer.PARTIAL}},
+		Kind:      lexer.CLASS,
+		Name:      "Program",
+		Body:      ast.ClassBody{Members: []ast.ClassMember{main, constructor}},
+		File:      file,
+		Line:      line,
+		Column:    column,
+	}
+}
+
+// Reports whether a statement contains a return with a value outside of local functions
+func returnsValue(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case ast.ReturnStmt:
+		return s.Value != nil
+	case ast.BlockStmt:
+		for _, inner := range s.Body {
+			if returnsValue(inner) {
+				return true
+			}
+		}
+	case ast.IfStmt:
+		return returnsValue(s.Then) || (s.Else != nil && returnsValue(s.Else))
+	case ast.WhileStmt:
+		return returnsValue(s.Body)
+	case ast.TryStmt:
+		for _, clause := range s.Catches {
+			if returnsValue(clause.Body) {
+				return true
+			}
+		}
+		return returnsValue(s.Body) || (s.Finally != nil && returnsValue(s.Finally))
+	case ast.SwitchStmt:
+		for _, section := range s.Sections {
+			if returnsValue(section.Body) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Finds the entry point among the static Main methods of the program. Top-level statements
+// take precedence, other Main methods are ignored with a warning.
+func (tc *TypeChecker) findEntryPoint(program ast.Program) EntryPoint {
+	candidates := []EntryPoint{}
+	var topLevel *EntryPoint
+	tc.collectEntryPoints(program.Classes, &candidates, &topLevel)
+
+	if topLevel != nil {
+		for _, candidate := range candidates {
+			tc.file = candidate.Method.File
+			tc.warnf(candidate.Method.Line, candidate.Method.Column, "the entry point of the program is global code; ignoring %s.Main entry point", candidate.Class)
+		}
+		return *topLevel
+	}
+
+	if len(candidates) == 0 {
+		panic("Error: program does not contain a static Main method suitable for an entry point")
+	}
+	if len(candidates) > 1 {
+		second := candidates[1].Method
+		tc.file = second.File
+		tc.errorf(second.Line, second.Column, "program has more than one entry point defined: %s.Main and %s.Main", candidates[0].Class, candidates[1].Class)
+	}
+	return candidates[0]
+}
+
+func (tc *TypeChecker) collectEntryPoints(classes []ast.ClassDeclStmt, candidates *[]EntryPoint, topLevel **EntryPoint) {
+	for _, class := range classes {
+		nested := []ast.ClassDeclStmt{}
+		for _, member := range class.Body.Members {
+			switch member := member.(type) {
+			case ast.MethodDeclStmt:
+				if member.Name != "Main" || !hasModifier(member.Modifiers, lexer.STATIC) {
+					continue
+				}
+				if member.IsTopLevel {
+					*topLevel = &EntryPoint{Class: class.Name, Method: member}
+				} else if tc.isEntryPointSignature(member) {
+					*candidates = append(*candidates, EntryPoint{Class: class.Name, Method: member})
+				} else {
+					tc.file = member.File
+					tc.warnf(member.Line, member.Column, "%s.Main has the wrong signature to be an entry point", class.Name)
+				}
+			case ast.ClassDeclStmt:
+				nested = append(nested, member)
+			}
+		}
+		tc.collectEntryPoints(nested, candidates, topLevel)
+	}
+}
+
+// Main returns void, int, Task or Task<int> and takes no parameters or a string array
+func (tc *TypeChecker) isEntryPointSignature(method ast.MethodDeclStmt) bool {
+	switch tc.registry.Parse(method.ReturnType.Name) {
+	case types.Void, types.Int, tc.registry.NewClass("Task"), tc.registry.NewGeneric("Task", []types.Type{types.Int}):
+	default:
+		return false
+	}
+
+	switch len(method.Parameters) {
+	case 0:
+		return true
+	case 1:
+		return tc.registry.Parse(method.Parameters[0].Type.Name) == tc.registry.NewArray(types.String) && len(method.Parameters[0].Modifiers) == 0
+	}
+	return false
+}
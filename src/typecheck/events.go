@@ -0,0 +1,159 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Events are members of a delegate type that other classes can only subscribe to with += and unsubscribe
+// from with -=. Inside of the declaring class a field-like event can be used like a field, an event with
+// add and remove accessors has no storage and can only be subscribed to there as well.
+
+func (tc *TypeChecker) CheckEventDeclStmt(event *ast.EventDeclStmt) {
+	event.Attributes = tc.checkAttributes(event.Attributes, targetEvent)
+	tc.checkNotAsync(event.Modifiers, event.Line, event.Column)
+	if !tc.isDelegateType(tc.registry.Parse(event.Type.Name)) {
+		tc.errorf(event.Type.Line, event.Type.Column, "the event %s must be of a delegate type, not %s", event.Name, event.Type.Name)
+	}
+
+	defined := map[string]bool{}
+	for i := range event.Accessors {
+		accessor := &event.Accessors[i]
+		if defined[accessor.Kind] {
+			tc.errorf(accessor.Line, accessor.Column, "the %s accessor is already defined", accessor.Kind)
+		}
+		defined[accessor.Kind] = true
+		tc.checkAccessor(accessor, nil, event.Type)
+	}
+	if len(event.Accessors) > 0 && !(defined["add"] && defined["remove"]) {
+		tc.errorf(event.Line, event.Column, "the event %s must have both add and remove accessors", event.Name)
+	}
+}
+
+// Field-like events are stored in a field of the delegate type that only the declaring class can see
+func (tc *TypeChecker) defineEvent(event ast.EventDeclStmt) {
+	tc.defineField(ast.FieldDeclStmt{Modifiers: event.Modifiers, Type: event.Type, Identifier: event.Name})
+}
+
+// Finds an event in a class or one of its base classes, also returns the name of the declaring class
+func (tc *TypeChecker) lookupEvent(className, eventName string) (ast.EventDeclStmt, string, bool) {
+	visited := map[string]bool{}
+	for current, ok := className, tc.isClassName(className); ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		visited[current] = true
+		if event, exists := tc.classes[current].Events[eventName]; exists {
+			return event, current, true
+		}
+	}
+	return ast.EventDeclStmt{}, "", false
+}
+
+func (tc *TypeChecker) checkEventAccessible(event ast.EventDeclStmt, declaring string, line, column int) {
+	if !tc.isMemberAccessible(event.Modifiers, declaring) {
+		tc.errorf(line, column, "%s.%s is inaccessible due to its protection level", declaring, event.Name)
+	}
+}
+
+// Reports uses of an event other than += and -= where they are not allowed
+func (tc *TypeChecker) checkEventUse(event ast.EventDeclStmt, declaring string, line, column int) {
+	tc.checkEventAccessible(event, declaring, line, column)
+	if len(event.Accessors) > 0 {
+		tc.errorf(line, column, "the event %s.%s can only appear on the left hand side of += or -=", declaring, event.Name)
+	}
+	if !isNestedIn(tc.currentClassName(), declaring) {
+		tc.errorf(line, column, "the event %s.%s can only appear on the left hand side of += or -= (except when used from within the type %s)", declaring, event.Name, declaring)
+	}
+}
+
+// receiver.Event used as a value, only field-like events of the current class can be
+func (tc *TypeChecker) checkEventAccess(expr ast.MemberAccessExpr, event ast.EventDeclStmt, declaring string) ast.TypedExpr {
+	tc.checkEventUse(event, declaring, expr.Line, expr.Column)
+	return ast.TypedExpr{Type: tc.registry.Parse(event.Type.Name), Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// receiver.Event(args) invokes the delegate stored in a field-like event
+func (tc *TypeChecker) checkEventInvocation(expr ast.MethodCallExpr, event ast.EventDeclStmt, declaring string) ast.TypedExpr {
+	tc.checkEventUse(event, declaring, expr.Line, expr.Column)
+	access := ast.MemberAccessExpr{Receiver: expr.Receiver, Member: expr.MethodName, Line: expr.Line, Column: expr.Column}
+	callee := ast.TypedExpr{Type: tc.registry.Parse(event.Type.Name), Expr: access, Line: expr.Line, Column: expr.Column}
+	return tc.CheckInvocationExpr(ast.InvocationExpr{Callee: callee, Args: expr.Args, Line: expr.Line, Column: expr.Column})
+}
+
+// Finds the event that an assignee like Clicked, button.Clicked or Button.Clicked refers to. The receiver
+// is the typed object the event belongs to or the class name for static events.
+func (tc *TypeChecker) eventTarget(assignee ast.Expr) (ast.Expr, ast.EventDeclStmt, string, bool) {
+	switch target := assignee.(type) {
+	case ast.IdentifierExpr:
+		if info, ok := tc.env.Lookup(target.Name); ok && !info.IsField {
+			break
+		}
+		className := tc.currentClassName()
+		event, declaring, ok := tc.lookupEvent(className, target.Name)
+		if !ok {
+			break
+		}
+		if hasModifier(event.Modifiers, lexer.STATIC) {
+			return ast.IdentifierExpr{Name: declaring, Line: target.Line, Column: target.Column}, event, declaring, true
+		}
+		this := ast.ThisExpr{Line: target.Line, Column: target.Column}
+		return ast.TypedExpr{Type: tc.registry.NewClass(className), Expr: this, Line: target.Line, Column: target.Column}, event, declaring, true
+	case ast.MemberAccessExpr:
+		if className, isTypeName := tc.typeNameOf(target.Receiver); isTypeName {
+			event, declaring, ok := tc.lookupEvent(className, target.Member)
+			if ok && !hasModifier(event.Modifiers, lexer.STATIC) {
+				tc.errorf(target.Line, target.Column, "an object reference is required for the non-static event %s.%s", declaring, event.Name)
+			}
+			return target.Receiver, event, declaring, ok
+		}
+		receiver := tc.CheckExpr(target.Receiver)
+		event, declaring, ok := tc.lookupEvent(receiver.Type.String(), target.Member)
+		if ok && hasModifier(event.Modifiers, lexer.STATIC) {
+			tc.errorf(target.Line, target.Column, "the static event %s.%s cannot be accessed with an instance reference; qualify it with a type name instead", declaring, event.Name)
+		}
+		if ok {
+			tc.checkDereference(receiver, target.Line, target.Column)
+		}
+		return receiver, event, declaring, ok
+	}
+	return nil, ast.EventDeclStmt{}, "", false
+}
+
+// Event += handler calls the add accessor and Event -= handler the remove accessor of the event
+func (tc *TypeChecker) checkEventSubscription(assignment ast.AssignmentExpr) (ast.TypedExpr, bool) {
+	if assignment.Operator.Kind != lexer.PLUS_EQUALS && assignment.Operator.Kind != lexer.MINUS_EQUALS {
+		return ast.TypedExpr{}, false
+	}
+	receiver, event, declaring, ok := tc.eventTarget(assignment.Assignee)
+	if !ok {
+		return ast.TypedExpr{}, false
+	}
+	tc.checkEventAccessible(event, declaring, assignment.Line, assignment.Column)
+	tc.checkObsolete(event.Attributes, declaring+"."+event.Name, assignment.Line, assignment.Column)
+
+	eventType := tc.registry.Parse(event.Type.Name)
+	handler := tc.CheckTargetTypedExpr(assignment.Value, eventType)
+	if !tc.isTypeCompatible(eventType, handler.Type) {
+		tc.errorf(assignment.Line, assignment.Column, "type mismatch: %s and %s", event.Type.Name, handler.Type)
+	}
+
+	accessor := "add_" + event.Name
+	if assignment.Operator.Kind == lexer.MINUS_EQUALS {
+		accessor = "remove_" + event.Name
+	}
+	subscription := ast.EventSubscriptionExpr{
+		Receiver: receiver,
+		Event:    event.Name,
+		Operator: assignment.Operator,
+		Handler:  handler,
+		Accessor: &ast.MethodSignature{
+			Class:          declaring,
+			Name:           accessor,
+			ParameterTypes: []types.Type{eventType},
+			ReturnType:     types.Void,
+			IsStatic:       hasModifier(event.Modifiers, lexer.STATIC),
+		},
+		Line:   assignment.Line,
+		Column: assignment.Column,
+	}
+	return ast.TypedExpr{Type: types.Void, Expr: subscription, Line: assignment.Line, Column: assignment.Column}, true
+}
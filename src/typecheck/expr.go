@@ -1,33 +1,72 @@
 package typecheck
 
-import "github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+import (
+	"math"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
 
 // TODO: Implement rest of check expr but with some sort of structure to control this monster of code
 func (tc *TypeChecker) CheckExpr(expr ast.Expr) ast.TypedExpr {
 	switch e := expr.(type) {
+	case ast.TypedExpr:
+		// Expressions the type checker synthesizes can have operands that are checked already
+		return e
 	case ast.IntLiteralExpr:
-		return ast.TypedExpr{Type: "int", Expr: e}
+		return ast.TypedExpr{Type: integerLiteralType(e), Expr: e, Line: e.Line, Column: e.Column}
+	case ast.RealLiteralExpr:
+		return tc.checkRealLiteral(e)
 	case ast.BoolLiteralExpr:
-		return ast.TypedExpr{Type: "bool", Expr: e}
+		return ast.TypedExpr{Type: types.Bool, Expr: e, Line: e.Line, Column: e.Column}
 	case ast.StringExpr:
-		return ast.TypedExpr{Type: "string", Expr: e}
+		return ast.TypedExpr{Type: types.String, Expr: e, Line: e.Line, Column: e.Column}
 	case ast.IdentifierExpr:
 		return tc.CheckIdentifierExpr(e)
 	case ast.NullLiteralExpr:
-		return ast.TypedExpr{Type: "null", Expr: e}
+		return ast.TypedExpr{Type: types.Null, Expr: e, Line: e.Line, Column: e.Column}
 	case ast.CharLiteralExpr:
-		return ast.TypedExpr{Type: "char", Expr: e}
+		return ast.TypedExpr{Type: types.Char, Expr: e, Line: e.Line, Column: e.Column}
 	case ast.BinaryExpr:
 		return tc.CheckBinaryExpr(e)
 	case ast.MethodCallExpr:
 		return tc.CheckMethodCallExpr(e)
 	case ast.AssignmentExpr:
-		assigneeType := tc.CheckExpr(e.Assignee)
-		valueType := tc.CheckExpr(e.Value)
-		if assigneeType != valueType {
-			tc.errorf(e.Line, e.Column, "type mismatch: %s and %s", assigneeType, valueType)
+		if targets, ok := e.Assignee.(ast.TupleExpr); ok {
+			return tc.CheckDeconstruction(targets, e)
+		}
+		isCompound := false
+		if e.Operator.Kind != lexer.ASSIGNMENT {
+			if subscription, ok := tc.checkEventSubscription(e); ok {
+				return subscription
+			}
+			e = desugarCompoundAssignment(e)
+			isCompound = true
+		}
+		// The assignee does not have to be definitely assigned before, only after the assignment
+		assigneeType, info, isVariable := tc.checkAssignee(e.Assignee, false, "the left-hand side of an assignment", e.Line, e.Column)
+		valueType := tc.CheckTargetTypedExpr(e.Value, assigneeType.Type)
+		// b += 1 on a byte b is b = (byte)(b + 1)
+		if isCompound && types.IsNumeric(valueType.Type) && types.IsNumeric(assigneeType.Type) && !tc.isTypeCompatible(assigneeType.Type, valueType.Type) {
+			cast := ast.CastExpr{Type: ast.Type{Name: assigneeType.Type.String(), Line: e.Line, Column: e.Column}, Expression: valueType, Line: e.Line, Column: e.Column}
+			valueType = ast.TypedExpr{Type: assigneeType.Type, Expr: cast, Line: e.Line, Column: e.Column}
+		}
+		if !tc.isTypeCompatible(assigneeType.Type, valueType.Type) {
+			tc.errorf(e.Line, e.Column, "type mismatch: %s and %s", assigneeType.Type, valueType.Type)
+		}
+		if tc.isPossibleNullConversion(assigneeType.Type, isVariable && info.IsNullable, valueType, e.Line) {
+			tc.warnf(e.Line, e.Column, "possible null reference assignment")
+		}
+		if id, ok := e.Assignee.(ast.IdentifierExpr); ok {
+			tc.env.MarkAssigned(id.Name)
+		}
+		if name := variableName(assigneeType); name != "" {
+			tc.updateNullState(name, valueType)
 		}
-		return assigneeType
+		e.Assignee = assigneeType
+		e.Value = valueType
+		return ast.TypedExpr{Type: assigneeType.Type, Expr: e, Line: e.Line, Column: e.Column}
 	case ast.PreDecrementExpr:
 		return tc.CheckUnaryExpr(e)
 	case ast.PreIncrementExpr:
@@ -36,53 +75,764 @@ func (tc *TypeChecker) CheckExpr(expr ast.Expr) ast.TypedExpr {
 		return tc.CheckUnaryExpr(e)
 	case ast.PostIncrementExpr:
 		return tc.CheckUnaryExpr(e)
+	case ast.ConstructorCallExpr:
+		return tc.CheckConstructorCallExpr(e)
+	case ast.ArrayCreationExpr:
+		return tc.CheckArrayCreationExpr(e)
+	case ast.SwitchExpr:
+		return tc.CheckSwitchExpr(e, nil)
+	case ast.IsPatternExpr:
+		return tc.CheckIsPatternExpr(e)
+	case ast.AsExpr:
+		return tc.CheckAsExpr(e)
+	case ast.PrefixExpr:
+		return tc.CheckPrefixExpr(e)
+	case ast.CastExpr:
+		return tc.CheckCastExpr(e)
+	case ast.ElementAccessExpr:
+		return tc.checkElementAccess(e, true, false)
+	case ast.MemberAccessExpr:
+		return tc.checkMemberAccess(e, true, false)
+	case ast.ThisExpr:
+		tc.checkThisAccess(e.Line, e.Column)
+		return ast.TypedExpr{Type: tc.registry.NewClass(tc.currentClassName()), Expr: e, Line: e.Line, Column: e.Column}
+	case ast.WithExpr:
+		return tc.CheckWithExpr(e)
+	case ast.ValueEqualsExpr:
+		return tc.CheckValueEqualsExpr(e)
+	case ast.ValueHashExpr:
+		return tc.CheckValueHashExpr(e)
+	case ast.NullForgivingExpr:
+		return tc.CheckNullForgivingExpr(e)
+	case ast.AwaitExpr:
+		return tc.CheckAwaitExpr(e)
+	case ast.CheckedExpr:
+		return tc.CheckCheckedExpr(e)
+	case ast.TupleExpr:
+		return tc.CheckTupleExpr(e, nil)
+	case ast.QueryExpr:
+		return tc.CheckExpr(tc.translateQuery(e))
+	case ast.DeclarationExpr:
+		tc.errorf(e.Line, e.Column, "a declaration is not allowed in this context")
+	case ast.LambdaExpr:
+		tc.errorf(e.Line, e.Column, "cannot infer the type of a lambda expression without a delegate target type")
+	case ast.ThrowExpr:
+		tc.errorf(e.Line, e.Column, "a throw expression is not allowed in this context")
 	default:
 		tc.errorf(expr.GetLine(), expr.GetColumn(), "unexpected expression")
 	}
-	return ast.TypedExpr{Type: "void"}
+	return ast.TypedExpr{Type: types.Error}
 }
 
 func (tc *TypeChecker) CheckBinaryExpr(expr ast.BinaryExpr) ast.TypedExpr {
+	if expr.Operator.Kind == lexer.NULL_COALESCING {
+		return tc.CheckNullCoalescingExpr(expr)
+	}
 	expr.Left = tc.CheckExpr(expr.Left)
-	expr.Right = tc.CheckExpr(expr.Right)
+	if expr.Operator.Kind == lexer.AND || expr.Operator.Kind == lexer.OR {
+		// The right side of a && b only runs if a is true, the right side of a || b only if a is false
+		tc.env = tc.narrow(expr.Left, expr.Operator.Kind == lexer.AND)
+		expr.Right = tc.CheckExpr(expr.Right)
+		tc.env = tc.env.outer
+	} else {
+		expr.Right = tc.CheckExpr(expr.Right)
+	}
+	if tc.hasUserOperand(expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type) {
+		return tc.checkUserBinaryExpr(expr)
+	}
+	if isStringConcatenation(expr.Operator.Kind, expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type) {
+		return ast.TypedExpr{Expr: expr, Type: types.String, Line: expr.Line, Column: expr.Column}
+	}
+	if left, right := tc.numericOperandTypes(expr); left != nil {
+		return tc.checkNumericBinaryExpr(expr, left, right)
+	}
 	if !tc.isBinaryCompatible(expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type) {
 		tc.errorf(expr.Line, expr.Column, "type mismatch during binary expression: %s and %s", expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type)
 	}
-	return ast.TypedExpr{Expr: expr, Type: "bool"}
+	// Tuples are compared element by element with == and !=
+	isTuple := tc.isTupleType(expr.Left.(ast.TypedExpr).Type) || tc.isTupleType(expr.Right.(ast.TypedExpr).Type)
+	if isTuple && expr.Operator.Kind != lexer.EQUALS && expr.Operator.Kind != lexer.NOT_EQUALS {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s",
+			expr.Operator.Value, expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type)
+	}
+	if expr.Operator.Kind == lexer.BITWISE_OR || expr.Operator.Kind == lexer.BITWISE_AND {
+		// Combines the members of flag enums like AttributeTargets.Class | AttributeTargets.Struct
+		left, right := expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type
+		typ := left
+		if underlying, ok := tc.nullableUnderlying(left); ok {
+			typ = underlying
+		}
+		if typ != types.Bool && !tc.isEnum(typ) {
+			tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s", expr.Operator.Value, left)
+		}
+		return ast.TypedExpr{Expr: expr, Type: tc.liftedType(typ, left, right), Line: expr.Line, Column: expr.Column}
+	}
+	// Delegates are combined with + and removed from each other with -
+	if left := expr.Left.(ast.TypedExpr).Type; tc.isDelegateType(left) && (expr.Operator.Kind == lexer.PLUS || expr.Operator.Kind == lexer.MINUS) {
+		return ast.TypedExpr{Expr: expr, Type: left, Line: expr.Line, Column: expr.Column}
+	}
+	tc.checkBoolOperator(expr)
+	return ast.TypedExpr{Expr: expr, Type: types.Bool, Line: expr.Line, Column: expr.Column}
+}
+
+// The operators left for compatible operands that are neither numeric nor strings are the equality
+// operators, the logical operators on bool and the comparison of enum values
+func (tc *TypeChecker) checkBoolOperator(expr ast.BinaryExpr) {
+	left, right := expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type
+	applicable := false
+	switch expr.Operator.Kind {
+	case lexer.EQUALS, lexer.NOT_EQUALS:
+		applicable = true
+	case lexer.AND, lexer.OR:
+		applicable = left == types.Bool && right == types.Bool
+	case lexer.LESS_THAN, lexer.LESS_THAN_OR_EQUAL, lexer.GREATER_THAN, lexer.GREATER_THAN_OR_EQUAL:
+		typ := left
+		if underlying, ok := tc.nullableUnderlying(left); ok {
+			typ = underlying
+		}
+		applicable = tc.isEnum(typ)
+	}
+	if !applicable {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s", expr.Operator.Value, left, right)
+	}
+}
+
+// The types of both operands of a binary expression if they are numeric, lifted operators apply to
+// nullable numeric types. Returns nil otherwise.
+func (tc *TypeChecker) numericOperandTypes(expr ast.BinaryExpr) (types.Type, types.Type) {
+	operands := [2]types.Type{expr.Left.(ast.TypedExpr).Type, expr.Right.(ast.TypedExpr).Type}
+	for i, typ := range operands {
+		if underlying, ok := tc.nullableUnderlying(typ); ok {
+			operands[i] = underlying
+		}
+		if !types.IsNumeric(operands[i]) {
+			return nil, nil
+		}
+	}
+	return operands[0], operands[1]
+}
+
+// Checks receiver.Member for reading if read is set and for assigning if write is set
+func (tc *TypeChecker) checkMemberAccess(expr ast.MemberAccessExpr, read, write bool) ast.TypedExpr {
+	enum, isTypeName := tc.typeNameOf(expr.Receiver)
+	if isTypeName && tc.isEnumName(enum) {
+		return tc.checkEnumMemberAccess(expr, enum)
+	}
+	if event, declaring, ok := tc.lookupEvent(enum, expr.Member); isTypeName && ok {
+		return tc.checkEventAccess(expr, event, declaring)
+	}
+	if _, _, ok := tc.lookupField(enum, expr.Member); isTypeName && ok {
+		return tc.checkFieldAccess(expr, nil, enum, read, write)
+	}
+	if !isTypeName {
+		receiver := tc.CheckExpr(expr.Receiver)
+		if underlying, ok := tc.nullableUnderlying(receiver.Type); ok {
+			return tc.checkNullableMemberAccess(expr, receiver, underlying)
+		}
+		if tc.isTupleType(receiver.Type) {
+			return tc.checkTupleMemberAccess(expr, receiver)
+		}
+		if event, declaring, ok := tc.lookupEvent(receiver.Type.String(), expr.Member); ok {
+			expr.Receiver = receiver
+			return tc.checkEventAccess(expr, event, declaring)
+		}
+		if _, _, ok := tc.lookupField(receiver.Type.String(), expr.Member); ok {
+			return tc.checkFieldAccess(expr, &receiver, receiver.Type.String(), read, write)
+		}
+		tc.errorf(expr.Line, expr.Column, "%s does not contain a definition for %s", receiver.Type, expr.Member)
+	}
+	tc.errorf(expr.Line, expr.Column, "%s does not contain a definition for %s", enum, expr.Member)
+	return ast.TypedExpr{Type: types.Error}
 }
 
 func (tc *TypeChecker) CheckMethodCallExpr(expr ast.MethodCallExpr) ast.TypedExpr {
-	// TODO: Implement
-	return ast.TypedExpr{Type: "void"}
+	// Calling a local, parameter or field of a delegate type invokes the delegate
+	if _, ok := expr.Receiver.(ast.ThisExpr); ok {
+		if info, ok := tc.env.Lookup(expr.MethodName); ok && tc.isDelegateType(info.Type) {
+			callee := tc.CheckIdentifierExpr(ast.IdentifierExpr{Name: expr.MethodName, Line: expr.Line, Column: expr.Column})
+			return tc.CheckInvocationExpr(ast.InvocationExpr{Callee: callee, Args: expr.Args, Line: expr.Line, Column: expr.Column})
+		}
+		if function, ok := tc.env.LookupFunction(expr.MethodName); ok {
+			method, args := tc.resolveOverload(expr.MethodName, []*MethodSymbol{function}, expr.Args, expr.Line, expr.Column)
+			expr.Args = args
+			expr.Signature = method.Signature()
+			return ast.TypedExpr{Type: method.ReturnType, Expr: expr, Line: expr.Line, Column: expr.Column}
+		}
+	}
+
+	className := tc.checkReceiver(&expr)
+	_, isStaticAccess := tc.typeNameOf(expr.Receiver)
+	if event, declaring, ok := tc.lookupEvent(className, expr.MethodName); ok && len(tc.lookupMethods(className, expr.MethodName)) == 0 {
+		return tc.checkEventInvocation(expr, event, declaring)
+	}
+	args := tc.prepareArguments(expr.Args)
+
+	// Extension methods are only considered if no instance method accepts the arguments
+	if receiver, ok := expr.Receiver.(ast.TypedExpr); ok && !isStaticAccess {
+		tc.instantiateQueryOperator(expr.MethodName, className, args, expr.Line, expr.Column)
+		extensions := tc.extensionMethods(expr.MethodName, className)
+		if len(extensions) > 0 && !tc.hasApplicableMethod(className, expr.MethodName, args, expr.Line, expr.Column) {
+			return tc.checkExtensionCall(expr, extensions, receiver, args)
+		}
+	}
+	if !tc.isClassName(className) {
+		tc.errorf(expr.Line, expr.Column, "type %s does not have a method called %s", className, expr.MethodName)
+	}
+	if receiver, ok := expr.Receiver.(ast.TypedExpr); ok {
+		tc.checkDereference(receiver, expr.Line, expr.Column)
+	}
+
+	candidates := []*MethodSymbol{}
+	for _, method := range tc.lookupMethods(className, expr.MethodName) {
+		if !tc.isAccessible(method) {
+			continue
+		}
+		if isStaticAccess && !method.IsStatic() {
+			tc.errorf(expr.Line, expr.Column, "an object reference is required for the non-static method %s", method)
+		}
+		candidates = append(candidates, method)
+	}
+	if len(candidates) == 0 {
+		tc.errorf(expr.Line, expr.Column, "%s does not contain an accessible method called %s", className, expr.MethodName)
+	}
+
+	method, bound := tc.resolvePreparedOverload(expr.MethodName, candidates, args, expr.Line, expr.Column)
+	tc.checkObsolete(method.Attributes, method.Class+"."+method.Name, expr.Line, expr.Column)
+	if receiver, ok := expr.Receiver.(ast.TypedExpr); ok && !method.IsStatic() {
+		if _, isThis := receiver.Expr.(ast.ThisExpr); isThis {
+			tc.checkThisAccess(expr.Line, expr.Column)
+		}
+	}
+	expr.Args = bound
+	expr.Signature = method.Signature()
+
+	return ast.TypedExpr{Type: method.ReturnType, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+func (tc *TypeChecker) hasApplicableMethod(className, methodName string, args []argument, line, column int) bool {
+	for _, method := range tc.lookupMethods(className, methodName) {
+		if !tc.isAccessible(method) {
+			continue
+		}
+		if _, ok := tc.tryBindArguments(method, args, line, column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Types the receiver of a method call and returns its type or the class whose methods can be called.
+// A class name as receiver is left untyped and marks a static call.
+func (tc *TypeChecker) checkReceiver(expr *ast.MethodCallExpr) string {
+	switch receiver := expr.Receiver.(type) {
+	case ast.ThisExpr:
+		className := tc.currentClassName()
+		// Static methods of enclosing classes can be called without qualification from nested classes
+		if len(tc.lookupMethods(className, expr.MethodName)) == 0 {
+			for outer := enclosingTypeName(className); outer != ""; outer = enclosingTypeName(outer) {
+				if len(tc.lookupMethods(outer, expr.MethodName)) > 0 {
+					expr.Receiver = ast.IdentifierExpr{Name: outer, Line: receiver.Line, Column: receiver.Column}
+					return outer
+				}
+			}
+		}
+		expr.Receiver = ast.TypedExpr{Type: tc.registry.NewClass(className), Expr: receiver, Line: receiver.Line, Column: receiver.Column}
+		return className
+	case ast.IdentifierExpr, ast.MemberAccessExpr:
+		if className, ok := tc.typeNameOf(receiver); ok && tc.isClassName(className) {
+			tc.checkObsolete(tc.typeAttributes(className), className, receiver.GetLine(), receiver.GetColumn())
+			return className
+		}
+	}
+
+	typedReceiver := tc.CheckExpr(expr.Receiver)
+	expr.Receiver = typedReceiver
+	return typedReceiver.Type.String()
+}
+
+func (tc *TypeChecker) CheckInvocationExpr(expr ast.InvocationExpr) ast.TypedExpr {
+	callee := expr.Callee.(ast.TypedExpr)
+	signature, _ := tc.delegateSignature(callee.Type)
+	tc.checkDereference(callee, expr.Line, expr.Column)
+
+	if len(expr.Args) != len(signature.Parameters) {
+		tc.errorf(expr.Line, expr.Column, "delegate %s expects %d arguments, got %d", callee.Type, len(signature.Parameters), len(expr.Args))
+	}
+
+	args := make([]ast.Expr, len(expr.Args))
+	for i, arg := range expr.Args {
+		typedArg := tc.CheckTargetTypedExpr(arg, signature.Parameters[i])
+		if !tc.isTypeCompatible(signature.Parameters[i], typedArg.Type) {
+			tc.errorf(arg.GetLine(), arg.GetColumn(), "type mismatch: expected %s, got %s", signature.Parameters[i], typedArg.Type)
+		}
+		args[i] = typedArg
+	}
+	expr.Args = args
+
+	return ast.TypedExpr{Type: signature.Result, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Lambdas, method groups and new() have no type on their own, they take the type they are converted to.
+// Tuple literals pass the element types of their target on to their elements.
+func (tc *TypeChecker) CheckTargetTypedExpr(expr ast.Expr, target types.Type) ast.TypedExpr {
+	switch e := expr.(type) {
+	case ast.ConstructorCallExpr:
+		if e.TypeName == "" && target != nil {
+			if !tc.isUserObject(target) {
+				tc.errorf(e.Line, e.Column, "cannot create an instance of type %s with new()", target)
+			}
+			e.TypeName = target.String()
+			return tc.CheckConstructorCallExpr(e)
+		}
+	case ast.LambdaExpr:
+		return tc.CheckLambdaExpr(e, target)
+	case ast.SwitchExpr:
+		return tc.CheckSwitchExpr(e, target)
+	case ast.TupleExpr:
+		return tc.CheckTupleExpr(e, target)
+	case ast.IdentifierExpr, ast.MemberAccessExpr:
+		expr = tc.checkMemberReceiver(expr)
+		if group, ok := tc.asMethodGroup(expr); ok {
+			return tc.CheckMethodGroupExpr(group, target)
+		}
+	}
+	typed := tc.CheckExpr(expr)
+	if target == nil {
+		return typed
+	}
+	return tc.convertConstant(typed, target, false)
+}
+
+// Checks the receiver of a member access unless it names a type, the methods of a method group
+// are looked up on the type of the receiver
+func (tc *TypeChecker) checkMemberReceiver(expr ast.Expr) ast.Expr {
+	if access, ok := expr.(ast.MemberAccessExpr); ok {
+		if _, isTypeName := tc.typeNameOf(access.Receiver); !isTypeName {
+			access.Receiver = tc.CheckExpr(access.Receiver)
+			return access
+		}
+	}
+	return expr
+}
+
+// Names of local functions and of methods used without a call are method groups. Methods of the
+// current class can be used without a receiver, static methods of other classes through the class
+// name and instance methods through a receiver of their class.
+func (tc *TypeChecker) asMethodGroup(expr ast.Expr) (ast.MethodGroupExpr, bool) {
+	switch e := expr.(type) {
+	case ast.IdentifierExpr:
+		if _, ok := tc.env.LookupFunction(e.Name); ok {
+			return ast.MethodGroupExpr{Receiver: ast.ThisExpr{Line: e.Line, Column: e.Column}, MethodName: e.Name, Line: e.Line, Column: e.Column}, true
+		}
+		if _, ok := tc.env.Lookup(e.Name); !ok && len(tc.lookupMethods(tc.currentClassName(), e.Name)) > 0 {
+			this := ast.TypedExpr{Type: tc.registry.NewClass(tc.currentClassName()), Expr: ast.ThisExpr{Line: e.Line, Column: e.Column}, Line: e.Line, Column: e.Column}
+			return ast.MethodGroupExpr{Receiver: this, MethodName: e.Name, Line: e.Line, Column: e.Column}, true
+		}
+	case ast.MemberAccessExpr:
+		if className, ok := tc.methodGroupClass(e.Receiver); ok && len(tc.lookupMethods(className, e.Member)) > 0 {
+			return ast.MethodGroupExpr{Receiver: e.Receiver, MethodName: e.Member, Line: e.Line, Column: e.Column}, true
+		}
+	}
+	return ast.MethodGroupExpr{}, false
+}
+
+// The class the methods of a group are declared in and whether the receiver is a class name
+func (tc *TypeChecker) methodGroupClass(receiver ast.Expr) (string, bool) {
+	if typed, ok := receiver.(ast.TypedExpr); ok {
+		return typed.Type.String(), true
+	}
+	return tc.typeNameOf(receiver)
+}
+
+func (tc *TypeChecker) CheckLambdaExpr(lambda ast.LambdaExpr, target types.Type) ast.TypedExpr {
+	signature, ok := tc.delegateSignature(target)
+	if !ok {
+		tc.errorf(lambda.Line, lambda.Column, "cannot convert lambda expression to non-delegate type %s", target)
+	}
+	if len(lambda.Parameters) != len(signature.Parameters) {
+		tc.errorf(lambda.Line, lambda.Column, "delegate %s does not take %d arguments", target, len(lambda.Parameters))
+	}
+
+	closure := &Closure{}
+	tc.env = NewClosureEnv(tc.env, closure)
+	defer func() { tc.env = tc.env.outer }()
+
+	// Implicitly typed parameters take their type from the delegate
+	parameters := make([]ast.Parameter, len(lambda.Parameters))
+	for i, param := range lambda.Parameters {
+		if param.Type.Name == "" {
+			param.Type = ast.Type{Name: signature.Parameters[i].String(), Line: lambda.Line, Column: lambda.Column}
+		} else if param.Type = tc.resolveLocalType(param.Type); tc.registry.Parse(param.Type.Name) != signature.Parameters[i] {
+			tc.errorf(param.Type.Line, param.Type.Column, "lambda parameter %s has type %s but delegate %s expects %s", param.Identifier, param.Type.Name, target, signature.Parameters[i])
+		}
+		if tc.env.IsDefinedInScope(param.Identifier) {
+			tc.errorf(lambda.Line, lambda.Column, "duplicate lambda parameter %s", param.Identifier)
+		}
+		tc.defineLambdaParameter(param.Identifier, tc.registry.Parse(param.Type.Name))
+		parameters[i] = param
+	}
+	lambda.Parameters = parameters
+
+	// Rethrows, finally, iterator and async restrictions, goto case and readonly assignments of constructors do not reach into the lambda body
+	catchDepth, finallyDepth, tryCatchDepth, switches, inConstructor, iteratorElement := tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.iteratorElement
+	inAsync, awaited, inFilter, method := tc.inAsync, tc.awaited, tc.inFilter, tc.method
+	tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.iteratorElement = 0, 0, 0, nil, false, nil
+	tc.inAsync, tc.inFilter = false, false
+	defer func() {
+		tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.iteratorElement = catchDepth, finallyDepth, tryCatchDepth, switches, inConstructor, iteratorElement
+		tc.inAsync, tc.awaited, tc.inFilter, tc.method = inAsync, awaited, inFilter, method
+	}()
+
+	// Returns inside the lambda body are checked against the delegate return type, unwrapped for async lambdas
+	returnType := signature.Result
+	if lambda.IsAsync {
+		returnType = tc.enterAsync(returnType, lambda.Parameters, lambda.Line, lambda.Column)
+	}
+	tc.method = &methodContext{returnType: returnType}
+	tc.env.MarkBody()
+
+	if lambda.Body != nil && containsYield(lambda.Body) {
+		tc.errorf(lambda.Line, lambda.Column, "the yield statement cannot be used inside of a lambda expression")
+	}
+
+	if throw, ok := lambda.Expression.(ast.ThrowExpr); ok {
+		lambda.Expression = tc.CheckThrowExpr(throw, returnType)
+	} else if lambda.Expression != nil {
+		if returnType == types.Void && !isStatementExpression(lambda.Expression) {
+			tc.errorf(lambda.Line, lambda.Column, "only assignment, call, increment, decrement, await and new object expressions can be used as the body of a lambda that returns void")
+		}
+		body := tc.CheckTargetTypedExpr(lambda.Expression, returnType)
+		if returnType != types.Void && !tc.isTypeCompatible(returnType, body.Type) {
+			tc.errorf(lambda.Line, lambda.Column, "type mismatch: expected %s, got %s", returnType, body.Type)
+		}
+		lambda.Expression = body
+	} else if block, ok := lambda.Body.(ast.BlockStmt); ok {
+		lambda.Body = tc.CheckBlockStmt(&block)
+		if !tc.isTypeCompatible(returnType, lambda.Body.(ast.TypedStmt).Type) {
+			tc.errorf(lambda.Line, lambda.Column, "type mismatch: expected %s, got %s", returnType, lambda.Body.(ast.TypedStmt).Type)
+		}
+	}
+	if lambda.IsAsync {
+		tc.checkAwaited(lambda.Line, lambda.Column)
+	}
+
+	lambda.Captures = closure.Captures
+	return ast.TypedExpr{Type: target, Expr: lambda, Line: lambda.Line, Column: lambda.Column}
+}
+
+func (tc *TypeChecker) CheckMethodGroupExpr(group ast.MethodGroupExpr, target types.Type) ast.TypedExpr {
+	signature, ok := tc.delegateSignature(target)
+	if !ok {
+		tc.errorf(group.Line, group.Column, "cannot convert method group %s to non-delegate type %s", group.MethodName, target)
+	}
+
+	// Like calls of local functions the group of a local function has an untyped this as receiver
+	if _, ok := group.Receiver.(ast.ThisExpr); ok {
+		function, _ := tc.env.LookupFunction(group.MethodName)
+		if !tc.matchesSignature(function, signature) {
+			tc.errorf(group.Line, group.Column, "no overload for %s matches delegate %s", group.MethodName, target)
+		}
+		group.Signature = function.Signature()
+		return ast.TypedExpr{Type: target, Expr: group, Line: group.Line, Column: group.Column}
+	}
+
+	className, _ := tc.methodGroupClass(group.Receiver)
+	receiver, hasValue := group.Receiver.(ast.TypedExpr)
+	_, isThis := receiver.Expr.(ast.ThisExpr)
+	if hasValue && !isThis {
+		tc.checkDereference(receiver, group.Line, group.Column)
+	}
+	for _, method := range tc.lookupMethods(className, group.MethodName) {
+		// Through a class name only static methods are accessible, through other receivers only instance methods
+		if (!hasValue && !method.IsStatic()) || (hasValue && !isThis && method.IsStatic()) {
+			continue
+		}
+		if tc.isAccessible(method) && tc.matchesSignature(method, signature) {
+			if isThis && !method.IsStatic() {
+				tc.checkThisAccess(group.Line, group.Column)
+			}
+			group.Signature = method.Signature()
+			return ast.TypedExpr{Type: target, Expr: group, Line: group.Line, Column: group.Column}
+		}
+	}
+
+	tc.errorf(group.Line, group.Column, "no overload for %s matches delegate %s", group.MethodName, target)
+	return ast.TypedExpr{Type: types.Error}
+}
+
+// Function types are interned, a method has the signature of a delegate if it has the same function type
+func (tc *TypeChecker) matchesSignature(method *MethodSymbol, signature *types.Function) bool {
+	return tc.functionType(method.ParameterTypes, method.ReturnType) == signature
+}
+
+func (tc *TypeChecker) CheckConstructorCallExpr(expr ast.ConstructorCallExpr) ast.TypedExpr {
+	if expr.TypeName == "" {
+		tc.errorf(expr.Line, expr.Column, "there is no target type for new()")
+	}
+	expr.TypeName = tc.resolveLocalType(ast.Type{Name: expr.TypeName, Line: expr.Line, Column: expr.Column}).Name
+	if !tc.isClassName(expr.TypeName) {
+		tc.errorf(expr.Line, expr.Column, "undefined class: %s", expr.TypeName)
+	}
+
+	candidates := []*MethodSymbol{}
+	for _, constructor := range tc.classes[expr.TypeName].Constructors {
+		if tc.isAccessible(constructor) {
+			candidates = append(candidates, constructor)
+		}
+	}
+	if len(candidates) == 0 {
+		tc.errorf(expr.Line, expr.Column, "%s does not have an accessible constructor", expr.TypeName)
+	}
+
+	constructor, args := tc.resolveOverload(expr.TypeName, candidates, expr.Args, expr.Line, expr.Column)
+	tc.checkObsolete(constructor.Attributes, expr.TypeName+"."+simpleTypeName(expr.TypeName), expr.Line, expr.Column)
+	expr.Args = args
+	expr.Signature = constructor.Signature()
+	if expr.Initializer != nil {
+		initializer := tc.checkObjectInitializer(*expr.Initializer, expr.TypeName)
+		expr.Initializer = &initializer
+	}
+
+	return ast.TypedExpr{Type: tc.registry.NewClass(expr.TypeName), Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// A throw expression never produces a value and therefore converts to any target type
+func (tc *TypeChecker) CheckThrowExpr(expr ast.ThrowExpr, target types.Type) ast.TypedExpr {
+	expr.Value = tc.checkThrownValue(expr.Value)
+	return ast.TypedExpr{Type: target, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+func (tc *TypeChecker) checkThrownValue(value ast.Expr) ast.TypedExpr {
+	typedValue := tc.CheckExpr(value)
+	if typedValue.Type != types.Null && !tc.isExceptionType(typedValue.Type) {
+		tc.errorf(value.GetLine(), value.GetColumn(), "thrown type %s must be Exception or derive from it", typedValue.Type)
+	}
+	return typedValue
 }
 
 func (tc *TypeChecker) CheckIdentifierExpr(expr ast.IdentifierExpr) ast.TypedExpr {
+	return tc.checkIdentifier(expr, true)
+}
+
+func (tc *TypeChecker) checkIdentifier(expr ast.IdentifierExpr, mustBeAssigned bool) ast.TypedExpr {
 	info, ok := tc.env.Lookup(expr.Name)
 	if !ok {
+		// Events with accessors and inherited events are not variables of the class
+		if event, declaring, isEvent := tc.lookupEvent(tc.currentClassName(), expr.Name); isEvent {
+			tc.checkEventUse(event, declaring, expr.Line, expr.Column)
+		}
 		tc.errorf(expr.Line, expr.Column, "undefined variable: %s", expr.Name)
 	}
+	if info.RangeOf != "" {
+		transparent := ast.IdentifierExpr{Name: info.RangeOf, Line: expr.Line, Column: expr.Column}
+		return tc.CheckExpr(ast.MemberAccessExpr{Receiver: transparent, Member: expr.Name, Line: expr.Line, Column: expr.Column})
+	}
+	if mustBeAssigned && info.IsUnassigned && !tc.env.IsAssigned(expr.Name) {
+		tc.errorf(expr.Line, expr.Column, "use of unassigned local variable %s", expr.Name)
+	}
 	if info.IsField || info.IsGlobal {
-		return ast.TypedExpr{Type: info.Type, Expr: ast.FieldVarExpr(expr)}
+		if field, _, ok := tc.lookupField(tc.currentClassName(), expr.Name); ok && !hasModifier(field.Modifiers, lexer.STATIC) && !hasModifier(field.Modifiers, lexer.CONST) {
+			tc.checkThisAccess(expr.Line, expr.Column)
+		}
+		tc.checkFieldObsolete(expr)
+		return ast.TypedExpr{Type: info.Type, Expr: ast.FieldVarExpr(expr), Line: expr.Line, Column: expr.Column}
 	} else {
-		return ast.TypedExpr{Type: info.Type, Expr: ast.LocalVarExpr(expr)}
+		return ast.TypedExpr{Type: info.Type, Expr: ast.LocalVarExpr(expr), Line: expr.Line, Column: expr.Column}
 	}
 }
 
+func (tc *TypeChecker) CheckPrefixExpr(expr ast.PrefixExpr) ast.TypedExpr {
+	operand := tc.CheckExpr(expr.Expression)
+	expr.Expression = operand
+	if tc.isUserObject(operand.Type) {
+		return tc.checkUserPrefixExpr(expr, operand)
+	}
+
+	// Operators lifted to nullable value types apply to the underlying type
+	typ := operand.Type
+	if underlying, ok := tc.nullableUnderlying(operand.Type); ok {
+		typ = underlying
+	}
+	switch expr.Operator.Kind {
+	case lexer.NOT:
+		if typ != types.Bool {
+			tc.errorf(expr.Line, expr.Column, "operator ! cannot be applied to operand of type %s", operand.Type)
+		}
+	case lexer.MINUS, lexer.PLUS:
+		// -2147483648 and -9223372036854775808 are the smallest int and long
+		if literal, ok := operand.Expr.(ast.IntLiteralExpr); ok && expr.Operator.Kind == lexer.MINUS {
+			if literal.Value == math.MaxInt32+1 && literal.Suffix == "" {
+				typ = types.Int
+			} else if literal.Value == math.MinInt64 && (literal.Suffix == "" || literal.Suffix == "L") {
+				typ = types.Long
+			}
+		}
+		promoted, ok := types.UnaryNumericPromotion(expr.Operator.Kind == lexer.MINUS, typ)
+		if !ok {
+			tc.errorf(expr.Line, expr.Column, "operator %s cannot be applied to operand of type %s", expr.Operator.Value, operand.Type)
+		}
+		typ = promoted
+	}
+	typed := ast.TypedExpr{Type: tc.liftedType(typ, operand.Type), Expr: expr, Line: expr.Line, Column: expr.Column}
+	if types.IsIntegral(typed.Type) {
+		tc.checkConstantOverflow(typed)
+	}
+	return typed
+}
+
+func (tc *TypeChecker) CheckIsPatternExpr(expr ast.IsPatternExpr) ast.TypedExpr {
+	input := tc.CheckExpr(expr.Expression)
+	if input.Type == types.Void {
+		tc.errorf(expr.Line, expr.Column, "cannot match a pattern against an expression of type void")
+	}
+	expr.Expression = input
+	expr.Pattern = tc.CheckPattern(expr.Pattern, input.Type)
+	return ast.TypedExpr{Type: types.Bool, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+func (tc *TypeChecker) CheckAsExpr(expr ast.AsExpr) ast.TypedExpr {
+	operand := tc.CheckExpr(expr.Expression)
+	expr.Expression = operand
+
+	expr.Type = tc.resolveLocalType(expr.Type)
+	if !tc.isKnownType(expr.Type.Name) {
+		tc.errorf(expr.Type.Line, expr.Type.Column, "the type or namespace name %s could not be found", expr.Type.Name)
+	}
+	typ := tc.registry.Parse(expr.Type.Name)
+	if !tc.isReferenceType(typ) && !tc.isNullableValueType(typ) {
+		tc.errorf(expr.Line, expr.Column, "the as operator must be used with a reference type or nullable type (%s is a non-nullable value type)", typ)
+	}
+	if operand.Type != types.Null && !tc.isTypeCompatible(typ, operand.Type) && !tc.isTypeCompatible(operand.Type, typ) {
+		tc.errorf(expr.Line, expr.Column, "cannot convert type %s to %s via a reference conversion", operand.Type, typ)
+	}
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// ++ and -- read and assign their operand, the expression has the type of the operand
 func (tc *TypeChecker) CheckUnaryExpr(expr ast.Expr) ast.TypedExpr {
-	// TODO: Implement
-	// switch e := expr.(type) {
-	// default:
-	// 	tc.errorf(e.GetLine(), e.GetColumn(), "unexpected unary expression")
-	// }
-	return ast.TypedExpr{Type: "void"}
+	var operand ast.TypedExpr
+	switch e := expr.(type) {
+	case ast.PreIncrementExpr:
+		operand, e.Signature = tc.checkIncrementOperand(e.Operand, lexer.INCREMENT, e.Line, e.Column)
+		e.Operand, expr = operand, e
+	case ast.PostIncrementExpr:
+		operand, e.Signature = tc.checkIncrementOperand(e.Operand, lexer.INCREMENT, e.Line, e.Column)
+		e.Operand, expr = operand, e
+	case ast.PreDecrementExpr:
+		operand, e.Signature = tc.checkIncrementOperand(e.Operand, lexer.DECREMENT, e.Line, e.Column)
+		e.Operand, expr = operand, e
+	case ast.PostDecrementExpr:
+		operand, e.Signature = tc.checkIncrementOperand(e.Operand, lexer.DECREMENT, e.Line, e.Column)
+		e.Operand, expr = operand, e
+	default:
+		tc.errorf(expr.GetLine(), expr.GetColumn(), "unexpected unary expression")
+	}
+	return ast.TypedExpr{Type: operand.Type, Expr: expr, Line: expr.GetLine(), Column: expr.GetColumn()}
+}
+
+// The operand of ++ and -- is a variable, property or indexer of a numeric or enum type, or of a type
+// that declares the operator. Returns the user-defined operator or nil for the built-in ones.
+func (tc *TypeChecker) checkIncrementOperand(operand ast.Expr, kind lexer.TokenKind, line, column int) (ast.TypedExpr, *ast.MethodSignature) {
+	typed, _, _ := tc.checkAssignee(operand, true, "the operand of an increment or decrement operator", line, column)
+	operator := map[lexer.TokenKind]string{lexer.INCREMENT: "++", lexer.DECREMENT: "--"}[kind]
+
+	var signature *ast.MethodSignature
+	typ := typed.Type
+	if underlying, ok := tc.nullableUnderlying(typ); ok {
+		typ = underlying
+	}
+	switch {
+	case tc.isUserObject(typ):
+		method, _ := tc.resolveOperator(ast.OperatorMethodName(kind, 1), []ast.TypedExpr{typed}, line, column)
+		if method == nil {
+			tc.errorf(line, column, "operator %s cannot be applied to operand of type %s", operator, typed.Type)
+		}
+		signature = method.Signature()
+	case !types.IsNumeric(typ) && !tc.isEnum(typ):
+		tc.errorf(line, column, "operator %s cannot be applied to operand of type %s", operator, typed.Type)
+	}
+
+	if id, ok := operand.(ast.IdentifierExpr); ok {
+		tc.env.MarkAssigned(id.Name)
+	}
+	return typed, signature
+}
+
+// Types the target of an assignment, ++ or --, which has to be a variable, property or indexer that can be
+// assigned. Also returns the symbol of the variable if the target is one.
+func (tc *TypeChecker) checkAssignee(target ast.Expr, read bool, what string, line, column int) (ast.TypedExpr, SymbolInfo, bool) {
+	var typed ast.TypedExpr
+	switch t := target.(type) {
+	case ast.IdentifierExpr:
+		if info, ok := tc.env.Lookup(t.Name); ok && info.RangeOf != "" {
+			tc.errorf(line, column, "cannot assign to %s because it is a range variable", t.Name)
+		}
+		typed = tc.checkIdentifier(t, read)
+	case ast.ElementAccessExpr:
+		typed = tc.checkElementAccess(t, read, true)
+	case ast.MemberAccessExpr:
+		if tc.isEnumMember(t) {
+			tc.errorf(line, column, "%s must be a variable, property or indexer", what)
+		}
+		typed = tc.checkMemberAccess(t, read, true)
+	default:
+		tc.errorf(target.GetLine(), target.GetColumn(), "%s must be a variable, property or indexer", what)
+	}
+
+	info, isVariable := tc.env.Lookup(variableName(typed))
+	if isVariable && info.IsConstant {
+		tc.errorf(line, column, "cannot assign to %s because it is a constant", variableName(typed))
+	} else if isVariable && tc.isReadOnly(variableName(typed), info) {
+		tc.errorf(line, column, "cannot assign to variable %s because it is a readonly variable", variableName(typed))
+	}
+	if isVariable && info.IsField {
+		tc.checkPropertyAssignable(tc.currentClassName(), variableName(typed), line, column)
+	}
+	return typed, info, isVariable
 }
 
 func (tc *TypeChecker) checkBoolCondition(condition ast.Expr) ast.TypedExpr {
 	condition = tc.CheckExpr(condition)
+	if converted, ok := tc.convertToBool(condition.(ast.TypedExpr)); ok {
+		return converted
+	}
 
-	if condition.(ast.TypedExpr).Type != "bool" {
+	if condition.(ast.TypedExpr).Type != types.Bool {
 		tc.errorf(condition.GetLine(), condition.GetColumn(), "type mismatch: expected boolean, got %s", condition.(ast.TypedExpr).Type)
 	}
 
 	return condition.(ast.TypedExpr)
 }
+
+func (tc *TypeChecker) CheckArrayCreationExpr(expr ast.ArrayCreationExpr) ast.TypedExpr {
+	expr.ElementType = tc.resolveLocalType(expr.ElementType)
+	elementType := tc.registry.Parse(expr.ElementType.Name)
+	for i, element := range expr.Elements {
+		typed := tc.CheckTargetTypedExpr(element, elementType)
+		if !tc.isTypeCompatible(elementType, typed.Type) {
+			tc.errorf(element.GetLine(), element.GetColumn(), "cannot implicitly convert type %s to %s", typed.Type, elementType)
+		}
+		expr.Elements[i] = typed
+	}
+	return ast.TypedExpr{Type: tc.registry.NewArray(elementType), Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+var compoundOperators = map[lexer.TokenKind]lexer.TokenKind{
+	lexer.PLUS_EQUALS:     lexer.PLUS,
+	lexer.MINUS_EQUALS:    lexer.MINUS,
+	lexer.MULTIPLY_EQUALS: lexer.MULTIPLY,
+	lexer.DIVIDE_EQUALS:   lexer.DIVIDE,
+	lexer.MODULUS_EQUALS:  lexer.MODULUS,
+}
+
+// a += b is checked as a = a + b
+func desugarCompoundAssignment(assignment ast.AssignmentExpr) ast.AssignmentExpr {
+	operator := assignment.Operator
+	assignment.Value = ast.BinaryExpr{
+		Left:     assignment.Assignee,
+		Operator: lexer.Token{Kind: compoundOperators[operator.Kind], Value: operator.Value[:len(operator.Value)-1], Line: operator.Line, Column: operator.Column},
+		Right:    assignment.Value,
+	}
+	assignment.Operator = lexer.Token{Kind: lexer.ASSIGNMENT, Value: "=", Line: operator.Line, Column: operator.Column}
+	return assignment
+}
@@ -0,0 +1,124 @@
+package typecheck
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Extension methods are static methods of top-level static classes whose first parameter has the this
+// modifier. They can be called like instance methods of the first parameter type where the namespace
+// of their class is visible, which is its own namespace, the namespaces around it and the using directives.
+
+// Namespaces of the built-in library that may be imported even though no class declares them
+var libraryNamespaces = map[string]bool{"System": true, "System.Collections.Generic": true, "System.Threading.Tasks": true}
+
+func isExtensionMethod(parameters []ast.Parameter) bool {
+	return len(parameters) > 0 && hasModifier(parameters[0].Modifiers, lexer.THIS)
+}
+
+func (tc *TypeChecker) checkExtensionMethodDeclaration(method *ast.MethodDeclStmt, className string) {
+	class := tc.classes[className].Decl
+	if !hasModifier(method.Modifiers, lexer.STATIC) {
+		tc.errorf(method.Line, method.Column, "extension method %s must be static", method.Name)
+	}
+	if !hasModifier(class.Modifiers, lexer.STATIC) {
+		tc.errorf(method.Line, method.Column, "extension method %s must be defined in a non-generic static class", method.Name)
+	}
+	if enclosingTypeName(className) != "" {
+		tc.errorf(method.Line, method.Column, "extension method %s must be defined in a top level static class; %s is a nested class", method.Name, className)
+	}
+
+	first := method.Parameters[0]
+	if hasModifier(first.Modifiers, lexer.PARAMS) || hasModifier(first.Modifiers, lexer.OUT) {
+		tc.errorf(first.Type.Line, first.Type.Column, "the first parameter of an extension method cannot be a params or out parameter")
+	}
+}
+
+// Constructors, indexers and local functions can not extend a type
+func (tc *TypeChecker) checkNotExtension(parameters []ast.Parameter) {
+	if isExtensionMethod(parameters) {
+		tc.errorf(parameters[0].Type.Line, parameters[0].Type.Column, "the parameter modifier this can only be used on the first parameter of an extension method")
+	}
+}
+
+// Checks that the imported namespaces exist and reports imports that are repeated
+func (tc *TypeChecker) checkUsings(file string, usings []ast.UsingDirective) {
+	tc.file = file
+	namespaces := map[string]bool{}
+	for _, class := range tc.classes {
+		for namespace := class.Decl.Namespace; namespace != ""; namespace = enclosingTypeName(namespace) {
+			namespaces[namespace] = true
+		}
+	}
+
+	imported := map[string]bool{}
+	for _, using := range usings {
+		if !namespaces[using.Namespace] && !libraryNamespaces[using.Namespace] {
+			tc.errorf(using.Line, using.Column, "the type or namespace name %s could not be found", using.Namespace)
+		}
+		if imported[using.Namespace] {
+			tc.warnf(using.Line, using.Column, "the using directive for %s appeared previously in this file", using.Namespace)
+		}
+		imported[using.Namespace] = true
+	}
+}
+
+func (tc *TypeChecker) isNamespaceVisible(namespace string) bool {
+	current := ""
+	if class, ok := tc.classes[tc.currentClassName()]; ok {
+		current = class.Decl.Namespace
+	}
+	if namespace == "" || namespace == current || strings.HasPrefix(current, namespace+".") {
+		return true
+	}
+	for _, using := range tc.usings[tc.file] {
+		if using.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Collects the visible extension methods with the name that accept the receiver type as their first argument
+func (tc *TypeChecker) extensionMethods(name, receiverType string) []*MethodSymbol {
+	classNames := make([]string, 0, len(tc.classes))
+	for className := range tc.classes {
+		classNames = append(classNames, className)
+	}
+	sort.Strings(classNames)
+
+	methods := []*MethodSymbol{}
+	for _, className := range classNames {
+		class := tc.classes[className]
+		if !hasModifier(class.Decl.Modifiers, lexer.STATIC) || enclosingTypeName(className) != "" || !tc.isNamespaceVisible(class.Decl.Namespace) {
+			continue
+		}
+		for _, method := range class.Methods[name] {
+			if method.IsStatic() && isExtensionMethod(method.Parameters) && tc.isAccessible(method) && tc.isExtensionReceiver(method.ParameterTypes[0], tc.registry.Parse(receiverType)) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods
+}
+
+// The receiver converts to the extended type by identity, a reference or a nullable conversion, not by a user-defined one
+func (tc *TypeChecker) isExtensionReceiver(extended, receiverType types.Type) bool {
+	return tc.isTypeCompatible(extended, receiverType) && tc.userDefinedConversion(receiverType, extended, false) == nil
+}
+
+// receiver.Name(args) becomes the static call Class.Name(receiver, args)
+func (tc *TypeChecker) checkExtensionCall(expr ast.MethodCallExpr, candidates []*MethodSymbol, receiver ast.TypedExpr, args []argument) ast.TypedExpr {
+	receiverArg := argument{expr: receiver, typed: receiver, line: receiver.Line, column: receiver.Column}
+	method, bound := tc.resolvePreparedOverload(expr.MethodName, candidates, append([]argument{receiverArg}, args...), expr.Line, expr.Column)
+	tc.checkObsolete(method.Attributes, method.Class+"."+method.Name, expr.Line, expr.Column)
+
+	expr.Receiver = ast.IdentifierExpr{Name: method.Class, Line: receiver.Line, Column: receiver.Column}
+	expr.Args = bound
+	expr.Signature = method.Signature()
+	return ast.TypedExpr{Type: method.ReturnType, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
@@ -0,0 +1,139 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Indexers are declared as methods named this whose return type is the element type. Element access
+// resolves them like overloaded methods and then checks that the accessor the access needs exists.
+
+func (tc *TypeChecker) CheckIndexerDeclStmt(indexer *ast.IndexerDeclStmt) {
+	indexer.Attributes = tc.checkAttributes(indexer.Attributes, targetProperty)
+	tc.checkNotAsync(indexer.Modifiers, indexer.Line, indexer.Column)
+	tc.checkParameterAttributes(indexer.Parameters)
+	tc.checkNotExtension(indexer.Parameters)
+
+	if hasModifier(indexer.Modifiers, lexer.STATIC) {
+		tc.errorf(indexer.Line, indexer.Column, "the modifier static is not valid for an indexer")
+	}
+	for _, param := range indexer.Parameters {
+		if modifier := referenceModifier(param.Modifiers); modifier == "ref" || modifier == "out" {
+			tc.errorf(param.Type.Line, param.Type.Column, "indexer parameters cannot have the %s modifier", modifier)
+		}
+	}
+	if len(indexer.Accessors) == 0 {
+		tc.errorf(indexer.Line, indexer.Column, "an indexer must have at least one accessor")
+	}
+
+	defined := map[string]bool{}
+	for i := range indexer.Accessors {
+		accessor := &indexer.Accessors[i]
+		if defined[accessor.Kind] {
+			tc.errorf(accessor.Line, accessor.Column, "the %s accessor is already defined", accessor.Kind)
+		}
+		defined[accessor.Kind] = true
+		if accessor.Body != nil {
+			tc.checkAccessor(accessor, indexer.Parameters, indexer.Type)
+		}
+	}
+}
+
+// The get accessor returns the element type, the set, add and remove accessors get the element or
+// the event handler as value
+func (tc *TypeChecker) checkAccessor(accessor *ast.Accessor, parameters []ast.Parameter, typ ast.Type) {
+	tc.env = NewTypeEnv(tc.env)
+	defer func() { tc.env = tc.env.outer }()
+
+	tc.defineParameters(parameters)
+	returnType, returnsNullable := tc.registry.Parse(typ.Name), typ.IsNullable
+	if accessor.Kind != "get" {
+		if tc.env.IsDefinedInScope("value") {
+			tc.errorf(accessor.Line, accessor.Column, "the parameter name value conflicts with the implicit parameter of the %s accessor", accessor.Kind)
+		}
+		tc.env.Define("value", returnType, false, false, true)
+		if typ.IsNullable {
+			tc.env.MarkNullable("value")
+		}
+		returnType, returnsNullable = types.Void, false
+	}
+	tc.enterMethod(returnType, returnsNullable)
+
+	block := accessor.Body.(ast.BlockStmt)
+	accessor.Body = tc.CheckBlockStmt(&block)
+	if !tc.isTypeCompatible(returnType, accessor.Body.(ast.TypedStmt).Type) {
+		tc.errorf(accessor.Line, accessor.Column, "type mismatch: expected %s, got %s", returnType, accessor.Body.(ast.TypedStmt).Type)
+	}
+}
+
+// Checks receiver[args] for reading if read is set and for assigning if write is set
+func (tc *TypeChecker) checkElementAccess(expr ast.ElementAccessExpr, read, write bool) ast.TypedExpr {
+	receiver := tc.CheckExpr(expr.Receiver)
+	tc.checkDereference(receiver, expr.Line, expr.Column)
+	expr.Receiver = receiver
+	// Compound assignments share the arguments between the assignee and the value
+	expr.Args = append([]ast.Expr{}, expr.Args...)
+
+	var typ types.Type
+	switch {
+	case receiver.Type.Kind() == types.ArrayKind:
+		typ = receiver.Type.(*types.Array).Element
+		expr.Args[0] = tc.checkArrayIndex(expr.Args, expr.Line, expr.Column)
+	case receiver.Type == types.String:
+		typ = types.Char
+		expr.Args[0] = tc.checkArrayIndex(expr.Args, expr.Line, expr.Column)
+		if write {
+			tc.errorf(expr.Line, expr.Column, "property or indexer string.this[int] cannot be assigned to -- it is read only")
+		}
+	default:
+		indexer, args := tc.resolveIndexer(receiver.Type.String(), expr.Args, read, write, expr.Line, expr.Column)
+		expr.Args = args
+		if read {
+			expr.Getter = indexer.GetterSignature()
+		}
+		if write {
+			expr.Setter = indexer.SetterSignature()
+		}
+		typ = indexer.ReturnType
+	}
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Arrays and strings are indexed by a single int
+func (tc *TypeChecker) checkArrayIndex(args []ast.Expr, line, column int) ast.TypedExpr {
+	if len(args) != 1 {
+		tc.errorf(line, column, "wrong number of indices inside []; expected 1")
+	}
+	if _, ok := args[0].(ast.ArgumentExpr); ok {
+		tc.errorf(args[0].GetLine(), args[0].GetColumn(), "an array index cannot have a name or a ref, out or in modifier")
+	}
+	index := tc.CheckExpr(args[0])
+	if !tc.isTypeCompatible(types.Int, index.Type) {
+		tc.errorf(args[0].GetLine(), args[0].GetColumn(), "cannot implicitly convert type %s to int", index.Type)
+	}
+	return index
+}
+
+// Picks the indexer of the type that fits the index arguments best
+func (tc *TypeChecker) resolveIndexer(typ string, args []ast.Expr, read, write bool, line, column int) (*MethodSymbol, []ast.Expr) {
+	candidates := []*MethodSymbol{}
+	for _, indexer := range tc.lookupMethods(typ, "this") {
+		if tc.isAccessible(indexer) {
+			candidates = append(candidates, indexer)
+		}
+	}
+	if len(candidates) == 0 {
+		tc.errorf(line, column, "cannot apply indexing with [] to an expression of type %s", typ)
+	}
+
+	indexer, bound := tc.resolveOverload("indexer", candidates, args, line, column)
+	tc.checkObsolete(indexer.Attributes, indexer.String(), line, column)
+	if read && !indexer.HasGetter {
+		tc.errorf(line, column, "the property or indexer %s cannot be used in this context because it lacks the get accessor", indexer)
+	}
+	if write && !indexer.HasSetter {
+		tc.errorf(line, column, "property or indexer %s cannot be assigned to -- it is read only", indexer)
+	}
+	return indexer, bound
+}
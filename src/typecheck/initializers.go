@@ -0,0 +1,121 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// An initializer either sets members and indexer entries of the created object or adds
+// elements to it as a collection, both kinds can not be mixed.
+func (tc *TypeChecker) checkObjectInitializer(initializer ast.ObjectInitializer, typ string) ast.ObjectInitializer {
+	// The initializer may be checked against several overloads, so the parsed elements are kept unchanged
+	initializer.Elements = append([]ast.InitializerElement{}, initializer.Elements...)
+	initialized := map[string]bool{}
+	for i, element := range initializer.Elements {
+		isCollectionElement := element.Args != nil
+		if i > 0 && isCollectionElement != (initializer.Elements[0].Args != nil) {
+			tc.errorf(element.Line, element.Column, "an initializer can not mix member initializers and collection elements")
+		}
+
+		switch {
+		case isCollectionElement:
+			element = tc.checkCollectionElement(element, typ)
+		case element.Index != nil:
+			element = tc.checkIndexInitializer(element, typ)
+		default:
+			if initialized[element.Member] {
+				tc.errorf(element.Line, element.Column, "duplicate initialization of member %s", element.Member)
+			}
+			initialized[element.Member] = true
+			element = tc.checkMemberInitializer(element, typ)
+		}
+		initializer.Elements[i] = element
+	}
+	return initializer
+}
+
+// Only accessible instance fields and properties that can be assigned are initialized, although
+// the object stored in a readonly field can be initialized with a nested initializer
+func (tc *TypeChecker) checkMemberInitializer(element ast.InitializerElement, typ string) ast.InitializerElement {
+	field, declaring, ok := tc.lookupField(typ, element.Member)
+	if !ok || !tc.isMemberAccessible(field.Modifiers, declaring) {
+		tc.errorf(element.Line, element.Column, "%s does not contain an accessible field called %s", typ, element.Member)
+	}
+	if hasModifier(field.Modifiers, lexer.STATIC) || hasModifier(field.Modifiers, lexer.CONST) {
+		tc.errorf(element.Line, element.Column, "static field %s can not be assigned in an object initializer", element.Member)
+	}
+	tc.checkObsolete(field.Attributes, declaring+"."+field.Identifier, element.Line, element.Column)
+
+	fieldType := tc.registry.Parse(field.Type.Name)
+	if element.Nested != nil {
+		element.Nested = tc.checkNestedInitializer(*element.Nested, fieldType)
+		return element
+	}
+	if hasModifier(field.Modifiers, lexer.READONLY) && !tc.isInitOnly(typ, element.Member) {
+		if _, declaring, isProperty := tc.lookupProperty(typ, element.Member); isProperty {
+			tc.errorf(element.Line, element.Column, "property or indexer %s.%s cannot be assigned to -- it is read only", declaring, element.Member)
+		}
+		tc.errorf(element.Line, element.Column, "readonly field %s can not be assigned in an object initializer", element.Member)
+	}
+	element.Value = tc.checkInitializerValue(element.Value, fieldType)
+	if tc.isPossibleNullConversion(fieldType, field.Type.IsNullable, element.Value, element.Line) {
+		tc.warnf(element.Line, element.Column, "possible null reference assignment")
+	}
+	return element
+}
+
+// [key] = value assigns through the indexer of the created object, [key] = { ... } initializes the element it gets
+func (tc *TypeChecker) checkIndexInitializer(element ast.InitializerElement, typ string) ast.InitializerElement {
+	isNested := element.Nested != nil
+	indexer, args := tc.resolveIndexer(typ, element.Index, isNested, !isNested, element.Line, element.Column)
+	element.Index = args
+
+	if isNested {
+		element.Signature = indexer.GetterSignature()
+		element.Nested = tc.checkNestedInitializer(*element.Nested, indexer.ReturnType)
+		return element
+	}
+	element.Signature = indexer.SetterSignature()
+	element.Value = tc.checkInitializerValue(element.Value, indexer.ReturnType)
+	return element
+}
+
+// Collection elements are passed to the best overload of an accessible Add method
+func (tc *TypeChecker) checkCollectionElement(element ast.InitializerElement, typ string) ast.InitializerElement {
+	candidates := []*MethodSymbol{}
+	for _, method := range tc.lookupMethods(typ, "Add") {
+		if tc.isAccessible(method) && !method.IsStatic() {
+			candidates = append(candidates, method)
+		}
+	}
+	if len(candidates) == 0 {
+		tc.errorf(element.Line, element.Column, "cannot initialize type %s with a collection initializer because it does not have an accessible Add method", typ)
+	}
+
+	method, args := tc.resolveOverload("Add", candidates, element.Args, element.Line, element.Column)
+	tc.checkObsolete(method.Attributes, method.Class+"."+method.Name, element.Line, element.Column)
+	element.Args = args
+	element.Signature = method.Signature()
+	return element
+}
+
+// Member = { ... } initializes the object the member already refers to, so it has to be a class
+func (tc *TypeChecker) checkNestedInitializer(initializer ast.ObjectInitializer, typ types.Type) *ast.ObjectInitializer {
+	if !tc.isUserObject(typ) {
+		tc.errorf(initializer.Line, initializer.Column, "cannot initialize a value of type %s with a nested initializer", typ)
+	}
+	if tc.isStruct(typ) {
+		tc.errorf(initializer.Line, initializer.Column, "cannot initialize the members of struct %s with a nested initializer", typ)
+	}
+	checked := tc.checkObjectInitializer(initializer, typ.String())
+	return &checked
+}
+
+func (tc *TypeChecker) checkInitializerValue(value ast.Expr, typ types.Type) ast.TypedExpr {
+	typed := tc.CheckTargetTypedExpr(value, typ)
+	if !tc.isTypeCompatible(typ, typed.Type) {
+		tc.errorf(value.GetLine(), value.GetColumn(), "type mismatch: expected %s, got %s", typ, typed.Type)
+	}
+	return typed
+}
@@ -0,0 +1,93 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// A method or local function whose body contains yield return or yield break is an iterator. It has to
+// return IEnumerable<T> or IEnumerator<T> and produces its elements of type T with yield return instead
+// of returning a value. The lowering pass turns iterators into state machine classes.
+
+// Reports whether an unchecked statement contains a yield statement outside of lambdas and local functions
+func containsYield(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case ast.YieldReturnStmt, ast.YieldBreakStmt:
+		return true
+	case ast.BlockStmt:
+		for _, inner := range s.Body {
+			if containsYield(inner) {
+				return true
+			}
+		}
+	case ast.IfStmt:
+		return containsYield(s.Then) || (s.Else != nil && containsYield(s.Else))
+	case ast.WhileStmt:
+		return containsYield(s.Body)
+	case ast.SwitchStmt:
+		for _, section := range s.Sections {
+			if containsYield(section.Body) {
+				return true
+			}
+		}
+	case ast.TryStmt:
+		if containsYield(s.Body) || (s.Finally != nil && containsYield(s.Finally)) {
+			return true
+		}
+		for _, clause := range s.Catches {
+			if containsYield(clause.Body) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the element type if the body is an iterator block, otherwise nil
+func (tc *TypeChecker) iteratorElementType(name string, returnType ast.Type, parameters []ast.Parameter, body ast.Stmt, line, column int) types.Type {
+	if !containsYield(body) {
+		return nil
+	}
+	generic, ok := tc.registry.Parse(returnType.Name).(*types.Generic)
+	if !ok || (generic.Name != "IEnumerable" && generic.Name != "IEnumerator") || len(generic.Arguments) != 1 {
+		tc.errorf(line, column, "the body of %s cannot be an iterator block because %s is not an iterator interface type", name, returnType.Name)
+	}
+	for _, param := range parameters {
+		if referenceModifier(param.Modifiers) != "" {
+			tc.errorf(param.Type.Line, param.Type.Column, "iterators cannot have ref, in or out parameters")
+		}
+	}
+	return generic.Arguments[0]
+}
+
+// Yield statements are only valid directly inside of an iterator and never inside of a finally clause
+func (tc *TypeChecker) checkYieldContext(line, column int) {
+	if tc.iteratorElement == nil {
+		tc.errorf(line, column, "the yield statement can only be used in the body of a method or local function returning IEnumerable<T> or IEnumerator<T>")
+	}
+	if tc.finallyDepth > 0 {
+		tc.errorf(line, column, "cannot yield in the body of a finally clause")
+	}
+}
+
+func (tc *TypeChecker) CheckYieldReturnStmt(stmt *ast.YieldReturnStmt) ast.TypedStmt {
+	tc.checkYieldContext(stmt.Line, stmt.Column)
+	if tc.tryCatchDepth > 0 {
+		tc.errorf(stmt.Line, stmt.Column, "cannot yield a value in the body of a try block with a catch clause")
+	}
+	if tc.catchDepth > 0 {
+		tc.errorf(stmt.Line, stmt.Column, "cannot yield a value in the body of a catch clause")
+	}
+
+	value := tc.CheckTargetTypedExpr(stmt.Value, tc.iteratorElement)
+	if !tc.isTypeCompatible(tc.iteratorElement, value.Type) {
+		tc.errorf(stmt.Line, stmt.Column, "type mismatch: expected %s, got %s", tc.iteratorElement, value.Type)
+	}
+	stmt.Value = value
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
+
+func (tc *TypeChecker) CheckYieldBreakStmt(stmt *ast.YieldBreakStmt) ast.TypedStmt {
+	tc.checkYieldContext(stmt.Line, stmt.Column)
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
@@ -0,0 +1,291 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// ClassSymbol is the member table of a class. Methods and constructors can be overloaded.
+// Properties are also listed in Fields by their field view.
+type ClassSymbol struct {
+	Decl         ast.ClassDeclStmt
+	Fields       map[string]ast.FieldDeclStmt
+	Properties   map[string]ast.PropertyDeclStmt
+	Events       map[string]ast.EventDeclStmt
+	Methods      map[string][]*MethodSymbol
+	Constructors []*MethodSymbol
+}
+
+type MethodSymbol struct {
+	Class      string
+	Name       string
+	Attributes []ast.Attribute
+	Modifiers  []ast.Modifier
+	Parameters []ast.Parameter
+	// The resolved types of Parameters
+	ParameterTypes []types.Type
+	ReturnType     types.Type
+	// The return type is a reference type declared with ?
+	ReturnsNullable bool
+	IsConstructor   bool
+	// Indexers are named this, ReturnType is the type of their elements
+	HasGetter bool
+	HasSetter bool
+}
+
+// Resolves the declared types of parameters
+func (tc *TypeChecker) parameterTypes(parameters []ast.Parameter) []types.Type {
+	parameterTypes := make([]types.Type, len(parameters))
+	for i, param := range parameters {
+		parameterTypes[i] = tc.registry.Parse(param.Type.Name)
+	}
+	return parameterTypes
+}
+
+// Parameters passed by reference keep their modifier so that F(int) and F(ref int) stay distinguishable
+func (method *MethodSymbol) parameterModifiers() []string {
+	modifiers := make([]string, len(method.Parameters))
+	for i, param := range method.Parameters {
+		modifiers[i] = referenceModifier(param.Modifiers)
+	}
+	return modifiers
+}
+
+func (method *MethodSymbol) parameterIndex(name string) int {
+	for i, param := range method.Parameters {
+		if param.Identifier == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (method *MethodSymbol) paramsIndex() int {
+	last := len(method.Parameters) - 1
+	if last >= 0 && hasModifier(method.Parameters[last].Modifiers, lexer.PARAMS) {
+		return last
+	}
+	return -1
+}
+
+// Returns "ref", "out" or "in" for parameters and arguments passed by reference
+func referenceModifier(modifiers []ast.Modifier) string {
+	switch {
+	case hasModifier(modifiers, lexer.REF):
+		return "ref"
+	case hasModifier(modifiers, lexer.OUT):
+		return "out"
+	case hasModifier(modifiers, lexer.IN):
+		return "in"
+	}
+	return ""
+}
+
+func (method *MethodSymbol) IsStatic() bool {
+	return hasModifier(method.Modifiers, lexer.STATIC)
+}
+
+func (method *MethodSymbol) Signature() *ast.MethodSignature {
+	return &ast.MethodSignature{
+		Class:              method.Class,
+		Name:               method.Name,
+		ParameterTypes:     method.ParameterTypes,
+		ParameterModifiers: method.parameterModifiers(),
+		ReturnType:         method.ReturnType,
+		ReturnsNullable:    method.ReturnsNullable,
+		IsStatic:           method.IsStatic(),
+	}
+}
+
+// The accessors of an indexer are the methods get_Item(index) and set_Item(index, value)
+func (method *MethodSymbol) GetterSignature() *ast.MethodSignature {
+	signature := method.Signature()
+	signature.Name = "get_Item"
+	return signature
+}
+
+func (method *MethodSymbol) SetterSignature() *ast.MethodSignature {
+	signature := method.Signature()
+	signature.Name = "set_Item"
+	signature.ParameterTypes = append(append([]types.Type{}, method.ParameterTypes...), method.ReturnType)
+	signature.ParameterModifiers = append(signature.ParameterModifiers, "")
+	signature.ReturnType = types.Void
+	signature.ReturnsNullable = false
+	return signature
+}
+
+func (method *MethodSymbol) String() string {
+	if method.Name == "this" {
+		return fmt.Sprintf("%s.this[%s]", method.Class, method.Signature().ParameterList())
+	}
+	return fmt.Sprintf("%s.%s(%s)", method.Class, method.Name, method.Signature().ParameterList())
+}
+
+func (method *MethodSymbol) hasSameParameters(other *MethodSymbol) bool {
+	if len(method.Parameters) != len(other.Parameters) {
+		return false
+	}
+	// ref, out and in can not be used to tell overloads apart from each other
+	for i, param := range method.Parameters {
+		byReference := referenceModifier(param.Modifiers) != ""
+		otherByReference := referenceModifier(other.Parameters[i].Modifiers) != ""
+		if method.ParameterTypes[i] != other.ParameterTypes[i] || byReference != otherByReference {
+			return false
+		}
+	}
+	return true
+}
+
+// Builds the member table of a class and rejects members that can not be told apart
+func (tc *TypeChecker) declareClass(decl ast.ClassDeclStmt) *ClassSymbol {
+	class := &ClassSymbol{
+		Decl:       decl,
+		Fields:     make(map[string]ast.FieldDeclStmt),
+		Properties: make(map[string]ast.PropertyDeclStmt),
+		Events:     make(map[string]ast.EventDeclStmt),
+		Methods:    make(map[string][]*MethodSymbol),
+	}
+
+	hasStaticConstructor := false
+	for _, member := range decl.Body.Members {
+		tc.file = member.GetFile()
+		switch member := member.(type) {
+		case ast.FieldDeclStmt:
+			if _, exists := class.Properties[member.Identifier]; exists {
+				tc.errorf(member.Line, member.Column, "class %s already defines a member called %s", decl.Name, member.Identifier)
+			}
+			if _, exists := class.Fields[member.Identifier]; exists {
+				tc.errorf(member.Line, member.Column, "class %s already defines a field called %s", decl.Name, member.Identifier)
+			}
+			if _, exists := class.Events[member.Identifier]; exists {
+				tc.errorf(member.Line, member.Column, "class %s already defines a member called %s", decl.Name, member.Identifier)
+			}
+			class.Fields[member.Identifier] = member
+		case ast.PropertyDeclStmt:
+			_, isField := class.Fields[member.Name]
+			_, isEvent := class.Events[member.Name]
+			if isField || isEvent {
+				tc.errorf(member.Line, member.Column, "class %s already defines a member called %s", decl.Name, member.Name)
+			}
+			class.Properties[member.Name] = member
+			class.Fields[member.Name] = propertyField(member)
+		case ast.EventDeclStmt:
+			_, isField := class.Fields[member.Name]
+			_, isEvent := class.Events[member.Name]
+			if isField || isEvent {
+				tc.errorf(member.Line, member.Column, "class %s already defines a member called %s", decl.Name, member.Name)
+			}
+			class.Events[member.Name] = member
+		case ast.MethodDeclStmt:
+			method := &MethodSymbol{Class: decl.Name, Name: member.Name, Attributes: member.Attributes, Modifiers: member.Modifiers, Parameters: member.Parameters, ParameterTypes: tc.parameterTypes(member.Parameters), ReturnType: tc.registry.Parse(member.ReturnType.Name), ReturnsNullable: member.ReturnType.IsNullable}
+			for _, existing := range class.Methods[member.Name] {
+				if existing.hasSameParameters(method) {
+					tc.errorf(member.Line, member.Column, "class %s already defines a member called %s with the same parameter types", decl.Name, member.Name)
+				}
+			}
+			class.Methods[member.Name] = append(class.Methods[member.Name], method)
+		case ast.IndexerDeclStmt:
+			indexer := &MethodSymbol{Class: decl.Name, Name: "this", Attributes: member.Attributes, Modifiers: member.Modifiers, Parameters: member.Parameters, ParameterTypes: tc.parameterTypes(member.Parameters), ReturnType: tc.registry.Parse(member.Type.Name), ReturnsNullable: member.Type.IsNullable}
+			for _, accessor := range member.Accessors {
+				indexer.HasGetter = indexer.HasGetter || accessor.Kind == "get"
+				indexer.HasSetter = indexer.HasSetter || accessor.Kind == "set"
+			}
+			for _, existing := range class.Methods["this"] {
+				if existing.hasSameParameters(indexer) {
+					tc.errorf(member.Line, member.Column, "class %s already defines an indexer with the same parameter types", decl.Name)
+				}
+			}
+			class.Methods["this"] = append(class.Methods["this"], indexer)
+		case ast.ConstructorDeclStmt:
+			// The static constructor runs when the class is initialized, it can not be called
+			if hasModifier(member.Modifiers, lexer.STATIC) {
+				if len(member.Parameters) > 0 {
+					tc.errorf(member.Line, member.Column, "a static constructor must be parameterless")
+				}
+				if hasStaticConstructor {
+					tc.errorf(member.Line, member.Column, "class %s already defines a static constructor", decl.Name)
+				}
+				hasStaticConstructor = true
+				continue
+			}
+			constructor := &MethodSymbol{Class: decl.Name, Name: member.Name, Attributes: member.Attributes, Modifiers: member.Modifiers, Parameters: member.Parameters, ParameterTypes: tc.parameterTypes(member.Parameters), ReturnType: types.Void, IsConstructor: true}
+			for _, existing := range class.Constructors {
+				if existing.hasSameParameters(constructor) {
+					tc.errorf(member.Line, member.Column, "class %s already defines a constructor with the same parameter types", decl.Name)
+				}
+			}
+			class.Constructors = append(class.Constructors, constructor)
+		}
+	}
+
+	return class
+}
+
+// Collects all methods with the given name visible in a class, including inherited ones
+// that are not hidden by a method with the same parameters in a derived class
+func (tc *TypeChecker) lookupMethods(className, methodName string) []*MethodSymbol {
+	methods := []*MethodSymbol{}
+	visited := map[string]bool{}
+
+	for current, ok := className, tc.isClassName(className); ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		visited[current] = true
+		for _, method := range tc.classes[current].Methods[methodName] {
+			hidden := false
+			for _, existing := range methods {
+				if existing.hasSameParameters(method) {
+					hidden = true
+					break
+				}
+			}
+			if !hidden {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	return methods
+}
+
+// Finds a field in a class or one of its base classes, also returns the name of the declaring class
+func (tc *TypeChecker) lookupField(className, fieldName string) (ast.FieldDeclStmt, string, bool) {
+	visited := map[string]bool{}
+	for current, ok := className, tc.isClassName(className); ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		visited[current] = true
+		if field, exists := tc.classes[current].Fields[fieldName]; exists {
+			return field, current, true
+		}
+	}
+	return ast.FieldDeclStmt{}, "", false
+}
+
+// Private members are only accessible inside of their class and its nested classes,
+// protected members also in derived classes
+func (tc *TypeChecker) isAccessible(method *MethodSymbol) bool {
+	return tc.isMemberAccessible(method.Modifiers, method.Class)
+}
+
+func (tc *TypeChecker) isMemberAccessible(modifiers []ast.Modifier, declaring string) bool {
+	current := tc.currentClassName()
+	if isPrivate(modifiers) {
+		return isNestedIn(current, declaring)
+	}
+	if hasModifier(modifiers, lexer.PROTECTED) {
+		return isNestedIn(current, declaring) || tc.isSubclassOf(current, declaring)
+	}
+	return true
+}
+
+// Reports whether class is the given outer class or one of the classes nested inside of it
+func isNestedIn(class, outer string) bool {
+	return class == outer || strings.HasPrefix(class, outer+".")
+}
+
+// Members without an access modifier are private
+func isPrivate(modifiers []ast.Modifier) bool {
+	return hasModifier(modifiers, lexer.PRIVATE) ||
+		!(hasModifier(modifiers, lexer.PUBLIC) || hasModifier(modifiers, lexer.PROTECTED) || hasModifier(modifiers, lexer.INTERNAL))
+}
@@ -0,0 +1,307 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Nullable value types like int? are types of their own. Reference types declared with ? are the same
+// types as without it, the annotation is only used by the null state analysis in a #nullable enable
+// context, which warns about dereferences and conversions of values that may be null.
+
+// The nullable context of a line is set by the last #nullable directive before it in the same file
+func (tc *TypeChecker) isNullableEnabled(line int) bool {
+	enabled := false
+	for _, directive := range tc.nullableDirectives[tc.file] {
+		if directive.Line > line {
+			break
+		}
+		enabled = directive.Setting == "enable"
+	}
+	return enabled
+}
+
+func (tc *TypeChecker) isValueType(typ types.Type) bool {
+	return typ == types.Bool || types.IsNumeric(typ) || tc.isEnum(typ) || tc.isStruct(typ) || tc.isTupleType(typ)
+}
+
+// Returns T for a nullable value type T?
+func (tc *TypeChecker) nullableUnderlying(typ types.Type) (types.Type, bool) {
+	nullable, ok := typ.(*types.Nullable)
+	if !ok || !tc.isValueType(nullable.Underlying) {
+		return typ, false
+	}
+	return nullable.Underlying, true
+}
+
+func (tc *TypeChecker) isNullableValueType(typ types.Type) bool {
+	_, ok := tc.nullableUnderlying(typ)
+	return ok
+}
+
+// Reports whether null is a value of the type
+func (tc *TypeChecker) isNullable(typ types.Type) bool {
+	return tc.isReferenceType(typ) || tc.isNullableValueType(typ)
+}
+
+// Reference types inside of array and generic types lose their annotation
+func (tc *TypeChecker) withoutAnnotation(typ string) string {
+	if underlying, ok := strings.CutSuffix(typ, "?"); ok && !tc.isValueType(tc.registry.Parse(underlying)) {
+		return underlying
+	}
+	return typ
+}
+
+// T? of a reference type T is T annotated as nullable
+func (tc *TypeChecker) annotateNullable(typ *ast.Type, underlying string) string {
+	if strings.HasPrefix(typ.Name, "Nullable<") {
+		tc.errorf(typ.Line, typ.Column, "the type %s must be a non-nullable value type in order to use it as parameter T in Nullable<T>", underlying)
+	}
+	if !tc.isNullableEnabled(typ.Line) {
+		tc.warnf(typ.Line, typ.Column, "the annotation for nullable reference types should only be used in code within a #nullable enable context")
+	}
+	typ.IsNullable = true
+	return underlying
+}
+
+// Lifted operators on nullable value types produce a nullable result
+func (tc *TypeChecker) liftedType(result types.Type, operands ...types.Type) types.Type {
+	for _, operand := range operands {
+		if tc.isNullableValueType(operand) && !tc.isNullableValueType(result) {
+			return tc.registry.NewNullable(result)
+		}
+	}
+	return result
+}
+
+// Reports whether an expression may evaluate to null according to the declared nullability
+// of the variables and methods it uses and the null checks before it
+func (tc *TypeChecker) isMaybeNull(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.TypedExpr:
+		if e.Type == types.Null {
+			return true
+		}
+		if !tc.isNullable(e.Type) {
+			return false
+		}
+		return tc.isMaybeNull(e.Expr)
+	case ast.NullLiteralExpr, ast.AsExpr:
+		return true
+	case ast.LocalVarExpr:
+		return tc.env.IsMaybeNull(e.Name)
+	case ast.FieldVarExpr:
+		return tc.env.IsMaybeNull(e.Name)
+	case ast.MethodCallExpr:
+		return e.Signature != nil && (e.Signature.ReturnsNullable || e.Signature.ReturnType.Kind() == types.NullableKind)
+	case ast.ElementAccessExpr:
+		return e.Getter != nil && (e.Getter.ReturnsNullable || e.Getter.ReturnType.Kind() == types.NullableKind)
+	case ast.BinaryExpr:
+		return e.Operator.Kind == lexer.NULL_COALESCING && tc.isMaybeNull(e.Right)
+	case ast.AssignmentExpr:
+		return tc.isMaybeNull(e.Value)
+	case ast.SwitchExpr:
+		for _, arm := range e.Arms {
+			if tc.isMaybeNull(arm.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isImplicitDefault(expr ast.Expr) bool {
+	if typed, ok := expr.(ast.TypedExpr); ok {
+		expr = typed.Expr
+	}
+	null, ok := expr.(ast.NullLiteralExpr)
+	return ok && null.IsImplicit
+}
+
+// Reports whether a value that may be null is converted to a reference type that is not annotated as nullable.
+// Default values of declarations without initializer are not reported.
+func (tc *TypeChecker) isPossibleNullConversion(target types.Type, targetNullable bool, value ast.Expr, line int) bool {
+	return !targetNullable && tc.isReferenceType(target) && tc.isNullableEnabled(line) && !isImplicitDefault(value) && tc.isMaybeNull(value)
+}
+
+// Values are not null after they have been dereferenced, so only the first dereference is reported
+func (tc *TypeChecker) checkDereference(value ast.TypedExpr, line, column int) {
+	if tc.isNullableEnabled(line) && tc.isMaybeNull(value) {
+		if tc.isNullableValueType(value.Type) {
+			tc.warnf(line, column, "nullable value type may be null")
+		} else {
+			tc.warnf(line, column, "dereference of a possibly null reference")
+		}
+	}
+	if name := variableName(value); name != "" {
+		tc.env.SetMaybeNull(name, false)
+	}
+}
+
+// Assignments set the null state of a variable to the null state of the assigned value
+func (tc *TypeChecker) updateNullState(name string, value ast.Expr) {
+	tc.env.SetMaybeNull(name, tc.isMaybeNull(value))
+}
+
+func (tc *TypeChecker) markNotNull(names []string) {
+	for _, name := range names {
+		tc.env.SetMaybeNull(name, false)
+	}
+}
+
+// Narrows the scope for the branch of a checked condition: pattern variables are assigned and
+// variables compared against null are known to be non-null
+func (tc *TypeChecker) narrow(condition ast.Expr, whenTrue bool) *TypeEnvironment {
+	env := NewNarrowingEnv(tc.env, assignedWhen(condition, whenTrue))
+	for _, name := range notNullWhen(condition, whenTrue) {
+		env.SetMaybeNull(name, false)
+	}
+	return env
+}
+
+// The variables that are known to be non-null when a checked condition evaluates to whenTrue,
+// e.g. x for x != null, x is string s or x.HasValue
+func notNullWhen(condition ast.Expr, whenTrue bool) []string {
+	switch c := condition.(type) {
+	case ast.TypedExpr:
+		return notNullWhen(c.Expr, whenTrue)
+	case ast.PrefixExpr:
+		if c.Operator.Kind == lexer.NOT {
+			return notNullWhen(c.Expression, !whenTrue)
+		}
+	case ast.MemberAccessExpr:
+		if receiver, ok := c.Receiver.(ast.TypedExpr); ok && c.Member == "HasValue" && whenTrue {
+			return variableNames(receiver)
+		}
+	case ast.IsPatternExpr:
+		if receiver, ok := c.Expression.(ast.TypedExpr); ok {
+			if (whenTrue && excludesNull(c.Pattern)) || (!whenTrue && isNullPattern(c.Pattern)) {
+				return variableNames(receiver)
+			}
+		}
+	case ast.BinaryExpr:
+		switch c.Operator.Kind {
+		case lexer.EQUALS, lexer.NOT_EQUALS:
+			if (c.Operator.Kind == lexer.NOT_EQUALS) != whenTrue {
+				return nil
+			}
+			left, right := c.Left.(ast.TypedExpr), c.Right.(ast.TypedExpr)
+			if right.Type == types.Null {
+				return variableNames(left)
+			}
+			if left.Type == types.Null {
+				return variableNames(right)
+			}
+		case lexer.AND, lexer.OR:
+			left, right := notNullWhen(c.Left, whenTrue), notNullWhen(c.Right, whenTrue)
+			// a && b is true if both are, a || b is false if both are
+			if (c.Operator.Kind == lexer.AND) == whenTrue {
+				return append(left, right...)
+			}
+			both := []string{}
+			for _, name := range left {
+				for _, other := range right {
+					if name == other {
+						both = append(both, name)
+					}
+				}
+			}
+			return both
+		}
+	}
+	return nil
+}
+
+func variableNames(expr ast.TypedExpr) []string {
+	if name := variableName(expr); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+func isNullPattern(pattern ast.Pattern) bool {
+	constant, ok := pattern.(ast.ConstantPattern)
+	if !ok {
+		return false
+	}
+	if typed, ok := constant.Value.(ast.TypedExpr); ok {
+		return typed.Type == types.Null
+	}
+	_, ok = constant.Value.(ast.NullLiteralExpr)
+	return ok
+}
+
+// Reports whether a pattern never matches null
+func excludesNull(pattern ast.Pattern) bool {
+	switch p := pattern.(type) {
+	case ast.ConstantPattern:
+		return !isNullPattern(p)
+	case ast.DeclarationPattern:
+		return p.Type.Name != "var"
+	case ast.TypePattern, ast.PropertyPattern, ast.RelationalPattern:
+		return true
+	case ast.NotPattern:
+		return isNullPattern(p.Pattern)
+	case ast.BinaryPattern:
+		if p.Operator == "and" {
+			return excludesNull(p.Left) || excludesNull(p.Right)
+		}
+		return excludesNull(p.Left) && excludesNull(p.Right)
+	}
+	return false
+}
+
+// a ?? b is a if it is not null and b otherwise. For a nullable value type T? the result is T if b is a T.
+func (tc *TypeChecker) CheckNullCoalescingExpr(expr ast.BinaryExpr) ast.TypedExpr {
+	left := tc.CheckExpr(expr.Left)
+	expr.Left = left
+
+	resultType := left.Type
+	if underlying, ok := tc.nullableUnderlying(left.Type); ok {
+		resultType = underlying
+	} else if left.Type != types.Null && !tc.isReferenceType(left.Type) {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator ?? cannot be applied to operand of type %s", left.Type)
+	}
+
+	// A throw expression converts to the type of the left operand
+	if throw, ok := expr.Right.(ast.ThrowExpr); ok {
+		expr.Right = tc.CheckThrowExpr(throw, resultType)
+		return ast.TypedExpr{Type: resultType, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+	}
+
+	right := tc.CheckTargetTypedExpr(expr.Right, resultType)
+	expr.Right = right
+	switch {
+	case left.Type == types.Null:
+		resultType = right.Type
+	case tc.isTypeCompatible(resultType, right.Type):
+	case tc.isTypeCompatible(left.Type, right.Type):
+		resultType = left.Type
+	default:
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator ?? cannot be applied to operands of type %s and %s", left.Type, right.Type)
+	}
+	return ast.TypedExpr{Type: resultType, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+}
+
+// HasValue and Value of nullable value types
+func (tc *TypeChecker) checkNullableMemberAccess(expr ast.MemberAccessExpr, receiver ast.TypedExpr, underlying types.Type) ast.TypedExpr {
+	expr.Receiver = receiver
+	switch expr.Member {
+	case "HasValue":
+		return ast.TypedExpr{Type: types.Bool, Expr: expr, Line: expr.Line, Column: expr.Column}
+	case "Value":
+		tc.checkDereference(receiver, expr.Line, expr.Column)
+		return ast.TypedExpr{Type: underlying, Expr: expr, Line: expr.Line, Column: expr.Column}
+	}
+	tc.errorf(expr.Line, expr.Column, "%s does not contain a definition for %s", receiver.Type, expr.Member)
+	return ast.TypedExpr{Type: types.Error}
+}
+
+func (tc *TypeChecker) CheckNullForgivingExpr(expr ast.NullForgivingExpr) ast.TypedExpr {
+	operand := tc.CheckExpr(expr.Operand)
+	expr.Operand = operand
+	return ast.TypedExpr{Type: operand.Type, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
@@ -0,0 +1,256 @@
+package typecheck
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Constant expressions of integral types are evaluated to find overflows and to convert int constants
+// to smaller integral types they fit into like byte b = 255.
+
+type integralRange struct {
+	min, max *big.Int
+}
+
+func newRange(min int64, max uint64) integralRange {
+	return integralRange{min: big.NewInt(min), max: new(big.Int).SetUint64(max)}
+}
+
+var integralRanges = map[types.Type]integralRange{
+	types.SByte:  newRange(math.MinInt8, math.MaxInt8),
+	types.Byte:   newRange(0, math.MaxUint8),
+	types.Short:  newRange(math.MinInt16, math.MaxInt16),
+	types.UShort: newRange(0, math.MaxUint16),
+	types.Char:   newRange(0, math.MaxUint16),
+	types.Int:    newRange(math.MinInt32, math.MaxInt32),
+	types.UInt:   newRange(0, math.MaxUint32),
+	types.Long:   newRange(math.MinInt64, math.MaxInt64),
+	types.ULong:  newRange(0, math.MaxUint64),
+}
+
+func fitsIntegral(value *big.Int, typ types.Type) bool {
+	limits := integralRanges[typ]
+	return value.Cmp(limits.min) >= 0 && value.Cmp(limits.max) <= 0
+}
+
+// Wraps a value around into the range of an integral type the way an unchecked conversion does
+func wrapIntegral(value *big.Int, typ types.Type) *big.Int {
+	limits := integralRanges[typ]
+	size := new(big.Int).Sub(limits.max, limits.min)
+	size.Add(size, big.NewInt(1))
+	wrapped := new(big.Int).Sub(value, limits.min)
+	wrapped.Mod(wrapped, size)
+	return wrapped.Add(wrapped, limits.min)
+}
+
+// The type of an integer literal is the first of int, uint, long and ulong that can hold its value and
+// that its suffix allows
+func integerLiteralType(literal ast.IntLiteralExpr) types.Type {
+	value := new(big.Int).SetUint64(uint64(literal.Value))
+	candidates := map[string][]types.Type{
+		"":   {types.Int, types.UInt, types.Long, types.ULong},
+		"U":  {types.UInt, types.ULong},
+		"L":  {types.Long, types.ULong},
+		"UL": {types.ULong},
+	}[literal.Suffix]
+	for _, typ := range candidates {
+		if fitsIntegral(value, typ) {
+			return typ
+		}
+	}
+	return types.ULong
+}
+
+func (tc *TypeChecker) checkRealLiteral(literal ast.RealLiteralExpr) ast.TypedExpr {
+	typ := types.Double
+	switch literal.Suffix {
+	case "F":
+		typ = types.Float
+		if literal.Value > math.MaxFloat32 {
+			tc.errorf(literal.Line, literal.Column, "floating-point constant is outside the range of type float")
+		}
+	case "M":
+		typ = types.Decimal
+	}
+	return ast.TypedExpr{Type: typ, Expr: literal, Line: literal.Line, Column: literal.Column}
+}
+
+// An int or long constant that fits into uint or ulong on the other side of a binary operator takes
+// that type, u + 1 is a uint and not a long
+func (tc *TypeChecker) constantOperandType(operand ast.TypedExpr, other types.Type) types.Type {
+	if (other != types.UInt && other != types.ULong) || (operand.Type != types.Int && operand.Type != types.Long) {
+		return operand.Type
+	}
+	if value, ok := tc.integralConstant(operand); ok && fitsIntegral(value, other) {
+		return other
+	}
+	return operand.Type
+}
+
+// Types a binary operator on numeric operands, the operands may be nullable value types
+func (tc *TypeChecker) checkNumericBinaryExpr(expr ast.BinaryExpr, left, right types.Type) ast.TypedExpr {
+	leftOperand, rightOperand := expr.Left.(ast.TypedExpr), expr.Right.(ast.TypedExpr)
+	operands := [2]types.Type{tc.constantOperandType(leftOperand, right), tc.constantOperandType(rightOperand, left)}
+	typ, ok := types.BinaryNumericPromotion(operands[0], operands[1])
+	if !ok {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s is ambiguous on operands of type %s and %s", expr.Operator.Value, leftOperand.Type, rightOperand.Type)
+	}
+	switch expr.Operator.Kind {
+	case lexer.EQUALS, lexer.NOT_EQUALS, lexer.LESS_THAN, lexer.LESS_THAN_OR_EQUAL, lexer.GREATER_THAN, lexer.GREATER_THAN_OR_EQUAL:
+		return ast.TypedExpr{Expr: expr, Type: types.Bool, Line: expr.Line, Column: expr.Column}
+	case lexer.BITWISE_OR, lexer.BITWISE_AND:
+		if !types.IsIntegral(typ) {
+			tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s", expr.Operator.Value, leftOperand.Type, rightOperand.Type)
+		}
+	case lexer.PLUS, lexer.MINUS, lexer.MULTIPLY, lexer.DIVIDE, lexer.MODULUS:
+	default:
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s", expr.Operator.Value, leftOperand.Type, rightOperand.Type)
+	}
+
+	typed := ast.TypedExpr{Expr: expr, Type: tc.liftedType(typ, leftOperand.Type, rightOperand.Type), Line: expr.Line, Column: expr.Column}
+	if types.IsIntegral(typed.Type) {
+		tc.checkConstantOverflow(typed)
+	}
+	return typed
+}
+
+// Overflows of constant expressions are errors unless they are in an unchecked context
+func (tc *TypeChecker) checkConstantOverflow(typed ast.TypedExpr) {
+	if binary, ok := typed.Expr.(ast.BinaryExpr); ok && (binary.Operator.Kind == lexer.DIVIDE || binary.Operator.Kind == lexer.MODULUS) {
+		if divisor, ok := tc.integralConstant(binary.Right); ok && divisor.Sign() == 0 {
+			tc.errorf(typed.Line, typed.Column, "division by constant zero")
+		}
+	}
+	value, ok := tc.constantOperation(typed.Expr)
+	if ok && !fitsIntegral(value, typed.Type) && !tc.unchecked {
+		tc.errorf(typed.Line, typed.Column, "the operation overflows at compile time in checked mode")
+	}
+}
+
+// Value of an integral constant expression, wrapped around into the range of its type
+func (tc *TypeChecker) integralConstant(expr ast.Expr) (*big.Int, bool) {
+	typed, ok := expr.(ast.TypedExpr)
+	if !ok || !types.IsIntegral(typed.Type) {
+		return nil, false
+	}
+	value, ok := tc.constantOperation(typed.Expr)
+	if !ok {
+		return nil, false
+	}
+	return wrapIntegral(value, typed.Type), true
+}
+
+// Value of a checked constant expression before it is converted to the type of the expression
+func (tc *TypeChecker) constantOperation(expr ast.Expr) (*big.Int, bool) {
+	switch e := expr.(type) {
+	case ast.IntLiteralExpr:
+		return new(big.Int).SetUint64(uint64(e.Value)), true
+	case ast.CharLiteralExpr:
+		return big.NewInt(int64(e.Value)), true
+	case ast.LocalVarExpr:
+		// Named constants have the value of their initializer
+		if info, ok := tc.env.Lookup(e.Name); ok && info.IsConstant && info.Value != nil {
+			return tc.integralConstant(info.Value)
+		}
+	case ast.FieldVarExpr:
+		if value, ok := tc.constantValue(tc.currentClassName(), e.Name); ok {
+			return tc.integralConstant(value)
+		}
+	case ast.MemberAccessExpr:
+		if className, ok := tc.typeNameOf(e.Receiver); ok {
+			if value, ok := tc.constantValue(className, e.Member); ok {
+				return tc.integralConstant(value)
+			}
+		}
+	case ast.CheckedExpr:
+		return tc.integralConstant(e.Expression)
+	case ast.CastExpr:
+		return tc.integralConstant(e.Expression)
+	case ast.PrefixExpr:
+		operand, ok := tc.integralConstant(e.Expression)
+		if !ok {
+			return nil, false
+		}
+		if e.Operator.Kind == lexer.MINUS {
+			return new(big.Int).Neg(operand), true
+		}
+		return operand, e.Operator.Kind == lexer.PLUS
+	case ast.BinaryExpr:
+		left, ok := tc.integralConstant(e.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := tc.integralConstant(e.Right)
+		if !ok {
+			return nil, false
+		}
+		result := new(big.Int)
+		switch e.Operator.Kind {
+		case lexer.PLUS:
+			return result.Add(left, right), true
+		case lexer.MINUS:
+			return result.Sub(left, right), true
+		case lexer.MULTIPLY:
+			return result.Mul(left, right), true
+		case lexer.DIVIDE:
+			// Integer division truncates towards zero and the remainder has the sign of the dividend
+			return result.Quo(left, right), right.Sign() != 0
+		case lexer.MODULUS:
+			return result.Rem(left, right), right.Sign() != 0
+		case lexer.BITWISE_AND:
+			return result.And(left, right), true
+		case lexer.BITWISE_OR:
+			return result.Or(left, right), true
+		}
+	}
+	return nil, false
+}
+
+// Implicit constant expression conversions: an int constant converts to sbyte, byte, short, ushort,
+// uint and ulong and a long constant to ulong if its value fits. Constants that do not fit are reported
+// unless explicit is set, casts report them themselves.
+func (tc *TypeChecker) convertConstant(typed ast.TypedExpr, target types.Type, explicit bool) ast.TypedExpr {
+	if underlying, ok := tc.nullableUnderlying(target); ok {
+		target = underlying
+	}
+	if (typed.Type != types.Int && typed.Type != types.Long) || !types.IsIntegral(target) || target == types.Char || target == typed.Type ||
+		types.IsImplicitNumericConversion(typed.Type, target) || (typed.Type == types.Long && target != types.ULong) {
+		return typed
+	}
+	value, ok := tc.integralConstant(typed)
+	if !ok {
+		return typed
+	}
+	if !fitsIntegral(value, target) {
+		if !explicit {
+			tc.errorf(typed.Line, typed.Column, "constant value %s cannot be converted to a %s", value, target)
+		}
+		return typed
+	}
+	typed.Type = target
+	return typed
+}
+
+// (T)c of an integral constant c that does not fit into T needs an unchecked context
+func (tc *TypeChecker) checkConstantCast(operand ast.TypedExpr, target types.Type, line, column int) {
+	if !types.IsIntegral(operand.Type) || !types.IsIntegral(target) || tc.unchecked {
+		return
+	}
+	if value, ok := tc.integralConstant(operand); ok && !fitsIntegral(value, target) {
+		tc.errorf(line, column, "constant value %s cannot be converted to a %s (use 'unchecked' syntax to override)", value, target)
+	}
+}
+
+// checked(x) and unchecked(x) check x in their context
+func (tc *TypeChecker) CheckCheckedExpr(expr ast.CheckedExpr) ast.TypedExpr {
+	unchecked := tc.unchecked
+	tc.unchecked = expr.Context == lexer.UNCHECKED
+	operand := tc.CheckExpr(expr.Expression)
+	tc.unchecked = unchecked
+	expr.Expression = operand
+	return ast.TypedExpr{Type: operand.Type, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
@@ -0,0 +1,330 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// User-defined operators are static methods named like op_Addition, so they are declared and
+// resolved like methods. An operator is looked up in the classes of its operand types.
+
+// Operators that have to be declared together
+var operatorPairs = map[string]string{
+	"op_Equality":           "op_Inequality",
+	"op_Inequality":         "op_Equality",
+	"op_LessThan":           "op_GreaterThan",
+	"op_GreaterThan":        "op_LessThan",
+	"op_LessThanOrEqual":    "op_GreaterThanOrEqual",
+	"op_GreaterThanOrEqual": "op_LessThanOrEqual",
+	"op_True":               "op_False",
+	"op_False":              "op_True",
+}
+
+func (tc *TypeChecker) checkOperatorDeclaration(method *ast.MethodDeclStmt, containing types.Type) {
+	if !hasModifier(method.Modifiers, lexer.STATIC) || !hasModifier(method.Modifiers, lexer.PUBLIC) {
+		tc.errorf(method.Line, method.Column, "user-defined operator %s must be declared static and public", method.Operator)
+	}
+	for _, param := range method.Parameters {
+		if modifier := referenceModifier(param.Modifiers); modifier == "ref" || modifier == "out" {
+			tc.errorf(method.Line, method.Column, "user-defined operators cannot have ref or out parameters")
+		}
+	}
+
+	// Inside of its own declaration a struct S can also be used as S?
+	isContaining := func(typ types.Type) bool {
+		return types.Underlying(typ) == containing
+	}
+	parameterTypes, returnType := tc.parameterTypes(method.Parameters), tc.registry.Parse(method.ReturnType.Name)
+
+	switch method.Name {
+	case "op_Implicit", "op_Explicit":
+		tc.checkConversionDeclaration(method, containing)
+		return
+	case "op_Increment", "op_Decrement":
+		if !isContaining(parameterTypes[0]) {
+			tc.errorf(method.Line, method.Column, "the parameter of a unary operator must be the containing type")
+		}
+		if !isContaining(returnType) && !tc.IsSubclassOf(returnType, containing) {
+			tc.errorf(method.Line, method.Column, "the return type for ++ or -- operator must match the parameter type or be derived from the parameter type")
+		}
+	case "op_True", "op_False":
+		if !isContaining(parameterTypes[0]) {
+			tc.errorf(method.Line, method.Column, "the parameter of a unary operator must be the containing type")
+		}
+		if returnType != types.Bool {
+			tc.errorf(method.Line, method.Column, "the return type of operator %s must be bool", method.Operator)
+		}
+	default:
+		if len(parameterTypes) == 1 && !isContaining(parameterTypes[0]) {
+			tc.errorf(method.Line, method.Column, "the parameter of a unary operator must be the containing type")
+		}
+		if len(parameterTypes) == 2 && !isContaining(parameterTypes[0]) && !isContaining(parameterTypes[1]) {
+			tc.errorf(method.Line, method.Column, "one of the parameters of a binary operator must be the containing type")
+		}
+	}
+	if returnType == types.Void {
+		tc.errorf(method.Line, method.Column, "user-defined operators cannot return void")
+	}
+
+	if pair, ok := operatorPairs[method.Name]; ok && !tc.declaresOperator(containing.String(), pair, method) {
+		tc.errorf(method.Line, method.Column, "the operator %s requires a matching operator %s to also be defined", method.Operator, operatorSymbol(pair))
+	}
+}
+
+// Reports whether the class itself declares the operator with the same parameter types as method
+func (tc *TypeChecker) declaresOperator(className, name string, method *ast.MethodDeclStmt) bool {
+	symbol := &MethodSymbol{Parameters: method.Parameters, ParameterTypes: tc.parameterTypes(method.Parameters)}
+	for _, other := range tc.classes[className].Methods[name] {
+		if other.hasSameParameters(symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func operatorSymbol(name string) string {
+	for kind, operator := range map[lexer.TokenKind]string{lexer.EQUALS: "==", lexer.NOT_EQUALS: "!=", lexer.LESS_THAN: "<", lexer.GREATER_THAN: ">", lexer.LESS_THAN_OR_EQUAL: "<=", lexer.GREATER_THAN_OR_EQUAL: ">="} {
+		if ast.OperatorMethodName(kind, 2) == name {
+			return operator
+		}
+	}
+	if name == "op_True" {
+		return "true"
+	}
+	return "false"
+}
+
+// A conversion converts between the enclosing type and another type that is not related to it by inheritance
+func (tc *TypeChecker) checkConversionDeclaration(method *ast.MethodDeclStmt, containing types.Type) {
+	if len(method.Parameters) != 1 {
+		tc.errorf(method.Line, method.Column, "user-defined conversion operators must take exactly one parameter")
+	}
+	from, to := tc.registry.Parse(method.Parameters[0].Type.Name), tc.registry.Parse(method.ReturnType.Name)
+	isContaining := func(typ types.Type) bool {
+		return types.Underlying(typ) == containing
+	}
+
+	switch {
+	case isContaining(from) && isContaining(to):
+		tc.errorf(method.Line, method.Column, "user-defined operator cannot convert a type to itself")
+	case !isContaining(from) && !isContaining(to):
+		tc.errorf(method.Line, method.Column, "user-defined conversion must convert to or from the enclosing type")
+	case tc.IsSubclassOf(containing, from) || tc.IsSubclassOf(containing, to):
+		tc.errorf(method.Line, method.Column, "user-defined conversions to or from a base type are not allowed")
+	case tc.IsSubclassOf(from, containing) || tc.IsSubclassOf(to, containing):
+		tc.errorf(method.Line, method.Column, "user-defined conversions to or from a derived type are not allowed")
+	}
+
+	// implicit and explicit conversions between the same types can not both be declared
+	other := "op_Explicit"
+	if method.Name == "op_Explicit" {
+		other = "op_Implicit"
+	}
+	for _, conversion := range tc.classes[containing.String()].Methods[other] {
+		if conversion.ReturnType == to && conversion.ParameterTypes[0] == from {
+			tc.errorf(method.Line, method.Column, "duplicate user-defined conversion in type %s", containing)
+		}
+	}
+}
+
+// Collects the accessible static operator methods with the name from the classes of the operand types
+func (tc *TypeChecker) operatorCandidates(name string, operandTypes ...types.Type) []*MethodSymbol {
+	candidates := []*MethodSymbol{}
+	for i, typ := range operandTypes {
+		if i > 0 && typ == operandTypes[0] {
+			continue
+		}
+		for _, method := range tc.lookupMethods(typ.String(), name) {
+			if method.IsStatic() && tc.isAccessible(method) {
+				candidates = append(candidates, method)
+			}
+		}
+	}
+	return candidates
+}
+
+// Resolves a user-defined operator for the operands like an overloaded method call.
+// Returns nil if no operator of the operand types accepts the operands.
+func (tc *TypeChecker) resolveOperator(name string, operands []ast.TypedExpr, line, column int) (*MethodSymbol, []ast.Expr) {
+	operandTypes := make([]types.Type, len(operands))
+	exprs := make([]ast.Expr, len(operands))
+	for i, operand := range operands {
+		operandTypes[i], exprs[i] = operand.Type, operand
+	}
+
+	applicable := []*MethodSymbol{}
+	args := tc.prepareArguments(exprs)
+	for _, candidate := range tc.operatorCandidates(name, operandTypes...) {
+		if _, ok := tc.tryBindArguments(candidate, args, line, column); ok {
+			applicable = append(applicable, candidate)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+	method, bound := tc.resolveOverload(name, applicable, exprs, line, column)
+	tc.checkObsolete(method.Attributes, method.Class+"."+method.Name, line, column)
+	return method, bound
+}
+
+// + with a string operand concatenates the text of both operands, objects are converted with ToString
+func isStringConcatenation(kind lexer.TokenKind, left, right types.Type) bool {
+	return kind == lexer.PLUS && (left == types.String || right == types.String) && left != types.Void && right != types.Void
+}
+
+func (tc *TypeChecker) hasUserOperand(operandTypes ...types.Type) bool {
+	for _, typ := range operandTypes {
+		if tc.isUserObject(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// x op y on class and struct operands uses the user-defined operator, reference types can be compared by reference without one
+func (tc *TypeChecker) checkUserBinaryExpr(expr ast.BinaryExpr) ast.TypedExpr {
+	left, right := expr.Left.(ast.TypedExpr), expr.Right.(ast.TypedExpr)
+	kind := expr.Operator.Kind
+	if kind == lexer.AND || kind == lexer.OR {
+		return tc.checkUserLogicalExpr(expr, left, right)
+	}
+
+	method, args := tc.resolveOperator(ast.OperatorMethodName(kind, 2), []ast.TypedExpr{left, right}, expr.Operator.Line, expr.Operator.Column)
+	if method == nil {
+		if isStringConcatenation(kind, left.Type, right.Type) {
+			return ast.TypedExpr{Type: types.String, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+		}
+		isEquality := kind == lexer.EQUALS || kind == lexer.NOT_EQUALS
+		isReference := (left.Type == types.Null || tc.isReferenceType(left.Type)) && (right.Type == types.Null || tc.isReferenceType(right.Type))
+		if isEquality && isReference && (tc.isTypeCompatible(left.Type, right.Type) || tc.isTypeCompatible(right.Type, left.Type)) {
+			return ast.TypedExpr{Type: types.Bool, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+		}
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s", expr.Operator.Value, left.Type, right.Type)
+	}
+	expr.Left, expr.Right = args[0], args[1]
+	expr.Signature = method.Signature()
+	return ast.TypedExpr{Type: method.ReturnType, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+}
+
+// x && y is evaluated as T.false(x) ? x : T.&(x, y) and x || y as T.true(x) ? x : T.|(x, y)
+func (tc *TypeChecker) checkUserLogicalExpr(expr ast.BinaryExpr, left, right ast.TypedExpr) ast.TypedExpr {
+	name, test := "op_BitwiseAnd", "op_False"
+	if expr.Operator.Kind == lexer.OR {
+		name, test = "op_BitwiseOr", "op_True"
+	}
+	method, args := tc.resolveOperator(name, []ast.TypedExpr{left, right}, expr.Operator.Line, expr.Operator.Column)
+	if method == nil {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "operator %s cannot be applied to operands of type %s and %s", expr.Operator.Value, left.Type, right.Type)
+	}
+	typ := method.ReturnType
+	if method.ParameterTypes[0] != typ || method.ParameterTypes[1] != typ {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "in order to be applicable as a short circuit operator a user-defined logical operator (%s) must have the same return type and parameter types", method)
+	}
+	if len(tc.lookupMethods(typ.String(), "op_True")) == 0 || len(tc.lookupMethods(typ.String(), "op_False")) == 0 {
+		tc.errorf(expr.Operator.Line, expr.Operator.Column, "the type %s must contain declarations of operator true and operator false", typ)
+	}
+	tc.resolveOperator(test, []ast.TypedExpr{args[0].(ast.TypedExpr)}, expr.Operator.Line, expr.Operator.Column)
+
+	expr.Left, expr.Right = args[0], args[1]
+	expr.Signature = method.Signature()
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Operator.Line, Column: expr.Operator.Column}
+}
+
+func (tc *TypeChecker) checkUserPrefixExpr(expr ast.PrefixExpr, operand ast.TypedExpr) ast.TypedExpr {
+	method, args := tc.resolveOperator(ast.OperatorMethodName(expr.Operator.Kind, 1), []ast.TypedExpr{operand}, expr.Line, expr.Column)
+	if method == nil {
+		tc.errorf(expr.Line, expr.Column, "operator %s cannot be applied to operand of type %s", expr.Operator.Value, operand.Type)
+	}
+	expr.Expression = args[0]
+	expr.Signature = method.Signature()
+	return ast.TypedExpr{Type: method.ReturnType, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Returns the conversion operator that converts from one type to another. Only exact and base class
+// matches of its parameter and return type are considered, so conversions are never chained.
+func (tc *TypeChecker) userDefinedConversion(from, to types.Type, explicit bool) *MethodSymbol {
+	if from == to || !tc.hasUserOperand(from, to) {
+		return nil
+	}
+	names := []string{"op_Implicit"}
+	if explicit {
+		names = append(names, "op_Explicit")
+	}
+
+	var found *MethodSymbol
+	for _, name := range names {
+		for _, conversion := range tc.operatorCandidates(name, from, to) {
+			param, result := conversion.ParameterTypes[0], conversion.ReturnType
+			if (param == from || tc.IsSubclassOf(from, param)) && (result == to || tc.IsSubclassOf(result, to)) {
+				// The conversion between the exact types is the most specific one
+				if param == from && result == to {
+					return conversion
+				}
+				if found == nil {
+					found = conversion
+				}
+			}
+		}
+	}
+	return found
+}
+
+// A condition that is not a bool is tested with an implicit conversion to bool or with its operator true
+func (tc *TypeChecker) convertToBool(condition ast.TypedExpr) (ast.TypedExpr, bool) {
+	if !tc.isUserObject(condition.Type) {
+		return condition, false
+	}
+	if conversion := tc.userDefinedConversion(condition.Type, types.Bool, false); conversion != nil {
+		return tc.callOperator(conversion, condition), true
+	}
+	if method, _ := tc.resolveOperator("op_True", []ast.TypedExpr{condition}, condition.Line, condition.Column); method != nil {
+		return tc.callOperator(method, condition), true
+	}
+	return condition, false
+}
+
+func (tc *TypeChecker) callOperator(method *MethodSymbol, operand ast.TypedExpr) ast.TypedExpr {
+	call := ast.MethodCallExpr{MethodName: method.Name, Args: []ast.Expr{operand}, Signature: method.Signature(), Line: operand.Line, Column: operand.Column}
+	return ast.TypedExpr{Type: method.ReturnType, Expr: call, Line: operand.Line, Column: operand.Column}
+}
+
+// (T)x allows every implicit conversion as well as the explicit numeric, enum, downcast,
+// nullable and user-defined conversions
+func (tc *TypeChecker) CheckCastExpr(expr ast.CastExpr) ast.TypedExpr {
+	expr.Type = tc.resolveLocalType(expr.Type)
+	target := tc.registry.Parse(expr.Type.Name)
+	if !tc.isKnownType(expr.Type.Name) {
+		tc.errorf(expr.Type.Line, expr.Type.Column, "the type or namespace name %s could not be found", target)
+	}
+	var operand ast.TypedExpr
+	if _, isTuple := expr.Expression.(ast.TupleExpr); isTuple || tc.isTargetTyped(expr.Expression) {
+		operand = tc.CheckTargetTypedExpr(expr.Expression, target)
+	} else {
+		operand = tc.convertConstant(tc.CheckExpr(expr.Expression), target, true)
+	}
+	expr.Expression = operand
+
+	if conversion := tc.userDefinedConversion(operand.Type, target, true); conversion != nil {
+		tc.checkObsolete(conversion.Attributes, conversion.Class+"."+conversion.Name, expr.Line, expr.Column)
+		expr.Signature = conversion.Signature()
+	} else if !tc.isTypeCompatible(target, operand.Type) && !tc.isExplicitConversion(operand.Type, target) {
+		tc.errorf(expr.Line, expr.Column, "cannot convert type %s to %s", operand.Type, target)
+	}
+	tc.checkConstantCast(operand, target, expr.Line, expr.Column)
+	return ast.TypedExpr{Type: target, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+func (tc *TypeChecker) isExplicitConversion(from, to types.Type) bool {
+	// Every numeric type and enum converts explicitly to every other one
+	isNumeric := func(typ types.Type) bool {
+		return types.IsNumeric(typ) || tc.isEnum(typ)
+	}
+	if underlying, ok := tc.nullableUnderlying(from); ok {
+		from = underlying
+	}
+	if underlying, ok := tc.nullableUnderlying(to); ok {
+		to = underlying
+	}
+	return (isNumeric(from) && isNumeric(to)) || tc.IsSubclassOf(to, from) || from == to
+}
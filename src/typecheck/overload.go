@@ -0,0 +1,452 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Lambdas, method groups and out variable declarations can only be typed against a parameter,
+// all other arguments are typed once up front
+type argument struct {
+	expr      ast.Expr
+	name      string
+	modifiers []ast.Modifier
+	typed     ast.TypedExpr
+	deferred  bool
+	line      int
+	column    int
+}
+
+// The result of mapping the arguments of a call onto the parameters of one candidate
+type binding struct {
+	method *MethodSymbol
+	// Typed arguments in parameter order, including default values and params arrays
+	args []ast.Expr
+	// The parameter type each argument was converted to, in argument order
+	targets []types.Type
+	// The return type inferred from the body of each lambda argument, in argument order
+	returnTypes  []types.Type
+	declarations []ast.DeclarationExpr
+	// Variables passed as out arguments, they are assigned once the call returns
+	assigned     []string
+	expanded     bool
+	usedDefaults bool
+	// Arguments that may be null passed to parameters that are not nullable
+	nullArguments []nullArgument
+}
+
+type nullArgument struct {
+	parameter    string
+	line, column int
+}
+
+// Picks the overload that fits the arguments best following the C# rules:
+// only applicable candidates are considered and one of them has to be better than all others
+func (tc *TypeChecker) resolveOverload(name string, candidates []*MethodSymbol, exprs []ast.Expr, line, column int) (*MethodSymbol, []ast.Expr) {
+	return tc.resolvePreparedOverload(name, candidates, tc.prepareArguments(exprs), line, column)
+}
+
+func (tc *TypeChecker) resolvePreparedOverload(name string, candidates []*MethodSymbol, args []argument, line, column int) (*MethodSymbol, []ast.Expr) {
+	// With a single candidate the argument errors are more helpful than a generic overload error
+	if len(candidates) == 1 {
+		return tc.finishBinding(tc.bindArguments(candidates[0], args, line, column))
+	}
+
+	applicable := []binding{}
+	for _, candidate := range candidates {
+		if bound, ok := tc.tryBindArguments(candidate, args, line, column); ok {
+			applicable = append(applicable, bound)
+		}
+	}
+	if len(applicable) == 0 {
+		tc.errorf(line, column, "no overload for %s matches the argument list (%s)", name, argumentTypes(args))
+	}
+
+	for _, candidate := range applicable {
+		isBest := true
+		for _, other := range applicable {
+			if candidate.method != other.method && !tc.isBetterFunction(candidate, other, args) {
+				isBest = false
+				break
+			}
+		}
+		if isBest {
+			return tc.finishBinding(candidate)
+		}
+	}
+
+	tc.errorf(line, column, "the call is ambiguous between the following methods: %s and %s", applicable[0].method, applicable[1].method)
+	return nil, nil
+}
+
+func (tc *TypeChecker) prepareArguments(exprs []ast.Expr) []argument {
+	args := make([]argument, len(exprs))
+	for i, expr := range exprs {
+		arg := argument{expr: expr, line: expr.GetLine(), column: expr.GetColumn()}
+		if wrapped, ok := expr.(ast.ArgumentExpr); ok {
+			arg.expr, arg.name, arg.modifiers = wrapped.Value, wrapped.Name, wrapped.Modifiers
+		}
+		arg.expr = tc.checkMemberReceiver(arg.expr)
+
+		if typed, ok := arg.expr.(ast.TypedExpr); ok {
+			// Operands of operators are checked before the operator is resolved
+			arg.typed = typed
+		} else if _, ok := arg.expr.(ast.DeclarationExpr); ok || tc.isTargetTyped(arg.expr) {
+			arg.deferred = true
+		} else if id, ok := arg.expr.(ast.IdentifierExpr); ok && referenceModifier(arg.modifiers) == "out" {
+			// Out arguments are assigned by the call, they do not have to be assigned before
+			arg.typed = tc.checkIdentifier(id, false)
+		} else {
+			arg.typed = tc.CheckExpr(arg.expr)
+		}
+		args[i] = arg
+	}
+	return args
+}
+
+// Variables declared in out arguments only come into scope once the overload is chosen
+func (tc *TypeChecker) finishBinding(bound binding) (*MethodSymbol, []ast.Expr) {
+	for _, decl := range bound.declarations {
+		if tc.env.IsDefinedInScope(decl.Identifier) {
+			tc.errorf(decl.Line, decl.Column, "variable %s is already defined in this scope", decl.Identifier)
+		}
+		tc.env.Define(decl.Identifier, tc.registry.Parse(decl.Type.Name), false, false, false)
+	}
+	tc.markAssigned(bound.assigned)
+	// Warnings are only reported for the chosen overload
+	for _, arg := range bound.nullArguments {
+		tc.warnf(arg.line, arg.column, "possible null reference argument for parameter %s in %s", arg.parameter, bound.method)
+	}
+	return bound.method, bound.args
+}
+
+// Maps named, positional and params arguments onto the parameters of method.
+// Reports an error by panicking if the method is not applicable.
+func (tc *TypeChecker) bindArguments(method *MethodSymbol, args []argument, line, column int) binding {
+	params := method.Parameters
+	paramsIndex := method.paramsIndex()
+	bound := binding{method: method, args: make([]ast.Expr, len(params)), targets: make([]types.Type, len(args)), returnTypes: make([]types.Type, len(args))}
+	filled := make([]bool, len(params))
+
+	// Named arguments can be followed by positional ones if they are in the position of their parameter
+	positional := 0
+	for i, arg := range args {
+		if arg.name == "" {
+			positional = i + 1
+		}
+	}
+	for i, arg := range args[:positional] {
+		if arg.name != "" && method.parameterIndex(arg.name) >= 0 && method.parameterIndex(arg.name) != i {
+			tc.errorf(arg.line, arg.column, "named argument %s is used out-of-position but is followed by an unnamed argument", arg.name)
+		}
+	}
+
+	// The expanded form of a params parameter is used when the arguments do not fit the normal form
+	if paramsIndex >= 0 && positional > paramsIndex {
+		last := args[paramsIndex]
+		bound.expanded = positional != len(params) || last.deferred || !tc.isTypeCompatible(method.ParameterTypes[paramsIndex], last.typed.Type)
+	} else if paramsIndex >= 0 && !filledByName(args, params[paramsIndex].Identifier) {
+		bound.expanded = true
+	}
+
+	var elementType types.Type
+	if bound.expanded {
+		elementType = method.ParameterTypes[paramsIndex]
+		if array, isArray := elementType.(*types.Array); isArray {
+			elementType = array.Element
+		}
+	}
+	elements := []ast.Expr{}
+	for i, arg := range args {
+		index := i
+		if arg.name != "" {
+			index = method.parameterIndex(arg.name)
+			if index < 0 {
+				tc.errorf(arg.line, arg.column, "%s does not have a parameter called %s", method, arg.name)
+			}
+			if filled[index] {
+				tc.errorf(arg.line, arg.column, "named argument %s specifies a parameter for which a positional argument has already been given", arg.name)
+			}
+		} else if bound.expanded && i >= paramsIndex {
+			element := tc.convertArgument(method, i, ast.Parameter{}, elementType, arg, &bound)
+			bound.targets[i] = elementType
+			elements = append(elements, element)
+			continue
+		} else if i >= len(params) {
+			tc.errorf(line, column, "no overload for %s takes %d arguments", method.Name, len(args))
+		}
+
+		bound.args[index] = tc.convertArgument(method, i, params[index], method.ParameterTypes[index], arg, &bound)
+		bound.targets[i] = method.ParameterTypes[index]
+		filled[index] = true
+	}
+
+	if bound.expanded {
+		array := ast.ArrayCreationExpr{ElementType: ast.Type{Name: elementType.String(), Line: line, Column: column}, Elements: elements, Line: line, Column: column}
+		bound.args[paramsIndex] = ast.TypedExpr{Type: method.ParameterTypes[paramsIndex], Expr: array, Line: line, Column: column}
+		filled[paramsIndex] = true
+	}
+
+	// Optional parameters without an argument take their default value
+	for i, param := range params {
+		if filled[i] {
+			continue
+		}
+		if param.Default == nil {
+			tc.errorf(line, column, "there is no argument given that corresponds to the required parameter %s of %s", param.Identifier, method)
+		}
+		bound.args[i] = tc.CheckExpr(param.Default)
+		bound.usedDefaults = true
+	}
+
+	return bound
+}
+
+func filledByName(args []argument, name string) bool {
+	for _, arg := range args {
+		if arg.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks that the argument modifier agrees with the parameter and converts the argument to the parameter type
+func (tc *TypeChecker) convertArgument(method *MethodSymbol, position int, param ast.Parameter, paramType types.Type, arg argument, bound *binding) ast.Expr {
+	paramModifier := referenceModifier(param.Modifiers)
+	argModifier := referenceModifier(arg.modifiers)
+
+	if paramModifier == "ref" || paramModifier == "out" {
+		if argModifier != paramModifier {
+			tc.errorf(arg.line, arg.column, "argument %d of %s must be passed with the '%s' keyword", position+1, method, paramModifier)
+		}
+	} else if argModifier != "" && argModifier != paramModifier {
+		tc.errorf(arg.line, arg.column, "argument %d of %s may not be passed with the '%s' keyword", position+1, method, argModifier)
+	}
+
+	var typed ast.TypedExpr
+
+	if decl, ok := arg.expr.(ast.DeclarationExpr); ok {
+		if argModifier != "out" {
+			tc.errorf(decl.Line, decl.Column, "variables can only be declared in out arguments")
+		}
+		if decl.Type.Name == "var" {
+			decl.Type.Name = paramType.String()
+		}
+		decl.Type = tc.resolveLocalType(decl.Type)
+		if tc.registry.Parse(decl.Type.Name) != paramType {
+			tc.errorf(decl.Line, decl.Column, "argument %d of %s: cannot convert from out %s to out %s", position+1, method, decl.Type.Name, paramType)
+		}
+		bound.declarations = append(bound.declarations, decl)
+		typed = ast.TypedExpr{Type: paramType, Expr: decl, Line: decl.Line, Column: decl.Column}
+	} else {
+		typed = arg.typed
+		if arg.deferred {
+			typed = tc.CheckTargetTypedExpr(arg.expr, paramType)
+			if lambda, ok := typed.Expr.(ast.LambdaExpr); ok {
+				bound.returnTypes[position] = tc.inferredReturnType(lambda)
+			}
+		}
+
+		if argModifier != "" && argModifier != "in" {
+			// ref and out need a variable of exactly the parameter type
+			if !isVariable(typed) {
+				tc.errorf(arg.line, arg.column, "a %s argument must be an assignable variable", argModifier)
+			}
+			if info, ok := tc.env.Lookup(variableName(typed)); ok && (info.IsConstant || tc.isReadOnly(variableName(typed), info)) {
+				tc.errorf(arg.line, arg.column, "cannot pass readonly variable %s as %s argument", variableName(typed), argModifier)
+			}
+			if typed.Type != paramType {
+				tc.errorf(arg.line, arg.column, "argument %d of %s: cannot convert from %s %s to %s %s", position+1, method, argModifier, typed.Type, argModifier, paramType)
+			}
+			if argModifier == "out" {
+				bound.assigned = append(bound.assigned, variableName(typed))
+			}
+		} else if !tc.isTypeCompatible(paramType, typed.Type) {
+			tc.errorf(arg.line, arg.column, "argument %d of %s: cannot convert from %s to %s", position+1, method, typed.Type, paramType)
+		}
+		if tc.isPossibleNullConversion(paramType, param.Type.IsNullable, typed, arg.line) {
+			bound.nullArguments = append(bound.nullArguments, nullArgument{parameter: param.Identifier, line: arg.line, column: arg.column})
+		}
+	}
+
+	if len(arg.modifiers) == 0 {
+		return typed
+	}
+	wrapped := ast.ArgumentExpr{Modifiers: arg.modifiers, Value: typed, Line: arg.line, Column: arg.column}
+	return ast.TypedExpr{Type: typed.Type, Expr: wrapped, Line: arg.line, Column: arg.column}
+}
+
+// Type errors are reported by panicking, so a failed trial binding is recovered and the scope restored
+func (tc *TypeChecker) tryBindArguments(method *MethodSymbol, args []argument, line, column int) (bound binding, ok bool) {
+	env, catchDepth, finallyDepth, tryCatchDepth := tc.env, tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isTypeError := r.(string); !isTypeError {
+				panic(r)
+			}
+			tc.env, tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth = env, catchDepth, finallyDepth, tryCatchDepth
+			ok = false
+		}
+	}()
+	return tc.bindArguments(method, args, line, column), true
+}
+
+func (tc *TypeChecker) isTargetTyped(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.LambdaExpr:
+		return true
+	case ast.ConstructorCallExpr:
+		return e.TypeName == ""
+	case ast.TupleExpr:
+		for _, element := range e.Elements {
+			if tc.isTargetTyped(element) {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := tc.asMethodGroup(expr)
+	return ok
+}
+
+func isVariable(expr ast.TypedExpr) bool {
+	switch expr.Expr.(type) {
+	case ast.LocalVarExpr, ast.FieldVarExpr:
+		return true
+	}
+	return false
+}
+
+func variableName(expr ast.TypedExpr) string {
+	switch variable := expr.Expr.(type) {
+	case ast.LocalVarExpr:
+		return variable.Name
+	case ast.FieldVarExpr:
+		return variable.Name
+	}
+	return ""
+}
+
+// A function is better if none of its conversions is worse and at least one is better.
+// On a tie the normal form beats the expanded form and a call without defaults beats one with defaults.
+func (tc *TypeChecker) isBetterFunction(candidate, other binding, args []argument) bool {
+	better := false
+	for i, arg := range args {
+		comparison := 0
+		if !arg.deferred {
+			comparison = tc.compareConversions(arg.typed.Type, candidate.targets[i], other.targets[i])
+		} else if candidate.returnTypes[i] != nil {
+			comparison = tc.compareLambdaConversions(candidate.returnTypes[i], candidate.targets[i], other.targets[i])
+		}
+		switch comparison {
+		case -1:
+			return false
+		case 1:
+			better = true
+		}
+	}
+	if better {
+		return true
+	}
+
+	for i := range args {
+		if candidate.targets[i] != other.targets[i] {
+			return false
+		}
+	}
+	if !candidate.expanded && other.expanded {
+		return true
+	}
+	return !candidate.usedDefaults && other.usedDefaults
+}
+
+// Returns 1 if converting from source to first is better than to second, -1 if it is worse and 0 otherwise
+func (tc *TypeChecker) compareConversions(source, first, second types.Type) int {
+	switch {
+	case first == second:
+		return 0
+	case source == first:
+		return 1
+	case source == second:
+		return -1
+	}
+
+	// The more specific target type is better
+	firstToSecond := tc.isTypeCompatible(second, first)
+	secondToFirst := tc.isTypeCompatible(first, second)
+	if firstToSecond && !secondToFirst {
+		return 1
+	}
+	if secondToFirst && !firstToSecond {
+		return -1
+	}
+	return 0
+}
+
+// A lambda converts better to a delegate that returns a value than to one that returns void. Between two
+// delegates with the same parameters that return values the better conversion of the inferred return type wins.
+func (tc *TypeChecker) compareLambdaConversions(inferred, first, second types.Type) int {
+	firstSignature, _ := tc.delegateSignature(first)
+	secondSignature, _ := tc.delegateSignature(second)
+	if len(firstSignature.Parameters) != len(secondSignature.Parameters) {
+		return 0
+	}
+	for i, param := range firstSignature.Parameters {
+		if param != secondSignature.Parameters[i] {
+			return 0
+		}
+	}
+	switch {
+	case firstSignature.Result == secondSignature.Result:
+		return 0
+	case secondSignature.Result == types.Void:
+		return 1
+	case firstSignature.Result == types.Void:
+		return -1
+	}
+	return tc.compareConversions(inferred, firstSignature.Result, secondSignature.Result)
+}
+
+// The type of the expression body or of the returned values of a checked lambda, nil if it returns nothing.
+// Async lambdas return a task of that type.
+func (tc *TypeChecker) inferredReturnType(lambda ast.LambdaExpr) types.Type {
+	var inferred types.Type
+	if body, ok := lambda.Expression.(ast.TypedExpr); ok {
+		if _, isThrow := body.Expr.(ast.ThrowExpr); !isThrow {
+			inferred = body.Type
+		}
+	} else if body, ok := lambda.Body.(ast.TypedStmt); ok {
+		inferred = body.Type
+	}
+	if inferred == types.Void {
+		inferred = nil
+	}
+	if !lambda.IsAsync {
+		return inferred
+	}
+	if inferred == nil {
+		return tc.registry.NewClass("Task")
+	}
+	return tc.registry.NewGeneric("Task", []types.Type{inferred})
+}
+
+func argumentTypes(args []argument) string {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		typ := "?"
+		if !arg.deferred {
+			typ = arg.typed.Type.String()
+		}
+		if modifier := referenceModifier(arg.modifiers); modifier != "" {
+			typ = modifier + " " + typ
+		}
+		if arg.name != "" {
+			typ = arg.name + ": " + typ
+		}
+		names[i] = typ
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,307 @@
+package typecheck
+
+import (
+	"math/big"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Checks a pattern against the type of the value it is matched with.
+// Variables declared by the pattern are defined in the current scope.
+func (tc *TypeChecker) CheckPattern(pattern ast.Pattern, inputType types.Type) ast.Pattern {
+	switch p := pattern.(type) {
+	case ast.DiscardPattern:
+		return p
+	case ast.ConstantPattern:
+		// A user defined type without a designation looks like a constant to the parser
+		if typ, ok := tc.typeNameOf(p.Value); ok && tc.isClassName(typ) {
+			return tc.CheckPattern(ast.TypePattern{Type: ast.Type{Name: typ, Line: p.Value.GetLine(), Column: p.Value.GetColumn()}, Line: p.Line, Column: p.Column}, inputType)
+		}
+		if !tc.isConstant(p.Value) {
+			tc.errorf(p.Line, p.Column, "a constant value is expected")
+		}
+		value := tc.convertConstant(tc.CheckExpr(p.Value), inputType, false)
+		if !tc.isTypeCompatible(inputType, value.Type) {
+			tc.errorf(p.Line, p.Column, "cannot implicitly convert type %s to %s", value.Type, inputType)
+		}
+		p.Value = value
+		return p
+	case ast.TypePattern:
+		p.Type = tc.resolveLocalType(p.Type)
+		tc.checkPatternType(p.Type, inputType)
+		return p
+	case ast.DeclarationPattern:
+		if p.Type.Name == "var" {
+			p.Type.Name = inputType.String()
+		} else {
+			p.Type = tc.resolveLocalType(p.Type)
+			tc.checkPatternType(p.Type, inputType)
+		}
+		tc.definePatternVariable(p.Identifier, tc.registry.Parse(p.Type.Name), p.Line, p.Column)
+		return p
+	case ast.RelationalPattern:
+		if !types.IsNumeric(inputType) {
+			tc.errorf(p.Line, p.Column, "relational patterns may not be used for a value of type %s", inputType)
+		}
+		if !tc.isConstant(p.Value) {
+			tc.errorf(p.Line, p.Column, "a constant value is expected")
+		}
+		value := tc.convertConstant(tc.CheckExpr(p.Value), inputType, false)
+		if !tc.isTypeCompatible(inputType, value.Type) {
+			tc.errorf(p.Line, p.Column, "cannot implicitly convert type %s to %s", value.Type, inputType)
+		}
+		p.Value = value
+		tc.checkPatternCanMatch(p, inputType)
+		return p
+	case ast.NotPattern:
+		if len(patternVariables(p.Pattern)) > 0 {
+			tc.errorf(p.Line, p.Column, "a variable may not be declared within a 'not' or 'or' pattern")
+		}
+		p.Pattern = tc.CheckPattern(p.Pattern, inputType)
+		return p
+	case ast.BinaryPattern:
+		if p.Operator == "or" && len(patternVariables(p)) > 0 {
+			tc.errorf(p.Line, p.Column, "a variable may not be declared within a 'not' or 'or' pattern")
+		}
+		p.Left = tc.CheckPattern(p.Left, inputType)
+		// The right side of and only sees values that matched the left side
+		rightType := inputType
+		if p.Operator == "and" {
+			rightType = tc.narrowedType(p.Left, inputType)
+		}
+		p.Right = tc.CheckPattern(p.Right, rightType)
+		if p.Operator == "and" {
+			tc.checkPatternCanMatch(p, inputType)
+		}
+		return p
+	case ast.PropertyPattern:
+		if p.Type.Name == "" {
+			p.Type.Name = inputType.String()
+		} else {
+			p.Type = tc.resolveLocalType(p.Type)
+			tc.checkPatternType(p.Type, inputType)
+		}
+		for i := range p.Properties {
+			property := &p.Properties[i]
+			field, owner, ok := tc.lookupField(p.Type.Name, property.Member)
+			if !ok {
+				tc.errorf(property.Line, property.Column, "%s does not contain a definition for %s", p.Type.Name, property.Member)
+			}
+			if isPrivate(field.Modifiers) && !isNestedIn(tc.currentClassName(), owner) {
+				tc.errorf(property.Line, property.Column, "%s.%s is inaccessible due to its protection level", owner, property.Member)
+			}
+			property.Pattern = tc.CheckPattern(property.Pattern, tc.registry.Parse(field.Type.Name))
+		}
+		tc.definePatternVariable(p.Identifier, tc.registry.Parse(p.Type.Name), p.Line, p.Column)
+		return p
+	}
+	tc.errorf(pattern.GetLine(), pattern.GetColumn(), "unexpected pattern")
+	return nil
+}
+
+// Pattern variables are only definitely assigned where the pattern is known to have matched
+func (tc *TypeChecker) definePatternVariable(name string, typ types.Type, line, column int) {
+	if name == "" || name == "_" {
+		return
+	}
+	if tc.env.IsDefinedInScope(name) {
+		tc.errorf(line, column, "variable %s is already defined in this scope", name)
+	}
+	tc.env.Define(name, typ, false, false, false)
+	tc.env.MarkUnassigned(name)
+}
+
+// Relational and constant patterns of integral values can contradict each other like in x is > 5 and < 3
+func (tc *TypeChecker) checkPatternCanMatch(pattern ast.Pattern, inputType types.Type) {
+	if matched, ok := tc.matchedRange(pattern, inputType); ok && matched.min.Cmp(matched.max) > 0 {
+		tc.errorf(pattern.GetLine(), pattern.GetColumn(), "an expression of type %s can never match the provided pattern", inputType)
+	}
+}
+
+// The smallest and the largest integral value a pattern can match, all values in between are not
+// necessarily matched. Reports false if the pattern is not made up of relational and constant patterns.
+func (tc *TypeChecker) matchedRange(pattern ast.Pattern, inputType types.Type) (integralRange, bool) {
+	limits, isIntegral := integralRanges[inputType]
+	if !isIntegral {
+		return integralRange{}, false
+	}
+	switch p := pattern.(type) {
+	case ast.ConstantPattern:
+		value, ok := tc.integralConstant(p.Value)
+		return integralRange{min: value, max: value}, ok
+	case ast.RelationalPattern:
+		value, ok := tc.integralConstant(p.Value)
+		if !ok {
+			return integralRange{}, false
+		}
+		one := big.NewInt(1)
+		switch p.Operator.Kind {
+		case lexer.LESS_THAN:
+			return integralRange{min: limits.min, max: new(big.Int).Sub(value, one)}, true
+		case lexer.LESS_THAN_OR_EQUAL:
+			return integralRange{min: limits.min, max: value}, true
+		case lexer.GREATER_THAN:
+			return integralRange{min: new(big.Int).Add(value, one), max: limits.max}, true
+		case lexer.GREATER_THAN_OR_EQUAL:
+			return integralRange{min: value, max: limits.max}, true
+		}
+	case ast.BinaryPattern:
+		left, leftOk := tc.matchedRange(p.Left, inputType)
+		right, rightOk := tc.matchedRange(p.Right, inputType)
+		switch {
+		case p.Operator == "and" && leftOk && rightOk:
+			return integralRange{min: maxInt(left.min, right.min), max: minInt(left.max, right.max)}, true
+		case p.Operator == "and" && leftOk:
+			return left, true
+		case p.Operator == "and" && rightOk:
+			return right, true
+		case p.Operator == "or" && leftOk && rightOk:
+			return integralRange{min: minInt(left.min, right.min), max: maxInt(left.max, right.max)}, true
+		}
+	}
+	return integralRange{}, false
+}
+
+func minInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) > 0 {
+		return a
+	}
+	return b
+}
+
+// The type of the values that a pattern lets through
+func (tc *TypeChecker) narrowedType(pattern ast.Pattern, inputType types.Type) types.Type {
+	switch p := pattern.(type) {
+	case ast.TypePattern:
+		return tc.registry.Parse(p.Type.Name)
+	case ast.DeclarationPattern:
+		return tc.registry.Parse(p.Type.Name)
+	case ast.PropertyPattern:
+		return tc.registry.Parse(p.Type.Name)
+	case ast.BinaryPattern:
+		if p.Operator == "and" {
+			return tc.narrowedType(p.Right, tc.narrowedType(p.Left, inputType))
+		}
+	}
+	return inputType
+}
+
+// The variables declared by a pattern, all of them are assigned once the pattern matched
+func patternVariables(pattern ast.Pattern) []string {
+	variables := []string{}
+	switch p := pattern.(type) {
+	case ast.DeclarationPattern:
+		if p.Identifier != "_" {
+			variables = append(variables, p.Identifier)
+		}
+	case ast.PropertyPattern:
+		for _, property := range p.Properties {
+			variables = append(variables, patternVariables(property.Pattern)...)
+		}
+		if p.Identifier != "" && p.Identifier != "_" {
+			variables = append(variables, p.Identifier)
+		}
+	case ast.BinaryPattern:
+		variables = append(variables, patternVariables(p.Left)...)
+		variables = append(variables, patternVariables(p.Right)...)
+	case ast.NotPattern:
+		variables = append(variables, patternVariables(p.Pattern)...)
+	}
+	return variables
+}
+
+// The pattern variables that are definitely assigned when a checked condition evaluates to whenTrue
+func assignedWhen(condition ast.Expr, whenTrue bool) []string {
+	switch c := condition.(type) {
+	case ast.TypedExpr:
+		return assignedWhen(c.Expr, whenTrue)
+	case ast.IsPatternExpr:
+		if whenTrue {
+			return patternVariables(c.Pattern)
+		}
+	case ast.PrefixExpr:
+		if c.Operator.Kind == lexer.NOT {
+			return assignedWhen(c.Expression, !whenTrue)
+		}
+	case ast.BinaryExpr:
+		left, right := assignedWhen(c.Left, whenTrue), assignedWhen(c.Right, whenTrue)
+		// a && b is true if both are, a || b is false if both are
+		if (c.Operator.Kind == lexer.AND && whenTrue) || (c.Operator.Kind == lexer.OR && !whenTrue) {
+			return append(left, right...)
+		}
+		if c.Operator.Kind == lexer.AND || c.Operator.Kind == lexer.OR {
+			both := []string{}
+			for _, name := range left {
+				for _, other := range right {
+					if name == other {
+						both = append(both, name)
+					}
+				}
+			}
+			return both
+		}
+	}
+	return nil
+}
+
+// The input has to be convertible to the pattern type or the other way around
+func (tc *TypeChecker) checkPatternType(typ ast.Type, inputType types.Type) {
+	if !tc.isKnownType(typ.Name) {
+		tc.errorf(typ.Line, typ.Column, "the type or namespace name %s could not be found", typ.Name)
+	}
+	if patternType := tc.registry.Parse(typ.Name); !tc.isTypeCompatible(patternType, inputType) && !tc.isTypeCompatible(inputType, patternType) {
+		tc.errorf(typ.Line, typ.Column, "an expression of type %s cannot be handled by a pattern of type %s", inputType, typ.Name)
+	}
+}
+
+func (tc *TypeChecker) isKnownType(name string) bool {
+	typ := tc.registry.Parse(name)
+	if typ == types.Bool || typ == types.String || typ == types.Object || types.IsNumeric(typ) {
+		return true
+	}
+	if tuple, ok := typ.(*types.Tuple); ok {
+		for _, element := range tuple.Elements {
+			if !tc.isKnownType(element.String()) {
+				return false
+			}
+		}
+		return true
+	}
+	return tc.isUserObject(typ) || tc.isEnum(typ) || tc.isDelegateType(typ) || tc.isNullableValueType(typ)
+}
+
+// Reports whether every value matched by later is already matched by earlier.
+// Guarded patterns never subsume anything since the guard might be false.
+func (tc *TypeChecker) subsumes(earlier, later ast.Pattern) bool {
+	switch e := earlier.(type) {
+	case ast.DiscardPattern:
+		return true
+	case ast.DeclarationPattern:
+		return tc.subsumes(ast.TypePattern{Type: e.Type}, later)
+	case ast.TypePattern:
+		switch l := later.(type) {
+		case ast.TypePattern:
+			return tc.isTypeCompatible(tc.registry.Parse(e.Type.Name), tc.registry.Parse(l.Type.Name))
+		case ast.DeclarationPattern:
+			return tc.isTypeCompatible(tc.registry.Parse(e.Type.Name), tc.registry.Parse(l.Type.Name))
+		case ast.ConstantPattern:
+			// Type patterns do not match null
+			value := l.Value.(ast.TypedExpr)
+			return value.Type != types.Null && tc.isTypeCompatible(tc.registry.Parse(e.Type.Name), value.Type)
+		}
+	case ast.ConstantPattern:
+		if l, ok := later.(ast.ConstantPattern); ok {
+			return ast.ConstantKeyOf(e.Value) == ast.ConstantKeyOf(l.Value)
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+)
+
+// Properties are members with get, set or init accessors. The rest of the type checker sees them as fields,
+// a property without a set accessor is a readonly field. Init-only properties can also be assigned in
+// object initializers and with expressions, properties with accessor bodies have no storage to assign.
+
+func (tc *TypeChecker) CheckPropertyDeclStmt(property *ast.PropertyDeclStmt) {
+	property.Attributes = tc.checkAttributes(property.Attributes, targetProperty)
+	tc.checkNotAsync(property.Modifiers, property.Line, property.Column)
+
+	defined := map[string]bool{}
+	bodies := 0
+	for i := range property.Accessors {
+		accessor := &property.Accessors[i]
+		if defined[accessor.Kind] {
+			tc.errorf(accessor.Line, accessor.Column, "the %s accessor is already defined", accessor.Kind)
+		}
+		defined[accessor.Kind] = true
+		if accessor.Body != nil {
+			bodies++
+			tc.checkAccessor(accessor, nil, property.Type)
+		}
+	}
+	if len(property.Accessors) == 0 {
+		tc.errorf(property.Line, property.Column, "the property %s must have at least one accessor", property.Name)
+	}
+	if defined["set"] && defined["init"] {
+		tc.errorf(property.Line, property.Column, "the property %s cannot have both a set and an init accessor", property.Name)
+	}
+	if defined["init"] && hasModifier(property.Modifiers, lexer.STATIC) {
+		tc.errorf(property.Line, property.Column, "the init accessor is not valid on the static property %s", property.Name)
+	}
+	if bodies > 0 && bodies < len(property.Accessors) {
+		tc.errorf(property.Line, property.Column, "the accessors of the property %s must either all have bodies or none of them", property.Name)
+	}
+	if bodies == 0 && !defined["get"] {
+		tc.errorf(property.Line, property.Column, "the auto-implemented property %s must have a get accessor", property.Name)
+	}
+
+	if property.Value == nil {
+		return
+	}
+	if bodies > 0 {
+		tc.errorf(property.Line, property.Column, "only auto-implemented properties can have initializers, %s is not one", property.Name)
+	}
+	propertyType := tc.registry.Parse(property.Type.Name)
+	value := tc.CheckTargetTypedExpr(property.Value, propertyType)
+	if !tc.isTypeCompatible(propertyType, value.Type) {
+		tc.errorf(property.Line, property.Column, "type mismatch: expected %s, got %s", property.Type.Name, value.Type)
+	}
+	if tc.isPossibleNullConversion(propertyType, property.Type.IsNullable, value, property.Line) {
+		tc.warnf(property.Line, property.Column, "converting null literal or possible null value to non-nullable type %s", property.Name)
+	}
+	property.Value = value
+}
+
+func hasAccessor(accessors []ast.Accessor, kind string) bool {
+	for _, accessor := range accessors {
+		if accessor.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Auto-implemented properties are backed by a hidden field, all of their accessors are without body
+func isAutoProperty(property ast.PropertyDeclStmt) bool {
+	for _, accessor := range property.Accessors {
+		if accessor.Body != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// The field view of a property, without a set accessor it can only be assigned in constructors
+func propertyField(property ast.PropertyDeclStmt) ast.FieldDeclStmt {
+	modifiers := property.Modifiers
+	if !hasAccessor(property.Accessors, "set") {
+		modifiers = append(append([]ast.Modifier{}, modifiers...), ast.Modifier{Kind: lexer.READONLY})
+	}
+	return ast.FieldDeclStmt{
+		Attributes: property.Attributes,
+		Modifiers:  modifiers,
+		Type:       property.Type,
+		Identifier: property.Name,
+		File:       property.File,
+		Line:       property.Line,
+		Column:     property.Column,
+	}
+}
+
+// Finds a property in a class or one of its base classes, also returns the name of the declaring class
+func (tc *TypeChecker) lookupProperty(className, propertyName string) (ast.PropertyDeclStmt, string, bool) {
+	visited := map[string]bool{}
+	for current, ok := className, tc.isClassName(className); ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		visited[current] = true
+		if property, exists := tc.classes[current].Properties[propertyName]; exists {
+			return property, current, true
+		}
+	}
+	return ast.PropertyDeclStmt{}, "", false
+}
+
+func (tc *TypeChecker) isInitOnly(className, name string) bool {
+	property, _, ok := tc.lookupProperty(className, name)
+	return ok && hasAccessor(property.Accessors, "init")
+}
+
+// Properties that only have a get accessor with a body can not be assigned at all, not even in constructors
+func (tc *TypeChecker) checkPropertyAssignable(className, name string, line, column int) {
+	property, declaring, ok := tc.lookupProperty(className, name)
+	if ok && !isAutoProperty(property) && !hasAccessor(property.Accessors, "set") {
+		tc.errorf(line, column, "property or indexer %s.%s cannot be assigned to -- it is read only", declaring, name)
+	}
+}
+
+// Reading a property needs its get accessor
+func (tc *TypeChecker) checkPropertyReadable(className, name string, line, column int) {
+	property, declaring, ok := tc.lookupProperty(className, name)
+	if ok && !hasAccessor(property.Accessors, "get") {
+		tc.errorf(line, column, "the property or indexer %s.%s cannot be used in this context because it lacks the get accessor", declaring, name)
+	}
+}
+
+// Checks receiver.Member where the member is a field or property of a user-defined type. A class name as
+// receiver accesses a static member, the receiver is nil then. Readonly members can only be assigned on this,
+// or on the class name if they are static, inside of a constructor of their class.
+func (tc *TypeChecker) checkFieldAccess(expr ast.MemberAccessExpr, receiver *ast.TypedExpr, className string, read, write bool) ast.TypedExpr {
+	field, declaring, _ := tc.lookupField(className, expr.Member)
+	if !tc.isMemberAccessible(field.Modifiers, declaring) {
+		tc.errorf(expr.Line, expr.Column, "%s.%s is inaccessible due to its protection level", declaring, expr.Member)
+	}
+	isStatic := hasModifier(field.Modifiers, lexer.STATIC) || hasModifier(field.Modifiers, lexer.CONST)
+	if receiver == nil && !isStatic {
+		tc.errorf(expr.Line, expr.Column, "an object reference is required for the non-static member %s.%s", declaring, expr.Member)
+	}
+	if receiver != nil && isStatic {
+		tc.errorf(expr.Line, expr.Column, "the static member %s.%s cannot be accessed with an instance reference; qualify it with a type name instead", declaring, expr.Member)
+	}
+	tc.checkObsolete(field.Attributes, declaring+"."+expr.Member, expr.Line, expr.Column)
+
+	if read {
+		tc.checkPropertyReadable(className, expr.Member, expr.Line, expr.Column)
+	}
+	if write {
+		tc.checkPropertyAssignable(className, expr.Member, expr.Line, expr.Column)
+		onThis := false
+		if receiver != nil {
+			_, onThis = receiver.Expr.(ast.ThisExpr)
+		}
+		switch {
+		case hasModifier(field.Modifiers, lexer.CONST):
+			tc.errorf(expr.Line, expr.Column, "cannot assign to %s.%s because it is a constant", declaring, expr.Member)
+		case hasModifier(field.Modifiers, lexer.READONLY) && !((onThis || receiver == nil) && tc.isConstructing(field, declaring)):
+			if tc.isInitOnly(className, expr.Member) {
+				tc.errorf(expr.Line, expr.Column, "init-only property %s.%s can only be assigned in an object initializer, or on this in a constructor", declaring, expr.Member)
+			}
+			tc.errorf(expr.Line, expr.Column, "cannot assign to %s.%s because it is read only", declaring, expr.Member)
+		}
+	}
+
+	if receiver != nil {
+		tc.checkDereference(*receiver, expr.Line, expr.Column)
+		expr.Receiver = *receiver
+	}
+	return ast.TypedExpr{Type: tc.registry.Parse(field.Type.Name), Expr: expr, Line: expr.Line, Column: expr.Column}
+}
@@ -0,0 +1,345 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Query expressions are translated into calls of the query operators before they are checked, so that
+// from x in xs where x > 0 select x * 2 is checked as xs.Where(x => x > 0).Select(x => x * 2). The range
+// variables become the parameters of the lambdas. Once a clause introduces a second range variable the
+// lambdas take a transparent identifier instead, a tuple of all range variables whose elements can be
+// used by their names.
+
+type queryTranslation struct {
+	tc     *TypeChecker
+	source ast.Expr
+	// The range variables in scope and the lambda parameter that carries them
+	variables []string
+	parameter string
+	// Whether a query operator was applied since the last from clause or continuation
+	applied bool
+}
+
+func isTransparentIdentifier(name string) bool {
+	return strings.HasPrefix(name, "<>h__TransparentIdentifier")
+}
+
+func (tc *TypeChecker) translateQuery(query ast.QueryExpr) ast.Expr {
+	from := query.Clauses[0].(ast.FromClause)
+	q := &queryTranslation{tc: tc, source: rangeSource(from.Type, from.Identifier, from.Source), variables: []string{from.Identifier}, parameter: from.Identifier}
+
+	clauses := query.Clauses[1:]
+	for i := 0; i < len(clauses); i++ {
+		// A select clause right after from or join is merged into the result selector
+		selection, isSelectNext := ast.SelectClause{}, false
+		if i+1 < len(clauses) {
+			selection, isSelectNext = clauses[i+1].(ast.SelectClause)
+		}
+
+		switch clause := clauses[i].(type) {
+		case ast.FromClause:
+			collection := q.lambda(rangeSource(clause.Type, clause.Identifier, clause.Source))
+			result := q.result(clause.Identifier, selection, isSelectNext)
+			q.call(clause.Line, clause.Column, "SelectMany", collection, result)
+			i += q.introduce(clause.Identifier, clause, isSelectNext)
+		case ast.LetClause:
+			elements := q.rangeVariables()
+			elements.Elements = append(elements.Elements, clause.Value)
+			elements.Names = append(elements.Names, clause.Identifier)
+			q.call(clause.Line, clause.Column, "Select", q.lambda(elements))
+			q.introduce(clause.Identifier, clause, false)
+		case ast.WhereClause:
+			q.call(clause.Line, clause.Column, "Where", q.lambda(clause.Condition))
+		case ast.JoinClause:
+			inner := rangeSource(clause.Type, clause.Identifier, clause.Source)
+			innerKey := ast.LambdaExpr{Parameters: []ast.Parameter{{Identifier: clause.Identifier}}, Expression: clause.InnerKey, Line: clause.InnerKey.GetLine(), Column: clause.InnerKey.GetColumn()}
+			name, operator := clause.Identifier, "Join"
+			if clause.Into != "" {
+				name, operator = clause.Into, "GroupJoin"
+			}
+			result := q.result(name, selection, isSelectNext)
+			q.call(clause.Line, clause.Column, operator, inner, q.lambda(clause.OuterKey), innerKey, result)
+			i += q.introduce(name, clause, isSelectNext)
+		case ast.OrderByClause:
+			for j, ordering := range clause.Orderings {
+				operator := "OrderBy"
+				if j > 0 {
+					operator = "ThenBy"
+				}
+				if ordering.Descending {
+					operator += "Descending"
+				}
+				q.call(ordering.Line, ordering.Column, operator, q.lambda(ordering.Key))
+			}
+		case ast.SelectClause:
+			// The selection of the range variable itself is left out unless the query would be its source
+			if id, ok := clause.Value.(ast.IdentifierExpr); !ok || !q.applied || len(q.variables) > 1 || id.Name != q.parameter {
+				q.call(clause.Line, clause.Column, "Select", q.lambda(clause.Value))
+			}
+		case ast.GroupClause:
+			if id, ok := clause.Element.(ast.IdentifierExpr); ok && len(q.variables) == 1 && id.Name == q.parameter {
+				q.call(clause.Line, clause.Column, "GroupBy", q.lambda(clause.Key))
+			} else {
+				q.call(clause.Line, clause.Column, "GroupBy", q.lambda(clause.Key), q.lambda(clause.Element))
+			}
+		case ast.IntoClause:
+			q.variables, q.parameter, q.applied = []string{clause.Identifier}, clause.Identifier, false
+		}
+	}
+	return q.source
+}
+
+// The source of a range variable with an explicit type converts its elements to the type
+func rangeSource(typ ast.Type, identifier string, source ast.Expr) ast.Expr {
+	if typ.Name == "" {
+		return source
+	}
+	element := ast.IdentifierExpr{Name: identifier, Line: typ.Line, Column: typ.Column}
+	cast := ast.CastExpr{Type: typ, Expression: element, Line: typ.Line, Column: typ.Column}
+	conversion := ast.LambdaExpr{Parameters: []ast.Parameter{{Identifier: identifier}}, Expression: cast, Line: typ.Line, Column: typ.Column}
+	return ast.MethodCallExpr{Receiver: source, MethodName: "Select", Args: []ast.Expr{conversion}, Line: typ.Line, Column: typ.Column}
+}
+
+// Applies the query operator to the current source
+func (q *queryTranslation) call(line, column int, operator string, args ...ast.Expr) {
+	q.source = ast.MethodCallExpr{Receiver: q.source, MethodName: operator, Args: args, Line: line, Column: column}
+	q.applied = true
+}
+
+// A lambda that takes the range variables in scope
+func (q *queryTranslation) lambda(body ast.Expr) ast.LambdaExpr {
+	return ast.LambdaExpr{Parameters: []ast.Parameter{{Identifier: q.parameter}}, Expression: body, Line: body.GetLine(), Column: body.GetColumn()}
+}
+
+// The result selector of a clause that introduces a range variable, which is the selection of the
+// following select clause or the tuple of all range variables
+func (q *queryTranslation) result(name string, selection ast.SelectClause, isSelectNext bool) ast.LambdaExpr {
+	parameters := []ast.Parameter{{Identifier: q.parameter}, {Identifier: name}}
+	if isSelectNext {
+		return ast.LambdaExpr{Parameters: parameters, Expression: selection.Value, Line: selection.Line, Column: selection.Column}
+	}
+	elements := q.rangeVariables()
+	elements.Elements = append(elements.Elements, ast.IdentifierExpr{Name: name, Line: elements.Line, Column: elements.Column})
+	elements.Names = append(elements.Names, name)
+	return ast.LambdaExpr{Parameters: parameters, Expression: elements, Line: elements.Line, Column: elements.Column}
+}
+
+// The range variables in scope as elements of a tuple literal
+func (q *queryTranslation) rangeVariables() ast.TupleExpr {
+	tuple := ast.TupleExpr{Line: q.source.GetLine(), Column: q.source.GetColumn()}
+	for _, variable := range q.variables {
+		tuple.Elements = append(tuple.Elements, ast.IdentifierExpr{Name: variable, Line: tuple.Line, Column: tuple.Column})
+		tuple.Names = append(tuple.Names, variable)
+	}
+	return tuple
+}
+
+// Brings a new range variable into scope, unless the following select clause was merged into the
+// result selector. Returns the number of clauses that were merged.
+func (q *queryTranslation) introduce(name string, at ast.QueryClause, isSelectNext bool) int {
+	for _, variable := range q.variables {
+		if variable == name {
+			q.tc.errorf(at.GetLine(), at.GetColumn(), "the range variable %s conflicts with a previous declaration of %s", name, name)
+		}
+	}
+	if isSelectNext {
+		return 1
+	}
+	q.variables = append(q.variables, name)
+	q.parameter = fmt.Sprintf("<>h__TransparentIdentifier%d", q.tc.transparentIdentifiers)
+	q.tc.transparentIdentifiers++
+	return 0
+}
+
+// Defines a lambda parameter, the elements of a transparent identifier are defined as range variables
+func (tc *TypeChecker) defineLambdaParameter(identifier string, typ types.Type) {
+	tc.env.Define(identifier, typ, false, false, true)
+	if !isTransparentIdentifier(identifier) {
+		return
+	}
+	tuple := typ.(*types.Tuple)
+	for i, name := range tuple.Names {
+		tc.env.DefineRangeVariable(name, tuple.Elements[i], identifier)
+	}
+}
+
+// The query operators of System.Linq are generic methods. The ones a call needs are added to the
+// built-in Enumerable class with the element type of the source and the results of the lambda
+// arguments, which are checked once with the parameter types to infer their result.
+func (tc *TypeChecker) instantiateQueryOperator(name, source string, args []argument, line, column int) {
+	enumerable, ok := tc.classes["Enumerable"]
+	sourceType := tc.registry.Parse(source)
+	element, isSequence := tc.elementType(sourceType)
+	if !ok || !isSequence || !tc.isNamespaceVisible(enumerable.Decl.Namespace) {
+		return
+	}
+	for _, arg := range args {
+		if arg.name != "" || len(arg.modifiers) > 0 {
+			return
+		}
+	}
+
+	parameters := []ast.Parameter{{Modifiers: []ast.Modifier{{Kind: lexer.THIS}}, Type: ast.Type{Name: source}, Identifier: "source"}}
+	parameter := func(identifier string, typ types.Type) {
+		parameters = append(parameters, collectionParameter(typ, identifier))
+	}
+	infer := func(i int, parameters ...types.Type) types.Type {
+		result, ok := tc.inferResultType(args[i], parameters)
+		if !ok {
+			tc.errorf(line, column, "the type arguments for method %s cannot be inferred from the usage", name)
+		}
+		return result
+	}
+
+	var returnType types.Type
+	switch {
+	case name == "Where" && len(args) == 1:
+		parameter("predicate", tc.funcType(element, types.Bool))
+		returnType = tc.sequenceType("IEnumerable", element)
+	case name == "Select" && len(args) == 1:
+		result := infer(0, element)
+		parameter("selector", tc.funcType(element, result))
+		returnType = tc.sequenceType("IEnumerable", result)
+	case name == "SelectMany" && (len(args) == 1 || len(args) == 2):
+		collection := infer(0, element)
+		inner, ok := tc.elementType(collection)
+		if !ok {
+			tc.errorf(line, column, "the collection selector of SelectMany must return a sequence, got %s", collection)
+		}
+		parameter("collectionSelector", tc.funcType(element, collection))
+		returnType = tc.sequenceType("IEnumerable", inner)
+		if len(args) == 2 {
+			result := infer(1, element, inner)
+			parameter("resultSelector", tc.funcType(element, inner, result))
+			returnType = tc.sequenceType("IEnumerable", result)
+		}
+	case (name == "OrderBy" || name == "OrderByDescending") && len(args) == 1,
+		(name == "ThenBy" || name == "ThenByDescending") && len(args) == 1 && isGenericOf(sourceType, "IOrderedEnumerable"):
+		parameter("keySelector", tc.funcType(element, infer(0, element)))
+		returnType = tc.sequenceType("IOrderedEnumerable", element)
+	case name == "GroupBy" && (len(args) == 1 || len(args) == 2):
+		key := infer(0, element)
+		parameter("keySelector", tc.funcType(element, key))
+		grouped := element
+		if len(args) == 2 {
+			grouped = infer(1, element)
+			parameter("elementSelector", tc.funcType(element, grouped))
+		}
+		returnType = tc.sequenceType("IEnumerable", tc.sequenceType("IGrouping", key, grouped))
+	case (name == "Join" || name == "GroupJoin") && len(args) == 4:
+		if args[0].deferred {
+			return
+		}
+		inner, ok := tc.elementType(args[0].typed.Type)
+		if !ok {
+			return
+		}
+		key := infer(1, element)
+		joined := inner
+		if name == "GroupJoin" {
+			joined = tc.sequenceType("IEnumerable", inner)
+		}
+		result := infer(3, element, joined)
+		parameter("inner", args[0].typed.Type)
+		parameter("outerKeySelector", tc.funcType(element, key))
+		parameter("innerKeySelector", tc.funcType(inner, key))
+		parameter("resultSelector", tc.funcType(element, joined, result))
+		returnType = tc.sequenceType("IEnumerable", result)
+	case name == "Sum" && len(args) == 1:
+		// Sum is declared for int, long, float, double and decimal and for their nullable forms
+		returnType = infer(0, element)
+		summed := returnType
+		if underlying, ok := tc.nullableUnderlying(returnType); ok {
+			summed = underlying
+		}
+		switch summed {
+		case types.Int, types.Long, types.Float, types.Double, types.Decimal:
+		default:
+			tc.errorf(line, column, "cannot sum values of type %s", returnType)
+		}
+		parameter("selector", tc.funcType(element, returnType))
+	case name == "ToList" && len(args) == 0:
+		returnType = tc.sequenceType("List", element)
+	case name == "ToArray" && len(args) == 0:
+		returnType = tc.registry.NewArray(element)
+	case name == "Count" && len(args) == 0:
+		returnType = types.Int
+	case name == "Any" && len(args) == 0:
+		returnType = types.Bool
+	case name == "First" && len(args) == 0:
+		returnType = element
+	default:
+		return
+	}
+
+	operator := &MethodSymbol{Class: "Enumerable", Name: name, Modifiers: []ast.Modifier{{Kind: lexer.PUBLIC}, {Kind: lexer.STATIC}}, Parameters: parameters, ParameterTypes: tc.parameterTypes(parameters), ReturnType: returnType}
+	for _, existing := range enumerable.Methods[name] {
+		if existing.hasSameParameters(operator) {
+			return
+		}
+	}
+	enumerable.Methods[name] = append(enumerable.Methods[name], operator)
+}
+
+// The element type of arrays and the sequences derived from IEnumerable<T> like List<T>
+func (tc *TypeChecker) elementType(typ types.Type) (types.Type, bool) {
+	if array, ok := typ.(*types.Array); ok {
+		return array.Element, true
+	}
+	visited := map[string]bool{}
+	for current, ok := typ.String(), true; ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		visited[current] = true
+		if generic, isGeneric := tc.registry.Parse(current).(*types.Generic); isGeneric && generic.Name == "IEnumerable" && len(generic.Arguments) == 1 {
+			return generic.Arguments[0], true
+		}
+	}
+	return nil, false
+}
+
+func isGenericOf(typ types.Type, name string) bool {
+	generic, ok := typ.(*types.Generic)
+	return ok && generic.Name == name
+}
+
+func (tc *TypeChecker) sequenceType(name string, arguments ...types.Type) types.Type {
+	typ := tc.registry.NewGeneric(name, arguments)
+	tc.instantiateCollection(typ)
+	return typ
+}
+
+func (tc *TypeChecker) funcType(parameters ...types.Type) types.Type {
+	return tc.registry.NewGeneric("Func", parameters)
+}
+
+// The result type of a lambda or delegate argument called with the parameter types
+func (tc *TypeChecker) inferResultType(arg argument, parameters []types.Type) (types.Type, bool) {
+	lambda, isLambda := arg.expr.(ast.LambdaExpr)
+	if !isLambda {
+		signature, ok := tc.delegateSignature(arg.typed.Type)
+		return signature.Result, !arg.deferred && ok && len(signature.Parameters) == len(parameters)
+	}
+	if lambda.Expression == nil || lambda.IsAsync || len(lambda.Parameters) != len(parameters) {
+		return nil, false
+	}
+
+	// The body is checked again once the operator is chosen, warnings are only reported then
+	env, warnings, inAsync, awaited := tc.env, len(tc.warnings), tc.inAsync, tc.awaited
+	tc.env, tc.inAsync = NewClosureEnv(tc.env, &Closure{}), false
+	defer func() {
+		tc.env, tc.warnings, tc.inAsync, tc.awaited = env, tc.warnings[:warnings], inAsync, awaited
+	}()
+	for i, param := range lambda.Parameters {
+		typ := parameters[i]
+		if param.Type.Name != "" {
+			typ = tc.registry.Parse(tc.resolveLocalType(param.Type).Name)
+		}
+		tc.defineLambdaParameter(param.Identifier, typ)
+	}
+	body := tc.CheckTargetTypedExpr(lambda.Expression, nil)
+	return body.Type, tc.isInferable(body.Type)
+}
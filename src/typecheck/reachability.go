@@ -0,0 +1,84 @@
+package typecheck
+
+import "github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+
+// Reports whether control can reach the end of an unchecked statement
+func isEndReachable(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case ast.BlockStmt:
+		for _, inner := range s.Body {
+			if !isEndReachable(inner) {
+				return false
+			}
+		}
+		return true
+	case ast.ReturnStmt, ast.ThrowStmt, ast.BreakStmt, ast.ContinueStmt, ast.GotoCaseStmt, ast.YieldBreakStmt:
+		return false
+	case ast.IfStmt:
+		return s.Else == nil || isEndReachable(s.Then) || isEndReachable(s.Else)
+	case ast.WhileStmt:
+		// while (true) only ends through a break
+		if condition, ok := s.Condition.(ast.BoolLiteralExpr); ok && condition.Value {
+			return containsBreak(s.Body)
+		}
+		return true
+	case ast.TryStmt:
+		if s.Finally != nil && !isEndReachable(s.Finally) {
+			return false
+		}
+		if isEndReachable(s.Body) {
+			return true
+		}
+		for _, clause := range s.Catches {
+			if isEndReachable(clause.Body) {
+				return true
+			}
+		}
+		return false
+	case ast.SwitchStmt:
+		// Sections can not fall through, so the end is only reached by a break or when no label matches
+		hasDefault := false
+		for _, section := range s.Sections {
+			if containsBreak(section.Body) {
+				return true
+			}
+			for _, label := range section.Labels {
+				if label.IsDefault {
+					hasDefault = true
+				}
+				if _, isDiscard := label.Pattern.(ast.DiscardPattern); isDiscard && label.Guard == nil {
+					hasDefault = true
+				}
+			}
+		}
+		return !hasDefault
+	}
+	return true
+}
+
+// Reports whether a statement contains a break that leaves the enclosing loop or switch
+func containsBreak(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case ast.BreakStmt:
+		return true
+	case ast.BlockStmt:
+		for _, inner := range s.Body {
+			if containsBreak(inner) {
+				return true
+			}
+		}
+	case ast.IfStmt:
+		return containsBreak(s.Then) || (s.Else != nil && containsBreak(s.Else))
+	case ast.TryStmt:
+		if containsBreak(s.Body) || (s.Finally != nil && containsBreak(s.Finally)) {
+			return true
+		}
+		for _, clause := range s.Catches {
+			if containsBreak(clause.Body) {
+				return true
+			}
+		}
+	}
+	// Breaks inside of nested loops and switches belong to those
+	return false
+}
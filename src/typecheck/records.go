@@ -0,0 +1,163 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// The parser synthesizes the members of records, so records are checked like the classes and structs
+// they are. Only the positional parameters and the with expressions that copy records need extra rules.
+
+func (tc *TypeChecker) isRecord(typ string) bool {
+	class, ok := tc.classes[typ]
+	return ok && class.Decl.IsRecord
+}
+
+func (tc *TypeChecker) checkRecordParameters(class *ast.ClassDeclStmt) {
+	for _, param := range class.Parameters {
+		if modifier := referenceModifier(param.Modifiers); modifier == "ref" || modifier == "out" {
+			tc.errorf(param.Type.Line, param.Type.Column, "the parameter %s of record %s cannot have the %s modifier", param.Identifier, class.Name, modifier)
+		}
+		if hasModifier(param.Modifiers, lexer.PARAMS) || hasModifier(param.Modifiers, lexer.THIS) {
+			tc.errorf(param.Type.Line, param.Type.Column, "the parameter %s of record %s cannot have a params or this modifier", param.Identifier, class.Name)
+		}
+	}
+}
+
+// receiver with { Member = value, ... } copies a record or struct and assigns members of the copy,
+// init-only properties included
+func (tc *TypeChecker) CheckWithExpr(expr ast.WithExpr) ast.TypedExpr {
+	receiver := tc.CheckExpr(expr.Receiver)
+	if class, ok := tc.classSymbol(receiver.Type); !ok || (!class.Decl.IsRecord && class.Decl.Kind != lexer.STRUCT) {
+		tc.errorf(expr.Line, expr.Column, "the receiver of a with expression must be a record or struct, not %s", receiver.Type)
+	}
+	tc.checkDereference(receiver, expr.Line, expr.Column)
+	expr.Receiver = receiver
+
+	initializer := expr.Initializer
+	initializer.Elements = append([]ast.InitializerElement{}, initializer.Elements...)
+	assigned := map[string]bool{}
+	for i, element := range initializer.Elements {
+		if element.Member == "" || element.Nested != nil {
+			tc.errorf(element.Line, element.Column, "a with expression can only assign members like Member = value")
+		}
+		if assigned[element.Member] {
+			tc.errorf(element.Line, element.Column, "duplicate initialization of member %s", element.Member)
+		}
+		assigned[element.Member] = true
+		initializer.Elements[i] = tc.checkMemberInitializer(element, receiver.Type.String())
+	}
+	expr.Initializer = initializer
+	return ast.TypedExpr{Type: receiver.Type, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Compares two values like EqualityComparer<T>.Default.Equals: with the == of the type if it has one, with the
+// Equals method the type declares otherwise and for structs without either member by member
+func (tc *TypeChecker) CheckValueEqualsExpr(expr ast.ValueEqualsExpr) ast.TypedExpr {
+	left, right := tc.CheckExpr(expr.Left), tc.CheckExpr(expr.Right)
+	return tc.valueEquality(left, right, expr.Line, expr.Column)
+}
+
+func (tc *TypeChecker) valueEquality(left, right ast.TypedExpr, line, column int) ast.TypedExpr {
+	at := func(expr ast.Expr) ast.TypedExpr {
+		return ast.TypedExpr{Type: types.Bool, Expr: expr, Line: line, Column: column}
+	}
+	and := func(first, second ast.Expr) ast.TypedExpr {
+		return at(ast.BinaryExpr{Left: first, Operator: lexer.NewToken(lexer.AND, "&&", line, column), Right: second, Line: line, Column: column})
+	}
+	equality := ast.BinaryExpr{Left: left, Operator: lexer.NewToken(lexer.EQUALS, "==", line, column), Right: right, Line: line, Column: column}
+
+	name := left.Type.String()
+	if !tc.isUserObject(left.Type) || left.Type != right.Type || len(tc.lookupMethods(name, "op_Equality")) > 0 {
+		return tc.CheckBinaryExpr(equality)
+	}
+	if method := tc.equalsMethod(name); method != nil {
+		call := at(ast.MethodCallExpr{Receiver: left, MethodName: "Equals", Args: []ast.Expr{right}, Signature: method.Signature(), Line: line, Column: column})
+		if tc.isStruct(left.Type) {
+			return call
+		}
+		// Equal references are equal values, a null reference can not call Equals
+		notNull := at(ast.PrefixExpr{Operator: lexer.NewToken(lexer.NOT, "!", line, column), Expression: tc.isNullTest(left), Line: line, Column: column})
+		return at(ast.BinaryExpr{Left: at(equality), Operator: lexer.NewToken(lexer.OR, "||", line, column), Right: and(notNull, call), Line: line, Column: column})
+	}
+	if !tc.isStruct(left.Type) {
+		return at(equality)
+	}
+
+	// ValueType.Equals compares all instance fields, the private ones included
+	var equal ast.TypedExpr
+	for i, field := range tc.instanceData(name) {
+		typ := tc.registry.Parse(field.Type.Name)
+		leftField := ast.TypedExpr{Type: typ, Expr: ast.MemberAccessExpr{Receiver: left, Member: field.Identifier, Line: line, Column: column}, Line: line, Column: column}
+		rightField := ast.TypedExpr{Type: typ, Expr: ast.MemberAccessExpr{Receiver: right, Member: field.Identifier, Line: line, Column: column}, Line: line, Column: column}
+		comparison := tc.valueEquality(leftField, rightField, line, column)
+		if i == 0 {
+			equal = comparison
+		} else {
+			equal = and(equal, comparison)
+		}
+	}
+	if equal.Expr == nil {
+		return at(ast.BoolLiteralExpr{Value: true, Line: line, Column: column})
+	}
+	return equal
+}
+
+// Hashes a value like EqualityComparer<T>.Default.GetHashCode: with the GetHashCode method the type declares
+// and by the runtime otherwise
+func (tc *TypeChecker) CheckValueHashExpr(expr ast.ValueHashExpr) ast.TypedExpr {
+	value := tc.CheckExpr(expr.Value)
+	if method := tc.hashCodeMethod(value.Type); method != nil {
+		expr.Method = method.Signature()
+	}
+	expr.Value = value
+	return ast.TypedExpr{Type: types.Int, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// The Equals method a type declares for values of its own type
+func (tc *TypeChecker) equalsMethod(className string) *MethodSymbol {
+	for _, method := range tc.lookupMethods(className, "Equals") {
+		if !method.IsStatic() && len(method.Parameters) == 1 && method.Parameters[0].Type.Name == className {
+			return method
+		}
+	}
+	return nil
+}
+
+// The parameterless GetHashCode method a user-defined type declares
+func (tc *TypeChecker) hashCodeMethod(typ types.Type) *MethodSymbol {
+	if !tc.isUserObject(typ) {
+		return nil
+	}
+	for _, method := range tc.lookupMethods(typ.String(), "GetHashCode") {
+		if !method.IsStatic() && len(method.Parameters) == 0 && method.ReturnType == types.Int {
+			return method
+		}
+	}
+	return nil
+}
+
+// The instance fields and auto-implemented properties of a class in declaration order
+func (tc *TypeChecker) instanceData(className string) []ast.FieldDeclStmt {
+	fields := []ast.FieldDeclStmt{}
+	for _, member := range tc.classes[className].Decl.Body.Members {
+		switch m := member.(type) {
+		case ast.FieldDeclStmt:
+			if !hasModifier(m.Modifiers, lexer.STATIC) && !hasModifier(m.Modifiers, lexer.CONST) {
+				fields = append(fields, m)
+			}
+		case ast.PropertyDeclStmt:
+			if !hasModifier(m.Modifiers, lexer.STATIC) && isAutoProperty(m) {
+				fields = append(fields, tc.classes[className].Fields[m.Name])
+			}
+		}
+	}
+	return fields
+}
+
+func (tc *TypeChecker) isNullTest(operand ast.TypedExpr) ast.TypedExpr {
+	null := ast.TypedExpr{Type: types.Null, Expr: ast.NullLiteralExpr{Line: operand.Line, Column: operand.Column}, Line: operand.Line, Column: operand.Column}
+	pattern := ast.ConstantPattern{Value: null, Line: operand.Line, Column: operand.Column}
+	return ast.TypedExpr{Type: types.Bool, Expr: ast.IsPatternExpr{Expression: operand, Pattern: pattern, Line: operand.Line, Column: operand.Column}, Line: operand.Line, Column: operand.Column}
+}
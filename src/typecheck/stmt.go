@@ -1,26 +1,39 @@
 package typecheck
 
 import (
+	"strings"
+
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
 )
 
 func (tc *TypeChecker) CheckClassDeclStmt(class *ast.ClassDeclStmt) {
 	tc.env = NewTypeEnv(tc.env)              // Create new scope
 	defer func() { tc.env = tc.env.outer }() // Pop scope after checking class
 
-	// Register class fields
-	for _, member := range class.Body.Members {
-		if field, ok := member.(ast.FieldDeclStmt); ok {
-			tc.env.Define(field.Identifier, field.Type.Name, true, true, false)
-		}
+	tc.file = class.File
+	tc.checkBaseTypes(class)
+	tc.checkRecordParameters(class)
+
+	tc.defineClassMembers(*class)
+
+	target := targetClass
+	if class.Kind == lexer.STRUCT {
+		target = targetStruct
 	}
+	class.Attributes = tc.checkAttributes(class.Attributes, target)
 
-	tc.env.Define("this", class.Name, true, false, false)
+	outerObsolete := tc.inObsolete
+	defer func() { tc.inObsolete = outerObsolete }()
+	classObsolete := outerObsolete || tc.isObsolete(class.Attributes)
 
 	// Check members
 	for i, member := range class.Body.Members {
 		var updatedMember ast.ClassMember
 
+		tc.file = member.GetFile()
+		tc.inObsolete = classObsolete || tc.isObsolete(memberAttributes(member))
 		switch member := member.(type) {
 		case ast.FieldDeclStmt:
 			tc.CheckFieldDeclStmt(&member)
@@ -31,6 +44,28 @@ func (tc *TypeChecker) CheckClassDeclStmt(class *ast.ClassDeclStmt) {
 		case ast.ConstructorDeclStmt:
 			tc.CheckConstructorDeclStmt(&member)
 			updatedMember = member
+		case ast.IndexerDeclStmt:
+			tc.CheckIndexerDeclStmt(&member)
+			updatedMember = member
+		case ast.EventDeclStmt:
+			tc.CheckEventDeclStmt(&member)
+			updatedMember = member
+		case ast.PropertyDeclStmt:
+			tc.CheckPropertyDeclStmt(&member)
+			updatedMember = member
+		case ast.EnumDeclStmt:
+			tc.CheckEnumDeclStmt(&member)
+			updatedMember = member
+		case ast.ClassDeclStmt:
+			// Nested classes do not see the instance members of the enclosing class
+			classEnv := tc.env
+			tc.env = classEnv.outer
+			tc.CheckClassDeclStmt(&member)
+			tc.env = classEnv
+			updatedMember = member
+		case ast.DelegateDeclStmt:
+			tc.checkDelegateAttributes(&member)
+			updatedMember = member
 		default:
 			//tc.errorf(member.GetLine(), member.GetColumn(), "unexpected class member")
 			updatedMember = member
@@ -40,12 +75,103 @@ func (tc *TypeChecker) CheckClassDeclStmt(class *ast.ClassDeclStmt) {
 	}
 }
 
+// Defines this, the fields, properties and field-like events of the class and the fields of its base
+// and enclosing classes it can use without qualification
+func (tc *TypeChecker) defineClassMembers(class ast.ClassDeclStmt) {
+	// Register class fields, properties and field-like events
+	for _, member := range class.Body.Members {
+		if field, ok := member.(ast.FieldDeclStmt); ok {
+			tc.defineField(field)
+		}
+		if property, ok := member.(ast.PropertyDeclStmt); ok {
+			tc.defineField(propertyField(property))
+		}
+		if event, ok := member.(ast.EventDeclStmt); ok && len(event.Accessors) == 0 {
+			tc.defineEvent(event)
+		}
+	}
+
+	// Register inherited fields that are visible to the class
+	for base, ok := tc.baseClassOf(class.Name); ok; base, ok = tc.baseClassOf(base) {
+		for _, field := range tc.classes[base].Fields {
+			if !isPrivate(field.Modifiers) && !tc.env.IsDefinedInScope(field.Identifier) {
+				tc.defineField(field)
+			}
+		}
+	}
+
+	// Nested classes can use the static fields and constants of their enclosing classes, even private ones
+	for outer := enclosingTypeName(class.Name); outer != ""; outer = enclosingTypeName(outer) {
+		for _, field := range tc.classes[outer].Fields {
+			isStatic := hasModifier(field.Modifiers, lexer.STATIC) || hasModifier(field.Modifiers, lexer.CONST)
+			if isStatic && !tc.env.IsDefinedInScope(field.Identifier) {
+				tc.defineField(field)
+			}
+		}
+	}
+
+	tc.env.Define("this", tc.registry.Parse(class.Name), true, false, false)
+}
+
+func (tc *TypeChecker) checkBaseTypes(class *ast.ClassDeclStmt) {
+	if class.Kind == lexer.STRUCT && len(class.BaseTypes) > 0 {
+		tc.errorf(class.BaseTypes[0].Line, class.BaseTypes[0].Column, "struct %s cannot inherit from %s", class.Name, class.BaseTypes[0].Name)
+	}
+	if len(class.BaseTypes) > 1 {
+		tc.errorf(class.Line, class.Column, "class %s can only inherit from a single base class", class.Name)
+	}
+	for _, base := range class.BaseTypes {
+		if !tc.isClassName(base.Name) {
+			tc.errorf(base.Line, base.Column, "base class %s of class %s is not defined", base.Name, class.Name)
+		}
+		if tc.isStructName(base.Name) {
+			tc.errorf(base.Line, base.Column, "%s cannot derive from struct %s", class.Name, base.Name)
+		}
+		if class.IsRecord != tc.isRecord(base.Name) {
+			if class.IsRecord {
+				tc.errorf(base.Line, base.Column, "record %s can only inherit from a record, not from %s", class.Name, base.Name)
+			}
+			tc.errorf(base.Line, base.Column, "class %s cannot inherit from record %s", class.Name, base.Name)
+		}
+	}
+	if tc.isSubclassOf(class.Name, class.Name) {
+		tc.errorf(class.Line, class.Column, "circular base class dependency involving %s", class.Name)
+	}
+}
+
+func (tc *TypeChecker) defineField(field ast.FieldDeclStmt) {
+	tc.env.Define(field.Identifier, tc.registry.Parse(field.Type.Name), true, true, false)
+	if hasModifier(field.Modifiers, lexer.CONST) {
+		tc.env.MarkConstant(field.Identifier, nil)
+	}
+	if hasModifier(field.Modifiers, lexer.READONLY) {
+		tc.env.MarkReadOnly(field.Identifier)
+	}
+	if field.Type.IsNullable {
+		tc.env.MarkNullable(field.Identifier)
+	}
+}
+
 func (tc *TypeChecker) CheckFieldDeclStmt(field *ast.FieldDeclStmt) {
-	typedExpression := tc.CheckExpr(field.Value)
+	field.Attributes = tc.checkAttributes(field.Attributes, targetField)
+	tc.checkNotAsync(field.Modifiers, field.Line, field.Column)
+	if hasModifier(field.Modifiers, lexer.CONST) {
+		if hasModifier(field.Modifiers, lexer.STATIC) {
+			tc.errorf(field.Line, field.Column, "the constant %s cannot be marked static", field.Identifier)
+		}
+		tc.checkConstantDeclaration(field.Modifiers, field.Type, field.Identifier, field.Value, field.Line, field.Column)
+		tc.checkCircularConstant(field)
+	}
+
+	fieldType := tc.registry.Parse(field.Type.Name)
+	typedExpression := tc.CheckTargetTypedExpr(field.Value, fieldType)
 
-	if !tc.isTypeCompatible(field.Type.Name, typedExpression.Type) {
+	if !tc.isTypeCompatible(fieldType, typedExpression.Type) {
 		tc.errorf(field.Line, field.Column, "type mismatch: expected %s, got %s", field.Type.Name, typedExpression.Type)
 	}
+	if tc.isPossibleNullConversion(fieldType, field.Type.IsNullable, typedExpression, field.Line) {
+		tc.warnf(field.Line, field.Column, "converting null literal or possible null value to non-nullable type %s", field.Type.Name)
+	}
 
 	field.Value = typedExpression
 }
@@ -57,17 +183,33 @@ func (tc *TypeChecker) CheckMethodDeclStmt(method *ast.MethodDeclStmt) {
 	// The entry for this has to exist at this point
 	symbolEntry, _ := tc.env.Lookup("this")
 
-	if symbolEntry.Type == method.Name {
+	if simpleTypeName(symbolEntry.Type.String()) == method.Name {
 		tc.errorf(method.GetLine(), method.GetColumn(), "method name can't be the same as the class name")
 	}
 
-	for _, param := range method.Parameters {
-		tc.env.Define(param.Identifier, param.Type.Name, false, false, true)
+	if method.Operator != "" {
+		tc.checkOperatorDeclaration(method, symbolEntry.Type)
+	}
+	if isExtensionMethod(method.Parameters) {
+		tc.checkExtensionMethodDeclaration(method, symbolEntry.Type.String())
+	}
+	method.Attributes = tc.checkAttributes(method.Attributes, targetMethod)
+	tc.checkParameterAttributes(method.Parameters)
+	tc.defineParameters(method.Parameters)
+	tc.checkOutParameters("the current method", method.Parameters, method.Body, method.Line, method.Column)
+
+	// Top-level statements are async as soon as they await
+	returnType := tc.registry.Parse(method.ReturnType.Name)
+	if hasModifier(method.Modifiers, lexer.ASYNC) {
+		returnType = tc.enterAsync(returnType, method.Parameters, method.Line, method.Column)
+	} else if method.IsTopLevel {
+		tc.inAsync, tc.awaited = true, false
 	}
+	defer func() { tc.inAsync = false }()
+	tc.enterMethod(returnType, method.ReturnType.IsNullable)
 
-	// Register thisMethod so that returns deep into the method body can be checked against the return type
-	// TODO: Check later on if this is really needed.
-	tc.env.Define("thisMethod", method.ReturnType.Name, false, false, false)
+	tc.iteratorElement = tc.iteratorElementType(method.Name, method.ReturnType, method.Parameters, method.Body, method.Line, method.Column)
+	defer func() { tc.iteratorElement = nil }()
 
 	// Check and type method body
 	if block, ok := method.Body.(ast.BlockStmt); ok {
@@ -76,9 +218,14 @@ func (tc *TypeChecker) CheckMethodDeclStmt(method *ast.MethodDeclStmt) {
 		tc.errorf(method.GetLine(), method.GetColumn(), "method body should be a block statement")
 	}
 
-	// Check return type
-	if !tc.isTypeCompatible(method.ReturnType.Name, method.Body.(ast.TypedStmt).Type) {
-		tc.errorf(method.GetLine(), method.GetColumn(), "type mismatch: expected %s, got %s", method.ReturnType.Name, method.Body.(ast.TypedStmt).Type)
+	// Check return type, iterators yield their values instead of returning them
+	if tc.iteratorElement == nil && !tc.isTypeCompatible(returnType, method.Body.(ast.TypedStmt).Type) {
+		tc.errorf(method.GetLine(), method.GetColumn(), "type mismatch: expected %s, got %s", returnType, method.Body.(ast.TypedStmt).Type)
+	}
+	if hasModifier(method.Modifiers, lexer.ASYNC) {
+		tc.checkAwaited(method.Line, method.Column)
+	} else if method.IsTopLevel && tc.awaited {
+		tc.makeTopLevelAsync(method)
 	}
 }
 
@@ -89,13 +236,20 @@ func (tc *TypeChecker) CheckConstructorDeclStmt(constructor *ast.ConstructorDecl
 	// The entry for this has to exist at this point
 	symbolEntry, _ := tc.env.Lookup("this")
 
-	if symbolEntry.Type != constructor.Name {
+	if simpleTypeName(symbolEntry.Type.String()) != constructor.Name {
 		tc.errorf(constructor.GetLine(), constructor.GetColumn(), "constructor name must be the same as the class name")
 	}
 
-	for _, param := range constructor.Parameters {
-		tc.env.Define(param.Identifier, param.Type.Name, false, false, true)
-	}
+	constructor.Attributes = tc.checkAttributes(constructor.Attributes, targetConstructor)
+	tc.checkNotAsync(constructor.Modifiers, constructor.Line, constructor.Column)
+	tc.checkParameterAttributes(constructor.Parameters)
+	tc.checkNotExtension(constructor.Parameters)
+	tc.defineParameters(constructor.Parameters)
+	tc.checkOutParameters("the constructor", constructor.Parameters, constructor.Body, constructor.Line, constructor.Column)
+
+	tc.enterMethod(types.Void, false)
+	tc.inConstructor, tc.inStaticConstructor = true, hasModifier(constructor.Modifiers, lexer.STATIC)
+	defer func() { tc.inConstructor, tc.inStaticConstructor = false, false }()
 
 	// Check and type constructor body
 	if block, ok := constructor.Body.(ast.BlockStmt); ok {
@@ -105,21 +259,101 @@ func (tc *TypeChecker) CheckConstructorDeclStmt(constructor *ast.ConstructorDecl
 	}
 
 	// Check return type
-	if constructor.Body.(ast.TypedStmt).Type != "void" {
+	if constructor.Body.(ast.TypedStmt).Type != types.Void {
 		tc.errorf(constructor.GetLine(), constructor.GetColumn(), "constructor can not have a return")
 	}
 }
 
+// Checks the parameter modifiers and default values and defines the parameters in the current scope
+func (tc *TypeChecker) defineParameters(parameters []ast.Parameter) {
+	optional := false
+	for i, param := range parameters {
+		if tc.env.IsDefinedInScope(param.Identifier) {
+			tc.errorf(param.Type.Line, param.Type.Column, "the parameter name %s is a duplicate", param.Identifier)
+		}
+
+		modifiers := 0
+		for _, kind := range []lexer.TokenKind{lexer.REF, lexer.OUT, lexer.IN, lexer.PARAMS} {
+			if hasModifier(param.Modifiers, kind) {
+				modifiers++
+			}
+		}
+		if modifiers > 1 {
+			tc.errorf(param.Type.Line, param.Type.Column, "the parameter %s can only have one of the modifiers ref, out, in and params", param.Identifier)
+		}
+		if i > 0 && hasModifier(param.Modifiers, lexer.THIS) {
+			tc.errorf(param.Type.Line, param.Type.Column, "the parameter modifier this can only be used on the first parameter of an extension method")
+		}
+
+		isParams := hasModifier(param.Modifiers, lexer.PARAMS)
+		if isParams {
+			if i != len(parameters)-1 {
+				tc.errorf(param.Type.Line, param.Type.Column, "a params parameter must be the last parameter in a parameter list")
+			}
+			if !strings.HasSuffix(param.Type.Name, "[]") {
+				tc.errorf(param.Type.Line, param.Type.Column, "the params parameter %s must be a single dimensional array", param.Identifier)
+			}
+			if param.Default != nil {
+				tc.errorf(param.Type.Line, param.Type.Column, "cannot specify a default value for a params parameter")
+			}
+		}
+
+		if param.Default != nil {
+			if modifier := referenceModifier(param.Modifiers); modifier == "ref" || modifier == "out" {
+				tc.errorf(param.Type.Line, param.Type.Column, "a %s parameter cannot have a default value", modifier)
+			}
+			if !tc.isConstant(param.Default) {
+				tc.errorf(param.Default.GetLine(), param.Default.GetColumn(), "default parameter value for %s must be a compile-time constant", param.Identifier)
+			}
+			if typed := tc.CheckExpr(param.Default); !tc.isTypeCompatible(tc.registry.Parse(param.Type.Name), typed.Type) {
+				tc.errorf(param.Default.GetLine(), param.Default.GetColumn(), "a value of type %s cannot be used as a default parameter for %s of type %s", typed.Type, param.Identifier, param.Type.Name)
+			}
+			optional = true
+		} else if optional && !isParams {
+			tc.errorf(param.Type.Line, param.Type.Column, "optional parameters must appear after all required parameters")
+		}
+
+		tc.env.Define(param.Identifier, tc.registry.Parse(param.Type.Name), false, false, true)
+		if hasModifier(param.Modifiers, lexer.IN) {
+			tc.env.MarkReadOnly(param.Identifier)
+		}
+		// Out parameters have to be assigned before they are read
+		if hasModifier(param.Modifiers, lexer.OUT) {
+			tc.env.MarkUnassigned(param.Identifier)
+		}
+		if param.Type.IsNullable {
+			tc.env.MarkNullable(param.Identifier)
+		}
+	}
+}
+
+// Makes the current scope the outermost one of a body whose returns are checked against returnType
+func (tc *TypeChecker) enterMethod(returnType types.Type, returnsNullable bool) {
+	tc.method = &methodContext{returnType: returnType, returnsNullable: returnsNullable}
+	tc.env.MarkBody()
+}
+
 func (tc *TypeChecker) CheckBlockStmt(block *ast.BlockStmt) ast.TypedStmt {
 	tc.env = NewTypeEnv(tc.env)
-	defer func() { tc.env = tc.env.outer }()
+	defer tc.leaveSequentialScope()
+	// checked { } and unchecked { } set the overflow checking context of their body
+	if block.Context != 0 {
+		unchecked := tc.unchecked
+		tc.unchecked = block.Context == lexer.UNCHECKED
+		defer func() { tc.unchecked = unchecked }()
+	}
 
-	possibleBlockTypes := []string{}
+	possibleBlockTypes := []types.Type{}
+	tc.declareLocalFunctions(block)
 
 	for i, stmt := range block.Body {
 		switch stmt := stmt.(type) {
 		case ast.ExpressionStmt:
 			block.Body[i] = tc.CheckExpressionStmt(&stmt)
+		case ast.VarDeclStmt:
+			block.Body[i] = tc.CheckVarDeclStmt(&stmt)
+		case ast.MultiVarDeclStmt:
+			block.Body[i] = tc.CheckMultiVarDeclStmt(&stmt)
 		case ast.BlockStmt:
 			block.Body[i] = tc.CheckBlockStmt(&stmt)
 			possibleBlockTypes = append(possibleBlockTypes, block.Body[i].(ast.TypedStmt).Type)
@@ -132,10 +366,28 @@ func (tc *TypeChecker) CheckBlockStmt(block *ast.BlockStmt) ast.TypedStmt {
 		case ast.ReturnStmt:
 			block.Body[i] = tc.CheckReturnStmt(&stmt)
 			possibleBlockTypes = append(possibleBlockTypes, block.Body[i].(ast.TypedStmt).Type)
+		case ast.TryStmt:
+			block.Body[i] = tc.CheckTryStmt(&stmt)
+			possibleBlockTypes = append(possibleBlockTypes, block.Body[i].(ast.TypedStmt).Type)
+		case ast.SwitchStmt:
+			block.Body[i] = tc.CheckSwitchStmt(&stmt)
+			possibleBlockTypes = append(possibleBlockTypes, block.Body[i].(ast.TypedStmt).Type)
+		case ast.GotoCaseStmt:
+			block.Body[i] = tc.CheckGotoCaseStmt(&stmt)
+		case ast.LocalFunctionStmt:
+			block.Body[i] = tc.CheckLocalFunctionStmt(&stmt)
+		case ast.ThrowStmt:
+			block.Body[i] = tc.CheckThrowStmt(&stmt)
+			// A throw leaves the method just like a return of the expected type would
+			possibleBlockTypes = append(possibleBlockTypes, tc.method.returnType)
+		case ast.YieldReturnStmt:
+			block.Body[i] = tc.CheckYieldReturnStmt(&stmt)
+		case ast.YieldBreakStmt:
+			block.Body[i] = tc.CheckYieldBreakStmt(&stmt)
 		case ast.BreakStmt:
-			block.Body[i] = ast.TypedStmt{Stmt: stmt, Type: "void"}
+			block.Body[i] = ast.TypedStmt{Stmt: stmt, Type: types.Void}
 		case ast.ContinueStmt:
-			block.Body[i] = ast.TypedStmt{Stmt: stmt, Type: "void"}
+			block.Body[i] = ast.TypedStmt{Stmt: stmt, Type: types.Void}
 		default:
 			tc.errorf(stmt.GetLine(), stmt.GetColumn(), "unexpected statement")
 		}
@@ -144,34 +396,306 @@ func (tc *TypeChecker) CheckBlockStmt(block *ast.BlockStmt) ast.TypedStmt {
 	return ast.TypedStmt{Stmt: block, Type: blockType}
 }
 
+// Local functions can be called anywhere in their block, even before their declaration
+func (tc *TypeChecker) declareLocalFunctions(block *ast.BlockStmt) {
+	for i, stmt := range block.Body {
+		function, ok := stmt.(ast.LocalFunctionStmt)
+		if !ok {
+			continue
+		}
+		if tc.env.IsDefinedInScope(function.Name) {
+			tc.errorf(function.Line, function.Column, "a local variable or function named %s is already defined in this scope", function.Name)
+		}
+		function.ReturnType = tc.resolveLocalType(function.ReturnType)
+		for j := range function.Parameters {
+			function.Parameters[j].Type = tc.resolveLocalType(function.Parameters[j].Type)
+		}
+		tc.env.DefineFunction(&MethodSymbol{
+			Class:           tc.currentClassName(),
+			Name:            function.Name,
+			Modifiers:       function.Modifiers,
+			Parameters:      function.Parameters,
+			ParameterTypes:  tc.parameterTypes(function.Parameters),
+			ReturnType:      tc.registry.Parse(function.ReturnType.Name),
+			ReturnsNullable: function.ReturnType.IsNullable,
+		})
+		block.Body[i] = function
+	}
+}
+
+// The body is checked like a lambda body, so references to enclosing locals are recorded as captures
+func (tc *TypeChecker) CheckLocalFunctionStmt(function *ast.LocalFunctionStmt) ast.TypedStmt {
+	closure := &Closure{}
+	tc.env = NewClosureEnv(tc.env, closure)
+	defer func() { tc.env = tc.env.outer }()
+
+	tc.checkParameterAttributes(function.Parameters)
+	tc.checkNotExtension(function.Parameters)
+	tc.defineParameters(function.Parameters)
+	tc.checkOutParameters("the local function", function.Parameters, function.Body, function.Line, function.Column)
+
+	catchDepth, finallyDepth, tryCatchDepth, switches, inConstructor, iteratorElement := tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.iteratorElement
+	inAsync, awaited, inStaticLocalFunction, method := tc.inAsync, tc.awaited, tc.inStaticLocalFunction, tc.method
+	isStatic := hasModifier(function.Modifiers, lexer.STATIC)
+	tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.inAsync = 0, 0, 0, nil, false, false
+	tc.inStaticLocalFunction = tc.inStaticLocalFunction || isStatic
+	defer func() {
+		tc.catchDepth, tc.finallyDepth, tc.tryCatchDepth, tc.switches, tc.inConstructor, tc.iteratorElement = catchDepth, finallyDepth, tryCatchDepth, switches, inConstructor, iteratorElement
+		tc.inAsync, tc.awaited, tc.inStaticLocalFunction, tc.method = inAsync, awaited, inStaticLocalFunction, method
+	}()
+	returnType := tc.registry.Parse(function.ReturnType.Name)
+	if hasModifier(function.Modifiers, lexer.ASYNC) {
+		returnType = tc.enterAsync(returnType, function.Parameters, function.Line, function.Column)
+	}
+	tc.enterMethod(returnType, function.ReturnType.IsNullable)
+	tc.iteratorElement = tc.iteratorElementType(function.Name, function.ReturnType, function.Parameters, function.Body, function.Line, function.Column)
+
+	if block, ok := function.Body.(ast.BlockStmt); ok {
+		function.Body = tc.CheckBlockStmt(&block)
+	} else {
+		tc.errorf(function.Line, function.Column, "local function body should be a block statement")
+	}
+	if tc.iteratorElement == nil && !tc.isTypeCompatible(returnType, function.Body.(ast.TypedStmt).Type) {
+		tc.errorf(function.Line, function.Column, "type mismatch: expected %s, got %s", returnType, function.Body.(ast.TypedStmt).Type)
+	}
+	if hasModifier(function.Modifiers, lexer.ASYNC) {
+		tc.checkAwaited(function.Line, function.Column)
+	}
+
+	if isStatic && len(closure.Captures) > 0 {
+		tc.errorf(function.Line, function.Column, "a static local function cannot contain a reference to %s", closure.Captures[0])
+	}
+	function.Captures = closure.Captures
+
+	return ast.TypedStmt{Stmt: function, Type: types.Void, Line: function.Line, Column: function.Column}
+}
+
+// Uses of this, also implicit ones through instance members, are not allowed inside of static local functions
+func (tc *TypeChecker) checkThisAccess(line, column int) {
+	if tc.inStaticLocalFunction {
+		tc.errorf(line, column, "a static local function cannot contain a reference to this")
+	}
+}
+
 func (tc *TypeChecker) CheckExpressionStmt(expr *ast.ExpressionStmt) ast.TypedStmt {
 	expr.Expression = tc.CheckExpr(expr.Expression)
 	return ast.TypedStmt{Stmt: expr, Type: expr.Expression.(ast.TypedExpr).Type}
 }
 
+func (tc *TypeChecker) CheckVarDeclStmt(stmt *ast.VarDeclStmt) ast.TypedStmt {
+	var typedValue ast.TypedExpr
+
+	if hasModifier(stmt.Modifiers, lexer.READONLY) {
+		tc.errorf(stmt.Line, stmt.Column, "the modifier readonly is not valid for local variables")
+	}
+	isConstant := hasModifier(stmt.Modifiers, lexer.CONST)
+	if isConstant {
+		if stmt.Type.Name == "var" {
+			tc.errorf(stmt.Line, stmt.Column, "implicitly-typed variables cannot be constant")
+		}
+		tc.checkConstantDeclaration(stmt.Modifiers, stmt.Type, stmt.Identifier, stmt.Value, stmt.Line, stmt.Column)
+	}
+
+	var typ types.Type
+	isImplicitlyTyped := stmt.Type.Name == "var"
+	if isImplicitlyTyped {
+		if _, ok := stmt.Value.(ast.LambdaExpr); ok {
+			tc.errorf(stmt.Line, stmt.Column, "cannot assign lambda expression to an implicitly-typed variable")
+		}
+		typedValue = tc.CheckExpr(stmt.Value)
+		if !tc.isInferable(typedValue.Type) {
+			tc.errorf(stmt.Line, stmt.Column, "cannot assign %s to an implicitly-typed variable", typedValue.Type)
+		}
+		typ = typedValue.Type
+		stmt.Type.Name = typ.String()
+	} else {
+		stmt.Type = tc.resolveLocalType(stmt.Type)
+		typ = tc.registry.Parse(stmt.Type.Name)
+		typedValue = tc.CheckTargetTypedExpr(stmt.Value, typ)
+		if !tc.isTypeCompatible(typ, typedValue.Type) {
+			tc.errorf(stmt.Line, stmt.Column, "type mismatch: expected %s, got %s", stmt.Type.Name, typedValue.Type)
+		}
+	}
+
+	if tc.env.IsDefinedInScope(stmt.Identifier) {
+		tc.errorf(stmt.Line, stmt.Column, "variable %s is already defined in this scope", stmt.Identifier)
+	}
+	// Implicitly typed locals of reference types are nullable, their null state comes from the value
+	isNullable := stmt.Type.IsNullable || (isImplicitlyTyped && tc.isReferenceType(typ))
+	if tc.isPossibleNullConversion(typ, isNullable, typedValue, stmt.Line) {
+		tc.warnf(stmt.Line, stmt.Column, "converting null literal or possible null value to non-nullable type %s", stmt.Type.Name)
+	}
+	tc.env.Define(stmt.Identifier, typ, false, false, false)
+	if isConstant {
+		tc.env.MarkConstant(stmt.Identifier, typedValue)
+	}
+	// Locals without an initializer have to be assigned before they are read
+	if stmt.IsUninitialized && !isConstant {
+		tc.env.MarkUnassigned(stmt.Identifier)
+	}
+	if isNullable {
+		tc.env.MarkNullable(stmt.Identifier)
+	}
+	tc.updateNullState(stmt.Identifier, typedValue)
+
+	stmt.Value = typedValue
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
+
+// All declarators share one scope, implicitly typed declarations may only have one of them
+func (tc *TypeChecker) CheckMultiVarDeclStmt(stmt *ast.MultiVarDeclStmt) ast.TypedStmt {
+	for i := range stmt.Declarations {
+		decl := &stmt.Declarations[i]
+		if decl.Type.Name == "var" {
+			tc.errorf(decl.Line, decl.Column, "implicitly-typed variables cannot have multiple declarators")
+		}
+		tc.CheckVarDeclStmt(decl)
+	}
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
+
+// Constants need an initializer that is known at compile time and a type that can hold such a value
+func (tc *TypeChecker) checkConstantDeclaration(modifiers []ast.Modifier, typ ast.Type, name string, value ast.Expr, line, column int) {
+	if hasModifier(modifiers, lexer.READONLY) {
+		tc.errorf(line, column, "the modifier readonly is not valid for the constant %s", name)
+	}
+	if value == nil {
+		tc.errorf(line, column, "the constant %s requires a value to be provided", name)
+	}
+	switch resolved := tc.registry.Parse(typ.Name); {
+	case types.IsNumeric(resolved), resolved == types.Bool, resolved == types.String, tc.isEnum(resolved), typ.Name == "var":
+	default:
+		tc.errorf(typ.Line, typ.Column, "the type %s cannot be declared const", typ.Name)
+	}
+	if !tc.isConstant(value) {
+		tc.errorf(value.GetLine(), value.GetColumn(), "the expression being assigned to %s must be constant", name)
+	}
+}
+
+// The value of a constant field cannot depend on the constant itself, e.g. const int A = B + 1; const int B = A + 1;
+func (tc *TypeChecker) checkCircularConstant(field *ast.FieldDeclStmt) {
+	self := tc.currentClassName() + "." + field.Identifier
+	visited := map[string]bool{}
+	var dependsOnSelf func(class string, value ast.Expr) bool
+	dependsOnSelf = func(class string, value ast.Expr) bool {
+		for _, name := range constantReferences(value) {
+			constant, declaring, ok := tc.lookupConstant(class, name)
+			key := declaring + "." + name
+			if !ok || visited[key] {
+				continue
+			}
+			visited[key] = true
+			if key == self || dependsOnSelf(declaring, constant.Value) {
+				return true
+			}
+		}
+		return false
+	}
+	if dependsOnSelf(tc.currentClassName(), field.Value) {
+		tc.errorf(field.Line, field.Column, "the evaluation of the constant value for %s involves a circular definition", field.Identifier)
+	}
+}
+
+// The checked value of a constant field. Constants can be used before their field is checked, so the value
+// is checked in the scope of the declaring class the first time it is needed.
+func (tc *TypeChecker) constantValue(className, name string) (ast.TypedExpr, bool) {
+	field, declaring, ok := tc.lookupConstant(className, name)
+	if !ok {
+		return ast.TypedExpr{}, false
+	}
+	key := declaring + "." + name
+	if value, ok := tc.constants[key]; ok {
+		// Circular definitions have no value, they are reported when the field is checked
+		return value, value.Expr != nil
+	}
+	tc.constants[key] = ast.TypedExpr{}
+
+	env, file, unchecked := tc.env, tc.file, tc.unchecked
+	defer func() { tc.env, tc.file, tc.unchecked = env, file, unchecked }()
+	root := tc.env
+	for root.outer != nil {
+		root = root.outer
+	}
+	tc.env = NewTypeEnv(root)
+	tc.file, tc.unchecked = tc.classes[declaring].Decl.File, false
+	tc.defineClassMembers(tc.classes[declaring].Decl)
+	value := tc.CheckTargetTypedExpr(field.Value, tc.registry.Parse(field.Type.Name))
+	tc.constants[key] = value
+	return value, true
+}
+
+// Constant fields are found like fields, in the class, its base classes and the enclosing classes
+func (tc *TypeChecker) lookupConstant(className, name string) (ast.FieldDeclStmt, string, bool) {
+	for class := className; class != ""; class = enclosingTypeName(class) {
+		if field, declaring, ok := tc.lookupField(class, name); ok {
+			return field, declaring, hasModifier(field.Modifiers, lexer.CONST)
+		}
+	}
+	return ast.FieldDeclStmt{}, "", false
+}
+
+// The names a constant expression refers to, see isConstant for the forms it can take
+func constantReferences(expr ast.Expr) []string {
+	switch e := expr.(type) {
+	case ast.IdentifierExpr:
+		return []string{e.Name}
+	case ast.PrefixExpr:
+		return constantReferences(e.Expression)
+	case ast.BinaryExpr:
+		return append(constantReferences(e.Left), constantReferences(e.Right)...)
+	case ast.CastExpr:
+		return constantReferences(e.Expression)
+	case ast.CheckedExpr:
+		return constantReferences(e.Expression)
+	}
+	return nil
+}
+
 func (tc *TypeChecker) CheckReturnStmt(stmt *ast.ReturnStmt) ast.TypedStmt {
+	if tc.finallyDepth > 0 {
+		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "control cannot leave the body of a finally clause")
+	}
+
+	if tc.iteratorElement != nil {
+		if stmt.Value != nil {
+			tc.errorf(stmt.Line, stmt.Column, "cannot return a value from an iterator; use the yield return statement to return a value, or yield break to end the iteration")
+		}
+		tc.errorf(stmt.Line, stmt.Column, "an iterator cannot contain a return statement; use yield break to end the iteration")
+	}
 
-	typ := "void"
+	method := tc.method
+	typ := types.Void
 	if stmt.Value != nil {
-		stmt.Value = tc.CheckExpr(stmt.Value)
+		stmt.Value = tc.CheckTargetTypedExpr(stmt.Value, method.returnType)
 		typ = stmt.Value.(ast.TypedExpr).Type
 	}
 
-	// thisMethod must exist at this point
-	method, _ := tc.env.Lookup("thisMethod")
-
-	if !tc.isTypeCompatible(method.Type, typ) {
-		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "type mismatch: expected %s, got %s", method.Type, typ)
+	if !tc.isTypeCompatible(method.returnType, typ) {
+		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "type mismatch: expected %s, got %s", method.returnType, typ)
+	}
+	if stmt.Value != nil && tc.isPossibleNullConversion(method.returnType, method.returnsNullable, stmt.Value, stmt.Line) {
+		tc.warnf(stmt.Line, stmt.Column, "possible null reference return")
 	}
 
 	return ast.TypedStmt{Stmt: stmt, Type: typ}
 }
 
 func (tc *TypeChecker) CheckWhileStmt(stmt *ast.WhileStmt) ast.TypedStmt {
+	// Without a break the loop only ends once its condition is false, so variables the false condition
+	// proves non-null are non-null after the loop
+	if !containsBreak(stmt.Body) {
+		defer func() { tc.markNotNull(notNullWhen(stmt.Condition, false)) }()
+	}
+	// Pattern variables of the condition are scoped to the loop
+	tc.env = NewTypeEnv(tc.env)
+	defer func() { tc.env = tc.env.outer }()
+
 	stmt.Condition = tc.checkBoolCondition(stmt.Condition)
 
 	if block, ok := stmt.Body.(ast.BlockStmt); ok {
+		tc.env = tc.narrow(stmt.Condition, true)
 		stmt.Body = tc.CheckBlockStmt(&block)
+		tc.env = tc.env.outer
 	} else {
 		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "while body should be a block statement")
 	}
@@ -181,27 +705,195 @@ func (tc *TypeChecker) CheckWhileStmt(stmt *ast.WhileStmt) ast.TypedStmt {
 
 func (tc *TypeChecker) CheckIfStmt(stmt *ast.IfStmt) ast.TypedStmt {
 	stmt.Condition = tc.checkBoolCondition(stmt.Condition)
-	var thenType, elseType string
+	var thenType, elseType types.Type
+
+	// Pattern variables of the condition are assigned in the branch where the patterns matched
+	// and after the if statement when the other branch can not complete, the same goes for null checks
+	assignedWhenTrue, assignedWhenFalse := assignedWhen(stmt.Condition, true), assignedWhen(stmt.Condition, false)
+	if !isEndReachable(stmt.Then) {
+		defer tc.markAssigned(assignedWhenFalse)
+		defer tc.markNotNull(notNullWhen(stmt.Condition, false))
+	}
+	if stmt.Else != nil && !isEndReachable(stmt.Else) {
+		defer tc.markAssigned(assignedWhenTrue)
+		defer tc.markNotNull(notNullWhen(stmt.Condition, true))
+	}
 
+	// Variables assigned in both branches are assigned after the if statement, a branch that can not
+	// complete does not count
+	completing := []*TypeEnvironment{}
 	if thenBlock, ok := stmt.Then.(ast.BlockStmt); ok {
+		tc.env = tc.narrow(stmt.Condition, true)
+		if isEndReachable(stmt.Then) {
+			completing = append(completing, tc.env)
+		}
 		stmt.Then = tc.CheckBlockStmt(&thenBlock)
+		tc.env = tc.env.outer
 		thenType = stmt.Then.(ast.TypedStmt).Type
 	} else {
 		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "while body should be a block statement")
 	}
 
 	if elseBlock, ok := stmt.Else.(ast.BlockStmt); ok {
+		tc.env = tc.narrow(stmt.Condition, false)
+		if isEndReachable(stmt.Else) {
+			completing = append(completing, tc.env)
+		}
 		stmt.Else = tc.CheckBlockStmt(&elseBlock)
+		tc.env = tc.env.outer
 		elseType = stmt.Else.(ast.TypedStmt).Type
+		tc.markAssignedInAll(completing)
+	} else if stmt.Else == nil {
+		elseType = types.Void
 	} else {
 		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "while body should be a block statement")
 	}
 
-	ifType := tc.upperBound([]string{thenType, elseType})
+	ifType := tc.upperBound([]types.Type{thenType, elseType})
 
-	if thenType == "void" || elseType == "void" {
-		ifType = "void"
+	if thenType == types.Void || elseType == types.Void {
+		ifType = types.Void
 	}
 
 	return ast.TypedStmt{Stmt: stmt, Type: ifType, Line: stmt.Line, Column: stmt.Column}
 }
+
+// Leaves a scope whose statements run in sequence, the variables of enclosing scopes that it assigned
+// are also assigned after it
+func (tc *TypeChecker) leaveSequentialScope() {
+	for name := range tc.env.assignedOuter() {
+		tc.env.outer.MarkAssigned(name)
+	}
+	tc.env = tc.env.outer
+}
+
+// Variables assigned in every one of the alternative branches are assigned after them
+func (tc *TypeChecker) markAssignedInAll(branches []*TypeEnvironment) {
+	if len(branches) == 0 {
+		return
+	}
+	for name := range branches[0].assignedOuter() {
+		inAll := true
+		for _, branch := range branches[1:] {
+			inAll = inAll && branch.assignedOuter()[name]
+		}
+		if inAll {
+			tc.env.MarkAssigned(name)
+		}
+	}
+}
+
+func (tc *TypeChecker) markAssigned(names []string) {
+	for _, name := range names {
+		tc.env.MarkAssigned(name)
+	}
+}
+
+func (tc *TypeChecker) CheckTryStmt(stmt *ast.TryStmt) ast.TypedStmt {
+	branchTypes := []types.Type{}
+
+	// Variables assigned in the try block and in every catch clause that can complete are assigned after them
+	completing := []*TypeEnvironment{}
+	if block, ok := stmt.Body.(ast.BlockStmt); ok {
+		if len(stmt.Catches) > 0 {
+			tc.tryCatchDepth++
+		}
+		tc.env = NewTypeEnv(tc.env)
+		if isEndReachable(stmt.Body) {
+			completing = append(completing, tc.env)
+		}
+		stmt.Body = tc.CheckBlockStmt(&block)
+		tc.env = tc.env.outer
+		if len(stmt.Catches) > 0 {
+			tc.tryCatchDepth--
+		}
+		branchTypes = append(branchTypes, stmt.Body.(ast.TypedStmt).Type)
+	} else {
+		tc.errorf(stmt.GetLine(), stmt.GetColumn(), "try body should be a block statement")
+	}
+
+	for i := range stmt.Catches {
+		tc.env = NewTypeEnv(tc.env)
+		if isEndReachable(stmt.Catches[i].Body) {
+			completing = append(completing, tc.env)
+		}
+		tc.CheckCatchClause(&stmt.Catches[i], stmt.Catches[:i])
+		tc.env = tc.env.outer
+		branchTypes = append(branchTypes, stmt.Catches[i].Body.(ast.TypedStmt).Type)
+	}
+	tc.markAssignedInAll(completing)
+
+	if stmt.Finally != nil {
+		if block, ok := stmt.Finally.(ast.BlockStmt); ok {
+			tc.finallyDepth++
+			stmt.Finally = tc.CheckBlockStmt(&block)
+			tc.finallyDepth--
+		} else {
+			tc.errorf(stmt.GetLine(), stmt.GetColumn(), "finally body should be a block statement")
+		}
+	}
+
+	tryType := tc.upperBound(branchTypes)
+	for _, typ := range branchTypes {
+		if typ == types.Void {
+			tryType = types.Void
+		}
+	}
+
+	return ast.TypedStmt{Stmt: stmt, Type: tryType, Line: stmt.Line, Column: stmt.Column}
+}
+
+func (tc *TypeChecker) CheckCatchClause(clause *ast.CatchClause, previous []ast.CatchClause) {
+	tc.env = NewTypeEnv(tc.env)
+	defer tc.leaveSequentialScope()
+
+	if clause.Type.Name != "" {
+		clause.Type = tc.resolveLocalType(clause.Type)
+	}
+	if clause.Type.Name != "" && !tc.isExceptionType(tc.registry.Parse(clause.Type.Name)) {
+		tc.errorf(clause.Type.Line, clause.Type.Column, "catch type %s must be Exception or derive from it", clause.Type.Name)
+	}
+
+	// A catch clause is unreachable if an earlier unfiltered clause already catches its type
+	for _, earlier := range previous {
+		if earlier.Filter != nil {
+			continue
+		}
+		if earlier.Type.Name == "" {
+			tc.errorf(clause.Line, clause.Column, "a catch clause that catches all exceptions must be the last catch clause")
+		}
+		if clause.Type.Name == earlier.Type.Name || tc.isSubclassOf(clause.Type.Name, earlier.Type.Name) {
+			tc.errorf(clause.Line, clause.Column, "a previous catch clause already catches all exceptions of this or a super type (%s)", earlier.Type.Name)
+		}
+	}
+
+	if clause.Identifier != "" {
+		tc.env.Define(clause.Identifier, tc.registry.Parse(clause.Type.Name), false, false, false)
+	}
+
+	if clause.Filter != nil {
+		tc.inFilter = true
+		clause.Filter = tc.checkBoolCondition(clause.Filter)
+		tc.inFilter = false
+	}
+
+	if block, ok := clause.Body.(ast.BlockStmt); ok {
+		tc.catchDepth++
+		clause.Body = tc.CheckBlockStmt(&block)
+		tc.catchDepth--
+	} else {
+		tc.errorf(clause.Line, clause.Column, "catch body should be a block statement")
+	}
+}
+
+func (tc *TypeChecker) CheckThrowStmt(stmt *ast.ThrowStmt) ast.TypedStmt {
+	if stmt.Value == nil {
+		if tc.catchDepth == 0 {
+			tc.errorf(stmt.Line, stmt.Column, "a throw statement with no arguments is not allowed outside of a catch clause")
+		}
+		return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+	}
+
+	stmt.Value = tc.checkThrownValue(stmt.Value)
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
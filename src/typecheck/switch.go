@@ -0,0 +1,214 @@
+package typecheck
+
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// The labels of an enclosing switch statement, used to resolve goto case and goto default
+type switchContext struct {
+	governingType types.Type
+	labels        map[ast.ConstantKey]bool
+	hasDefault    bool
+}
+
+func (tc *TypeChecker) CheckSwitchStmt(stmt *ast.SwitchStmt) ast.TypedStmt {
+	expression := tc.CheckExpr(stmt.Expression)
+	if expression.Type == types.Void {
+		tc.errorf(stmt.Line, stmt.Column, "cannot switch on an expression of type void")
+	}
+	stmt.Expression = expression
+
+	context := &switchContext{governingType: expression.Type, labels: map[ast.ConstantKey]bool{}}
+	tc.switches = append(tc.switches, context)
+	defer func() { tc.switches = tc.switches[:len(tc.switches)-1] }()
+
+	// All labels are checked before the sections so that goto case can jump forward.
+	// Each section gets its own scope for the variables declared by its patterns.
+	scopes := make([]*TypeEnvironment, len(stmt.Sections))
+	handled := []ast.Pattern{}
+	for i := range stmt.Sections {
+		section := &stmt.Sections[i]
+		tc.env = NewTypeEnv(tc.env)
+		scopes[i] = tc.env
+
+		for j := range section.Labels {
+			label := &section.Labels[j]
+			if label.IsDefault {
+				if context.hasDefault {
+					tc.errorf(label.Line, label.Column, "the switch statement contains multiple default labels")
+				}
+				context.hasDefault = true
+				continue
+			}
+
+			label.Pattern = tc.CheckPattern(label.Pattern, expression.Type)
+			if constant, ok := label.Pattern.(ast.ConstantPattern); ok && label.Guard == nil {
+				key := ast.ConstantKeyOf(constant.Value)
+				if context.labels[key] {
+					tc.errorf(label.Line, label.Column, "the switch statement contains multiple cases with the label value %s", key)
+				}
+				context.labels[key] = true
+			}
+			for _, previous := range handled {
+				if tc.subsumes(previous, label.Pattern) {
+					tc.errorf(label.Line, label.Column, "the switch case is unreachable, it has already been handled by a previous case")
+				}
+			}
+
+			if label.Guard != nil {
+				tc.env = NewNarrowingEnv(tc.env, patternVariables(label.Pattern))
+				label.Guard = tc.checkBoolCondition(label.Guard)
+				tc.env = tc.env.outer
+			} else {
+				handled = append(handled, label.Pattern)
+			}
+		}
+
+		// With stacked labels it is unknown which pattern matched
+		if len(section.Labels) == 1 && !section.Labels[0].IsDefault {
+			tc.markAssigned(patternVariables(section.Labels[0].Pattern))
+		}
+
+		tc.env = tc.env.outer
+	}
+
+	sectionTypes := []types.Type{}
+	for i := range stmt.Sections {
+		section := &stmt.Sections[i]
+		if isEndReachable(section.Body) {
+			if i == len(stmt.Sections)-1 {
+				tc.errorf(section.Line, section.Column, "control cannot fall out of switch from final case label")
+			}
+			tc.errorf(section.Line, section.Column, "control cannot fall through from one case label to another")
+		}
+
+		tc.env = scopes[i]
+		body := section.Body.(ast.BlockStmt)
+		section.Body = tc.CheckBlockStmt(&body)
+		tc.env = tc.env.outer
+		sectionTypes = append(sectionTypes, section.Body.(ast.TypedStmt).Type)
+	}
+
+	// With a default label one of the sections runs, what all of them assign is assigned after the switch
+	if context.hasDefault {
+		tc.markAssignedInAll(scopes)
+	}
+
+	return ast.TypedStmt{Stmt: stmt, Type: tc.upperBound(sectionTypes), Line: stmt.Line, Column: stmt.Column}
+}
+
+func (tc *TypeChecker) CheckGotoCaseStmt(stmt *ast.GotoCaseStmt) ast.TypedStmt {
+	if len(tc.switches) == 0 {
+		tc.errorf(stmt.Line, stmt.Column, "goto case and goto default are only valid inside of a switch statement")
+	}
+	context := tc.switches[len(tc.switches)-1]
+
+	if stmt.Value == nil {
+		if !context.hasDefault {
+			tc.errorf(stmt.Line, stmt.Column, "no such label 'default:' within the scope of the goto statement")
+		}
+		return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+	}
+
+	if !tc.isConstant(stmt.Value) {
+		tc.errorf(stmt.Line, stmt.Column, "a constant value is expected")
+	}
+	value := tc.CheckExpr(stmt.Value)
+	if !tc.isTypeCompatible(context.governingType, value.Type) {
+		tc.errorf(stmt.Line, stmt.Column, "cannot implicitly convert type %s to %s", value.Type, context.governingType)
+	}
+	if key := ast.ConstantKeyOf(value); !context.labels[key] {
+		tc.errorf(stmt.Line, stmt.Column, "no such label 'case %s:' within the scope of the goto statement", key)
+	}
+	stmt.Value = value
+
+	return ast.TypedStmt{Stmt: stmt, Type: types.Void, Line: stmt.Line, Column: stmt.Column}
+}
+
+// Without a target type the switch expression gets the best common type of its arms
+func (tc *TypeChecker) CheckSwitchExpr(expr ast.SwitchExpr, target types.Type) ast.TypedExpr {
+	input := tc.CheckExpr(expr.Expression)
+	if input.Type == types.Void {
+		tc.errorf(expr.Line, expr.Column, "cannot switch on an expression of type void")
+	}
+	expr.Expression = input
+	if len(expr.Arms) == 0 {
+		tc.errorf(expr.Line, expr.Column, "a switch expression must have at least one arm")
+	}
+
+	scopes := make([]*TypeEnvironment, len(expr.Arms))
+	handled := []ast.Pattern{}
+	armTypes := []types.Type{}
+	throws := []int{}
+	for i := range expr.Arms {
+		arm := &expr.Arms[i]
+		tc.env = NewTypeEnv(tc.env)
+		scopes[i] = tc.env
+
+		arm.Pattern = tc.CheckPattern(arm.Pattern, input.Type)
+		tc.markAssigned(patternVariables(arm.Pattern))
+		for _, previous := range handled {
+			if tc.subsumes(previous, arm.Pattern) {
+				tc.errorf(arm.Line, arm.Column, "the pattern has already been handled by a previous arm of the switch expression")
+			}
+		}
+		if arm.Guard != nil {
+			arm.Guard = tc.checkBoolCondition(arm.Guard)
+		} else {
+			handled = append(handled, arm.Pattern)
+		}
+
+		// Throw expressions take the type of the switch expression once it is known
+		if _, ok := arm.Value.(ast.ThrowExpr); ok {
+			throws = append(throws, i)
+		} else if target != nil {
+			value := tc.CheckTargetTypedExpr(arm.Value, target)
+			if !tc.isTypeCompatible(target, value.Type) {
+				tc.errorf(arm.Value.GetLine(), arm.Value.GetColumn(), "cannot implicitly convert type %s to %s", value.Type, target)
+			}
+			arm.Value = value
+		} else {
+			value := tc.CheckExpr(arm.Value)
+			armTypes = append(armTypes, value.Type)
+			arm.Value = value
+		}
+
+		tc.env = tc.env.outer
+	}
+
+	typ := target
+	if typ == nil {
+		typ = tc.bestCommonType(armTypes)
+		if typ == nil {
+			tc.errorf(expr.Line, expr.Column, "no best type was found for the switch expression")
+		}
+	}
+	for _, i := range throws {
+		tc.env = scopes[i]
+		expr.Arms[i].Value = tc.CheckThrowExpr(expr.Arms[i].Value.(ast.ThrowExpr), typ)
+		tc.env = tc.env.outer
+	}
+
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// The type all other types convert to, or nil if there is none
+func (tc *TypeChecker) bestCommonType(candidates []types.Type) types.Type {
+	for _, candidate := range candidates {
+		if candidate == types.Null || candidate == types.Void {
+			continue
+		}
+		isBest := true
+		for _, other := range candidates {
+			if !tc.isTypeCompatible(candidate, other) {
+				isBest = false
+				break
+			}
+		}
+		if isBest {
+			return candidate
+		}
+	}
+	return nil
+}
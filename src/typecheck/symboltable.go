@@ -1,15 +1,55 @@
 package typecheck
 
+import (
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
 type SymbolInfo struct {
-	Type        string
+	Type        types.Type
 	IsGlobal    bool
 	IsField     bool
 	IsParameter bool
+	IsReadOnly  bool
+	IsConstant  bool
+	// The checked value of a constant local, constant fields are evaluated by constantValue
+	Value ast.Expr
+	// Pattern variables are declared even if the pattern does not match
+	IsUnassigned bool
+	// Reference types declared with ? like string?
+	IsNullable bool
+	// Range variables of a query carried by a transparent identifier are elements of its tuple
+	RangeOf string
+}
+
+// Closure collects the enclosing locals that a lambda body refers to
+type Closure struct {
+	Captures []string
+}
+
+func (closure *Closure) capture(name string) {
+	for _, captured := range closure.Captures {
+		if captured == name {
+			return
+		}
+	}
+	closure.Captures = append(closure.Captures, name)
 }
 
 type TypeEnvironment struct {
 	symbols map[string]SymbolInfo
-	outer   *TypeEnvironment
+	// Local functions declared in this scope, they are visible in the whole scope
+	functions map[string]*MethodSymbol
+	outer     *TypeEnvironment
+	closure   *Closure
+	// Variables known to be definitely assigned inside of this scope
+	assigned map[string]bool
+	// Narrowing scopes only carry assignment information, symbols are defined in the enclosing scope
+	narrowing bool
+	// Null states of variables at the current point of the scope, true if the variable may be null
+	nullStates map[string]bool
+	// The outermost scope of a method, local function, lambda or accessor body
+	body bool
 }
 
 func NewTypeEnv(outer *TypeEnvironment) *TypeEnvironment {
@@ -19,14 +59,187 @@ func NewTypeEnv(outer *TypeEnvironment) *TypeEnvironment {
 	}
 }
 
+// NewClosureEnv creates a scope that marks the boundary of a lambda body
+func NewClosureEnv(outer *TypeEnvironment, closure *Closure) *TypeEnvironment {
+	env := NewTypeEnv(outer)
+	env.closure = closure
+	return env
+}
+
+// NewNarrowingEnv creates a scope in which the given variables are definitely assigned,
+// e.g. the then branch of if (o is int i)
+func NewNarrowingEnv(outer *TypeEnvironment, assigned []string) *TypeEnvironment {
+	env := NewTypeEnv(outer)
+	env.narrowing = true
+	for _, name := range assigned {
+		env.MarkAssigned(name)
+	}
+	return env
+}
+
 func (env *TypeEnvironment) Lookup(name string) (SymbolInfo, bool) {
 	info, ok := env.symbols[name]
 	if !ok && env.outer != nil {
-		return env.outer.Lookup(name)
+		info, ok = env.outer.Lookup(name)
+		// Locals and parameters found beyond a lambda boundary are captured by that lambda, constants are not
+		if ok && env.closure != nil && !info.IsField && !info.IsGlobal && !info.IsConstant && info.RangeOf == "" {
+			env.closure.capture(name)
+		}
 	}
 	return info, ok
 }
 
-func (env *TypeEnvironment) Define(name, typ string, isGlobal, isField, isParameter bool) {
+func (env *TypeEnvironment) IsDefinedInScope(name string) bool {
+	if env.narrowing {
+		return env.outer.IsDefinedInScope(name)
+	}
+	_, isSymbol := env.symbols[name]
+	_, isFunction := env.functions[name]
+	return isSymbol || isFunction
+}
+
+func (env *TypeEnvironment) DefineFunction(function *MethodSymbol) {
+	if env.narrowing {
+		env.outer.DefineFunction(function)
+		return
+	}
+	if env.functions == nil {
+		env.functions = make(map[string]*MethodSymbol)
+	}
+	env.functions[function.Name] = function
+}
+
+// Local functions are looked up from the innermost scope outwards, a local variable with the same name hides them
+func (env *TypeEnvironment) LookupFunction(name string) (*MethodSymbol, bool) {
+	for current := env; current != nil; current = current.outer {
+		if function, ok := current.functions[name]; ok {
+			return function, true
+		}
+		if _, ok := current.symbols[name]; ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func (env *TypeEnvironment) Define(name string, typ types.Type, isGlobal, isField, isParameter bool) {
+	if env.narrowing {
+		env.outer.Define(name, typ, isGlobal, isField, isParameter)
+		return
+	}
 	env.symbols[name] = SymbolInfo{Type: typ, IsGlobal: isGlobal, IsField: isField, IsParameter: isParameter}
 }
+
+func (env *TypeEnvironment) DefineRangeVariable(name string, typ types.Type, transparent string) {
+	if env.narrowing {
+		env.outer.DefineRangeVariable(name, typ, transparent)
+		return
+	}
+	env.symbols[name] = SymbolInfo{Type: typ, IsParameter: true, RangeOf: transparent}
+}
+
+func (env *TypeEnvironment) MarkReadOnly(name string) {
+	if env.narrowing {
+		env.outer.MarkReadOnly(name)
+		return
+	}
+	info := env.symbols[name]
+	info.IsReadOnly = true
+	env.symbols[name] = info
+}
+
+func (env *TypeEnvironment) MarkConstant(name string, value ast.Expr) {
+	if env.narrowing {
+		env.outer.MarkConstant(name, value)
+		return
+	}
+	info := env.symbols[name]
+	info.IsConstant, info.Value = true, value
+	env.symbols[name] = info
+}
+
+func (env *TypeEnvironment) MarkNullable(name string) {
+	if env.narrowing {
+		env.outer.MarkNullable(name)
+		return
+	}
+	info := env.symbols[name]
+	info.IsNullable = true
+	env.symbols[name] = info
+}
+
+func (env *TypeEnvironment) MarkUnassigned(name string) {
+	if env.narrowing {
+		env.outer.MarkUnassigned(name)
+		return
+	}
+	info := env.symbols[name]
+	info.IsUnassigned = true
+	env.symbols[name] = info
+}
+
+func (env *TypeEnvironment) MarkBody() {
+	env.body = true
+}
+
+func (env *TypeEnvironment) MarkAssigned(name string) {
+	if env.assigned == nil {
+		env.assigned = make(map[string]bool)
+	}
+	env.assigned[name] = true
+}
+
+// The variables of enclosing scopes that were assigned inside of this scope
+func (env *TypeEnvironment) assignedOuter() map[string]bool {
+	assigned := map[string]bool{}
+	for name := range env.assigned {
+		if _, isLocal := env.symbols[name]; !isLocal {
+			assigned[name] = true
+		}
+	}
+	return assigned
+}
+
+// Reports whether a variable is definitely assigned at the current point of the scope
+func (env *TypeEnvironment) IsAssigned(name string) bool {
+	for current := env; current != nil; current = current.outer {
+		if current.assigned[name] {
+			return true
+		}
+		if info, ok := current.symbols[name]; ok {
+			return !info.IsUnassigned
+		}
+	}
+	return false
+}
+
+// Records whether a variable may be null. A variable that may be null in a nested scope may also be
+// null after it, up to the method the assignment happens in, a variable known to be non-null only
+// stays so until the end of the scope.
+func (env *TypeEnvironment) SetMaybeNull(name string, maybeNull bool) {
+	for current := env; current != nil; current = current.outer {
+		if current.nullStates == nil {
+			current.nullStates = make(map[string]bool)
+		}
+		current.nullStates[name] = maybeNull
+		if !maybeNull {
+			return
+		}
+		if _, isDefined := current.symbols[name]; isDefined || current.body {
+			return
+		}
+	}
+}
+
+// Variables without a recorded state may be null if their declared type is nullable
+func (env *TypeEnvironment) IsMaybeNull(name string) bool {
+	for current := env; current != nil; current = current.outer {
+		if maybeNull, ok := current.nullStates[name]; ok {
+			return maybeNull
+		}
+		if info, ok := current.symbols[name]; ok {
+			return info.IsNullable || info.Type.Kind() == types.NullableKind
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package typecheck
+
+import (
+	"fmt"
+
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Tuple types are named like (int, string) or (int Count, string Name). The element names are part of
+// the type name but tuples with the same element types convert to each other regardless of their names.
+
+func (tc *TypeChecker) isTupleType(typ types.Type) bool {
+	return typ.Kind() == types.TupleKind
+}
+
+// Element names have to be unique in every tuple type, also in the tuples nested inside of a type
+func (tc *TypeChecker) checkTupleElementNames(typ types.Type, line, column int) {
+	switch t := typ.(type) {
+	case *types.Tuple:
+		seen := map[string]bool{}
+		for i, name := range t.Names {
+			if name != "" && seen[name] {
+				tc.errorf(line, column, "tuple element names must be unique, %s is a duplicate", name)
+			}
+			seen[name] = true
+			tc.checkTupleElementNames(t.Elements[i], line, column)
+		}
+	case *types.Generic:
+		for _, argument := range t.Arguments {
+			tc.checkTupleElementNames(argument, line, column)
+		}
+	case *types.Array:
+		tc.checkTupleElementNames(t.Element, line, column)
+	case *types.Nullable:
+		tc.checkTupleElementNames(t.Underlying, line, column)
+	}
+}
+
+// The elements of a tuple are named Item1, Item2, ... besides their declared names
+func tupleElementIndex(names []string, member string) int {
+	for i, name := range names {
+		if name == member || fmt.Sprintf("Item%d", i+1) == member {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tuple literals take the element types of their target type if their elements convert to them,
+// which gives elements like null or lambdas a type. Element names that differ from the target are ignored.
+func (tc *TypeChecker) CheckTupleExpr(expr ast.TupleExpr, target types.Type) ast.TypedExpr {
+	targetTuple, hasTarget := target.(*types.Tuple)
+	hasTarget = hasTarget && len(targetTuple.Elements) == len(expr.Elements)
+
+	// Lambda bodies are checked more than once, the typed elements do not replace the parsed ones
+	elements := make([]ast.Expr, len(expr.Elements))
+	elementTypes, names := make([]types.Type, len(expr.Elements)), make([]string, len(expr.Elements))
+	for i, element := range expr.Elements {
+		if decl, ok := element.(ast.DeclarationExpr); ok {
+			tc.errorf(decl.Line, decl.Column, "a declaration is only allowed on the left side of a deconstruction")
+		}
+		var elementTarget types.Type
+		if hasTarget {
+			elementTarget = targetTuple.Elements[i]
+		}
+		typed := tc.CheckTargetTypedExpr(element, elementTarget)
+		elements[i] = typed
+		elementTypes[i] = typed.Type
+		if hasTarget && tc.isTypeCompatible(targetTuple.Elements[i], typed.Type) {
+			elementTypes[i] = targetTuple.Elements[i]
+		}
+
+		// Names are inferred from variables like in (count, name)
+		names[i] = expr.Names[i]
+		if id, ok := element.(ast.IdentifierExpr); ok && names[i] == "" {
+			names[i] = id.Name
+		}
+		if hasTarget && expr.Names[i] != "" && expr.Names[i] != targetTuple.Names[i] {
+			tc.warnf(element.GetLine(), element.GetColumn(), "the tuple element name %s is ignored because a different name or no name is specified by the target type %s", expr.Names[i], target)
+		}
+	}
+
+	expr.Elements = elements
+
+	typ := tc.registry.NewTuple(elementTypes, names)
+	if hasTarget && tc.isTypeCompatible(target, typ) {
+		typ = target
+	}
+	return ast.TypedExpr{Type: typ, Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Reports whether a value of the type can be stored in an implicitly typed variable
+func (tc *TypeChecker) isInferable(typ types.Type) bool {
+	if tuple, ok := typ.(*types.Tuple); ok {
+		for _, element := range tuple.Elements {
+			if !tc.isInferable(element) {
+				return false
+			}
+		}
+		return true
+	}
+	return typ != types.Null && typ != types.Void
+}
+
+func (tc *TypeChecker) checkTupleMemberAccess(expr ast.MemberAccessExpr, receiver ast.TypedExpr) ast.TypedExpr {
+	tuple := receiver.Type.(*types.Tuple)
+	index := tupleElementIndex(tuple.Names, expr.Member)
+	if index < 0 {
+		tc.errorf(expr.Line, expr.Column, "%s does not contain a definition for %s", receiver.Type, expr.Member)
+	}
+	expr.Receiver = receiver
+	return ast.TypedExpr{Type: tuple.Elements[index], Expr: expr, Line: expr.Line, Column: expr.Column}
+}
+
+// Checks (a, var b) = value. The value is taken apart by its tuple elements or by the Deconstruct method
+// of its type, each part is assigned to a variable, declared as a new variable or discarded with _.
+func (tc *TypeChecker) CheckDeconstruction(targets ast.TupleExpr, assignment ast.AssignmentExpr) ast.TypedExpr {
+	if assignment.Operator.Kind != lexer.ASSIGNMENT {
+		tc.errorf(assignment.Line, assignment.Column, "a deconstruction can only be used with the = operator")
+	}
+	value := tc.CheckExpr(assignment.Value)
+	deconstruction := tc.checkDeconstructionTargets(targets, value.Type, assignment.Line, assignment.Column)
+	deconstruction.Value = value
+	return ast.TypedExpr{Type: value.Type, Expr: deconstruction, Line: assignment.Line, Column: assignment.Column}
+}
+
+func (tc *TypeChecker) checkDeconstructionTargets(targets ast.TupleExpr, typ types.Type, line, column int) ast.DeconstructionExpr {
+	elementTypes, signature := tc.deconstruct(typ, len(targets.Elements), line, column)
+	deconstruction := ast.DeconstructionExpr{Targets: make([]ast.Expr, len(targets.Elements)), Signature: signature, Line: line, Column: column}
+
+	declared := map[string]bool{}
+	for i, target := range targets.Elements {
+		switch t := target.(type) {
+		case ast.TupleExpr:
+			nested := tc.checkDeconstructionTargets(t, elementTypes[i], t.Line, t.Column)
+			deconstruction.Targets[i] = ast.TypedExpr{Type: elementTypes[i], Expr: nested, Line: t.Line, Column: t.Column}
+		case ast.DeclarationExpr:
+			if declared[t.Identifier] && t.Identifier != "_" {
+				tc.errorf(t.Line, t.Column, "variable %s is already defined in this scope", t.Identifier)
+			}
+			declared[t.Identifier] = true
+			deconstruction.Targets[i] = tc.declareDeconstructionVariable(t, elementTypes[i])
+		default:
+			deconstruction.Targets[i] = tc.checkDeconstructionAssignee(target, elementTypes[i])
+		}
+	}
+	return deconstruction
+}
+
+// Returns the element types a value of the type deconstructs into and the Deconstruct method used for it
+func (tc *TypeChecker) deconstruct(typ types.Type, count, line, column int) ([]types.Type, *ast.MethodSignature) {
+	if tuple, ok := typ.(*types.Tuple); ok {
+		if len(tuple.Elements) != count {
+			tc.errorf(line, column, "cannot deconstruct a tuple of %d elements into %d variables", len(tuple.Elements), count)
+		}
+		return tuple.Elements, nil
+	}
+
+	for _, method := range tc.lookupMethods(typ.String(), "Deconstruct") {
+		if !tc.isAccessible(method) || method.IsStatic() || len(method.Parameters) != count || method.ReturnType != types.Void {
+			continue
+		}
+		elementTypes := make([]types.Type, count)
+		for i, param := range method.Parameters {
+			if referenceModifier(param.Modifiers) != "out" {
+				elementTypes = nil
+				break
+			}
+			elementTypes[i] = method.ParameterTypes[i]
+		}
+		if elementTypes != nil {
+			tc.checkObsolete(method.Attributes, method.Class+"."+method.Name, line, column)
+			return elementTypes, method.Signature()
+		}
+	}
+	tc.errorf(line, column, "no suitable Deconstruct instance method was found for type %s with %d out parameters", typ, count)
+	return nil, nil
+}
+
+func (tc *TypeChecker) declareDeconstructionVariable(decl ast.DeclarationExpr, typ types.Type) ast.TypedExpr {
+	if decl.Type.Name == "var" {
+		if !tc.isInferable(typ) {
+			tc.errorf(decl.Line, decl.Column, "cannot assign %s to an implicitly-typed variable", typ)
+		}
+		decl.Type.Name = typ.String()
+	} else {
+		decl.Type = tc.resolveLocalType(decl.Type)
+		if !tc.isTypeCompatible(tc.registry.Parse(decl.Type.Name), typ) {
+			tc.errorf(decl.Line, decl.Column, "type mismatch: expected %s, got %s", decl.Type.Name, typ)
+		}
+	}
+
+	// var _ and T _ are discards
+	if decl.Identifier != "_" {
+		if tc.env.IsDefinedInScope(decl.Identifier) {
+			tc.errorf(decl.Line, decl.Column, "variable %s is already defined in this scope", decl.Identifier)
+		}
+		tc.env.Define(decl.Identifier, tc.registry.Parse(decl.Type.Name), false, false, false)
+		if decl.Type.IsNullable {
+			tc.env.MarkNullable(decl.Identifier)
+		}
+	}
+	return ast.TypedExpr{Type: tc.registry.Parse(decl.Type.Name), Expr: decl, Line: decl.Line, Column: decl.Column}
+}
+
+// Existing variables are assigned like with =, a _ that is not a variable discards its element
+func (tc *TypeChecker) checkDeconstructionAssignee(target ast.Expr, typ types.Type) ast.TypedExpr {
+	id, isIdentifier := target.(ast.IdentifierExpr)
+	if isIdentifier && id.Name == "_" {
+		if _, isDefined := tc.env.Lookup("_"); !isDefined {
+			return ast.TypedExpr{Type: typ, Expr: id, Line: id.Line, Column: id.Column}
+		}
+	}
+
+	var typed ast.TypedExpr
+	access, isElementAccess := target.(ast.ElementAccessExpr)
+	if isIdentifier {
+		typed = tc.checkIdentifier(id, false)
+	} else if isElementAccess {
+		typed = tc.checkElementAccess(access, false, true)
+	} else {
+		typed = tc.CheckExpr(target)
+	}
+	if !isVariable(typed) && !isElementAccess {
+		tc.errorf(target.GetLine(), target.GetColumn(), "the left side of a deconstruction must be a variable, a declaration or a discard")
+	}
+	if info, ok := tc.env.Lookup(variableName(typed)); ok && info.IsConstant {
+		tc.errorf(target.GetLine(), target.GetColumn(), "cannot assign to %s because it is a constant", variableName(typed))
+	} else if ok && tc.isReadOnly(variableName(typed), info) {
+		tc.errorf(target.GetLine(), target.GetColumn(), "cannot assign to variable %s because it is a readonly variable", variableName(typed))
+	}
+	if !tc.isTypeCompatible(typed.Type, typ) {
+		tc.errorf(target.GetLine(), target.GetColumn(), "type mismatch: %s and %s", typed.Type, typ)
+	}
+	if isIdentifier {
+		tc.env.MarkAssigned(id.Name)
+	}
+	return typed
+}
@@ -2,23 +2,114 @@ package typecheck
 
 import (
 	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/builtins"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
 )
 
 type TypeChecker struct {
 	env     *TypeEnvironment
-	classes map[string]ast.ClassDeclStmt
+	library ast.Program
+	// The types of this compilation
+	registry  *types.Registry
+	classes   map[string]*ClassSymbol
+	enums     map[string]ast.EnumDeclStmt
+	delegates map[string]ast.DelegateDeclStmt
+	// Checked values of constant fields by declaring class and name
+	constants map[string]ast.TypedExpr
+	// Source file of the declaration being checked, reported with every error
+	file     string
+	warnings []string
+	// #nullable directives by file
+	nullableDirectives map[string][]ast.NullableDirective
+	// using directives by file
+	usings map[string][]ast.UsingDirective
+
+	// Used to validate rethrows and returns inside of try statements
+	catchDepth   int
+	finallyDepth int
+	// Yield return is not allowed in a try block that has catch clauses
+	tryCatchDepth int
+	// The method, local function, lambda or accessor whose body is being checked
+	method *methodContext
+	// Element type of the iterator being checked, empty outside of iterators
+	iteratorElement types.Type
+	// Await is only valid inside of async bodies, awaited records whether the current one awaits
+	inAsync bool
+	awaited bool
+	// Await is not allowed in catch filters
+	inFilter bool
+	// Readonly fields can only be assigned inside of the constructors of their class, static ones only
+	// inside of the static constructor
+	inConstructor       bool
+	inStaticConstructor bool
+	// Static local functions cannot refer to this or to instance members, neither can the lambdas and
+	// local functions nested in them
+	inStaticLocalFunction bool
+	// Uses of obsolete declarations are not reported inside of obsolete declarations
+	inObsolete bool
+	// Enclosing switch statements for goto case
+	switches []*switchContext
+	// Number of transparent identifiers of translated query expressions
+	transparentIdentifiers int
+	// Overflows of constant expressions are errors outside of unchecked contexts
+	unchecked bool
+}
+
+type methodContext struct {
+	// Returns deep into the body are checked against it, it is unwrapped for async bodies
+	returnType types.Type
+	// The return type is a reference type declared with ?
+	returnsNullable bool
 }
 
 func NewTypeChecker() *TypeChecker {
-	return &TypeChecker{env: NewTypeEnv(nil)}
+	return &TypeChecker{env: NewTypeEnv(nil), library: builtins.Load(), registry: types.NewRegistry(), nullableDirectives: map[string][]ast.NullableDirective{}, usings: map[string][]ast.UsingDirective{}}
 }
 
 func (tc *TypeChecker) CheckProgram(prog *ast.Program) ast.Program {
-	tc.classes = make(map[string]ast.ClassDeclStmt)
-	for i := range prog.Classes {
-		tc.classes[prog.Classes[i].Name] = prog.Classes[i]
+	tc.classes = make(map[string]*ClassSymbol)
+	tc.enums = make(map[string]ast.EnumDeclStmt)
+	tc.delegates = make(map[string]ast.DelegateDeclStmt)
+	tc.constants = make(map[string]ast.TypedExpr)
+	for _, delegate := range tc.library.Delegates {
+		tc.delegates[delegate.Name] = delegate
+	}
+	for _, delegate := range prog.Delegates {
+		tc.delegates[delegate.Name] = delegate
+	}
+	for _, class := range prog.Classes {
+		for _, member := range class.Body.Members {
+			if delegate, ok := member.(ast.DelegateDeclStmt); ok {
+				tc.delegates[delegate.Name] = delegate
+			}
+		}
+	}
+
+	if len(prog.NullableDirectives) > 0 {
+		tc.nullableDirectives[""] = prog.NullableDirectives
+	}
+	if len(prog.Usings) > 0 {
+		tc.usings[""] = prog.Usings
+	}
+
+	if len(prog.Statements) > 0 {
+		prog.Classes = append(prog.Classes, topLevelClass(prog.Statements, ""))
+		prog.Statements = nil
 	}
 
+	// Delegates have to be known before the member types are resolved
+	tc.library.Classes = tc.declareTypes(tc.library.Classes, tc.library.Enums)
+	prog.Classes = tc.declareTypes(prog.Classes, prog.Enums)
+	for _, file := range sortedKeys(tc.usings) {
+		tc.checkUsings(file, tc.usings[file])
+	}
+
+	for i := range prog.Delegates {
+		tc.checkDelegateAttributes(&prog.Delegates[i])
+	}
+	for i := range prog.Enums {
+		tc.CheckEnumDeclStmt(&prog.Enums[i])
+	}
 	for i := range prog.Classes {
 		tc.CheckClassDeclStmt(&prog.Classes[i])
 	}
@@ -26,8 +117,11 @@ func (tc *TypeChecker) CheckProgram(prog *ast.Program) ast.Program {
 	return *prog
 }
 
+func (tc *TypeChecker) Warnings() []string {
+	return tc.warnings
+}
 
-
-
-
-
+// The types of the checked program, lowering creates its types in the same registry
+func (tc *TypeChecker) Registry() *types.Registry {
+	return tc.registry
+}
@@ -1,62 +1,237 @@
 package typecheck
 
-import "fmt"
+import (
+	"fmt"
 
-func (tc *TypeChecker) isTypeCompatible(a, b string) bool {
-	if a == "char" && b == "int" {
-		return true
-	} else if a == "int" && b == "char" {
-		return true
-	} else if tc.isUserObject(a) && b == "null" {
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/ast"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/lexer"
+	"github.com/FabianRolfMatthiasNoll/Golang-CSharp-Compiler/src/types"
+)
+
+// Reports whether a value of type b converts implicitly to type a
+func (tc *TypeChecker) isTypeCompatible(a, b types.Type) bool {
+	return types.IsAssignable(b, a, tc)
+}
+
+func (tc *TypeChecker) isBinaryCompatible(a, b types.Type) bool {
+	if (a == types.Null && tc.isNullable(b)) || (tc.isNullable(a) && b == types.Null) {
 		return true
-	} else if a == "null" && tc.isUserObject(b) {
+	}
+	// Lifted operators apply to the underlying types of nullable value types
+	if underlying, ok := tc.nullableUnderlying(a); ok {
+		a = underlying
+	}
+	if underlying, ok := tc.nullableUnderlying(b); ok {
+		b = underlying
+	}
+	if _, ok := types.BinaryNumericPromotion(a, b); ok && types.IsNumeric(a) && types.IsNumeric(b) {
 		return true
 	} else if a == b {
 		return true
 	}
+	return types.IsTupleAssignable(a, b, tc.isBinaryCompatible)
+}
+
+// The type checker provides the declarations that assignability depends on
+
+func (tc *TypeChecker) IsSubclassOf(derived, base types.Type) bool {
+	return tc.isSubclassOf(derived.String(), base.String())
+}
+
+func (tc *TypeChecker) IsReferenceType(typ types.Type) bool {
+	return tc.isReferenceType(typ)
+}
+
+func (tc *TypeChecker) HasImplicitConversion(from, to types.Type) bool {
+	return tc.userDefinedConversion(from, to, false) != nil
+}
+
+// The member table of a declared class, struct, record or interface, or of an instantiated collection type
+func (tc *TypeChecker) classSymbol(typ types.Type) (*ClassSymbol, bool) {
+	switch t := typ.(type) {
+	case *types.Class:
+		class, ok := tc.classes[t.Name]
+		return class, ok
+	case *types.Generic:
+		class, ok := tc.classes[t.String()]
+		return class, ok
+	}
+	return nil, false
+}
+
+func (tc *TypeChecker) isUserObject(typ types.Type) bool {
+	_, ok := tc.classSymbol(typ)
+	return ok
+}
+
+// Like isUserObject for the qualified name of a type
+func (tc *TypeChecker) isClassName(name string) bool {
+	_, ok := tc.classes[name]
+	return ok
+}
+
+func (tc *TypeChecker) baseClassOf(className string) (string, bool) {
+	class, ok := tc.classes[className]
+	if !ok || len(class.Decl.BaseTypes) == 0 || !tc.isClassName(class.Decl.BaseTypes[0].Name) {
+		return "", false
+	}
+	return class.Decl.BaseTypes[0].Name, true
+}
+
+func (tc *TypeChecker) isSubclassOf(derived, base string) bool {
+	visited := map[string]bool{}
+	for current, ok := tc.baseClassOf(derived); ok && !visited[current]; current, ok = tc.baseClassOf(current) {
+		if current == base {
+			return true
+		}
+		visited[current] = true
+	}
 	return false
 }
 
-func (tc *TypeChecker) isBinaryCompatible(a, b string) bool {
-	if a == "char" && b == "int" {
-		return true
-	} else if a == "int" && b == "char" {
+func (tc *TypeChecker) isReferenceType(typ types.Type) bool {
+	return typ == types.String || typ == types.Object || typ.Kind() == types.ArrayKind || (tc.isUserObject(typ) && !tc.isStruct(typ)) || tc.isDelegateType(typ)
+}
+
+func (tc *TypeChecker) isExceptionType(typ types.Type) bool {
+	exception := tc.registry.NewClass("Exception")
+	return typ == exception || tc.IsSubclassOf(typ, exception)
+}
+
+func hasModifier(modifiers []ast.Modifier, kind lexer.TokenKind) bool {
+	for _, modifier := range modifiers {
+		if modifier.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Readonly fields are writable inside of the constructors of their class, readonly parameters never
+func (tc *TypeChecker) isReadOnly(name string, info SymbolInfo) bool {
+	if !info.IsReadOnly || !info.IsField {
+		return info.IsReadOnly
+	}
+	field, declaring, ok := tc.lookupField(tc.currentClassName(), name)
+	return !ok || !tc.isConstructing(field, declaring)
+}
+
+// Static fields are initialized by the static constructor, instance fields by the instance constructors.
+// Derived classes can not assign the readonly fields of their base classes.
+func (tc *TypeChecker) isConstructing(field ast.FieldDeclStmt, declaring string) bool {
+	return tc.inConstructor && declaring == tc.currentClassName() && hasModifier(field.Modifiers, lexer.STATIC) == tc.inStaticConstructor
+}
+
+// Default values of optional parameters have to be known at compile time
+func isConstantExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.IntLiteralExpr, ast.RealLiteralExpr, ast.BoolLiteralExpr, ast.CharLiteralExpr, ast.StringExpr, ast.NullLiteralExpr:
 		return true
-	} else if a == b {
+	case ast.PrefixExpr:
+		return (e.Operator.Kind == lexer.MINUS || e.Operator.Kind == lexer.PLUS || e.Operator.Kind == lexer.NOT) && isConstantExpr(e.Expression)
+	}
+	return false
+}
+
+// Like expression statements, the expression body of a lambda that returns void has to be an assignment,
+// a call, an increment, a decrement, an object creation or an await
+func isStatementExpression(expr ast.Expr) bool {
+	switch expr.(type) {
+	case ast.AssignmentExpr, ast.MethodCallExpr, ast.PostDecrementExpr, ast.PreDecrementExpr, ast.PostIncrementExpr, ast.PreIncrementExpr, ast.ConstructorCallExpr, ast.AwaitExpr:
 		return true
 	}
 	return false
 }
 
-func (tc *TypeChecker) isUserObject(typ string) bool {
-	_, ok := tc.classes[typ]
+// Besides literals, constant expressions can use enum members, const variables and operators on constants
+func (tc *TypeChecker) isConstant(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.IdentifierExpr:
+		info, ok := tc.env.Lookup(e.Name)
+		return ok && info.IsConstant
+	case ast.PrefixExpr:
+		return (e.Operator.Kind == lexer.MINUS || e.Operator.Kind == lexer.PLUS || e.Operator.Kind == lexer.NOT) && tc.isConstant(e.Expression)
+	case ast.BinaryExpr:
+		return tc.isConstant(e.Left) && tc.isConstant(e.Right)
+	case ast.CastExpr:
+		return tc.isConstant(e.Expression)
+	case ast.CheckedExpr:
+		return tc.isConstant(e.Expression)
+	}
+	return isConstantExpr(expr) || tc.isEnumMember(expr)
+}
+
+// Resolves the function type of the built-in Func/Action types and of user declared delegates. A method group
+// or lambda converts to a delegate if it has the function type of the delegate.
+func (tc *TypeChecker) delegateSignature(typ types.Type) (*types.Function, bool) {
+	if typ == nil {
+		return &types.Function{}, false
+	}
+	if generic, ok := typ.(*types.Generic); ok {
+		arguments := generic.Arguments
+		switch generic.Name {
+		case "Func":
+			return tc.functionType(arguments[:len(arguments)-1], arguments[len(arguments)-1]), true
+		case "Action":
+			return tc.functionType(arguments, types.Void), true
+		}
+	}
+	if typ == tc.registry.NewClass("Action") {
+		return tc.functionType(nil, types.Void), true
+	}
+
+	delegate, ok := tc.delegates[typ.String()]
+	if !ok {
+		return &types.Function{}, false
+	}
+	parameters := make([]types.Type, len(delegate.Parameters))
+	for i, param := range delegate.Parameters {
+		parameters[i] = tc.registry.Parse(param.Type.Name)
+	}
+	return tc.functionType(parameters, tc.registry.Parse(delegate.ReturnType.Name)), true
+}
+
+func (tc *TypeChecker) functionType(parameters []types.Type, result types.Type) *types.Function {
+	return tc.registry.NewFunction(parameters, result).(*types.Function)
+}
+
+func (tc *TypeChecker) isDelegateType(typ types.Type) bool {
+	_, ok := tc.delegateSignature(typ)
 	return ok
 }
 
+func (tc *TypeChecker) currentClassName() string {
+	this, ok := tc.env.Lookup("this")
+	if !ok {
+		return ""
+	}
+	return this.Type.String()
+}
+
 // Helper function to find the upper bound of a list of types
-func (tc *TypeChecker) upperBound(types []string) string {
-	if len(types) == 0 {
-		return "void"
+func (tc *TypeChecker) upperBound(list []types.Type) types.Type {
+	if len(list) == 0 {
+		return types.Void
 	}
-	if len(types) == 1 {
-		return types[0]
+	if len(list) == 1 {
+		return list[0]
 	}
 
 	// Example of a type hierarchy for determining upper bounds
-	typeHierarchy := map[string]int{
-		"int":    1,
-		"float":  2,
-		"string": 3,
-		"object": 4,
-		"null":   5,
-		"bool":   6,
-		"char":   7,
+	typeHierarchy := map[types.Type]int{
+		types.Int:    1,
+		types.Float:  2,
+		types.String: 3,
+		types.Object: 4,
+		types.Null:   5,
+		types.Bool:   6,
+		types.Char:   7,
 	}
 
 	maxRank := 0
-	upperType := "void"
+	upperType := types.Void
 
-	for _, t := range types {
+	for _, t := range list {
 		if rank, exists := typeHierarchy[t]; exists && rank > maxRank {
 			maxRank = rank
 			upperType = t
@@ -68,5 +243,18 @@ func (tc *TypeChecker) upperBound(types []string) string {
 
 func (tc *TypeChecker) errorf(line, column int, format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
+	if tc.file != "" {
+		panic(fmt.Sprintf("%s: Error at line %d, column %d: %s", tc.file, line, column, message))
+	}
 	panic(fmt.Sprintf("Error at line %d, column %d: %s", line, column, message))
 }
+
+// Warnings are collected and do not stop the type checking
+func (tc *TypeChecker) warnf(line, column int, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if tc.file != "" {
+		tc.warnings = append(tc.warnings, fmt.Sprintf("%s: Warning at line %d, column %d: %s", tc.file, line, column, message))
+		return
+	}
+	tc.warnings = append(tc.warnings, fmt.Sprintf("Warning at line %d, column %d: %s", line, column, message))
+}
@@ -0,0 +1,48 @@
+package types
+
+// Declarations answers the questions about declared types that assignability depends on
+type Declarations interface {
+	IsSubclassOf(derived, base Type) bool
+	IsReferenceType(typ Type) bool
+	// Reports whether a user-defined implicit conversion converts from into to
+	HasImplicitConversion(from, to Type) bool
+}
+
+// Reports whether a value of type from converts implicitly to type to: identity, the implicit numeric
+// conversions, null to reference and nullable types, T to T?, derived classes to their bases,
+// user-defined conversions, everything to object and tuples element by element
+func IsAssignable(from, to Type, declarations Declarations) bool {
+	switch {
+	case from == to, IsImplicitNumericConversion(from, to):
+		return true
+	case from == Null:
+		return declarations.IsReferenceType(to) || to.Kind() == NullableKind
+	case to == Null:
+		return declarations.IsReferenceType(from)
+	case to.Kind() == NullableKind && IsAssignable(from, Underlying(to), declarations):
+		return true
+	case declarations.IsSubclassOf(from, to), declarations.HasImplicitConversion(from, to):
+		return true
+	case to == Object:
+		return from != Void
+	}
+	return IsTupleAssignable(from, to, func(from, to Type) bool { return IsAssignable(from, to, declarations) })
+}
+
+// Tuples convert element by element, their element names do not matter
+func IsTupleAssignable(from, to Type, isAssignable func(from, to Type) bool) bool {
+	fromTuple, ok := from.(*Tuple)
+	if !ok {
+		return false
+	}
+	toTuple, ok := to.(*Tuple)
+	if !ok || len(fromTuple.Elements) != len(toTuple.Elements) {
+		return false
+	}
+	for i := range fromTuple.Elements {
+		if !isAssignable(fromTuple.Elements[i], toTuple.Elements[i]) {
+			return false
+		}
+	}
+	return true
+}
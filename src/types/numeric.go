@@ -0,0 +1,90 @@
+package types
+
+// The integral types, char included, and float, double and decimal are the numeric types
+
+// Implicit numeric conversions by source type, there is none to char
+var implicitNumericConversions = map[Type][]Type{
+	SByte:  {Short, Int, Long, Float, Double, Decimal},
+	Byte:   {Short, UShort, Int, UInt, Long, ULong, Float, Double, Decimal},
+	Short:  {Int, Long, Float, Double, Decimal},
+	UShort: {Int, UInt, Long, ULong, Float, Double, Decimal},
+	Int:    {Long, Float, Double, Decimal},
+	UInt:   {Long, ULong, Float, Double, Decimal},
+	Long:   {Float, Double, Decimal},
+	ULong:  {Float, Double, Decimal},
+	Char:   {UShort, Int, UInt, Long, ULong, Float, Double, Decimal},
+	Float:  {Double},
+}
+
+func IsIntegral(typ Type) bool {
+	switch typ {
+	case SByte, Byte, Short, UShort, Char, Int, UInt, Long, ULong:
+		return true
+	}
+	return false
+}
+
+func IsNumeric(typ Type) bool {
+	return IsIntegral(typ) || typ == Float || typ == Double || typ == Decimal
+}
+
+func IsSigned(typ Type) bool {
+	switch typ {
+	case SByte, Short, Int, Long:
+		return true
+	}
+	return false
+}
+
+func IsImplicitNumericConversion(from, to Type) bool {
+	for _, target := range implicitNumericConversions[from] {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Binary numeric promotion converts both operands of an arithmetic, comparison or bitwise operator to
+// the returned type. There is no predefined operator for decimal and float or double and for ulong and
+// a signed type.
+func BinaryNumericPromotion(left, right Type) (Type, bool) {
+	switch {
+	case left == Decimal || right == Decimal:
+		if left == Float || left == Double || right == Float || right == Double {
+			return nil, false
+		}
+		return Decimal, true
+	case left == Double || right == Double:
+		return Double, true
+	case left == Float || right == Float:
+		return Float, true
+	case left == ULong || right == ULong:
+		if IsSigned(left) || IsSigned(right) {
+			return nil, false
+		}
+		return ULong, true
+	case left == Long || right == Long:
+		return Long, true
+	case (left == UInt && IsSigned(right)) || (right == UInt && IsSigned(left)):
+		return Long, true
+	case left == UInt || right == UInt:
+		return UInt, true
+	}
+	return Int, true
+}
+
+// Unary numeric promotion for + and -, negating a uint gives a long and there is no - for ulong
+func UnaryNumericPromotion(negate bool, typ Type) (Type, bool) {
+	switch typ {
+	case SByte, Byte, Short, UShort, Char:
+		return Int, true
+	case UInt:
+		if negate {
+			return Long, true
+		}
+	case ULong:
+		return typ, !negate
+	}
+	return typ, IsNumeric(typ)
+}
@@ -0,0 +1,274 @@
+// Package types describes the types of checked expressions and symbols. Types are interned by their
+// name in the registry of a compilation, the same type is always the same value, so types are identical
+// if they are ==.
+package types
+
+import "strings"
+
+type Kind int
+
+const (
+	PrimitiveKind Kind = iota
+	ClassKind
+	ArrayKind
+	GenericKind
+	NullableKind
+	TupleKind
+	FunctionKind
+	NullKind
+	ErrorKind
+)
+
+type Type interface {
+	Kind() Kind
+	// The name of the type as it is written in source, tuple element names included
+	String() string
+}
+
+// The types with a keyword: void, bool, char, string, object and the numeric types
+type Primitive struct {
+	name string
+}
+
+func (typ *Primitive) Kind() Kind     { return PrimitiveKind }
+func (typ *Primitive) String() string { return typ.name }
+
+// Declared classes, structs, records, interfaces, enums and delegates, Name is the qualified name of
+// nested types like Outer.Inner
+type Class struct {
+	Name string
+}
+
+func (typ *Class) Kind() Kind     { return ClassKind }
+func (typ *Class) String() string { return typ.Name }
+
+type Array struct {
+	Element Type
+	name    string
+}
+
+func (typ *Array) Kind() Kind     { return ArrayKind }
+func (typ *Array) String() string { return typ.name }
+
+// An instance of a generic type like List<int> or Func<int, bool>
+type Generic struct {
+	Name      string
+	Arguments []Type
+	name      string
+}
+
+func (typ *Generic) Kind() Kind     { return GenericKind }
+func (typ *Generic) String() string { return typ.name }
+
+// T? of a value type T, or a reference type annotated as nullable
+type Nullable struct {
+	Underlying Type
+	name       string
+}
+
+func (typ *Nullable) Kind() Kind     { return NullableKind }
+func (typ *Nullable) String() string { return typ.name }
+
+// Names has an empty string for every element without a name
+type Tuple struct {
+	Elements []Type
+	Names    []string
+	name     string
+}
+
+func (typ *Tuple) Kind() Kind     { return TupleKind }
+func (typ *Tuple) String() string { return typ.name }
+
+// The signature of a method, local function, lambda or delegate, the type a method group or lambda needs
+// a delegate to convert to
+type Function struct {
+	Parameters []Type
+	Result     Type
+	name       string
+}
+
+func (typ *Function) Kind() Kind     { return FunctionKind }
+func (typ *Function) String() string { return typ.name }
+
+// The type of the null literal
+type nullType struct{}
+
+func (typ *nullType) Kind() Kind     { return NullKind }
+func (typ *nullType) String() string { return "null" }
+
+// The type of expressions that could not be typed
+type errorType struct{}
+
+func (typ *errorType) Kind() Kind     { return ErrorKind }
+func (typ *errorType) String() string { return "<error>" }
+
+var (
+	Void    Type = &Primitive{name: "void"}
+	Bool    Type = &Primitive{name: "bool"}
+	Char    Type = &Primitive{name: "char"}
+	String  Type = &Primitive{name: "string"}
+	Object  Type = &Primitive{name: "object"}
+	SByte   Type = &Primitive{name: "sbyte"}
+	Byte    Type = &Primitive{name: "byte"}
+	Short   Type = &Primitive{name: "short"}
+	UShort  Type = &Primitive{name: "ushort"}
+	Int     Type = &Primitive{name: "int"}
+	UInt    Type = &Primitive{name: "uint"}
+	Long    Type = &Primitive{name: "long"}
+	ULong   Type = &Primitive{name: "ulong"}
+	Float   Type = &Primitive{name: "float"}
+	Double  Type = &Primitive{name: "double"}
+	Decimal Type = &Primitive{name: "decimal"}
+	Null    Type = &nullType{}
+	Error   Type = &errorType{}
+)
+
+// The types that are shared by all registries
+var predeclared = []Type{Void, Bool, Char, String, Object, SByte, Byte, Short, UShort, Int, UInt, Long, ULong, Float, Double, Decimal, Null, Error}
+
+// A Registry holds the types of one compilation by their name. It is not safe for concurrent use,
+// every compilation creates its own one.
+type Registry struct {
+	types map[string]Type
+}
+
+func NewRegistry() *Registry {
+	r := &Registry{types: map[string]Type{}}
+	for _, typ := range predeclared {
+		r.types[typ.String()] = typ
+	}
+	return r
+}
+
+func (r *Registry) intern(typ Type) Type {
+	if existing, ok := r.types[typ.String()]; ok {
+		return existing
+	}
+	r.types[typ.String()] = typ
+	return typ
+}
+
+func (r *Registry) NewClass(name string) Type {
+	if typ, ok := r.types[name]; ok {
+		return typ
+	}
+	return r.intern(&Class{Name: name})
+}
+
+func (r *Registry) NewArray(element Type) Type {
+	return r.intern(&Array{Element: element, name: element.String() + "[]"})
+}
+
+func (r *Registry) NewGeneric(name string, arguments []Type) Type {
+	return r.intern(&Generic{Name: name, Arguments: arguments, name: name + "<" + joinTypes(arguments) + ">"})
+}
+
+func (r *Registry) NewNullable(underlying Type) Type {
+	if underlying.Kind() == NullableKind {
+		return underlying
+	}
+	return r.intern(&Nullable{Underlying: underlying, name: underlying.String() + "?"})
+}
+
+func (r *Registry) NewTuple(elements []Type, names []string) Type {
+	if names == nil {
+		names = make([]string, len(elements))
+	}
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		parts[i] = element.String()
+		if names[i] != "" {
+			parts[i] += " " + names[i]
+		}
+	}
+	return r.intern(&Tuple{Elements: elements, Names: names, name: "(" + strings.Join(parts, ", ") + ")"})
+}
+
+// Functions are named like the function pointer types delegate*<int, string, bool>, the result comes last
+func (r *Registry) NewFunction(parameters []Type, result Type) Type {
+	return r.intern(&Function{Parameters: parameters, Result: result, name: "delegate*<" + joinTypes(append(append([]Type{}, parameters...), result)) + ">"})
+}
+
+func joinTypes(list []Type) string {
+	names := make([]string, len(list))
+	for i, typ := range list {
+		names[i] = typ.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Parses a type name like int, List<int>, int?[], (int Count, string Name) or delegate*<int, bool>, an empty
+// name is no type
+func (r *Registry) Parse(name string) Type {
+	if typ, ok := r.types[name]; ok || name == "" {
+		return typ
+	}
+	switch {
+	case strings.HasSuffix(name, "[]"):
+		return r.NewArray(r.Parse(name[:len(name)-2]))
+	case strings.HasSuffix(name, "?"):
+		return r.NewNullable(r.Parse(name[:len(name)-1]))
+	case strings.HasPrefix(name, "(") && strings.HasSuffix(name, ")"):
+		parts := splitTopLevel(name[1:len(name)-1], ',')
+		elements, names := make([]Type, len(parts)), make([]string, len(parts))
+		for i, part := range parts {
+			words := splitTopLevel(part, ' ')
+			elements[i] = r.Parse(words[0])
+			if len(words) > 1 {
+				names[i] = words[len(words)-1]
+			}
+		}
+		return r.NewTuple(elements, names)
+	case strings.HasPrefix(name, "delegate*<") && strings.HasSuffix(name, ">"):
+		parts := splitTopLevel(name[len("delegate*<"):len(name)-1], ',')
+		signature := make([]Type, len(parts))
+		for i, part := range parts {
+			signature[i] = r.Parse(part)
+		}
+		return r.NewFunction(signature[:len(signature)-1], signature[len(signature)-1])
+	case strings.HasSuffix(name, ">") && strings.Contains(name, "<"):
+		start := strings.Index(name, "<")
+		parts := splitTopLevel(name[start+1:len(name)-1], ',')
+		arguments := make([]Type, len(parts))
+		for i, part := range parts {
+			arguments[i] = r.Parse(part)
+		}
+		return r.NewGeneric(name[:start], arguments)
+	}
+	return r.NewClass(name)
+}
+
+// Splits at the separators that are not nested inside of <> or ()
+func splitTopLevel(s string, separator byte) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case separator:
+			if depth == 0 {
+				if part := strings.TrimSpace(s[start:i]); part != "" {
+					parts = append(parts, part)
+				}
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// Reports whether two types are the same type, tuples with different element names are different types
+func Identical(a, b Type) bool {
+	return a == b
+}
+
+// The type without a nullable annotation
+func Underlying(typ Type) Type {
+	if nullable, ok := typ.(*Nullable); ok {
+		return nullable.Underlying
+	}
+	return typ
+}